@@ -0,0 +1,234 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib"
+)
+
+// Outcomes of a signing request
+const (
+	OutcomeSigned   = "signed"
+	OutcomeRejected = "rejected"
+)
+
+// GenesisHash is the previous hash of the first entry of the journal
+var GenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrChainBroken   = errors.New("audit chain is broken")
+	ErrEntryNotFound = errors.New("audit entry not found")
+)
+
+// Entry is a signing request recorded in the audit journal. Method and
+// MessageHash are only set for off-chain messages.
+//
+// Entries are chained: PrevHash is the Hash of the previous entry and Hash the
+// SHA-256 of the JSON encoding of the entry with an empty Hash. Altering,
+// removing or reordering entries breaks the chain, see Verify.
+type Entry struct {
+	Sequence       uint64    `json:"sequence"`
+	Time           time.Time `json:"time"`
+	RequestID      string    `json:"requestId"`
+	DisplayName    string    `json:"displayName"`
+	UUID           string    `json:"uuid"`
+	CoinType       uint16    `json:"coinType"`
+	Path           string    `json:"path"`
+	Method         string    `json:"method,omitempty"`
+	MessageHash    string    `json:"messageHash,omitempty"`
+	Outcome        string    `json:"outcome"`
+	Reason         string    `json:"reason,omitempty"`
+	Recipient      string    `json:"recipient,omitempty"`
+	Amount         string    `json:"amount,omitempty"`
+	Token          string    `json:"token,omitempty"`
+	TokenRecipient string    `json:"tokenRecipient,omitempty"`
+	TokenAmount    string    `json:"tokenAmount,omitempty"`
+	TxHash         string    `json:"txHash,omitempty"`
+	PrevHash       string    `json:"prevHash"`
+	Hash           string    `json:"hash"`
+}
+
+// SetTransaction records the recipient and amounts of a decoded payload: the
+// first native transfer and the first token transfer
+func (e *Entry) SetTransaction(summary *lib.TransactionSummary) {
+	if summary == nil {
+		return
+	}
+
+	e.Amount = summary.NativeValue().String()
+	for _, transfer := range summary.Transfers {
+		if transfer.Token == "" && e.Recipient == "" {
+			e.Recipient = transfer.To
+		}
+		if transfer.Token != "" && e.Token == "" {
+			e.Token = transfer.Token
+			e.TokenRecipient = transfer.To
+			if transfer.Value != nil {
+				e.TokenAmount = transfer.Value.String()
+			}
+		}
+	}
+}
+
+// ComputeHash returns the hash of the entry, its Hash field excluded
+func (e *Entry) ComputeHash() (string, error) {
+	unhashed := *e
+	unhashed.Hash = ""
+
+	data, err := json.Marshal(unhashed)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// head is the last entry of the journal
+type head struct {
+	Sequence uint64 `json:"sequence"`
+	Hash     string `json:"hash"`
+}
+
+func entryKey(sequence uint64) string {
+	// zero padded so storage lists entries in order
+	return fmt.Sprintf("%sentries/%020d", config.AuditStoragePath, sequence)
+}
+
+func loadHead(ctx context.Context, storage logical.Storage) (head, error) {
+	entry, err := storage.Get(ctx, config.AuditStoragePath+"head")
+	if err != nil {
+		return head{}, err
+	}
+	if entry == nil {
+		return head{Hash: GenesisHash}, nil
+	}
+
+	var h head
+	if err := entry.DecodeJSON(&h); err != nil {
+		return head{}, err
+	}
+	return h, nil
+}
+
+// Append chains entry to the journal, setting its Sequence, PrevHash and Hash.
+// Callers serialise appends.
+func Append(ctx context.Context, storage logical.Storage, entry *Entry) error {
+	h, err := loadHead(ctx, storage)
+	if err != nil {
+		return err
+	}
+
+	entry.Sequence = h.Sequence + 1
+	entry.PrevHash = h.Hash
+	entry.Hash, err = entry.ComputeHash()
+	if err != nil {
+		return err
+	}
+
+	storageEntry, err := logical.StorageEntryJSON(entryKey(entry.Sequence), entry)
+	if err != nil {
+		return err
+	}
+	if err := storage.Put(ctx, storageEntry); err != nil {
+		return err
+	}
+
+	headEntry, err := logical.StorageEntryJSON(config.AuditStoragePath+"head",
+		head{Sequence: entry.Sequence, Hash: entry.Hash})
+	if err != nil {
+		return err
+	}
+	return storage.Put(ctx, headEntry)
+}
+
+// Get loads the entry of sequence
+func Get(ctx context.Context, storage logical.Storage, sequence uint64) (*Entry, error) {
+	storageEntry, err := storage.Get(ctx, entryKey(sequence))
+	if err != nil {
+		return nil, err
+	}
+	if storageEntry == nil {
+		return nil, fmt.Errorf("%w: %d", ErrEntryNotFound, sequence)
+	}
+
+	var entry Entry
+	if err := storageEntry.DecodeJSON(&entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// List returns up to limit entries following sequence after, oldest first.
+// next is the sequence to list after for the following page, 0 on the last page.
+func List(ctx context.Context, storage logical.Storage, after uint64, limit int) (entries []*Entry,
+	next uint64, err error) {
+	h, err := loadHead(ctx, storage)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for sequence := after + 1; sequence <= h.Sequence && len(entries) < limit; sequence++ {
+		entry, err := Get(ctx, storage, sequence)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) > 0 && entries[len(entries)-1].Sequence < h.Sequence {
+		next = entries[len(entries)-1].Sequence
+	}
+	return entries, next, nil
+}
+
+// Verify walks the journal and checks every entry hash and link up to the
+// head. It returns the number of entries and the head hash, and an error
+// wrapping ErrChainBroken naming the first altered entry.
+func Verify(ctx context.Context, storage logical.Storage) (count uint64, headHash string, err error) {
+	h, err := loadHead(ctx, storage)
+	if err != nil {
+		return 0, "", err
+	}
+
+	prevHash := GenesisHash
+	for sequence := uint64(1); sequence <= h.Sequence; sequence++ {
+		entry, err := Get(ctx, storage, sequence)
+		if errors.Is(err, ErrEntryNotFound) {
+			return sequence - 1, prevHash, fmt.Errorf("%w: entry %d is missing", ErrChainBroken, sequence)
+		}
+		if err != nil {
+			return 0, "", err
+		}
+
+		hash, err := entry.ComputeHash()
+		if err != nil {
+			return 0, "", err
+		}
+		switch {
+		case entry.Sequence != sequence:
+			return sequence - 1, prevHash, fmt.Errorf("%w: entry %d holds sequence %d", ErrChainBroken,
+				sequence, entry.Sequence)
+		case entry.PrevHash != prevHash:
+			return sequence - 1, prevHash, fmt.Errorf("%w: entry %d does not link to entry %d", ErrChainBroken,
+				sequence, sequence-1)
+		case entry.Hash != hash:
+			return sequence - 1, prevHash, fmt.Errorf("%w: entry %d was altered", ErrChainBroken, sequence)
+		}
+		prevHash = entry.Hash
+	}
+
+	if prevHash != h.Hash {
+		return h.Sequence, prevHash, fmt.Errorf("%w: head does not match entry %d", ErrChainBroken, h.Sequence)
+	}
+	return h.Sequence, h.Hash, nil
+}
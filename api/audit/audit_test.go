@@ -0,0 +1,197 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/lib"
+)
+
+var testTime = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// appendEntries journals count signing requests of alternating outcomes
+func appendEntries(t *testing.T, storage logical.Storage, count int) []*Entry {
+	t.Helper()
+
+	entries := make([]*Entry, 0, count)
+	for idx := range count {
+		entry := &Entry{
+			Time:      testTime.Add(time.Duration(idx) * time.Minute),
+			RequestID: fmt.Sprintf("request-%d", idx),
+			UUID:      "user",
+			CoinType:  60,
+			Path:      "m/44'/60'/0'/0/0",
+			Outcome:   OutcomeSigned,
+		}
+		if idx%2 == 1 {
+			entry.Outcome, entry.Reason = OutcomeRejected, "signing policy violation"
+		}
+		require.NoError(t, Append(context.Background(), storage, entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAppend(t *testing.T) {
+	storage := &logical.InmemStorage{}
+	entries := appendEntries(t, storage, 3)
+
+	assert.Equal(t, uint64(1), entries[0].Sequence)
+	assert.Equal(t, GenesisHash, entries[0].PrevHash)
+	for idx := 1; idx < len(entries); idx++ {
+		assert.Equal(t, uint64(idx+1), entries[idx].Sequence)
+		assert.Equal(t, entries[idx-1].Hash, entries[idx].PrevHash)
+	}
+
+	stored, err := Get(context.Background(), storage, 2)
+	require.NoError(t, err)
+	assert.Equal(t, entries[1], stored)
+
+	_, err = Get(context.Background(), storage, 4)
+	assert.ErrorIs(t, err, ErrEntryNotFound)
+}
+
+func TestEntry_SetTransaction(t *testing.T) {
+	entry := &Entry{}
+	entry.SetTransaction(&lib.TransactionSummary{
+		Transfers: []lib.Transfer{
+			{To: "0xtoken", Value: big.NewInt(0)},
+			{To: "0xrecipient", Value: big.NewInt(5000), Token: "0xtoken"},
+		},
+	})
+
+	assert.Equal(t, "0xtoken", entry.Recipient)
+	assert.Equal(t, "0", entry.Amount)
+	assert.Equal(t, "0xtoken", entry.Token)
+	assert.Equal(t, "0xrecipient", entry.TokenRecipient)
+	assert.Equal(t, "5000", entry.TokenAmount)
+
+	undecoded := &Entry{}
+	undecoded.SetTransaction(nil)
+	assert.Equal(t, &Entry{}, undecoded)
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+	appendEntries(t, storage, 5)
+
+	page, next, err := List(ctx, storage, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, uint64(1), page[0].Sequence)
+	assert.Equal(t, uint64(2), next)
+
+	page, next, err = List(ctx, storage, next, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, uint64(3), page[0].Sequence)
+	assert.Equal(t, uint64(4), next)
+
+	page, next, err = List(ctx, storage, next, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, uint64(5), page[0].Sequence)
+	assert.Zero(t, next)
+
+	page, next, err = List(ctx, &logical.InmemStorage{}, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, page)
+	assert.Zero(t, next)
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("empty journal", func(t *testing.T) {
+		count, headHash, err := Verify(ctx, &logical.InmemStorage{})
+		require.NoError(t, err)
+		assert.Zero(t, count)
+		assert.Equal(t, GenesisHash, headHash)
+	})
+
+	t.Run("intact journal", func(t *testing.T) {
+		storage := &logical.InmemStorage{}
+		entries := appendEntries(t, storage, 4)
+
+		count, headHash, err := Verify(ctx, storage)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(4), count)
+		assert.Equal(t, entries[3].Hash, headHash)
+	})
+
+	tamper := []struct {
+		name    string
+		tamper  func(t *testing.T, storage logical.Storage, entries []*Entry)
+		wantErr string
+	}{
+		{
+			name: "altered entry",
+			tamper: func(t *testing.T, storage logical.Storage, entries []*Entry) {
+				altered := *entries[1]
+				altered.Reason = ""
+				altered.Outcome = OutcomeSigned
+				putEntry(t, storage, &altered)
+			},
+			wantErr: "entry 2 was altered",
+		},
+		{
+			name: "altered and rehashed entry",
+			tamper: func(t *testing.T, storage logical.Storage, entries []*Entry) {
+				altered := *entries[1]
+				altered.UUID = "someone-else"
+				var err error
+				altered.Hash, err = altered.ComputeHash()
+				require.NoError(t, err)
+				putEntry(t, storage, &altered)
+			},
+			wantErr: "entry 3 does not link to entry 2",
+		},
+		{
+			name: "removed entry",
+			tamper: func(t *testing.T, storage logical.Storage, _ []*Entry) {
+				require.NoError(t, storage.Delete(context.Background(), entryKey(3)))
+			},
+			wantErr: "entry 3 is missing",
+		},
+		{
+			name: "swapped entries",
+			tamper: func(t *testing.T, storage logical.Storage, entries []*Entry) {
+				for key, entry := range map[string]*Entry{entryKey(1): entries[1], entryKey(2): entries[0]} {
+					storageEntry, err := logical.StorageEntryJSON(key, entry)
+					require.NoError(t, err)
+					require.NoError(t, storage.Put(context.Background(), storageEntry))
+				}
+			},
+			wantErr: "entry 1 holds sequence 2",
+		},
+	}
+
+	for _, tt := range tamper {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &logical.InmemStorage{}
+			entries := appendEntries(t, storage, 4)
+			tt.tamper(t, storage, entries)
+
+			_, _, err := Verify(ctx, storage)
+			require.ErrorIs(t, err, ErrChainBroken)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// putEntry overwrites the stored entry of its sequence
+func putEntry(t *testing.T, storage logical.Storage, entry *Entry) {
+	t.Helper()
+
+	storageEntry, err := logical.StorageEntryJSON(entryKey(entry.Sequence), entry)
+	require.NoError(t, err)
+	require.NoError(t, storage.Put(context.Background(), storageEntry))
+}
@@ -2,16 +2,36 @@ package api
 
 import (
 	"context"
+	"hash/fnv"
 	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/keycache"
+	"github.com/payment-system/dq-vault/api/keyring"
+	"github.com/payment-system/dq-vault/api/logging"
+	"github.com/payment-system/dq-vault/api/metrics"
+	"github.com/payment-system/dq-vault/api/mountconfig"
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib"
+	"github.com/payment-system/dq-vault/lib/adapter"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // Factory creates a new usable instance of this secrets engine.
 func Factory(ctx context.Context, c *logical.BackendConfig) (logical.Backend, error) {
 	b := NewBackend(c)
+	if err := b.setupKeyring(c); err != nil {
+		return nil, errors.Wrap(err, "failed to set up keyring")
+	}
 	if err := b.Setup(ctx, c); err != nil {
 		return nil, errors.Wrap(err, "failed to create vault factory")
 	}
@@ -22,16 +42,214 @@ func Factory(ctx context.Context, c *logical.BackendConfig) (logical.Backend, er
 type Backend struct {
 	*framework.Backend
 	logger *slog.Logger
+	// logLevel is the level of logger, set from the log_level mount option
+	logLevel slog.LevelVar
+	// mountLogLevel is the level of the log_level mount option, used when the
+	// mount configuration sets none
+	mountLogLevel slog.Level
+	// mountConfig caches the configuration stored at dq/config
+	mountConfig atomic.Pointer[mountconfig.Config]
+	// metrics are the Prometheus collectors of the mount, on a registry of its own
+	metrics *metrics.Metrics
+	// keyring seals the entries of users, nil when the mount has no
+	// key-encryption key
+	keyring *keyring.Keyring
+	// keyCache holds the account keys of users when enabled by the mount configuration
+	keyCache *keycache.Cache
+	// adapterInventory holds the blockchain adapters of the mount, see adapters
+	adapterInventory *adapter.Inventory
+	adapterOnce      sync.Once
+
+	// clock returns the current time, time.Now when nil
+	clock func() time.Time
+	// signLocks serialise the velocity checks and updates of users, striped by
+	// the hash of their uuid
+	signLocks [signLockStripes]sync.Mutex
+	// auditLock serialises the appends to the audit journal
+	auditLock sync.Mutex
+}
+
+// newLogger returns the logger of the mount: text records on stderr at the
+// log_level mount option, sensitive attributes redacted
+func (b *Backend) newLogger(conf *logical.BackendConfig) *slog.Logger {
+	var options map[string]string
+	if conf != nil {
+		options = conf.Config
+	}
+
+	keys := logging.DefaultRedactedKeys
+	if extra := options[config.LogRedactKeysOption]; extra != "" {
+		keys = append(slices.Clone(keys), strings.Split(extra, ",")...)
+	}
+
+	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: &b.logLevel})
+	logger := slog.New(logging.NewRedactingHandler(handler, keys))
+
+	if option := options[config.LogLevelOption]; option != "" {
+		level, err := logging.ParseLevel(option)
+		if err != nil {
+			logger.Warn("ignoring log level mount option", "error", err)
+		} else {
+			b.logLevel.Set(level)
+		}
+	}
+	b.mountLogLevel = b.logLevel.Level()
+	return logger
+}
+
+// now returns the current time in UTC
+func (b *Backend) now() time.Time {
+	if b.clock != nil {
+		return b.clock().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// adapters returns the blockchain adapters of the backend, logging to its
+// logger without the attributes of any request
+func (b *Backend) adapters() *adapter.Inventory {
+	b.adapterOnce.Do(func() {
+		b.adapterInventory = adapter.NewInventory(b.logger)
+	})
+	return b.adapterInventory
+}
+
+// signLockStripes is the count of the locks users are striped over, bounding
+// the memory held by locks whatever the count of users
+const signLockStripes = 256
+
+// signLockStripe returns the index of the lock of uuid
+func signLockStripe(uuid string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(uuid))
+	return int(h.Sum32() % signLockStripes)
+}
+
+// lockUser locks the signing of uuid and returns the unlock function
+func (b *Backend) lockUser(uuid string) func() {
+	mutex := &b.signLocks[signLockStripe(uuid)]
+	mutex.Lock()
+	return mutex.Unlock
+}
+
+// lockUsers locks the signing of every user of uuids and returns the unlock
+// function. Users sharing a lock take it once, and locks are taken in stripe
+// order so that concurrent batches cannot deadlock.
+func (b *Backend) lockUsers(uuids []string) func() {
+	stripes := make([]int, 0, len(uuids))
+	for _, uuid := range uuids {
+		stripes = append(stripes, signLockStripe(uuid))
+	}
+	slices.Sort(stripes)
+	stripes = slices.Compact(stripes)
+
+	for _, stripe := range stripes {
+		b.signLocks[stripe].Lock()
+	}
+	return func() {
+		for _, stripe := range slices.Backward(stripes) {
+			b.signLocks[stripe].Unlock()
+		}
+	}
+}
+
+// userKeychains derives the keychains of the requests of a user. The keys of
+// paths the adapters resolve below a BIP-32 account, m/purpose'/coin'/account',
+// are derived from the account key, held by the key cache when enabled; the
+// seed of the user is derived at most once, when the cache has no key to offer.
+type userKeychains struct {
+	backend   *Backend
+	inventory *adapter.Inventory
+	user      *helpers.User
+	seed      []byte
+	accounts  []*lib.Keychain
+}
+
+// userKeychains returns the keychains of user, release zeroizes their keys
+func (b *Backend) userKeychains(user *helpers.User, inventory *adapter.Inventory) *userKeychains {
+	return &userKeychains{
+		backend:   b,
+		inventory: inventory,
+		user:      user,
+	}
+}
+
+// get returns the keychain deriving derivationPath for coinType
+func (k *userKeychains) get(coinType int, derivationPath string) (*lib.Keychain, error) {
+	cache := k.backend.keyCache
+	accountPath, ok := k.inventory.AccountPath(uint16(coinType), derivationPath)
+	if !ok || cache == nil || !cache.Enabled() {
+		seed, err := k.deriveSeed()
+		if err != nil {
+			return nil, err
+		}
+		return lib.NewSeedKeychain(seed), nil
+	}
+
+	if keychain, ok := cache.Get(k.user.UUID, accountPath, k.user.UpdatedAt); ok {
+		k.accounts = append(k.accounts, keychain)
+		return keychain, nil
+	}
+	seed, err := k.deriveSeed()
+	if err != nil {
+		return nil, err
+	}
+	keychain, err := lib.NewSeedKeychain(seed).AccountKeychain(accountPath)
+	if err != nil {
+		return nil, err
+	}
+	k.accounts = append(k.accounts, keychain)
+	cache.Put(k.user.UUID, accountPath, k.user.UpdatedAt, keychain)
+	return keychain, nil
+}
+
+// deriveSeed derives the seed of the user on its first call
+func (k *userKeychains) deriveSeed() ([]byte, error) {
+	if k.seed != nil {
+		return k.seed, nil
+	}
+	seed, err := lib.SeedFromMnemonic(k.user.Mnemonic, k.user.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	k.seed = seed
+	return seed, nil
+}
+
+// release zeroizes the seed and the account keys derived or loaded
+func (k *userKeychains) release() {
+	clear(k.seed)
+	for _, keychain := range k.accounts {
+		keychain.Zero()
+	}
+}
+
+// userKeychain returns the keychain of user deriving derivationPath for
+// coinType, and the function zeroizing its keys
+func (b *Backend) userKeychain(user *helpers.User, inventory *adapter.Inventory, coinType int,
+	derivationPath string) (*lib.Keychain, func(), error) {
+	keychains := b.userKeychains(user, inventory)
+	keychain, err := keychains.get(coinType, derivationPath)
+	if err != nil {
+		keychains.release()
+		return nil, nil, err
+	}
+	return keychain, keychains.release, nil
 }
 
 // NewBackend creates a new backend.
-func NewBackend(_ *logical.BackendConfig) *Backend {
+func NewBackend(conf *logical.BackendConfig) *Backend {
 	var b Backend
 
-	b.logger = slog.With(slog.String("component", "backend"))
+	b.logger = b.newLogger(conf).With(slog.String("component", "backend"))
+	b.metrics = metrics.New(prometheus.NewRegistry())
+	b.keyCache = keycache.New(0, 0)
 	b.Backend = &framework.Backend{
-		BackendType: logical.TypeLogical,
-		Help:        backendHelp,
+		BackendType:  logical.TypeLogical,
+		Help:         backendHelp,
+		Invalidate:   b.invalidate,
+		Clean:        b.clean,
+		PeriodicFunc: b.periodic,
 		Paths: []*framework.Path{
 
 			// api/register
@@ -60,6 +278,12 @@ Returns randomly generated user UUID
 						Description: "Passphrase of user (optional)",
 						Default:     "",
 					},
+					"language": {
+						Type: framework.TypeString,
+						Description: "BIP-39 word list of the mnemonic: english, japanese, korean, spanish, " +
+							"chinese_simplified, chinese_traditional, french, italian or czech",
+						Default: lib.LanguageEnglish,
+					},
 				},
 				Callbacks: map[logical.Operation]framework.OperationFunc{
 					logical.UpdateOperation: b.pathRegister,
@@ -87,7 +311,18 @@ Returns randomly generated user UUID
 					},
 					"payload": {
 						Type:        framework.TypeString,
-						Description: "Raw transaction payload",
+						Description: "Raw transaction payload, or a base64 PSBT for UTXO coin types",
+					},
+					"isDev": {
+						Type: framework.TypeBool,
+						Description: "Development mode: use testnet networks (address versions, HRPs, key prefixes) " +
+							"and reject mainnet chain IDs when signing",
+						Default: false,
+					},
+					"idempotencyKey": {
+						Type: framework.TypeString,
+						Description: "Client-supplied key of the request: retries with the same key and request " +
+							"return the signature of the first one, other requests under the key are rejected",
 					},
 				},
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -95,6 +330,115 @@ Returns randomly generated user UUID
 				},
 			},
 
+			// api/sign/batch
+			{
+				Pattern:      "sign/batch",
+				HelpSynopsis: "Generate signatures of several raw transactions",
+				HelpDescription: `
+
+Signs every item like sign, loading each user and deriving its keys once. Items are evaluated
+independently and return a signature or an error of code and message, in request order.
+With atomic, no signature is returned, nor velocity consumed, unless every item is signed.
+
+`,
+				Fields: map[string]*framework.FieldSchema{
+					"items": {
+						Type: framework.TypeSlice,
+						Description: "Transactions to sign, objects with uuid, path, coinType, payload " +
+							"and isDev (optional)",
+					},
+					"atomic": {
+						Type:        framework.TypeBool,
+						Description: "All or nothing: return signatures only when every item is signed",
+						Default:     false,
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.pathSignBatch,
+				},
+			},
+
+			// api/sign/message
+			{
+				Pattern:      "sign/message",
+				HelpSynopsis: "Sign an off-chain message",
+				HelpDescription: `
+
+Signs an off-chain message with the key derived from stored mnemonic and passphrase using deviation path.
+Supports personal_sign (EIP-191) and eth_signTypedData_v4 (EIP-712).
+Returns the 65 byte r||s||v signature. Users under a restrictive signing policy
+cannot sign messages; requests are journaled like dq/sign ones.
+
+`,
+				Fields: map[string]*framework.FieldSchema{
+					"uuid": {
+						Type:        framework.TypeString,
+						Description: "UUID of user",
+					},
+					"path": {
+						Type:        framework.TypeString,
+						Description: "Deviation path to obtain keys",
+						Default:     "",
+					},
+					"coinType": {
+						Type:        framework.TypeInt,
+						Description: "Cointype of signing key",
+					},
+					"method": {
+						Type:        framework.TypeString,
+						Description: "Signing method: personal_sign or eth_signTypedData_v4",
+						Default:     "personal_sign",
+					},
+					"message": {
+						Type: framework.TypeString,
+						Description: "Message to sign: text or 0x prefixed hex for personal_sign, " +
+							"typed data JSON for eth_signTypedData_v4",
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.pathSignMessage,
+				},
+			},
+
+			// api/decode
+			{
+				Pattern:      "decode",
+				HelpSynopsis: "Describe a transaction payload without signing it",
+				HelpDescription: `
+
+Parses a payload the way sign does, without deriving any key, and returns a
+normalized description: transaction type, chain ID, recipient, native value,
+token contract, token recipient and amount, method name and fee fields.
+With a uuid, the signing policy of the user is evaluated as well and the
+response tells whether it would allow the transaction and why not.
+
+`,
+				Fields: map[string]*framework.FieldSchema{
+					"uuid": {
+						Type:        framework.TypeString,
+						Description: "UUID of user whose signing policy to evaluate (optional)",
+						Default:     "",
+					},
+					"coinType": {
+						Type:        framework.TypeInt,
+						Description: "Cointype of transaction",
+					},
+					"payload": {
+						Type:        framework.TypeString,
+						Description: "Raw transaction payload, or a base64 PSBT for UTXO coin types",
+					},
+					"isDev": {
+						Type: framework.TypeBool,
+						Description: "Development mode: use testnet networks (address versions, HRPs, key prefixes) " +
+							"and reject mainnet chain IDs when signing",
+						Default: false,
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.pathDecode,
+				},
+			},
+
 			// api/address
 			{
 				Pattern:         "address",
@@ -114,12 +458,86 @@ Returns randomly generated user UUID
 						Type:        framework.TypeInt,
 						Description: "Cointype of transaction",
 					},
+					"isDev": {
+						Type:        framework.TypeBool,
+						Description: "Development mode: derive the testnet address (address versions and HRPs)",
+						Default:     false,
+					},
 				},
 				Callbacks: map[logical.Operation]framework.OperationFunc{
 					logical.UpdateOperation: b.pathAddress,
 				},
 			},
 
+			// api/pubkey
+			{
+				Pattern:      "pubkey",
+				HelpSynopsis: "Export the public key of a derivation path",
+				HelpDescription: `
+
+Returns the public key of a derivation path in the native format of the chain.
+For secp256k1 chains the compressed and uncompressed SEC1 encodings are returned as well.
+
+`,
+				Fields: map[string]*framework.FieldSchema{
+					"uuid": {
+						Type:        framework.TypeString,
+						Description: "UUID of user",
+					},
+					"path": {
+						Type:        framework.TypeString,
+						Description: "Derivation path of the key",
+					},
+					"coinType": {
+						Type:        framework.TypeInt,
+						Description: "Cointype of the key",
+					},
+					"isDev": {
+						Type:        framework.TypeBool,
+						Description: "Development mode: encode the key with testnet key prefixes",
+						Default:     false,
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.pathPubKey,
+				},
+			},
+
+			// api/xpub
+			{
+				Pattern:      "xpub",
+				HelpSynopsis: "Export the account-level extended public key",
+				HelpDescription: `
+
+Returns the extended public key of an account-level path (m/purpose'/coin'/account').
+The version follows the purpose: xpub/tpub for 44' and 86', ypub/upub for 49'
+and zpub/vpub for 84'. Watch-only services derive receive addresses from it.
+
+`,
+				Fields: map[string]*framework.FieldSchema{
+					"uuid": {
+						Type:        framework.TypeString,
+						Description: "UUID of user",
+					},
+					"path": {
+						Type:        framework.TypeString,
+						Description: "Account-level derivation path, e.g., m/84'/0'/0'",
+					},
+					"coinType": {
+						Type:        framework.TypeInt,
+						Description: "Cointype of the key",
+					},
+					"isDev": {
+						Type:        framework.TypeBool,
+						Description: "Development mode: encode the key with testnet versions (tpub, upub, vpub)",
+						Default:     false,
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.pathXpub,
+				},
+			},
+
 			// api/address/batch
 			{
 				Pattern:      "address/batch",
@@ -127,7 +545,8 @@ Returns randomly generated user UUID
 				HelpDescription: `
 
 Generates a batch of addresses from stored mnemonic and passphrase using a templated derivation path.
-(e.g., m/44'/60'/0'/0/%d).
+(e.g., m/44'/60'/0'/0/%d). Addresses are returned ordered by index with their path and public key;
+larger batches are paged with the nextCursor of each response.
 
 `,
 				Fields: map[string]*framework.FieldSchema{
@@ -152,12 +571,430 @@ Generates a batch of addresses from stored mnemonic and passphrase using a templ
 						Type:        framework.TypeInt,
 						Description: "Number of addresses to generate",
 					},
+					"cursor": {
+						Type:        framework.TypeString,
+						Description: "nextCursor of the previous page of the batch, overrides startIndex",
+					},
+					"isDev": {
+						Type:        framework.TypeBool,
+						Description: "Development mode: derive testnet addresses (address versions and HRPs)",
+						Default:     false,
+					},
 				},
 				Callbacks: map[logical.Operation]framework.OperationFunc{
 					logical.UpdateOperation: b.pathAddressBatch,
 				},
 			},
 
+			// api/users
+			{
+				Pattern:      "users/?$",
+				HelpSynopsis: "List registered users",
+				HelpDescription: `
+
+Lists the UUIDs of all registered users, soft deleted users included.
+
+`,
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ListOperation: b.pathUsersList,
+				},
+			},
+
+			// api/users/<uuid>
+			{
+				Pattern:      "users/" + framework.GenericNameRegex("uuid"),
+				HelpSynopsis: "Read or delete a user",
+				HelpDescription: `
+
+Read returns the username, timestamps and master key fingerprint of a user.
+The mnemonic and passphrase are never returned.
+Delete removes the user. With soft=true the user is kept as a tombstone
+and its keys can no longer be used for addresses or signatures.
+
+`,
+				Fields: map[string]*framework.FieldSchema{
+					"uuid": {
+						Type:        framework.TypeString,
+						Description: "UUID of user",
+					},
+					"soft": {
+						Type:        framework.TypeBool,
+						Description: "Keep a tombstone of the user instead of removing it",
+						Default:     false,
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation:   b.pathUsersRead,
+					logical.DeleteOperation: b.pathUsersDelete,
+				},
+			},
+
+			// api/users/<uuid>/backup
+			{
+				Pattern:      "users/" + framework.GenericNameRegex("uuid") + "/backup",
+				HelpSynopsis: "Export a SLIP-39 Shamir backup of a user",
+				HelpDescription: `
+
+Splits the mnemonic entropy of a user into SLIP-39 share groups, encrypted with
+the passphrase of the user. Recovery needs groupThreshold groups, each with the
+threshold of its members, so no single custodian holds the mnemonic. Groups are
+given as <threshold>of<count>, e.g., groupThreshold=2 groups=2of3,3of5,1of1.
+
+`,
+				Fields: map[string]*framework.FieldSchema{
+					"uuid": {
+						Type:        framework.TypeString,
+						Description: "UUID of user",
+					},
+					"groupThreshold": {
+						Type:        framework.TypeInt,
+						Description: "Number of groups needed to recover the mnemonic",
+						Default:     1,
+					},
+					"groups": {
+						Type:        framework.TypeCommaStringSlice,
+						Description: "Member threshold and count of every group, e.g., 2of3",
+						Default:     []string{"1of1"},
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.pathUserBackup,
+				},
+			},
+
+			// api/recover
+			{
+				Pattern:      "recover",
+				HelpSynopsis: "Recover a user from SLIP-39 shares",
+				HelpDescription: `
+
+Recovers a mnemonic from SLIP-39 shares exported by users/<uuid>/backup and
+registers a new user with it. With a uuid, nothing is stored: the response
+tells whether the shares and passphrase recover the keys of that user.
+A wrong passphrase recovers a different wallet rather than failing; compare
+the returned fingerprint with the one of the original user.
+
+`,
+				Fields: map[string]*framework.FieldSchema{
+					"shares": {
+						Type:        framework.TypeCommaStringSlice,
+						Description: "SLIP-39 share mnemonics",
+					},
+					"passphrase": {
+						Type:        framework.TypeString,
+						Description: "Passphrase of user the shares are encrypted with (optional)",
+						Default:     "",
+					},
+					"uuid": {
+						Type:        framework.TypeString,
+						Description: "UUID of user to verify the shares against (optional)",
+						Default:     "",
+					},
+					"username": {
+						Type:        framework.TypeString,
+						Description: "Username of the recovered user (optional)",
+						Default:     "",
+					},
+					"language": {
+						Type:        framework.TypeString,
+						Description: "BIP-39 word list of the recovered mnemonic",
+						Default:     lib.LanguageEnglish,
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.pathRecover,
+				},
+			},
+
+			// api/policies
+			{
+				Pattern:      "policies/?$",
+				HelpSynopsis: "List signing policies",
+				HelpDescription: `
+
+Lists the UUIDs of the users having a signing policy, and default when the
+global default policy is set.
+
+`,
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ListOperation: b.pathPoliciesList,
+				},
+			},
+
+			// api/policies/<uuid>
+			{
+				Pattern:      "policies/" + framework.GenericNameRegex("uuid"),
+				HelpSynopsis: "Manage the signing policy of a user",
+				HelpDescription: `
+
+A signing policy restricts the transactions signed with the keys of a user:
+allowed destination addresses, maximum native value per transaction and coin
+type, allowed chain IDs, allowed ERC-20/TRC-20 token contracts, which also bound
+the contracts transactions may call, and rolling-window velocity limits per coin
+type and token. Empty settings leave the property unrestricted. The policy stored under "default" applies to users
+without their own policy. Policies are evaluated against the decoded payload
+before any key is derived; violations fail with HTTP 403. Payloads that cannot
+be decoded (Solana, Bitshares) are only signed under unrestricted policies.
+
+`,
+				Fields: map[string]*framework.FieldSchema{
+					"uuid": {
+						Type:        framework.TypeString,
+						Description: "UUID of user, or default for the global policy",
+					},
+					"allowedDestinations": {
+						Type:        framework.TypeCommaStringSlice,
+						Description: "Addresses transactions may send value or tokens to",
+					},
+					"maxValues": {
+						Type: framework.TypeKVPairs,
+						Description: "Maximum native value per transaction keyed by coin type, in the smallest " +
+							"unit (wei, sun, satoshi), e.g., 60=1000000000000000000",
+					},
+					"allowedChainIds": {
+						Type:        framework.TypeCommaIntSlice,
+						Description: "Chain IDs transactions may be signed for",
+					},
+					"allowedTokens": {
+						Type:        framework.TypeCommaStringSlice,
+						Description: "ERC-20/TRC-20 token contracts transactions may transfer, and the only contracts they may call",
+					},
+					"velocityLimits": {
+						Type: framework.TypeSlice,
+						Description: "Rolling-window limits, objects with coinType, token (optional), " +
+							"window (e.g., 24h), maxValue and maxCount",
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation:   b.pathPoliciesRead,
+					logical.UpdateOperation: b.pathPoliciesWrite,
+					logical.DeleteOperation: b.pathPoliciesDelete,
+				},
+			},
+
+			// api/velocity/<uuid>
+			{
+				Pattern:      "velocity/" + framework.GenericNameRegex("uuid"),
+				HelpSynopsis: "Read the velocity limit consumption of a user",
+				HelpDescription: `
+
+Returns, for every velocity limit of the policy applying to a user, the value
+and number of transactions signed within the current window, what remains of
+the value limit and when the oldest signature of the window expires.
+
+`,
+				Fields: map[string]*framework.FieldSchema{
+					"uuid": {
+						Type:        framework.TypeString,
+						Description: "UUID of user",
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.pathVelocityRead,
+				},
+			},
+
+			// api/audit
+			{
+				Pattern:      "audit/?$",
+				HelpSynopsis: "List the signing audit journal",
+				HelpDescription: `
+
+Lists the sequence numbers of the audit journal entries following after,
+oldest first, with their time, uuid, coin type and outcome. When more entries
+remain, next holds the value of after for the following page.
+
+`,
+				Fields: map[string]*framework.FieldSchema{
+					"after": {
+						Type:        framework.TypeInt,
+						Description: "Sequence number to list after",
+						Default:     0,
+					},
+					"limit": {
+						Type:        framework.TypeInt,
+						Description: "Maximum number of entries to list (at most 1000)",
+						Default:     100,
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ListOperation: b.pathAuditList,
+				},
+			},
+
+			// api/audit/verify
+			{
+				Pattern:      "audit/verify",
+				HelpSynopsis: "Verify the signing audit journal",
+				HelpDescription: `
+
+Walks the audit journal and checks that every entry hashes to its recorded
+hash and links to the previous one. Returns whether the chain is intact, the
+number of entries and the head hash; keep the head hash elsewhere to detect
+the removal of the latest entries.
+
+`,
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.pathAuditVerify,
+				},
+			},
+
+			// api/audit/<sequence>
+			{
+				Pattern:      "audit/(?P<sequence>[0-9]+)$",
+				HelpSynopsis: "Read a signing audit journal entry",
+				HelpDescription: `
+
+Every signing request, signed or rejected, is journaled with the uuid, coin
+type and path, the decoded recipient and amounts, the transaction hash, the
+request ID and display name of the caller and the hash of the previous entry.
+
+`,
+				Fields: map[string]*framework.FieldSchema{
+					"sequence": {
+						Type:        framework.TypeInt,
+						Description: "Sequence number of the entry",
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.pathAuditRead,
+				},
+			},
+
+			// api/config
+			{
+				Pattern:      "config",
+				HelpSynopsis: "Configure the mount",
+				HelpDescription: `
+
+Reads or writes the configuration of the mount, read by the handlers at request
+time: entropy length of generated mnemonics, maximum count of address batches,
+coin types keys may be used for (all when empty), default derivation templates
+per coin type used when a request has no path (%d standing for the address
+index, 0 outside batches), whether isDev requests are allowed, the log level,
+the bounds of the key cache, which holds the account keys of recently used users
+to spare the PBKDF2 derivation of their seeds, and how long the signatures of
+idempotent sign requests are kept. Writes only change the fields of the request;
+delete restores the defaults.
+
+`,
+				Fields: map[string]*framework.FieldSchema{
+					"entropyLength": {
+						Type:        framework.TypeInt,
+						Description: "Entropy in bits of generated mnemonics: 128, 160, 192, 224 or 256",
+					},
+					"maxBatchSize": {
+						Type:        framework.TypeInt,
+						Description: "Maximum count of address/batch requests and sign/batch items",
+					},
+					"enabledCoinTypes": {
+						Type:        framework.TypeCommaIntSlice,
+						Description: "Coin types keys may be used for, all when empty",
+					},
+					"derivationTemplates": {
+						Type:        framework.TypeKVPairs,
+						Description: "Default derivation templates keyed by coin type, e.g., 60=m/44'/60'/0'/0/%d",
+					},
+					"devModeEnabled": {
+						Type:        framework.TypeBool,
+						Description: "Allow requests with isDev set",
+					},
+					"logLevel": {
+						Type:        framework.TypeString,
+						Description: "Log level overriding the log_level mount option: DEBUG, INFO, WARN or ERROR",
+					},
+					"keyCacheSize": {
+						Type:        framework.TypeInt,
+						Description: "Maximum count of account keys held in memory, 0 disables the key cache",
+					},
+					"keyCacheTTL": {
+						Type:        framework.TypeInt,
+						Description: "Time in seconds account keys are held in memory",
+					},
+					"idempotencyTTL": {
+						Type:        framework.TypeInt,
+						Description: "Time in seconds the signatures of requests with an idempotency key are kept",
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation:   b.pathConfigRead,
+					logical.UpdateOperation: b.pathConfigWrite,
+					logical.DeleteOperation: b.pathConfigDelete,
+				},
+			},
+
+			// api/keyring
+			{
+				Pattern:      "keyring",
+				HelpSynopsis: "Read the keyring sealing the secrets of users",
+				HelpDescription: `
+
+Returns the key-encryption key of the mount, set with the kek_type mount option,
+and the versions of the data-encryption keys it wraps. The entries of users are
+sealed with the latest version; entries stored in plaintext or sealed with an
+older version are sealed again with it when read.
+
+`,
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.pathKeyringRead,
+				},
+			},
+
+			// api/keyring/rotate
+			{
+				Pattern:      "keyring/rotate",
+				HelpSynopsis: "Rotate the data-encryption key of the mount",
+				HelpDescription: `
+
+Adds a data-encryption key version sealing the entries of users from now on.
+Entries sealed with older versions remain readable and are sealed again when
+read, or at once with reencrypt.
+
+`,
+				Fields: map[string]*framework.FieldSchema{
+					"reencrypt": {
+						Type:        framework.TypeBool,
+						Description: "Seal every user entry again with the new version",
+						Default:     false,
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.pathKeyringRotate,
+				},
+			},
+
+			// api/keyring/rewrap
+			{
+				Pattern:      "keyring/rewrap",
+				HelpSynopsis: "Wrap the data-encryption keys again",
+				HelpDescription: `
+
+Wraps every data-encryption key version again with the key-encryption key, e.g.,
+after the rotation of the transit key, so that older key-encryption key versions
+can be retired.
+
+`,
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.pathKeyringRewrap,
+				},
+			},
+
+			// api/metrics
+			{
+				Pattern:      "metrics",
+				HelpSynopsis: "Export the metrics of the plugin",
+				HelpDescription: `
+
+Returns the metrics of the mount in the Prometheus text exposition format:
+requests per path and coin type, errors per path, status code and reason,
+signing and derivation latency histograms and signing policy rejections.
+
+`,
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.pathMetrics,
+				},
+			},
+
 			// api/info
 			{
 				Pattern:      "info",
@@ -174,6 +1011,8 @@ get help.
 			},
 		},
 	}
+	b.sealPaths()
+	b.instrumentPaths()
 	return &b
 }
 
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/api/logging"
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib"
+	"github.com/payment-system/dq-vault/lib/adapter/evm"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+func TestNewBackend_LogLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]string
+		want    slog.Level
+	}{
+		{name: "default", options: nil, want: slog.LevelInfo},
+		{name: "debug", options: map[string]string{config.LogLevelOption: "debug"}, want: slog.LevelDebug},
+		{name: "error", options: map[string]string{config.LogLevelOption: "ERROR"}, want: slog.LevelError},
+		{name: "invalid", options: map[string]string{config.LogLevelOption: "verbose"}, want: slog.LevelInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBackend(&logical.BackendConfig{Config: tt.options})
+			assert.Equal(t, tt.want, b.logLevel.Level())
+		})
+	}
+}
+
+func TestBackend_LogRedaction(t *testing.T) {
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	backend := &Backend{logger: slog.New(logging.NewRedactingHandler(handler, logging.DefaultRedactedKeys))}
+	storage := &logical.InmemStorage{}
+
+	registered, err := backend.pathRegister(ctx, &logical.Request{Storage: storage}, createRegisterFieldData(
+		map[string]interface{}{"mnemonic": signTestValidMnemonic, "passphrase": signTestPassphrase}))
+	require.NoError(t, err)
+	uuid := registered.Data["uuid"].(string)
+
+	invalidMnemonic := "legal winner thank year wave sausage worth useful legal winner thank thank"
+	_, err = backend.pathRegister(ctx, &logical.Request{Storage: storage}, createRegisterFieldData(
+		map[string]interface{}{"mnemonic": invalidMnemonic + " extra"}))
+	require.Error(t, err)
+
+	signed, err := backend.pathSign(ctx, &logical.Request{Storage: storage}, createSignFieldData(
+		map[string]interface{}{
+			"uuid":     uuid,
+			"path":     signTestDerivationPath,
+			"coinType": int(slip44.Ether),
+			"payload":  signTestPayload,
+		}))
+	require.NoError(t, err)
+	signature := strings.TrimPrefix(signed.Data["signature"].(string), "0x")
+
+	seed, err := lib.SeedFromMnemonic(signTestValidMnemonic, signTestPassphrase)
+	require.NoError(t, err)
+	privateKey, err := evm.NewEthereumAdapter(slog.New(slog.DiscardHandler)).
+		DerivePrivateKey(lib.NewSeedKeychain(seed), signTestDerivationPath, false)
+	require.NoError(t, err)
+
+	logs := buf.String()
+	require.NotEmpty(t, logs)
+	for name, secret := range map[string]string{
+		"mnemonic":         "abandon",
+		"invalid mnemonic": "sausage",
+		"passphrase":       signTestPassphrase,
+		"payload":          "gasPrice",
+		"signature":        signature,
+		"private key":      privateKey,
+	} {
+		assert.NotContains(t, logs, secret, "%s reached the log", name)
+	}
+}
+
+func TestBackend_LockUsers(t *testing.T) {
+	b := NewBackend(&logical.BackendConfig{})
+
+	// find a user sharing the lock of another
+	first, second := "user-0", ""
+	for i := 1; second == ""; i++ {
+		if uuid := "user-" + strconv.Itoa(i); signLockStripe(uuid) == signLockStripe(first) {
+			second = uuid
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		unlock := b.lockUsers([]string{first, second, "other"})
+		unlock()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("users sharing a lock deadlocked")
+	}
+
+	unlock := b.lockUsers([]string{second})
+	assert.False(t, b.signLocks[signLockStripe(first)].TryLock(), "users sharing a lock exclude each other")
+	unlock()
+	assert.True(t, b.signLocks[signLockStripe(first)].TryLock(), "unlocked")
+}
+
+func TestBackend_Adapters(t *testing.T) {
+	b := NewBackend(&logical.BackendConfig{})
+	assert.Same(t, b.adapters(), b.adapters())
+	assert.NotSame(t, b.adapters(), NewBackend(&logical.BackendConfig{}).adapters(), "built per backend")
+}
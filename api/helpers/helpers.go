@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -18,14 +19,28 @@ var (
 	ErrInvalidPath      = errors.New("provide a valid path")
 	ErrUUIDDoesNotExist = errors.New("UUID does not exists")
 	ErrUnknownFields    = errors.New("unknown fields provided")
+	ErrUserDeleted      = errors.New("user has been deleted")
 )
 
 // User -- stores data related to user
+//
+// DeletedAt is set on users retired with a soft delete. The entry is kept as
+// a tombstone, but its keys can no longer be used.
 type User struct {
 	Username   string `json:"username"`
 	UUID       string `json:"uuid"`
 	Mnemonic   string `json:"mnemonic"`
 	Passphrase string `json:"passphrase"`
+	// Language is the BIP-39 word list of the mnemonic, English when empty
+	Language  string     `json:"language,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// IsDeleted reports whether the user has been soft deleted
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
 }
 
 // NewUUID returns a globally unique random generated guid
@@ -75,35 +90,57 @@ func New(text string) error {
 	return &errorString{text}
 }
 
-// ValidateData - validates data provided to create signature
-func ValidateData(ctx context.Context, req *logical.Request, uuid, derivationPath string) error {
-	// Check if user provided UUID or not
+// UUIDExists checks if uuid exists or not
+func UUIDExists(ctx context.Context, req *logical.Request, uuid string) (bool, error) {
+	entry, err := req.Storage.Get(ctx, config.StorageBasePath+uuid)
+	if err != nil {
+		return false, err
+	}
+	return entry != nil, nil
+}
+
+// GetUser loads the user stored under uuid, soft deleted users included
+func GetUser(ctx context.Context, storage logical.Storage, uuid string) (*User, error) {
 	if uuid == "" {
-		return ErrInvalidUUID
+		return nil, ErrInvalidUUID
 	}
 
-	// base check: if derivation path is valid or not
-	if derivationPath == "" {
-		return ErrInvalidPath
+	entry, err := storage.Get(ctx, config.StorageBasePath+uuid)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrUUIDDoesNotExist
 	}
 
-	if !UUIDExists(ctx, req, uuid) {
-		return ErrUUIDDoesNotExist
+	var user User
+	if err := entry.DecodeJSON(&user); err != nil {
+		return nil, err
 	}
-	return nil
+	return &user, nil
 }
 
-// UUIDExists checks if uuid exists or not
-func UUIDExists(ctx context.Context, req *logical.Request, uuid string) bool {
-	vals, err := req.Storage.List(ctx, config.StorageBasePath)
+// LoadUser validates the data of a key request and loads the user whose keys
+// it uses. Soft deleted users are rejected.
+func LoadUser(ctx context.Context, req *logical.Request, uuid, derivationPath string) (*User, error) {
+	// Check if user provided UUID or not
+	if uuid == "" {
+		return nil, ErrInvalidUUID
+	}
+
+	// base check: if derivation path is valid or not
+	if derivationPath == "" {
+		return nil, ErrInvalidPath
+	}
+
+	user, err := GetUser(ctx, req.Storage, uuid)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
-	for _, val := range vals {
-		if val == uuid {
-			return true
-		}
+	// keys of soft deleted users must not be used anymore
+	if user.IsDeleted() {
+		return nil, ErrUserDeleted
 	}
-	return false
+	return user, nil
 }
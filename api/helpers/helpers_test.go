@@ -0,0 +1,103 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/config"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func putUser(t testing.TB, ctx context.Context, storage logical.Storage, user *User) {
+	t.Helper()
+
+	entry, err := logical.StorageEntryJSON(config.StorageBasePath+user.UUID, user)
+	require.NoError(t, err)
+	require.NoError(t, storage.Put(ctx, entry))
+}
+
+func TestLoadUser(t *testing.T) {
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+	req := &logical.Request{Storage: storage}
+
+	deletedAt := time.Now().UTC()
+	putUser(t, ctx, storage, &User{UUID: "active", Mnemonic: testMnemonic})
+	putUser(t, ctx, storage, &User{UUID: "deleted", Mnemonic: testMnemonic, DeletedAt: &deletedAt})
+	require.NoError(t, storage.Put(ctx, &logical.StorageEntry{
+		Key:   config.StorageBasePath + "corrupt",
+		Value: []byte("{invalid json}"),
+	}))
+
+	tests := []struct {
+		name          string
+		uuid          string
+		path          string
+		expectedError error
+	}{
+		{name: "active user", uuid: "active", path: "m/44'/60'/0'/0/0"},
+		{name: "empty uuid", uuid: "", path: "m/44'/60'/0'/0/0", expectedError: ErrInvalidUUID},
+		{name: "empty path", uuid: "active", path: "", expectedError: ErrInvalidPath},
+		{name: "unknown user", uuid: "unknown", path: "m/44'/60'/0'/0/0", expectedError: ErrUUIDDoesNotExist},
+		{name: "deleted user", uuid: "deleted", path: "m/44'/60'/0'/0/0", expectedError: ErrUserDeleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := LoadUser(ctx, req, tt.uuid, tt.path)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.uuid, user.UUID)
+			assert.Equal(t, testMnemonic, user.Mnemonic)
+		})
+	}
+
+	t.Run("corrupt entry", func(t *testing.T) {
+		_, err := LoadUser(ctx, req, "corrupt", "m/44'/60'/0'/0/0")
+		assert.Error(t, err)
+	})
+
+	t.Run("deleted users still exist", func(t *testing.T) {
+		user, err := GetUser(ctx, storage, "deleted")
+		require.NoError(t, err)
+		assert.True(t, user.IsDeleted())
+		exists, err := UUIDExists(ctx, req, "deleted")
+		require.NoError(t, err)
+		assert.True(t, exists)
+		exists, err = UUIDExists(ctx, req, "unknown")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+// BenchmarkLoadUser shows that loading a user does not depend on the number of stored users
+func BenchmarkLoadUser(b *testing.B) {
+	ctx := context.Background()
+
+	for _, users := range []int{100, 10000, 100000} {
+		storage := &logical.InmemStorage{}
+		for i := range users {
+			putUser(b, ctx, storage, &User{UUID: fmt.Sprintf("user-%d", i), Mnemonic: testMnemonic})
+		}
+		req := &logical.Request{Storage: storage}
+		uuid := fmt.Sprintf("user-%d", users-1)
+
+		b.Run(fmt.Sprintf("users=%d", users), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := LoadUser(ctx, req, uuid, "m/44'/60'/0'/0/0"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
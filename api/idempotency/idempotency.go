@@ -0,0 +1,200 @@
+// Package idempotency keeps the outcome of signing requests under keys chosen
+// by clients, so that retried requests return the signature already produced
+// instead of signing again.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/config"
+)
+
+// expiryBucket is the span of the expiry buckets records are indexed by
+const expiryBucket = time.Hour
+
+// bucketFormat formats the start of expiry buckets in storage keys, in UTC
+const bucketFormat = "20060102T15"
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrKeyReused  = errors.New("idempotency key was used with a different request")
+	ErrInvalidKey = errors.New("idempotency key is too long")
+)
+
+// Record is the outcome of a signing request stored under its idempotency key
+type Record struct {
+	// RequestHash is the SHA-256 of the request, see RequestHash
+	RequestHash string    `json:"requestHash"`
+	Signature   string    `json:"signature"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// ValidateKey checks the length of an idempotency key
+func ValidateKey(key string) error {
+	if len(key) > config.IdempotencyKeyMaxLength {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// RequestHash returns the hex SHA-256 of the JSON encoding of request
+func RequestHash(request interface{}) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// storageKey returns the storage key of the record of key, the key is hashed
+// as it is chosen by clients
+func storageKey(uuid, key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return config.IdempotencyStoragePath + uuid + "/" + hex.EncodeToString(hash[:])
+}
+
+// indexKey returns the key of the entry indexing the record stored at
+// recordKey under the bucket of its expiry
+func indexKey(recordKey string, expiresAt time.Time) string {
+	bucket := expiresAt.UTC().Truncate(expiryBucket).Format(bucketFormat)
+	return config.IdempotencyExpiryStoragePath + bucket + "/" +
+		strings.TrimPrefix(recordKey, config.IdempotencyStoragePath)
+}
+
+// Get loads the record of uuid stored under key. It returns nil when none is
+// stored or it has expired at now.
+func Get(ctx context.Context, storage logical.Storage, uuid, key string, now time.Time) (*Record, error) {
+	record, err := load(ctx, storage, storageKey(uuid, key))
+	if err != nil || record == nil {
+		return nil, err
+	}
+	if !now.Before(record.ExpiresAt) {
+		return nil, nil
+	}
+	return record, nil
+}
+
+// load loads the record stored at recordKey, nil when none is
+func load(ctx context.Context, storage logical.Storage, recordKey string) (*Record, error) {
+	entry, err := storage.Get(ctx, recordKey)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+
+	var record Record
+	if err := entry.DecodeJSON(&record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Put stores the record of uuid under key, indexed by the bucket of its expiry
+func Put(ctx context.Context, storage logical.Storage, uuid, key string, record *Record) error {
+	recordKey := storageKey(uuid, key)
+	entry, err := logical.StorageEntryJSON(recordKey, record)
+	if err != nil {
+		return err
+	}
+	if err := storage.Put(ctx, entry); err != nil {
+		return err
+	}
+	return storage.Put(ctx, &logical.StorageEntry{Key: indexKey(recordKey, record.ExpiresAt)})
+}
+
+// Delete deletes the records of uuid, their index entries are dropped by Prune
+func Delete(ctx context.Context, storage logical.Storage, uuid string) error {
+	prefix := config.IdempotencyStoragePath + uuid + "/"
+	keys, err := storage.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := storage.Delete(ctx, prefix+key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteRecord deletes the record of uuid stored under key, its index entry is
+// dropped by Prune
+func DeleteRecord(ctx context.Context, storage logical.Storage, uuid, key string) error {
+	return storage.Delete(ctx, storageKey(uuid, key))
+}
+
+// Prune deletes the records expired at now and returns their count. Only the
+// records indexed by the expiry buckets started at now are read.
+func Prune(ctx context.Context, storage logical.Storage, now time.Time) (int, error) {
+	buckets, err := storage.List(ctx, config.IdempotencyExpiryStoragePath)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	for _, bucket := range buckets {
+		bucket = strings.TrimSuffix(bucket, "/")
+		start, err := time.Parse(bucketFormat, bucket)
+		if err != nil || start.After(now) {
+			continue
+		}
+
+		count, err := pruneBucket(ctx, storage, config.IdempotencyExpiryStoragePath+bucket+"/", now)
+		pruned += count
+		if err != nil {
+			return pruned, err
+		}
+	}
+	return pruned, nil
+}
+
+// pruneBucket deletes the records indexed under prefix that expired at now,
+// and the index entries of the records deleted, expired or stored again since
+func pruneBucket(ctx context.Context, storage logical.Storage, prefix string, now time.Time) (int, error) {
+	uuids, err := storage.List(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	for _, uuid := range uuids {
+		keys, err := storage.List(ctx, prefix+uuid)
+		if err != nil {
+			return pruned, err
+		}
+		for _, key := range keys {
+			recordKey := config.IdempotencyStoragePath + uuid + key
+			record, err := load(ctx, storage, recordKey)
+			if err != nil {
+				return pruned, err
+			}
+
+			switch {
+			case record == nil:
+				// deleted with its user
+			case now.Before(record.ExpiresAt):
+				// records stored again under their key are indexed by a later bucket
+				if indexKey(recordKey, record.ExpiresAt) == prefix+uuid+key {
+					continue
+				}
+			default:
+				if err := storage.Delete(ctx, recordKey); err != nil {
+					return pruned, err
+				}
+				pruned++
+			}
+			if err := storage.Delete(ctx, prefix+uuid+key); err != nil {
+				return pruned, err
+			}
+		}
+	}
+	return pruned, nil
+}
@@ -0,0 +1,141 @@
+package idempotency
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPut(t *testing.T) {
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	record, err := Get(ctx, storage, "uuid", "key", now)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	require.NoError(t, Put(ctx, storage, "uuid", "key", &Record{
+		RequestHash: "hash",
+		Signature:   "signature",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	}))
+
+	record, err = Get(ctx, storage, "uuid", "key", now.Add(time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, "signature", record.Signature)
+
+	// keys are scoped per user
+	record, err = Get(ctx, storage, "other", "key", now)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	record, err = Get(ctx, storage, "uuid", "key", now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Nil(t, record, "expired")
+}
+
+func TestRequestHash(t *testing.T) {
+	type request struct {
+		Payload string `json:"payload"`
+	}
+	a, err := RequestHash(request{Payload: "a"})
+	require.NoError(t, err)
+	again, err := RequestHash(request{Payload: "a"})
+	require.NoError(t, err)
+	b, err := RequestHash(request{Payload: "b"})
+	require.NoError(t, err)
+
+	assert.Equal(t, a, again)
+	assert.NotEqual(t, a, b)
+	assert.Len(t, a, 64)
+}
+
+func TestValidateKey(t *testing.T) {
+	assert.NoError(t, ValidateKey(""))
+	assert.NoError(t, ValidateKey(strings.Repeat("k", 255)))
+	assert.ErrorIs(t, ValidateKey(strings.Repeat("k", 256)), ErrInvalidKey)
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+
+	require.NoError(t, Put(ctx, storage, "uuid", "a", &Record{}))
+	require.NoError(t, Put(ctx, storage, "uuid", "b", &Record{}))
+	require.NoError(t, Put(ctx, storage, "other", "a", &Record{}))
+
+	require.NoError(t, Delete(ctx, storage, "uuid"))
+	keys, err := storage.List(ctx, "idempotency/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other/"}, keys)
+
+	require.NoError(t, DeleteRecord(ctx, storage, "other", "a"))
+	record, err := Get(ctx, storage, "other", "a", time.Time{})
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+// countingStorage counts the entries read from a storage
+type countingStorage struct {
+	logical.Storage
+	gets int
+}
+
+func (s *countingStorage) Get(ctx context.Context, key string) (*logical.StorageEntry, error) {
+	s.gets++
+	return s.Storage.Get(ctx, key)
+}
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+	storage := &countingStorage{Storage: &logical.InmemStorage{}}
+	now := time.Date(2026, 1, 1, 12, 30, 0, 0, time.UTC)
+
+	for key, expiresAt := range map[string]time.Time{
+		"expired":      now,
+		"expiring":     now.Add(10 * time.Minute),
+		"live":         now.Add(2 * time.Hour),
+		"stored again": now.Add(-time.Hour),
+	} {
+		require.NoError(t, Put(ctx, storage, "uuid", key, &Record{ExpiresAt: expiresAt}))
+	}
+	require.NoError(t, Put(ctx, storage, "uuid", "stored again", &Record{ExpiresAt: now.Add(2 * time.Hour)}))
+	require.NoError(t, Put(ctx, storage, "deleted", "key", &Record{ExpiresAt: now.Add(-time.Hour)}))
+	require.NoError(t, Delete(ctx, storage, "deleted"))
+
+	storage.gets = 0
+	pruned, err := Prune(ctx, storage, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	assert.Equal(t, 4, storage.gets, "records of later buckets are not read")
+
+	keys, err := storage.List(ctx, "idempotency/uuid/")
+	require.NoError(t, err)
+	assert.Len(t, keys, 3)
+	for _, key := range []string{"expiring", "live", "stored again"} {
+		record, err := Get(ctx, storage, "uuid", key, now)
+		require.NoError(t, err)
+		assert.NotNil(t, record, key)
+	}
+
+	buckets, err := storage.List(ctx, "idempotency-expiry/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"20260101T12/", "20260101T14/"}, buckets,
+		"the index entries of deleted and stored again records are dropped")
+
+	// the bucket of expiring is pruned once it expired
+	pruned, err = Prune(ctx, storage, now.Add(10*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	buckets, err = storage.List(ctx, "idempotency-expiry/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"20260101T14/"}, buckets)
+}
@@ -0,0 +1,193 @@
+// Package keycache caches the account keychains of users in memory, sparing
+// the 2048 PBKDF2-HMAC-SHA512 rounds of their seed derivation on every request.
+// Only account-level keys, m/purpose'/coin'/account', are held: the seed and
+// the other accounts of a user are never kept.
+package keycache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/payment-system/dq-vault/lib"
+)
+
+// key identifies the keychain of an account of a user
+type key struct {
+	uuid        string
+	accountPath string
+}
+
+// entry is a cached keychain, valid for the version of the user it was derived from
+type entry struct {
+	key       key
+	version   time.Time
+	keychain  *lib.Keychain
+	expiresAt time.Time
+}
+
+// Cache is a bounded LRU cache of account keychains keyed by uuid and account
+// path whose entries expire after a TTL. Keys are zeroized when evicted,
+// invalidated or purged.
+type Cache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	entries    map[key]*list.Element
+	lru        *list.List
+
+	// now returns the current time, time.Now when nil
+	now func() time.Time
+}
+
+// New returns a cache of at most maxEntries keychains, each held for ttl. The
+// cache is disabled when maxEntries or ttl is not positive.
+func New(maxEntries int, ttl time.Duration) *Cache {
+	return &Cache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    make(map[key]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// timeNow returns the current time of the cache
+func (c *Cache) timeNow() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// Configure sets the bounds of the cache, evicting the entries above them
+func (c *Cache) Configure(maxEntries int, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.maxEntries == maxEntries && c.ttl == ttl {
+		return
+	}
+
+	c.maxEntries, c.ttl = maxEntries, ttl
+	if !c.enabled() {
+		c.purge()
+		return
+	}
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+	// entries expire no later than the new TTL allows
+	latest := c.timeNow().Add(ttl)
+	for element := c.lru.Front(); element != nil; element = element.Next() {
+		if e := element.Value.(*entry); e.expiresAt.After(latest) {
+			e.expiresAt = latest
+		}
+	}
+}
+
+// Enabled reports whether the cache holds keychains
+func (c *Cache) Enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enabled()
+}
+
+// enabled reports whether the cache holds keychains, its lock held
+func (c *Cache) enabled() bool {
+	return c.maxEntries > 0 && c.ttl > 0
+}
+
+// Get returns a copy of the keychain of the account at accountPath of uuid
+// derived from the version of the user updated at version, false when it is
+// not cached or has expired. Callers zero it once done.
+func (c *Cache) Get(uuid, accountPath string, version time.Time) (*lib.Keychain, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key{uuid: uuid, accountPath: accountPath}]
+	if !ok {
+		return nil, false
+	}
+	e := element.Value.(*entry)
+	if !e.version.Equal(version) || !c.timeNow().Before(e.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+	keychain, err := e.keychain.Clone()
+	if err != nil {
+		c.remove(element)
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return keychain, true
+}
+
+// Put caches a copy of the keychain of the account at accountPath of uuid
+// derived from the version of the user updated at version, evicting the least
+// recently used keychain when full
+func (c *Cache) Put(uuid, accountPath string, version time.Time, keychain *lib.Keychain) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.enabled() {
+		return
+	}
+
+	k := key{uuid: uuid, accountPath: accountPath}
+	if element, ok := c.entries[k]; ok {
+		c.remove(element)
+	}
+	held, err := keychain.Clone()
+	if err != nil {
+		return
+	}
+	e := &entry{
+		key:       k,
+		version:   version,
+		keychain:  held,
+		expiresAt: c.timeNow().Add(c.ttl),
+	}
+	c.entries[k] = c.lru.PushFront(e)
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Invalidate drops the keychains of uuid, e.g., when the user is modified
+func (c *Cache) Invalidate(uuid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for element := c.lru.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*entry).key.uuid == uuid {
+			c.remove(element)
+		}
+		element = next
+	}
+}
+
+// Purge drops every keychain
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.purge()
+}
+
+// Len returns the count of cached keychains, expired ones included
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// purge drops every keychain, its lock held
+func (c *Cache) purge() {
+	for c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove drops the entry of element and zeroizes its keychain, the lock held
+func (c *Cache) remove(element *list.Element) {
+	e := c.lru.Remove(element).(*entry)
+	delete(c.entries, e.key)
+	e.keychain.Zero()
+}
@@ -0,0 +1,230 @@
+package keycache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/lib"
+)
+
+const (
+	testMnemonic    = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	testAccountPath = "m/44'/60'/0'"
+	testPath        = "m/44'/60'/0'/0/0"
+)
+
+// testClock is a settable clock
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func newTestCache(maxEntries int, ttl time.Duration) (*Cache, *testClock) {
+	clock := &testClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	c := New(maxEntries, ttl)
+	c.now = clock.Now
+	return c, clock
+}
+
+// testKeychain returns the keychain of the account m/44'/60'/0' of the test mnemonic
+func testKeychain(t testing.TB) *lib.Keychain {
+	t.Helper()
+
+	seed, err := lib.SeedFromMnemonic(testMnemonic, "")
+	require.NoError(t, err)
+	keychain, err := lib.NewSeedKeychain(seed).AccountKeychain(testAccountPath)
+	require.NoError(t, err)
+	return keychain
+}
+
+// heldKeychain returns the keychain held by the cache for the account of uuid
+func heldKeychain(c *Cache, uuid string) *lib.Keychain {
+	return c.entries[key{uuid: uuid, accountPath: testAccountPath}].Value.(*entry).keychain
+}
+
+// assertZeroed asserts that keychain derives no key anymore
+func assertZeroed(t *testing.T, keychain *lib.Keychain, msg string) {
+	t.Helper()
+
+	_, err := keychain.DerivePrivateKey(testPath)
+	assert.Error(t, err, msg)
+}
+
+func TestCache_GetPut(t *testing.T) {
+	version := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c, clock := newTestCache(2, time.Minute)
+	require.True(t, c.Enabled())
+
+	_, ok := c.Get("a", testAccountPath, version)
+	assert.False(t, ok)
+
+	keychain := testKeychain(t)
+	want, err := keychain.DerivePrivateKey(testPath)
+	require.NoError(t, err)
+	c.Put("a", testAccountPath, version, keychain)
+	keychain.Zero()
+
+	got, ok := c.Get("a", testAccountPath, version)
+	require.True(t, ok)
+	privateKey, err := got.DerivePrivateKey(testPath)
+	require.NoError(t, err)
+	assert.Equal(t, want.Serialize(), privateKey.Serialize(), "the cache holds its own copy")
+
+	// callers own the returned keychain
+	got.Zero()
+	got, ok = c.Get("a", testAccountPath, version)
+	require.True(t, ok)
+	_, err = got.DerivePrivateKey(testPath)
+	require.NoError(t, err)
+
+	t.Run("other account", func(t *testing.T) {
+		_, ok := c.Get("a", "m/44'/60'/1'", version)
+		assert.False(t, ok)
+	})
+
+	t.Run("modified user", func(t *testing.T) {
+		_, ok := c.Get("a", testAccountPath, version.Add(time.Second))
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		c.Put("a", testAccountPath, version, testKeychain(t))
+		clock.now = clock.now.Add(time.Minute)
+		_, ok := c.Get("a", testAccountPath, version)
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Len())
+	})
+}
+
+func TestCache_Eviction(t *testing.T) {
+	var version time.Time
+	c, _ := newTestCache(2, time.Minute)
+	keychain := testKeychain(t)
+
+	c.Put("a", testAccountPath, version, keychain)
+	c.Put("b", testAccountPath, version, keychain)
+	_, ok := c.Get("a", testAccountPath, version)
+	require.True(t, ok)
+
+	// b is the least recently used
+	c.Put("c", testAccountPath, version, keychain)
+	assert.Equal(t, 2, c.Len())
+	_, ok = c.Get("b", testAccountPath, version)
+	assert.False(t, ok)
+	_, ok = c.Get("a", testAccountPath, version)
+	assert.True(t, ok)
+	_, ok = c.Get("c", testAccountPath, version)
+	assert.True(t, ok)
+}
+
+func TestCache_Zeroize(t *testing.T) {
+	var version time.Time
+	c, _ := newTestCache(1, time.Minute)
+	keychain := testKeychain(t)
+
+	c.Put("a", testAccountPath, version, keychain)
+	held := heldKeychain(c, "a")
+	c.Put("b", testAccountPath, version, keychain)
+	assertZeroed(t, held, "zeroized on eviction")
+
+	held = heldKeychain(c, "b")
+	c.Invalidate("b")
+	assertZeroed(t, held, "zeroized on invalidation")
+
+	c.Put("c", testAccountPath, version, keychain)
+	held = heldKeychain(c, "c")
+	c.Purge()
+	assertZeroed(t, held, "zeroized on purge")
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	var version time.Time
+	c, _ := newTestCache(3, time.Minute)
+	other, err := lib.NewSeedKeychain(make([]byte, 64)).AccountKeychain("m/44'/60'/1'")
+	require.NoError(t, err)
+
+	c.Put("a", testAccountPath, version, testKeychain(t))
+	c.Put("a", "m/44'/60'/1'", version, other)
+	c.Put("b", testAccountPath, version, testKeychain(t))
+
+	// every account of the user is dropped
+	c.Invalidate("a")
+	assert.Equal(t, 1, c.Len())
+	_, ok := c.Get("b", testAccountPath, version)
+	assert.True(t, ok)
+}
+
+func TestCache_Configure(t *testing.T) {
+	var version time.Time
+	keychain := testKeychain(t)
+
+	t.Run("disabled", func(t *testing.T) {
+		c, _ := newTestCache(0, time.Minute)
+		assert.False(t, c.Enabled())
+		c.Put("a", testAccountPath, version, keychain)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("shrunk", func(t *testing.T) {
+		c, _ := newTestCache(3, time.Minute)
+		for i := range 3 {
+			c.Put(strconv.Itoa(i), testAccountPath, version, keychain)
+		}
+		c.Configure(1, time.Minute)
+		assert.Equal(t, 1, c.Len())
+		_, ok := c.Get("2", testAccountPath, version)
+		assert.True(t, ok, "the most recently used keychain is kept")
+	})
+
+	t.Run("shorter TTL", func(t *testing.T) {
+		c, clock := newTestCache(3, time.Hour)
+		c.Put("a", testAccountPath, version, keychain)
+		c.Configure(3, time.Minute)
+		clock.now = clock.now.Add(time.Minute)
+		_, ok := c.Get("a", testAccountPath, version)
+		assert.False(t, ok)
+	})
+
+	t.Run("disabling purges", func(t *testing.T) {
+		c, _ := newTestCache(3, time.Minute)
+		c.Put("a", testAccountPath, version, keychain)
+		held := heldKeychain(c, "a")
+		c.Configure(0, time.Minute)
+		assert.Equal(t, 0, c.Len())
+		assertZeroed(t, held, "zeroized on purge")
+	})
+}
+
+// BenchmarkKeychain compares a cache hit with the PBKDF2 derivation of a
+// BIP-39 seed and of its account key
+func BenchmarkKeychain(b *testing.B) {
+	var version time.Time
+
+	b.Run("derived", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			seed, _ := lib.SeedFromMnemonic(testMnemonic, "")
+			keychain, _ := lib.NewSeedKeychain(seed).AccountKeychain(testAccountPath)
+			keychain.Zero()
+			clear(seed)
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		c := New(1, time.Minute)
+		c.Put("a", testAccountPath, version, testKeychain(b))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			keychain, _ := c.Get("a", testAccountPath, version)
+			keychain.Zero()
+		}
+	})
+}
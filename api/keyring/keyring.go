@@ -0,0 +1,461 @@
+// Package keyring encrypts storage entries with per-mount data-encryption keys
+// wrapped by a key-encryption key held outside of Vault storage, so that the
+// secrets of users do not appear in raw storage dumps or snapshots.
+package keyring
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/config"
+)
+
+const (
+	// keyLength is the length in bytes of the AES-256 data-encryption keys
+	keyLength = 32
+	// versionLength is the length of the key version of a sealed value
+	versionLength = 4
+	// entryLockStripes is the count of the locks entries are striped over
+	entryLockStripes = 256
+)
+
+// sealedPrefix marks sealed values; plaintext JSON entries start with {
+var sealedPrefix = []byte("dq:sealed:")
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrKEKMismatch      = errors.New("keyring is wrapped by another key-encryption key")
+	ErrUnknownVersion   = errors.New("unknown data-encryption key version")
+	ErrSealedTooShort   = errors.New("sealed value is too short")
+	ErrInvalidKeyLength = errors.New("unwrapped data-encryption key has an invalid length")
+)
+
+// KeyVersion is a data-encryption key of the keyring, wrapped by the
+// key-encryption key
+type KeyVersion struct {
+	Version    int       `json:"version"`
+	WrappedKey []byte    `json:"wrappedKey"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// storedKeyring is the keyring entry, stored at config.KeyringStoragePath
+type storedKeyring struct {
+	KEK         string       `json:"kek"`
+	Keys        []KeyVersion `json:"keys"`
+	RewrappedAt time.Time    `json:"rewrappedAt,omitempty"`
+}
+
+// Info describes the keyring without any key material
+type Info struct {
+	KEK           string
+	LatestVersion int
+	Versions      []KeyVersion
+	RewrappedAt   time.Time
+}
+
+// Keyring holds the data-encryption keys of a mount, unwrapped on first use
+type Keyring struct {
+	wrapper KeyWrapper
+
+	mu     sync.RWMutex
+	stored *storedKeyring
+	keys   map[int][]byte
+
+	// entryLocks serialise the writes and migrations of entries, striped by
+	// the hash of their storage key, so that a migration never overwrites a
+	// newer write
+	entryLocks [entryLockStripes]sync.Mutex
+}
+
+// New returns a keyring whose data-encryption keys are wrapped by wrapper
+func New(wrapper KeyWrapper) *Keyring {
+	return &Keyring{wrapper: wrapper}
+}
+
+// KEK returns the name of the key-encryption key
+func (k *Keyring) KEK() string {
+	return k.wrapper.Name()
+}
+
+// load unwraps the stored keys, creating the keyring with a first key when
+// the mount has none
+func (k *Keyring) load(ctx context.Context, storage logical.Storage) error {
+	k.mu.RLock()
+	loaded := k.stored != nil
+	k.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.stored != nil {
+		return nil
+	}
+
+	entry, err := storage.Get(ctx, config.KeyringStoragePath)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		stored := &storedKeyring{KEK: k.wrapper.Name()}
+		keys := make(map[int][]byte)
+		if err := k.addKey(ctx, stored, keys); err != nil {
+			return err
+		}
+		if err := save(ctx, storage, stored); err != nil {
+			clearKeys(keys)
+			return err
+		}
+		k.stored, k.keys = stored, keys
+		return nil
+	}
+
+	var stored storedKeyring
+	if err := entry.DecodeJSON(&stored); err != nil {
+		return err
+	}
+	if stored.KEK != k.wrapper.Name() {
+		return fmt.Errorf("%w: %s, the mount is configured with %s", ErrKEKMismatch, stored.KEK, k.wrapper.Name())
+	}
+	keys, err := k.unwrapKeys(ctx, &stored)
+	if err != nil {
+		return err
+	}
+	k.stored, k.keys = &stored, keys
+	return nil
+}
+
+// unwrapKeys unwraps the keys of stored
+func (k *Keyring) unwrapKeys(ctx context.Context, stored *storedKeyring) (map[int][]byte, error) {
+	keys := make(map[int][]byte, len(stored.Keys))
+	for _, version := range stored.Keys {
+		key, err := k.wrapper.Unwrap(ctx, version.WrappedKey)
+		if err != nil {
+			clearKeys(keys)
+			return nil, fmt.Errorf("unwrap key version %d: %w", version.Version, err)
+		}
+		if len(key) != keyLength {
+			clearKeys(keys)
+			return nil, fmt.Errorf("%w: version %d", ErrInvalidKeyLength, version.Version)
+		}
+		keys[version.Version] = key
+	}
+	return keys, nil
+}
+
+// addKey generates the next key version of stored, wrapped
+func (k *Keyring) addKey(ctx context.Context, stored *storedKeyring, keys map[int][]byte) error {
+	key := make([]byte, keyLength)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	wrapped, err := k.wrapper.Wrap(ctx, key)
+	if err != nil {
+		clear(key)
+		return err
+	}
+
+	version := latestVersion(stored) + 1
+	stored.Keys = append(stored.Keys, KeyVersion{Version: version, WrappedKey: wrapped, CreatedAt: time.Now().UTC()})
+	keys[version] = key
+	return nil
+}
+
+// latestVersion returns the latest key version of stored, 0 when it has none
+func latestVersion(stored *storedKeyring) int {
+	if len(stored.Keys) == 0 {
+		return 0
+	}
+	return stored.Keys[len(stored.Keys)-1].Version
+}
+
+// save stores the keyring entry
+func save(ctx context.Context, storage logical.Storage, stored *storedKeyring) error {
+	entry, err := logical.StorageEntryJSON(config.KeyringStoragePath, stored)
+	if err != nil {
+		return err
+	}
+	return storage.Put(ctx, entry)
+}
+
+// Info describes the keyring, creating it when the mount has none
+func (k *Keyring) Info(ctx context.Context, storage logical.Storage) (*Info, error) {
+	if err := k.load(ctx, storage); err != nil {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return &Info{
+		KEK:           k.stored.KEK,
+		LatestVersion: latestVersion(k.stored),
+		Versions:      slices.Clone(k.stored.Keys),
+		RewrappedAt:   k.stored.RewrappedAt,
+	}, nil
+}
+
+// LatestVersion returns the version of the key sealing new values
+func (k *Keyring) LatestVersion(ctx context.Context, storage logical.Storage) (int, error) {
+	if err := k.load(ctx, storage); err != nil {
+		return 0, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return latestVersion(k.stored), nil
+}
+
+// Rotate adds a data-encryption key version that seals values from now on and
+// returns it. Values sealed with older versions remain readable.
+func (k *Keyring) Rotate(ctx context.Context, storage logical.Storage) (int, error) {
+	if err := k.load(ctx, storage); err != nil {
+		return 0, err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	stored := *k.stored
+	stored.Keys = slices.Clone(k.stored.Keys)
+	if err := k.addKey(ctx, &stored, k.keys); err != nil {
+		return 0, err
+	}
+	version := latestVersion(&stored)
+	if err := save(ctx, storage, &stored); err != nil {
+		clear(k.keys[version])
+		delete(k.keys, version)
+		return 0, err
+	}
+	k.stored = &stored
+	return version, nil
+}
+
+// Rewrap wraps every data-encryption key again with the key-encryption key,
+// e.g., after the rotation of the transit key
+func (k *Keyring) Rewrap(ctx context.Context, storage logical.Storage) error {
+	if err := k.load(ctx, storage); err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	stored := *k.stored
+	stored.Keys = slices.Clone(k.stored.Keys)
+	for i, version := range stored.Keys {
+		wrapped, err := k.wrapper.Wrap(ctx, k.keys[version.Version])
+		if err != nil {
+			return fmt.Errorf("wrap key version %d: %w", version.Version, err)
+		}
+		stored.Keys[i].WrappedKey = wrapped
+	}
+	stored.RewrappedAt = time.Now().UTC()
+	if err := save(ctx, storage, &stored); err != nil {
+		return err
+	}
+	k.stored = &stored
+	return nil
+}
+
+// Invalidate drops the unwrapped keys, zeroized, so that they are loaded
+// again on next use
+func (k *Keyring) Invalidate() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	clearKeys(k.keys)
+	k.stored, k.keys = nil, nil
+}
+
+// clearKeys zeroizes keys
+func clearKeys(keys map[int][]byte) {
+	for _, key := range keys {
+		clear(key)
+	}
+}
+
+// aead returns the AES-256-GCM cipher of key version, the latest when 0
+func (k *Keyring) aead(ctx context.Context, storage logical.Storage, version int) (cipher.AEAD, int, error) {
+	if err := k.load(ctx, storage); err != nil {
+		return nil, 0, err
+	}
+
+	k.mu.RLock()
+	if version == 0 {
+		version = latestVersion(k.stored)
+	}
+	key, ok := k.keys[version]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, 0, err
+	}
+	return aead, version, nil
+}
+
+// Seal encrypts the value of the storage entry key with the latest key
+// version. The key is authenticated, sealed values cannot be moved to another
+// entry.
+func (k *Keyring) Seal(ctx context.Context, storage logical.Storage, key string, value []byte) ([]byte, error) {
+	aead, version, err := k.aead(ctx, storage, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := make([]byte, 0, len(sealedPrefix)+versionLength+aead.NonceSize()+len(value)+aead.Overhead())
+	sealed = append(sealed, sealedPrefix...)
+	sealed = binary.BigEndian.AppendUint32(sealed, uint32(version))
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed = append(sealed, nonce...)
+	return aead.Seal(sealed, nonce, value, []byte(key)), nil
+}
+
+// Open decrypts a value sealed by Seal and returns the key version that
+// sealed it. Values that are not sealed are returned as they are, version 0.
+func (k *Keyring) Open(ctx context.Context, storage logical.Storage, key string,
+	value []byte) ([]byte, int, error) {
+	if !IsSealed(value) {
+		return value, 0, nil
+	}
+
+	sealed := value[len(sealedPrefix):]
+	if len(sealed) < versionLength {
+		return nil, 0, ErrSealedTooShort
+	}
+	version := int(binary.BigEndian.Uint32(sealed))
+	aead, _, err := k.aead(ctx, storage, version)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sealed = sealed[versionLength:]
+	if len(sealed) < aead.NonceSize() {
+		return nil, 0, ErrSealedTooShort
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(key))
+	if err != nil {
+		return nil, 0, fmt.Errorf("open %s: %w", key, err)
+	}
+	return plaintext, version, nil
+}
+
+// IsSealed reports whether value was sealed by a keyring
+func IsSealed(value []byte) bool {
+	return bytes.HasPrefix(value, sealedPrefix)
+}
+
+// Storage returns storage whose entries under prefix are sealed when written
+// and opened when read. Entries read in plaintext or sealed with an older key
+// version are sealed again with the latest one, migrating them transparently.
+// Storage already sealing its entries under prefix with k is returned as is,
+// as sealing it twice would lock the entries it migrates twice.
+func (k *Keyring) Storage(storage logical.Storage, prefix string, logger *slog.Logger) logical.Storage {
+	if sealed, ok := storage.(*sealedStorage); ok && sealed.keyring == k && sealed.prefix == prefix {
+		return storage
+	}
+	return &sealedStorage{Storage: storage, keyring: k, prefix: prefix, logger: logger}
+}
+
+// sealedStorage seals the entries of storage under prefix
+type sealedStorage struct {
+	logical.Storage
+	keyring *Keyring
+	prefix  string
+	logger  *slog.Logger
+}
+
+// Get reads the entry key, opened, and migrates it to the latest key version
+func (s *sealedStorage) Get(ctx context.Context, key string) (*logical.StorageEntry, error) {
+	entry, err := s.Storage.Get(ctx, key)
+	if err != nil || entry == nil || !strings.HasPrefix(key, s.prefix) {
+		return entry, err
+	}
+
+	value, version, err := s.keyring.Open(ctx, s.Storage, key, entry.Value)
+	if err != nil {
+		return nil, err
+	}
+	opened := &logical.StorageEntry{Key: entry.Key, Value: value, SealWrap: entry.SealWrap}
+
+	latest, err := s.keyring.LatestVersion(ctx, s.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if version < latest {
+		// standby nodes cannot write, the active node migrates the entry
+		if err := s.migrate(ctx, entry, opened); err != nil {
+			s.logger.Warn("migrate sealed entry", "key", key, "error", err)
+		} else {
+			s.logger.Info("sealed entry migrated", "key", key, "from", version, "to", latest)
+		}
+	}
+	return opened, nil
+}
+
+// migrate seals opened again with the latest key version, unless the entry
+// was written since it was read as raw
+func (s *sealedStorage) migrate(ctx context.Context, raw, opened *logical.StorageEntry) error {
+	unlock := s.keyring.lockEntry(raw.Key)
+	defer unlock()
+
+	current, err := s.Storage.Get(ctx, raw.Key)
+	if err != nil {
+		return err
+	}
+	if current == nil || !bytes.Equal(current.Value, raw.Value) {
+		return nil
+	}
+	return s.put(ctx, opened)
+}
+
+// Put writes entry, sealed when under prefix
+func (s *sealedStorage) Put(ctx context.Context, entry *logical.StorageEntry) error {
+	if !strings.HasPrefix(entry.Key, s.prefix) {
+		return s.Storage.Put(ctx, entry)
+	}
+
+	unlock := s.keyring.lockEntry(entry.Key)
+	defer unlock()
+	return s.put(ctx, entry)
+}
+
+// put seals and writes entry, its lock held
+func (s *sealedStorage) put(ctx context.Context, entry *logical.StorageEntry) error {
+	sealed, err := s.keyring.Seal(ctx, s.Storage, entry.Key, entry.Value)
+	if err != nil {
+		return err
+	}
+	return s.Storage.Put(ctx, &logical.StorageEntry{Key: entry.Key, Value: sealed, SealWrap: entry.SealWrap})
+}
+
+// lockEntry locks the writes of the storage entry key and returns the unlock
+// function
+func (k *Keyring) lockEntry(key string) func() {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	mutex := &k.entryLocks[h.Sum32()%entryLockStripes]
+	mutex.Lock()
+	return mutex.Unlock
+}
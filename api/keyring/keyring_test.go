@@ -0,0 +1,333 @@
+package keyring
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/config"
+)
+
+// fakeTransit stands in for a transit secrets engine, its key versions
+// XOR-ing the plaintext
+type fakeTransit struct {
+	keys [][]byte
+	err  error
+}
+
+func newFakeTransit() *fakeTransit {
+	return &fakeTransit{keys: [][]byte{bytes.Repeat([]byte{0x5a}, 32)}}
+}
+
+func (f *fakeTransit) rotate() {
+	f.keys = append(f.keys, bytes.Repeat([]byte{byte(0x5a + len(f.keys))}, 32))
+}
+
+func xor(key, data []byte) []byte {
+	out := make([]byte, len(data))
+	for i := range data {
+		out[i] = data[i] ^ key[i%len(key)]
+	}
+	return out
+}
+
+func (f *fakeTransit) Write(path string, data map[string]interface{}) (*vaultapi.Secret, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	switch {
+	case strings.HasPrefix(path, "transit/encrypt/"):
+		plaintext, err := base64.StdEncoding.DecodeString(data["plaintext"].(string))
+		if err != nil {
+			return nil, err
+		}
+		version := len(f.keys)
+		ciphertext := base64.StdEncoding.EncodeToString(xor(f.keys[version-1], plaintext))
+		return &vaultapi.Secret{Data: map[string]interface{}{
+			"ciphertext": fmt.Sprintf("vault:v%d:%s", version, ciphertext),
+		}}, nil
+	case strings.HasPrefix(path, "transit/decrypt/"):
+		var version int
+		var ciphertext string
+		if _, err := fmt.Sscanf(strings.Replace(data["ciphertext"].(string), ":", " ", 2), "vault v%d %s",
+			&version, &ciphertext); err != nil {
+			return nil, err
+		}
+		raw, err := base64.StdEncoding.DecodeString(ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		return &vaultapi.Secret{Data: map[string]interface{}{
+			"plaintext": base64.StdEncoding.EncodeToString(xor(f.keys[version-1], raw)),
+		}}, nil
+	}
+	return nil, nil
+}
+
+func writeKEKFile(t *testing.T, encode func([]byte) string) string {
+	t.Helper()
+
+	key := make([]byte, kekLength)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "kek")
+	require.NoError(t, os.WriteFile(path, []byte(encode(key)), 0o600))
+	return path
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestFileWrapper(t *testing.T) {
+	ctx := context.Background()
+	encodings := map[string]func([]byte) string{
+		"raw":    func(key []byte) string { return string(key) },
+		"hex":    func(key []byte) string { return hex.EncodeToString(key) + "\n" },
+		"base64": base64.StdEncoding.EncodeToString,
+	}
+
+	for name, encode := range encodings {
+		t.Run(name, func(t *testing.T) {
+			path := writeKEKFile(t, encode)
+			wrapper, err := NewFileWrapper(path)
+			require.NoError(t, err)
+			assert.Equal(t, "file/"+path, wrapper.Name())
+
+			key := []byte("0123456789abcdef0123456789abcdef")
+			wrapped, err := wrapper.Wrap(ctx, key)
+			require.NoError(t, err)
+			assert.NotContains(t, string(wrapped), string(key))
+
+			unwrapped, err := wrapper.Unwrap(ctx, wrapped)
+			require.NoError(t, err)
+			assert.Equal(t, key, unwrapped)
+
+			wrapped[len(wrapped)-1] ^= 1
+			_, err = wrapper.Unwrap(ctx, wrapped)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("invalid key", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "kek")
+		require.NoError(t, os.WriteFile(path, []byte("too short"), 0o600))
+		_, err := NewFileWrapper(path)
+		assert.ErrorIs(t, err, ErrInvalidKEKFile)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := NewFileWrapper(filepath.Join(t.TempDir(), "missing"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestTransitWrapper(t *testing.T) {
+	ctx := context.Background()
+	transit := newFakeTransit()
+	wrapper := NewTransitWrapper(transit, "/transit/", "dq-kek")
+	assert.Equal(t, "transit/dq-kek", wrapper.Name())
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	wrapped, err := wrapper.Wrap(ctx, key)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(wrapped), "vault:v1:"))
+
+	unwrapped, err := wrapper.Unwrap(ctx, wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, key, unwrapped)
+
+	transit.err = errors.New("permission denied")
+	_, err = wrapper.Wrap(ctx, key)
+	assert.ErrorContains(t, err, "permission denied")
+
+	transit.err = nil
+	wrapper = NewTransitWrapper(transit, "other", "dq-kek")
+	_, err = wrapper.Wrap(ctx, key)
+	assert.ErrorIs(t, err, ErrTransitResponse)
+}
+
+func TestKeyring_SealOpen(t *testing.T) {
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+	keyring := New(NewTransitWrapper(newFakeTransit(), "transit", "dq-kek"))
+
+	value := []byte(`{"mnemonic":"abandon abandon"}`)
+	sealed, err := keyring.Seal(ctx, storage, "users/a", value)
+	require.NoError(t, err)
+	assert.True(t, IsSealed(sealed))
+	assert.NotContains(t, string(sealed), "abandon")
+
+	// the keyring is created on first use
+	entry, err := storage.Get(ctx, config.KeyringStoragePath)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.NotContains(t, string(entry.Value), "abandon")
+
+	opened, version, err := keyring.Open(ctx, storage, "users/a", sealed)
+	require.NoError(t, err)
+	assert.Equal(t, value, opened)
+	assert.Equal(t, 1, version)
+
+	t.Run("bound to the storage key", func(t *testing.T) {
+		_, _, err := keyring.Open(ctx, storage, "users/b", sealed)
+		assert.Error(t, err)
+	})
+
+	t.Run("plaintext", func(t *testing.T) {
+		opened, version, err := keyring.Open(ctx, storage, "users/a", value)
+		require.NoError(t, err)
+		assert.Equal(t, value, opened)
+		assert.Equal(t, 0, version)
+	})
+
+	t.Run("unwrapped by another node", func(t *testing.T) {
+		other := New(keyring.wrapper)
+		opened, _, err := other.Open(ctx, storage, "users/a", sealed)
+		require.NoError(t, err)
+		assert.Equal(t, value, opened)
+	})
+
+	t.Run("other key-encryption key", func(t *testing.T) {
+		other := New(NewTransitWrapper(newFakeTransit(), "transit", "other"))
+		_, _, err := other.Open(ctx, storage, "users/a", sealed)
+		assert.ErrorIs(t, err, ErrKEKMismatch)
+	})
+}
+
+func TestKeyring_RotateRewrap(t *testing.T) {
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+	transit := newFakeTransit()
+	keyring := New(NewTransitWrapper(transit, "transit", "dq-kek"))
+
+	sealed, err := keyring.Seal(ctx, storage, "users/a", []byte("secret"))
+	require.NoError(t, err)
+
+	version, err := keyring.Rotate(ctx, storage)
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	rotated, err := keyring.Seal(ctx, storage, "users/a", []byte("secret"))
+	require.NoError(t, err)
+	_, version, err = keyring.Open(ctx, storage, "users/a", rotated)
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	// the transit key rotates, the data-encryption keys are wrapped again
+	transit.rotate()
+	require.NoError(t, keyring.Rewrap(ctx, storage))
+
+	info, err := keyring.Info(ctx, storage)
+	require.NoError(t, err)
+	assert.Equal(t, "transit/dq-kek", info.KEK)
+	assert.Equal(t, 2, info.LatestVersion)
+	require.Len(t, info.Versions, 2)
+	assert.False(t, info.RewrappedAt.IsZero())
+	for _, version := range info.Versions {
+		assert.True(t, strings.HasPrefix(string(version.WrappedKey), "vault:v2:"))
+	}
+
+	keyring.Invalidate()
+	opened, version, err := keyring.Open(ctx, storage, "users/a", sealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), opened)
+	assert.Equal(t, 1, version)
+}
+
+func TestKeyring_Storage(t *testing.T) {
+	ctx := context.Background()
+	raw := &logical.InmemStorage{}
+	keyring := New(NewTransitWrapper(newFakeTransit(), "transit", "dq-kek"))
+	storage := keyring.Storage(raw, config.StorageBasePath, testLogger())
+
+	legacy := &logical.StorageEntry{Key: config.StorageBasePath + "legacy", Value: []byte(`{"mnemonic":"legacy"}`)}
+	require.NoError(t, raw.Put(ctx, legacy))
+	require.NoError(t, storage.Put(ctx, &logical.StorageEntry{Key: config.StorageBasePath + "new",
+		Value: []byte(`{"mnemonic":"new"}`)}))
+	require.NoError(t, storage.Put(ctx, &logical.StorageEntry{Key: config.PolicyStoragePath + "default",
+		Value: []byte(`{}`)}))
+
+	rawValue := func(key string) []byte {
+		t.Helper()
+		entry, err := raw.Get(ctx, key)
+		require.NoError(t, err)
+		require.NotNil(t, entry)
+		return entry.Value
+	}
+
+	t.Run("sealed under prefix only", func(t *testing.T) {
+		assert.True(t, IsSealed(rawValue(config.StorageBasePath+"new")))
+		assert.Equal(t, []byte(`{}`), rawValue(config.PolicyStoragePath+"default"))
+
+		entry, err := storage.Get(ctx, config.StorageBasePath+"new")
+		require.NoError(t, err)
+		assert.Equal(t, []byte(`{"mnemonic":"new"}`), entry.Value)
+	})
+
+	t.Run("plaintext migrated on read", func(t *testing.T) {
+		entry, err := storage.Get(ctx, legacy.Key)
+		require.NoError(t, err)
+		assert.Equal(t, legacy.Value, entry.Value)
+
+		_, version, err := keyring.Open(ctx, raw, legacy.Key, rawValue(legacy.Key))
+		require.NoError(t, err)
+		assert.Equal(t, 1, version)
+	})
+
+	t.Run("older versions migrated on read", func(t *testing.T) {
+		_, err := keyring.Rotate(ctx, raw)
+		require.NoError(t, err)
+
+		entry, err := storage.Get(ctx, config.StorageBasePath+"new")
+		require.NoError(t, err)
+		assert.Equal(t, []byte(`{"mnemonic":"new"}`), entry.Value)
+
+		_, version, err := keyring.Open(ctx, raw, entry.Key, rawValue(entry.Key))
+		require.NoError(t, err)
+		assert.Equal(t, 2, version)
+	})
+
+	t.Run("read-only storage", func(t *testing.T) {
+		inmem := &logical.InmemStorage{}
+		require.NoError(t, inmem.Put(ctx, legacy))
+		stored, err := raw.Get(ctx, config.KeyringStoragePath)
+		require.NoError(t, err)
+		require.NoError(t, inmem.Put(ctx, stored))
+
+		standby := New(keyring.wrapper)
+		storage := standby.Storage(readOnlyStorage{inmem}, config.StorageBasePath, testLogger())
+		entry, err := storage.Get(ctx, legacy.Key)
+		require.NoError(t, err)
+		assert.Equal(t, legacy.Value, entry.Value)
+
+		stored, err = inmem.Get(ctx, legacy.Key)
+		require.NoError(t, err)
+		assert.Equal(t, legacy.Value, stored.Value, "not migrated")
+	})
+}
+
+// readOnlyStorage rejects writes like the storage of a performance standby
+type readOnlyStorage struct {
+	*logical.InmemStorage
+}
+
+func (s readOnlyStorage) Put(_ context.Context, _ *logical.StorageEntry) error {
+	return logical.ErrReadOnly
+}
@@ -0,0 +1,174 @@
+package keyring
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// Key-encryption key types, the values of the kek_type mount option
+const (
+	KEKFile    = "file"
+	KEKTransit = "transit"
+)
+
+// kekLength is the length in bytes of the AES-256 key of a key file
+const kekLength = 32
+
+// fileWrapAAD binds the data-encryption keys wrapped by a key file to their use
+var fileWrapAAD = []byte("dq-vault keyring")
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrInvalidKEKFile     = errors.New("key file must hold a 32 byte key, raw, hex or base64 encoded")
+	ErrTransitResponse    = errors.New("unexpected transit response")
+	ErrWrappedKeyTooShort = errors.New("wrapped key is too short")
+)
+
+// KeyWrapper wraps and unwraps the data-encryption keys of a keyring with a
+// key-encryption key held outside of Vault storage
+type KeyWrapper interface {
+	// Name identifies the key-encryption key, e.g., transit/dq-kek
+	Name() string
+	Wrap(ctx context.Context, key []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// FileWrapper wraps keys with an AES-256-GCM key read from a local file,
+// a stand-in for an HSM reached over PKCS#11
+type FileWrapper struct {
+	name string
+	aead cipher.AEAD
+}
+
+// NewFileWrapper returns a wrapper using the key of the file at path
+func NewFileWrapper(path string) (*FileWrapper, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parseKEK(content)
+	clear(content)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(key)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &FileWrapper{name: KEKFile + "/" + path, aead: aead}, nil
+}
+
+// parseKEK decodes a raw, hex or base64 encoded 32 byte key
+func parseKEK(content []byte) ([]byte, error) {
+	if len(content) == kekLength {
+		return append([]byte(nil), content...), nil
+	}
+	text := strings.TrimSpace(string(content))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == kekLength {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == kekLength {
+		return key, nil
+	}
+	return nil, ErrInvalidKEKFile
+}
+
+// Name returns the path of the key file
+func (w *FileWrapper) Name() string {
+	return w.name
+}
+
+// Wrap encrypts key, the nonce prefixing the ciphertext
+func (w *FileWrapper) Wrap(_ context.Context, key []byte) ([]byte, error) {
+	nonce := make([]byte, w.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return w.aead.Seal(nonce, nonce, key, fileWrapAAD), nil
+}
+
+// Unwrap decrypts a key wrapped by Wrap
+func (w *FileWrapper) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < w.aead.NonceSize() {
+		return nil, ErrWrappedKeyTooShort
+	}
+	nonce, ciphertext := wrapped[:w.aead.NonceSize()], wrapped[w.aead.NonceSize():]
+	return w.aead.Open(nil, nonce, ciphertext, fileWrapAAD)
+}
+
+// TransitClient writes to the Vault API, *vaultapi.Logical satisfies it
+type TransitClient interface {
+	Write(path string, data map[string]interface{}) (*vaultapi.Secret, error)
+}
+
+// TransitWrapper wraps keys with a key of a Vault transit secrets engine
+type TransitWrapper struct {
+	client TransitClient
+	mount  string
+	key    string
+}
+
+// NewTransitWrapper returns a wrapper using the transit key named key of the
+// transit engine mounted at mount
+func NewTransitWrapper(client TransitClient, mount, key string) *TransitWrapper {
+	return &TransitWrapper{client: client, mount: strings.Trim(mount, "/"), key: key}
+}
+
+// Name returns the path of the transit key
+func (w *TransitWrapper) Name() string {
+	return w.mount + "/" + w.key
+}
+
+// Wrap encrypts key with transit, the ciphertext is the vault:v<n>: string
+func (w *TransitWrapper) Wrap(_ context.Context, key []byte) ([]byte, error) {
+	ciphertext, err := w.write("encrypt", "ciphertext", map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(ciphertext), nil
+}
+
+// Unwrap decrypts a key wrapped by Wrap
+func (w *TransitWrapper) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	plaintext, err := w.write("decrypt", "plaintext", map[string]interface{}{
+		"ciphertext": string(wrapped),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(plaintext)
+}
+
+// write calls the transit operation and returns the string field of its response
+func (w *TransitWrapper) write(operation, field string, data map[string]interface{}) (string, error) {
+	secret, err := w.client.Write(w.mount+"/"+operation+"/"+w.key, data)
+	if err != nil {
+		return "", fmt.Errorf("transit %s: %w", operation, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return "", fmt.Errorf("%w: transit %s returned no data", ErrTransitResponse, operation)
+	}
+	value, ok := secret.Data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("%w: transit %s returned no %s", ErrTransitResponse, operation, field)
+	}
+	return value, nil
+}
@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/payment-system/dq-vault/config"
+)
+
+// Redacted replaces the values of sensitive attributes
+const Redacted = "[REDACTED]"
+
+// LevelFatal is the slog level of config.Fatal, above slog.LevelError
+const LevelFatal = slog.LevelError + 4
+
+// ErrInvalidLogLevel is returned for log levels other than the config log levels
+var ErrInvalidLogLevel = errors.New("invalid log level")
+
+// DefaultRedactedKeys are the attribute keys whose values never reach the log
+// output: key material, user secrets, payloads and signed transactions.
+var DefaultRedactedKeys = []string{
+	"privateKey", "mnemonic", "passphrase", "seed", "payload", "tx", "signature",
+}
+
+// RedactingHandler is a slog.Handler replacing the values of sensitive
+// attributes with Redacted before passing records to the next handler.
+// Keys are matched case-insensitively, in groups and in attributes added
+// with Logger.With as well.
+type RedactingHandler struct {
+	next slog.Handler
+	keys map[string]bool
+}
+
+// NewRedactingHandler returns a handler redacting the attributes named by keys
+func NewRedactingHandler(next slog.Handler, keys []string) *RedactingHandler {
+	redacted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		redacted[strings.ToLower(strings.TrimSpace(key))] = true
+	}
+	return &RedactingHandler{next: next, keys: redacted}
+}
+
+// Enabled reports whether the next handler handles records of level
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle redacts the attributes of record and passes it to the next handler
+func (h *RedactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redact(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+// WithAttrs redacts attrs once and returns a handler adding them to every record
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		redacted = append(redacted, h.redact(attr))
+	}
+	return &RedactingHandler{next: h.next.WithAttrs(redacted), keys: h.keys}
+}
+
+// WithGroup returns a handler nesting the attributes of records in group
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{next: h.next.WithGroup(name), keys: h.keys}
+}
+
+func (h *RedactingHandler) redact(attr slog.Attr) slog.Attr {
+	if h.keys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, Redacted)
+	}
+
+	value := attr.Value.Resolve()
+	if value.Kind() != slog.KindGroup {
+		return slog.Attr{Key: attr.Key, Value: value}
+	}
+
+	group := value.Group()
+	redacted := make([]any, 0, len(group))
+	for _, member := range group {
+		redacted = append(redacted, h.redact(member))
+	}
+	return slog.Group(attr.Key, redacted...)
+}
+
+// ParseLevel parses a log level of the config package (INFO, WARN, ERROR,
+// DEBUG, FATAL), case-insensitively
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(level)) {
+	case config.Debug:
+		return slog.LevelDebug, nil
+	case config.Info:
+		return slog.LevelInfo, nil
+	case config.Warn:
+		return slog.LevelWarn, nil
+	case config.Error:
+		return slog.LevelError, nil
+	case config.Fatal:
+		return LevelFatal, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrInvalidLogLevel, level)
+	}
+}
@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLogger returns a logger redacting keys into the returned buffer
+func newTestLogger(keys []string) (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return slog.New(NewRedactingHandler(handler, keys)), &buf
+}
+
+func TestRedactingHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		log      func(logger *slog.Logger)
+		want     []string
+		notWants []string
+	}{
+		{
+			name: "top-level attributes",
+			log: func(logger *slog.Logger) {
+				logger.Info("request", "path", "m/44'/60'/0'/0/0", "payload", "0xdeadbeef")
+			},
+			want:     []string{"path=m/44'/60'/0'/0/0", "payload=" + Redacted},
+			notWants: []string{"deadbeef"},
+		},
+		{
+			name: "case-insensitive keys",
+			log: func(logger *slog.Logger) {
+				logger.Info("register", "Mnemonic", "abandon ability", "PASSPHRASE", "hunter2")
+			},
+			want:     []string{"Mnemonic=" + Redacted, "PASSPHRASE=" + Redacted},
+			notWants: []string{"abandon", "hunter2"},
+		},
+		{
+			name: "groups",
+			log: func(logger *slog.Logger) {
+				logger.Info("signed", slog.Group("result", slog.String("signature", "0xcafe"),
+					slog.Group("inner", slog.String("privateKey", "f00d"), slog.Int("index", 3))))
+			},
+			want:     []string{"result.signature=" + Redacted, "result.inner.privateKey=" + Redacted, "result.inner.index=3"},
+			notWants: []string{"cafe", "f00d"},
+		},
+		{
+			name: "attributes added with With",
+			log: func(logger *slog.Logger) {
+				logger.With("tx", "0xbeef").WithGroup("op").Info("done", "seed", "5eed")
+			},
+			want:     []string{"tx=" + Redacted, "op.seed=" + Redacted},
+			notWants: []string{"beef", "5eed"},
+		},
+		{
+			name: "extra keys",
+			log: func(logger *slog.Logger) {
+				logger.Info("request", "username", "alice")
+			},
+			want:     []string{"username=" + Redacted},
+			notWants: []string{"alice"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(append(slices.Clone(DefaultRedactedKeys), " username "))
+			tt.log(logger)
+
+			for _, want := range tt.want {
+				assert.Contains(t, buf.String(), want)
+			}
+			for _, notWant := range tt.notWants {
+				assert.NotContains(t, buf.String(), notWant)
+			}
+		})
+	}
+}
+
+func TestRedactingHandler_Enabled(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})
+	logger := slog.New(NewRedactingHandler(handler, DefaultRedactedKeys))
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "shown")
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		level   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{level: "DEBUG", want: slog.LevelDebug},
+		{level: "info", want: slog.LevelInfo},
+		{level: " Warn ", want: slog.LevelWarn},
+		{level: "ERROR", want: slog.LevelError},
+		{level: "FATAL", want: LevelFatal},
+		{level: "verbose", wantErr: true},
+		{level: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			level, err := ParseLevel(tt.level)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidLogLevel)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, level)
+		})
+	}
+}
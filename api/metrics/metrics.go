@@ -0,0 +1,185 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/payment-system/dq-vault/lib/adapter"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+// Namespace prefixes the names of the metrics of the plugin
+const Namespace = "dq"
+
+// ContentType is the content type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Derivation kinds, the kind label of the derivation latency
+const (
+	DerivationAddress      = "address"
+	DerivationAddressBatch = "address_batch"
+	DerivationPublicKey    = "pubkey"
+	DerivationXpub         = "xpub"
+)
+
+// Error reasons, the reason label of the error counter
+const (
+	ReasonNoAdapter  = "no_adapter"
+	ReasonValidation = "validation"
+	ReasonPolicy     = "policy"
+	ReasonNotFound   = "not_found"
+	ReasonInternal   = "internal"
+)
+
+// otherCoinType labels coin types outside of SLIP-44 supported list, so that
+// arbitrary request values do not create series
+const otherCoinType = "other"
+
+// Metrics are the Prometheus collectors of a backend.
+//
+// The methods are no-ops on a nil *Metrics.
+type Metrics struct {
+	registry           *prometheus.Registry
+	requests           *prometheus.CounterVec
+	errors             *prometheus.CounterVec
+	signDuration       *prometheus.HistogramVec
+	derivationDuration *prometheus.HistogramVec
+	policyRejections   *prometheus.CounterVec
+}
+
+// New registers the collectors of the plugin on registry
+func New(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		registry: registry,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "requests_total",
+			Help:      "Requests handled, by path and coin type.",
+		}, []string{"path", "coin_type"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "errors_total",
+			Help:      "Failed requests, by path, HTTP status code and reason.",
+		}, []string{"path", "code", "reason"}),
+		signDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "sign_duration_seconds",
+			Help:      "Latency of transaction signing, key derivation included, by coin type.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+		}, []string{"coin_type"}),
+		derivationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "derivation_duration_seconds",
+			Help:      "Latency of key and address derivation, by coin type and kind.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+		}, []string{"coin_type", "kind"}),
+		policyRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "policy_rejections_total",
+			Help:      "Signing requests rejected by a signing policy or velocity limit, by coin type.",
+		}, []string{"coin_type"}),
+	}
+
+	registry.MustRegister(m.requests, m.errors, m.signDuration, m.derivationDuration, m.policyRejections)
+	return m
+}
+
+// CoinTypeLabel returns the coin_type label of coinType
+func CoinTypeLabel(coinType int) string {
+	if coinType < 0 || coinType > int(^uint16(0)) || !slip44.IsSupportedCoinType(uint16(coinType)) {
+		return otherCoinType
+	}
+	return strconv.Itoa(coinType)
+}
+
+// ObserveRequest counts a request of path, and its error when err is not nil.
+// coinType is empty for paths without a coin type.
+func (m *Metrics) ObserveRequest(path, coinType string, err error) {
+	if m == nil {
+		return
+	}
+
+	m.requests.WithLabelValues(path, coinType).Inc()
+	if err == nil {
+		return
+	}
+
+	code := http.StatusInternalServerError
+	var codedErr logical.HTTPCodedError
+	if errors.As(err, &codedErr) {
+		code = codedErr.Code()
+	}
+	m.errors.WithLabelValues(path, strconv.Itoa(code), errorReason(err, code)).Inc()
+}
+
+// errorReason classifies an error returned with the HTTP status code
+func errorReason(err error, code int) string {
+	// coded errors only keep the message of the adapter error
+	if errors.Is(err, adapter.ErrNoAdapterFound) || strings.Contains(err.Error(), adapter.ErrNoAdapterFound.Error()) {
+		return ReasonNoAdapter
+	}
+
+	switch {
+	case code == http.StatusForbidden:
+		return ReasonPolicy
+	case code == http.StatusNotFound:
+		return ReasonNotFound
+	case code >= http.StatusInternalServerError:
+		return ReasonInternal
+	default:
+		return ReasonValidation
+	}
+}
+
+// ObserveSign records the latency of signing a transaction of coinType
+func (m *Metrics) ObserveSign(coinType int, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.signDuration.WithLabelValues(CoinTypeLabel(coinType)).Observe(elapsed.Seconds())
+}
+
+// ObserveDerivation records the latency of a derivation of kind for coinType
+func (m *Metrics) ObserveDerivation(coinType int, kind string, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.derivationDuration.WithLabelValues(CoinTypeLabel(coinType), kind).Observe(elapsed.Seconds())
+}
+
+// PolicyRejected counts a signing request of coinType rejected by its policy
+func (m *Metrics) PolicyRejected(coinType int) {
+	if m == nil {
+		return
+	}
+	m.policyRejections.WithLabelValues(CoinTypeLabel(coinType)).Inc()
+}
+
+// Text returns the metrics in the Prometheus text exposition format
+func (m *Metrics) Text() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	families, err := m.registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
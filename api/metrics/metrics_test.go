@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/lib/adapter"
+)
+
+func TestCoinTypeLabel(t *testing.T) {
+	tests := []struct {
+		coinType int
+		want     string
+	}{
+		{coinType: 0, want: "0"},
+		{coinType: 60, want: "60"},
+		{coinType: 195, want: "195"},
+		{coinType: 4242, want: "other"},
+		{coinType: -1, want: "other"},
+		{coinType: 1 << 20, want: "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.coinType), func(t *testing.T) {
+			assert.Equal(t, tt.want, CoinTypeLabel(tt.coinType))
+		})
+	}
+}
+
+func TestMetrics_ObserveRequest(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+
+	m.ObserveRequest("sign", "60", nil)
+	m.ObserveRequest("sign", "60", logical.CodedError(http.StatusForbidden, "signing policy violation"))
+	m.ObserveRequest("sign", "60", logical.CodedError(http.StatusUnprocessableEntity, "invalid payload"))
+	m.ObserveRequest("address", "other", logical.CodedError(http.StatusUnprocessableEntity,
+		fmt.Errorf("%w: 4242", adapter.ErrNoAdapterFound).Error()))
+	m.ObserveRequest("users/<uuid>", "", logical.CodedError(http.StatusNotFound, "user not found"))
+	m.ObserveRequest("audit/verify", "", errors.New("storage unavailable"))
+
+	assert.InDelta(t, 3, testutil.ToFloat64(m.requests.WithLabelValues("sign", "60")), 0)
+	assert.Equal(t, 4, testutil.CollectAndCount(m.requests))
+
+	tests := []struct {
+		path, code, reason string
+	}{
+		{"sign", "403", ReasonPolicy},
+		{"sign", "422", ReasonValidation},
+		{"address", "422", ReasonNoAdapter},
+		{"users/<uuid>", "404", ReasonNotFound},
+		{"audit/verify", "500", ReasonInternal},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, 1, testutil.ToFloat64(m.errors.WithLabelValues(tt.path, tt.code, tt.reason)), 0,
+			"%s %s %s", tt.path, tt.code, tt.reason)
+	}
+	assert.Equal(t, len(tests), testutil.CollectAndCount(m.errors))
+}
+
+func TestMetrics_Latency(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+
+	m.ObserveSign(60, 3*time.Millisecond)
+	m.ObserveSign(60, 5*time.Millisecond)
+	m.ObserveDerivation(0, DerivationXpub, time.Millisecond)
+	m.PolicyRejected(195)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(m.signDuration))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.derivationDuration))
+	assert.InDelta(t, 1, testutil.ToFloat64(m.policyRejections.WithLabelValues("195")), 0)
+
+	text, err := m.Text()
+	require.NoError(t, err)
+	assert.Contains(t, string(text), `dq_sign_duration_seconds_count{coin_type="60"} 2`)
+	assert.Contains(t, string(text), `dq_sign_duration_seconds_sum{coin_type="60"} 0.008`)
+	assert.Contains(t, string(text), `dq_derivation_duration_seconds_count{coin_type="0",kind="xpub"} 1`)
+	assert.Contains(t, string(text), `dq_policy_rejections_total{coin_type="195"} 1`)
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	var m *Metrics
+
+	assert.NotPanics(t, func() {
+		m.ObserveRequest("sign", "60", errors.New("failed"))
+		m.ObserveSign(60, time.Millisecond)
+		m.ObserveDerivation(60, DerivationAddress, time.Millisecond)
+		m.PolicyRejected(60)
+	})
+
+	text, err := m.Text()
+	require.NoError(t, err)
+	assert.Empty(t, text)
+}
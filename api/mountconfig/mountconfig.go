@@ -0,0 +1,171 @@
+package mountconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/logging"
+	"github.com/payment-system/dq-vault/config"
+)
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrInvalidConfig    = errors.New("invalid mount configuration")
+	ErrCoinTypeDisabled = errors.New("coin type is disabled on this mount")
+	ErrDevModeDisabled  = errors.New("development mode is disabled on this mount")
+)
+
+// entropyLengths are the entropy lengths of BIP-39 mnemonics, 12 to 24 words
+var entropyLengths = []int{128, 160, 192, 224, 256}
+
+// Config is the configuration of a mount, stored at config.MountConfigStoragePath
+type Config struct {
+	// EntropyLength is the entropy, in bits, of the mnemonics generated by register
+	EntropyLength int `json:"entropyLength"`
+	// MaxBatchSize bounds the count of address/batch requests and sign/batch items
+	MaxBatchSize int `json:"maxBatchSize"`
+	// EnabledCoinTypes lists the coin types keys may be used for, all when empty
+	EnabledCoinTypes []uint16 `json:"enabledCoinTypes,omitempty"`
+	// DerivationTemplates are the derivation paths of coin types used when a
+	// request has none, %d standing for the address index
+	DerivationTemplates map[uint16]string `json:"derivationTemplates,omitempty"`
+	// DevModeEnabled allows requests with isDev set
+	DevModeEnabled bool `json:"devModeEnabled"`
+	// LogLevel overrides the log_level mount option when set
+	LogLevel string `json:"logLevel,omitempty"`
+	// KeyCacheSize bounds the count of account keys held in memory, the key
+	// cache is disabled when 0
+	KeyCacheSize int `json:"keyCacheSize"`
+	// KeyCacheTTL is the time in seconds account keys are held in memory
+	KeyCacheTTL int `json:"keyCacheTTL"`
+	// IdempotencyTTL is the time in seconds the outcomes of signing requests
+	// with an idempotency key are kept
+	IdempotencyTTL int       `json:"idempotencyTTL"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// Default returns the configuration of mounts that were never configured
+func Default() *Config {
+	return &Config{
+		EntropyLength:  config.Entropy,
+		MaxBatchSize:   config.MaxBatchSize,
+		DevModeEnabled: true,
+		KeyCacheTTL:    config.KeyCacheTTL,
+		IdempotencyTTL: config.IdempotencyTTL,
+	}
+}
+
+// Validate checks the settings of the configuration
+func (c *Config) Validate() error {
+	if !slices.Contains(entropyLengths, c.EntropyLength) {
+		return fmt.Errorf("%w: entropy length must be one of %v", ErrInvalidConfig, entropyLengths)
+	}
+	if c.MaxBatchSize < 1 || c.MaxBatchSize > config.MaxBatchSizeLimit {
+		return fmt.Errorf("%w: max batch size must be between 1 and %d", ErrInvalidConfig, config.MaxBatchSizeLimit)
+	}
+	for coinType, template := range c.DerivationTemplates {
+		if err := validateTemplate(template); err != nil {
+			return fmt.Errorf("%w: derivation template of coin type %d: %w", ErrInvalidConfig, coinType, err)
+		}
+	}
+	if c.LogLevel != "" {
+		if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+		}
+	}
+	if c.KeyCacheSize < 0 || c.KeyCacheSize > config.KeyCacheSizeLimit {
+		return fmt.Errorf("%w: key cache size must be between 0 and %d", ErrInvalidConfig, config.KeyCacheSizeLimit)
+	}
+	if c.KeyCacheTTL < 1 || c.KeyCacheTTL > config.KeyCacheTTLLimit {
+		return fmt.Errorf("%w: key cache TTL must be between 1 and %d seconds", ErrInvalidConfig,
+			config.KeyCacheTTLLimit)
+	}
+	if c.IdempotencyTTL < 1 || c.IdempotencyTTL > config.IdempotencyTTLLimit {
+		return fmt.Errorf("%w: idempotency TTL must be between 1 and %d seconds", ErrInvalidConfig,
+			config.IdempotencyTTLLimit)
+	}
+	return nil
+}
+
+// validateTemplate checks that template is a derivation path with one %d index
+func validateTemplate(template string) error {
+	if !strings.HasPrefix(template, "m/") {
+		return errors.New("must start with m/")
+	}
+	if strings.Count(template, "%d") != 1 || strings.Count(template, "%") != 1 {
+		return errors.New("must hold exactly one %d")
+	}
+	return nil
+}
+
+// ParseDerivationTemplates parses templates keyed by coin type
+func ParseDerivationTemplates(raw map[string]string) (map[uint16]string, error) {
+	templates := make(map[uint16]string, len(raw))
+	for key, template := range raw {
+		coinType, err := strconv.ParseUint(strings.TrimSpace(key), 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid coin type %q", ErrInvalidConfig, key)
+		}
+		templates[uint16(coinType)] = strings.TrimSpace(template)
+	}
+	return templates, nil
+}
+
+// Allow rejects requests for disabled coin types, and development mode
+// requests when it is disabled
+func (c *Config) Allow(coinType uint16, isDev bool) error {
+	if len(c.EnabledCoinTypes) > 0 && !slices.Contains(c.EnabledCoinTypes, coinType) {
+		return fmt.Errorf("%w: %d", ErrCoinTypeDisabled, coinType)
+	}
+	if isDev && !c.DevModeEnabled {
+		return ErrDevModeDisabled
+	}
+	return nil
+}
+
+// DerivationTemplate returns the derivation template of coinType
+func (c *Config) DerivationTemplate(coinType uint16) (string, bool) {
+	template, ok := c.DerivationTemplates[coinType]
+	return template, ok
+}
+
+// DerivationPath returns the default derivation path of coinType at index
+func (c *Config) DerivationPath(coinType uint16, index int) (string, bool) {
+	template, ok := c.DerivationTemplate(coinType)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf(template, index), true
+}
+
+// Load loads the configuration of the mount, Default when it was never configured
+func Load(ctx context.Context, storage logical.Storage) (*Config, error) {
+	entry, err := storage.Get(ctx, config.MountConfigStoragePath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return Default(), nil
+	}
+
+	c := Default()
+	if err := entry.DecodeJSON(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save stores the configuration of the mount
+func Save(ctx context.Context, storage logical.Storage, c *Config) error {
+	entry, err := logical.StorageEntryJSON(config.MountConfigStoragePath, c)
+	if err != nil {
+		return err
+	}
+	return storage.Put(ctx, entry)
+}
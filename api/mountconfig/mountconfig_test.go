@@ -0,0 +1,107 @@
+package mountconfig
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/config"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr bool
+	}{
+		{name: "default", modify: func(_ *Config) {}},
+		{name: "12 word mnemonics", modify: func(c *Config) { c.EntropyLength = 128 }},
+		{name: "invalid entropy length", modify: func(c *Config) { c.EntropyLength = 100 }, wantErr: true},
+		{name: "zero batch size", modify: func(c *Config) { c.MaxBatchSize = 0 }, wantErr: true},
+		{name: "batch size above limit", modify: func(c *Config) { c.MaxBatchSize = config.MaxBatchSizeLimit + 1 },
+			wantErr: true},
+		{name: "derivation template", modify: func(c *Config) {
+			c.DerivationTemplates = map[uint16]string{60: "m/44'/60'/0'/0/%d"}
+		}},
+		{name: "template without index", modify: func(c *Config) {
+			c.DerivationTemplates = map[uint16]string{60: "m/44'/60'/0'/0/0"}
+		}, wantErr: true},
+		{name: "template with extra verb", modify: func(c *Config) {
+			c.DerivationTemplates = map[uint16]string{60: "m/44'/60'/%s/0/%d"}
+		}, wantErr: true},
+		{name: "template without root", modify: func(c *Config) {
+			c.DerivationTemplates = map[uint16]string{60: "44'/60'/0'/0/%d"}
+		}, wantErr: true},
+		{name: "log level", modify: func(c *Config) { c.LogLevel = config.Debug }},
+		{name: "invalid log level", modify: func(c *Config) { c.LogLevel = "VERBOSE" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			tt.modify(c)
+			err := c.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidConfig)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConfig_Allow(t *testing.T) {
+	c := Default()
+	assert.NoError(t, c.Allow(60, true))
+
+	c.EnabledCoinTypes = []uint16{0, 60}
+	c.DevModeEnabled = false
+	assert.NoError(t, c.Allow(60, false))
+	assert.ErrorIs(t, c.Allow(195, false), ErrCoinTypeDisabled)
+	assert.ErrorIs(t, c.Allow(60, true), ErrDevModeDisabled)
+}
+
+func TestConfig_DerivationPath(t *testing.T) {
+	c := Default()
+	_, ok := c.DerivationPath(60, 0)
+	assert.False(t, ok)
+
+	c.DerivationTemplates = map[uint16]string{60: "m/44'/60'/0'/0/%d"}
+	path, ok := c.DerivationPath(60, 7)
+	assert.True(t, ok)
+	assert.Equal(t, "m/44'/60'/0'/0/7", path)
+}
+
+func TestParseDerivationTemplates(t *testing.T) {
+	templates, err := ParseDerivationTemplates(map[string]string{" 60 ": " m/44'/60'/0'/0/%d "})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint16]string{60: "m/44'/60'/0'/0/%d"}, templates)
+
+	_, err = ParseDerivationTemplates(map[string]string{"eth": "m/44'/60'/0'/0/%d"})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+
+	_, err = ParseDerivationTemplates(map[string]string{"70000": "m/44'/60'/0'/0/%d"})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestLoadSave(t *testing.T) {
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+
+	c, err := Load(ctx, storage)
+	require.NoError(t, err)
+	assert.Equal(t, Default(), c)
+
+	c.MaxBatchSize = 50
+	c.EnabledCoinTypes = []uint16{60}
+	c.DerivationTemplates = map[uint16]string{60: "m/44'/60'/0'/0/%d"}
+	c.DevModeEnabled = false
+	require.NoError(t, Save(ctx, storage, c))
+
+	loaded, err := Load(ctx, storage)
+	require.NoError(t, err)
+	assert.Equal(t, c, loaded)
+}
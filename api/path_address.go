@@ -4,14 +4,12 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/payment-system/dq-vault/api/helpers"
-	"github.com/payment-system/dq-vault/config"
-	"github.com/payment-system/dq-vault/lib"
-	"github.com/payment-system/dq-vault/lib/adapter"
-	"github.com/payment-system/dq-vault/lib/slip44"
+	"github.com/payment-system/dq-vault/api/metrics"
 )
 
 func (b *Backend) pathAddress(ctx context.Context, req *logical.Request,
@@ -34,46 +32,37 @@ func (b *Backend) pathAddress(ctx context.Context, req *logical.Request,
 
 	isDev := d.Get("isDev").(bool)
 
-	if uint16(coinType) == slip44.Bitshares {
-		derivationPath = config.BitsharesDerivationPath
+	mountConfig, err := b.checkMountConfig(ctx, req.Storage, coinType, isDev)
+	if err != nil {
+		backendLogger.Error("mount config", "error", err)
+		return nil, err
 	}
+	derivationPath = requestDerivationPath(mountConfig, coinType, derivationPath)
 
 	backendLogger.Info("request", "path", derivationPath, "cointype", coinType)
 
-	// validate data provided
-	if err := helpers.ValidateData(ctx, req, uuid, derivationPath); err != nil {
-		backendLogger.Error("validate data", "error", err)
-		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
-	}
-
-	// path where user data is stored in vault
-	path := config.StorageBasePath + uuid
-	entry, err := req.Storage.Get(ctx, path)
+	// validate data provided and load the user
+	userInfo, err := helpers.LoadUser(ctx, req, uuid, derivationPath)
 	if err != nil {
-		backendLogger.Error("get", "error", err)
+		backendLogger.Error("load user", "error", err)
 		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
 	}
 
-	// obtain mnemonic and passphrase of user
-	var userInfo helpers.User
-	err = entry.DecodeJSON(&userInfo)
-	if err != nil {
-		backendLogger.Error("decode json", "error", err)
-		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
-	}
+	// obtains blockchain adapater based on coinType
+	adapterInventory := b.adapters()
 
-	seed, err := lib.SeedFromMnemonic(userInfo.Mnemonic, userInfo.Passphrase)
+	keys, release, err := b.userKeychain(userInfo, adapterInventory, coinType, derivationPath)
 	if err != nil {
-		backendLogger.Error("seed from mnemonic", "error", err)
+		backendLogger.Error("user keychain", "error", err)
 		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
 	}
+	defer release()
 
 	backendLogger.Info("dp", "dp", derivationPath)
 
-	// obtains blockchain adapater based on coinType
-	adapterInventory := adapter.GetInventory(backendLogger)
-
-	address, err := adapterInventory.DeriveAddress(seed, uint16(coinType), derivationPath, isDev)
+	start := time.Now()
+	address, err := adapterInventory.DeriveAddress(keys, uint16(coinType), derivationPath, isDev)
+	b.metrics.ObserveDerivation(coinType, metrics.DerivationAddress, time.Since(start))
 	if err != nil {
 		backendLogger.Error("derive address", "error", err)
 		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
@@ -2,20 +2,62 @@ package api
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/metrics"
 	"github.com/payment-system/dq-vault/config"
-	"github.com/payment-system/dq-vault/lib"
 	"github.com/payment-system/dq-vault/lib/adapter"
 	"github.com/payment-system/dq-vault/lib/slip44"
 )
 
+// Static error variables to avoid dynamic error creation
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// batchCursor is the position of the next page of an address batch, bound to
+// the user, coin type, template and network of the batch
+type batchCursor struct {
+	UUID     string `json:"u"`
+	CoinType int    `json:"c"`
+	Template string `json:"t"`
+	IsDev    bool   `json:"d"`
+	Next     int    `json:"n"`
+}
+
+// encode returns the opaque form of the cursor
+func (c batchCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeBatchCursor parses an encoded cursor
+func decodeBatchCursor(encoded string) (batchCursor, error) {
+	var c batchCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.Next < 0 {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
 // pathAddressBatch generates a batch of addresses using a templated derivation path.
+// Batches larger than the maximum batch size are paged: responses hold a
+// nextCursor which, passed as cursor, continues the batch.
 func (b *Backend) pathAddressBatch(ctx context.Context, req *logical.Request,
 	d *framework.FieldData) (*logical.Response, error) {
 	backendLogger := b.logger.With(slog.String("op", "path_address_batch"))
@@ -30,63 +72,78 @@ func (b *Backend) pathAddressBatch(ctx context.Context, req *logical.Request,
 	isDev := d.Get("isDev").(bool)
 	startIndex := d.Get("startIndex").(int)
 	count := d.Get("count").(int)
+	cursor := d.Get("cursor").(string)
 
-	if count <= 0 || count > 1000 {
-		return nil, logical.CodedError(http.StatusBadRequest, "count must be between 1 and 1000")
+	mountConfig, err := b.checkMountConfig(ctx, req.Storage, coinType, isDev)
+	if err != nil {
+		backendLogger.Error("mount config", "error", err)
+		return nil, err
+	}
+
+	if count <= 0 || count > mountConfig.MaxBatchSize {
+		return nil, logical.CodedError(http.StatusBadRequest,
+			fmt.Sprintf("count must be between 1 and %d", mountConfig.MaxBatchSize))
 	}
 
 	if uint16(coinType) == slip44.Bitshares {
 		pathTemplate = config.BitsharesDerivationPath
+	} else if pathTemplate == "" {
+		pathTemplate, _ = mountConfig.DerivationTemplate(uint16(coinType))
 	}
 
-	// Validate base data
-	if err := helpers.ValidateData(ctx, req, uuid, pathTemplate); err != nil {
-		backendLogger.Error("validate data", "error", err)
-		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	if cursor != "" {
+		position, err := decodeBatchCursor(cursor)
+		if err != nil || position.UUID != uuid || position.CoinType != coinType ||
+			position.Template != pathTemplate || position.IsDev != isDev {
+			backendLogger.Error("decode cursor", "error", ErrInvalidCursor)
+			return nil, logical.CodedError(http.StatusBadRequest, ErrInvalidCursor.Error())
+		}
+		startIndex = position.Next
+	}
+	if startIndex < 0 || startIndex > hdkeychain.HardenedKeyStart-count {
+		return nil, logical.CodedError(http.StatusBadRequest, adapter.ErrBatchIndexOutOfRange.Error())
 	}
 
-	// Retrieve user info
-	path := config.StorageBasePath + uuid
-	entry, err := req.Storage.Get(ctx, path)
+	// validate data provided and load the user
+	userInfo, err := helpers.LoadUser(ctx, req, uuid, pathTemplate)
 	if err != nil {
-		backendLogger.Error("get", "error", err)
+		backendLogger.Error("load user", "error", err)
 		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
 	}
 
-	var userInfo helpers.User
-	err = entry.DecodeJSON(&userInfo)
+	adapterInventory := b.adapters()
+
+	keys, release, err := b.userKeychain(userInfo, adapterInventory, coinType, pathTemplate)
 	if err != nil {
-		backendLogger.Error("decode json", "error", err)
+		backendLogger.Error("user keychain", "error", err)
 		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
 	}
+	defer release()
 
-	seed, err := lib.SeedFromMnemonic(userInfo.Mnemonic, userInfo.Passphrase)
+	start := time.Now()
+	addresses, err := adapterInventory.DeriveAddressBatch(ctx, keys, uint16(coinType), pathTemplate,
+		startIndex, count, isDev)
+	b.metrics.ObserveDerivation(coinType, metrics.DerivationAddressBatch, time.Since(start))
 	if err != nil {
-		backendLogger.Error("seed from mnemonic", "error", err)
+		backendLogger.Error("derive address batch", "error", err)
 		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
 	}
 
-	adapterInventory := adapter.GetInventory(backendLogger)
-
-	addresses := make(map[string]string, count)
-	for i := startIndex; i < startIndex+count; i++ {
-		var derivationPath string
-		if pathTemplate == config.BitsharesDerivationPath {
-			derivationPath = pathTemplate
-		} else {
-			derivationPath = fmt.Sprintf(pathTemplate, i)
-		}
-		address, err := adapterInventory.DeriveAddress(seed, uint16(coinType), derivationPath, isDev)
-		if err != nil {
-			backendLogger.Error("derive address", "error", err, "index", i)
-			return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
-		}
-		addresses[derivationPath] = address
+	data := map[string]interface{}{
+		"addresses": addresses,
+	}
+	// templates without an index, e.g., the one of bitshares, have no next page
+	if next := startIndex + count; strings.Contains(pathTemplate, "%d") && next < hdkeychain.HardenedKeyStart {
+		data["nextCursor"] = batchCursor{
+			UUID:     uuid,
+			CoinType: coinType,
+			Template: pathTemplate,
+			IsDev:    isDev,
+			Next:     next,
+		}.encode()
 	}
 
 	return &logical.Response{
-		Data: map[string]interface{}{
-			"addresses": addresses,
-		},
+		Data: data,
 	}, nil
 }
@@ -3,7 +3,9 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"testing"
 
@@ -14,7 +16,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/mountconfig"
 	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib/adapter"
 )
 
 // MockStorageBatch implements logical.Storage for testing
@@ -72,6 +76,10 @@ func createBatchFieldData(data map[string]interface{}) *framework.FieldData {
 			Type:        framework.TypeInt,
 			Description: "Count",
 		},
+		"cursor": {
+			Type:        framework.TypeString,
+			Description: "Cursor",
+		},
 	}
 	return &framework.FieldData{
 		Raw:    data,
@@ -81,9 +89,12 @@ func createBatchFieldData(data map[string]interface{}) *framework.FieldData {
 
 func createBatchTestBackend(_ *testing.T) *Backend {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	return &Backend{
+	b := &Backend{
 		logger: logger,
 	}
+	// the storage mocks only expect user entries
+	b.mountConfig.Store(mountconfig.Default())
+	return b
 }
 
 func createUserStorageEntryBatch(t *testing.T, uuid, mnemonic, passphrase string) *logical.StorageEntry {
@@ -111,7 +122,6 @@ func TestBackend_PathAddressBatch(t *testing.T) {
 	mockStorage := new(MockStorageBatch)
 	entry := createUserStorageEntryBatch(t, testUUID, testMnemonic, testPass)
 	mockStorage.On("Get", ctx, config.StorageBasePath+testUUID).Return(entry, nil)
-	mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{testUUID}, nil)
 
 	backend := createBatchTestBackend(t)
 	fieldData := createBatchFieldData(map[string]interface{}{
@@ -139,10 +149,70 @@ func TestBackend_PathAddressBatch(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
 	assert.Contains(t, resp.Data, "addresses")
-	addresses, ok := resp.Data["addresses"].(map[string]string)
+	addresses, ok := resp.Data["addresses"].([]adapter.DerivedAddress)
 	assert.True(t, ok)
 	assert.Len(t, addresses, 3)
-	for _, addr := range addresses {
-		assert.NotEmpty(t, addr)
+	for i, addr := range addresses {
+		assert.Equal(t, i, addr.Index)
+		assert.Equal(t, fmt.Sprintf("m/44'/60'/0'/0/%d", i), addr.Path)
+		assert.NotEmpty(t, addr.Address)
+		assert.NotEmpty(t, addr.PublicKey)
+	}
+	assert.NotEmpty(t, resp.Data["nextCursor"])
+}
+
+func TestBackend_PathAddressBatch_Cursor(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+	storage := createPoliciesStorage(t)
+
+	batch := func(data map[string]interface{}) (*logical.Response, error) {
+		return backend.pathAddressBatch(ctx, &logical.Request{Storage: storage, Data: data},
+			createBatchFieldData(data))
+	}
+	batchData := func(count int, cursor string) map[string]interface{} {
+		return map[string]interface{}{
+			"uuid":         signTestUUID,
+			"pathTemplate": "m/44'/60'/0'/0/%d",
+			"coinType":     60,
+			"isDev":        false,
+			"startIndex":   0,
+			"count":        count,
+			"cursor":       cursor,
+		}
 	}
+
+	resp, err := batch(batchData(4, ""))
+	require.NoError(t, err)
+	all := resp.Data["addresses"].([]adapter.DerivedAddress)
+
+	// two pages of two addresses
+	resp, err = batch(batchData(2, ""))
+	require.NoError(t, err)
+	first := resp.Data["addresses"].([]adapter.DerivedAddress)
+	cursor := resp.Data["nextCursor"].(string)
+
+	resp, err = batch(batchData(2, cursor))
+	require.NoError(t, err)
+	second := resp.Data["addresses"].([]adapter.DerivedAddress)
+	assert.Equal(t, all, append(first, second...))
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := batch(batchData(2, "not a cursor"))
+		requireCode(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("other batch", func(t *testing.T) {
+		data := batchData(2, cursor)
+		data["pathTemplate"] = "m/44'/60'/1'/0/%d"
+		_, err := batch(data)
+		requireCode(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("index out of range", func(t *testing.T) {
+		data := batchData(2, "")
+		data["startIndex"] = 1<<31 - 1
+		_, err := batch(data)
+		requireCode(t, err, http.StatusBadRequest)
+	})
 }
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -15,7 +16,10 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/keycache"
+	"github.com/payment-system/dq-vault/api/mountconfig"
 	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib"
 	"github.com/payment-system/dq-vault/lib/slip44"
 )
 
@@ -26,6 +30,11 @@ const (
 	testMnemonic       = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
 	testPassphrase     = "test-passphrase"
 	testAddress        = "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"
+
+	testTronDerivationPath    = "m/44'/195'/0'/0/0"
+	testTronAddress           = "THPVCSbkJFG5MqMH9YZvCw5FDq2cX6KYgw"
+	testBitcoinDerivationPath = "m/84'/0'/0'/0/0"
+	testSolanaDerivationPath  = "m/44'/501'/0'/0'"
 )
 
 // MockStorage implements logical.Storage for testing
@@ -87,9 +96,12 @@ func createFieldData(data map[string]interface{}) *framework.FieldData {
 // Helper function to create test backend
 func createTestBackend(_ *testing.T) *Backend {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	return &Backend{
+	b := &Backend{
 		logger: logger,
 	}
+	// the storage mocks only expect user entries
+	b.mountConfig.Store(mountconfig.Default())
+	return b
 }
 
 // Helper function to create user storage entry
@@ -133,8 +145,6 @@ func TestBackend_PathAddress(t *testing.T) {
 			setupStorage: func(ms *MockStorage) {
 				entry := createUserStorageEntry(t, testUser)
 				ms.On("Get", ctx, config.StorageBasePath+testUUID).Return(entry, nil)
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{testUUID}, nil)
 			},
 			want: &logical.Response{
 				Data: map[string]interface{}{
@@ -154,8 +164,6 @@ func TestBackend_PathAddress(t *testing.T) {
 			setupStorage: func(ms *MockStorage) {
 				entry := createUserStorageEntry(t, testUser)
 				ms.On("Get", ctx, config.StorageBasePath+testUUID).Return(entry, nil)
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{testUUID}, nil)
 			},
 			want: &logical.Response{
 				Data: map[string]interface{}{
@@ -198,8 +206,6 @@ func TestBackend_PathAddress(t *testing.T) {
 				"isDev": false,
 			},
 			setupStorage: func(ms *MockStorage) {
-				// Mock List for UUID existence check since ValidateData will be called
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{testUUID}, nil)
 				// Mock Get since coinType=0 doesn't cause validation failure
 				testUser := helpers.User{
 					Mnemonic:   testMnemonic,
@@ -220,8 +226,6 @@ func TestBackend_PathAddress(t *testing.T) {
 				"isDev":    false,
 			},
 			setupStorage: func(ms *MockStorage) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{testUUID}, nil)
 				ms.On("Get", ctx, config.StorageBasePath+testUUID).Return(nil, assert.AnError)
 			},
 			wantErr:        true,
@@ -236,8 +240,6 @@ func TestBackend_PathAddress(t *testing.T) {
 				"isDev":    false,
 			},
 			setupStorage: func(ms *MockStorage) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{testUUID}, nil)
 				// Return an error instead of nil entry to avoid panic
 				ms.On("Get", ctx, config.StorageBasePath+testUUID).Return((*logical.StorageEntry)(nil), assert.AnError)
 			},
@@ -253,8 +255,6 @@ func TestBackend_PathAddress(t *testing.T) {
 				"isDev":    false,
 			},
 			setupStorage: func(ms *MockStorage) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{testUUID}, nil)
 				invalidEntry := &logical.StorageEntry{
 					Key:   config.StorageBasePath + testUUID,
 					Value: []byte("{invalid json}"),
@@ -279,8 +279,6 @@ func TestBackend_PathAddress(t *testing.T) {
 				}
 				entry := createUserStorageEntry(t, emptyUser)
 				ms.On("Get", ctx, config.StorageBasePath+testUUID).Return(entry, nil)
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{testUUID}, nil)
 			},
 			wantErr:        true,
 			wantStatusCode: http.StatusUnprocessableEntity,
@@ -296,8 +294,6 @@ func TestBackend_PathAddress(t *testing.T) {
 			setupStorage: func(ms *MockStorage) {
 				entry := createUserStorageEntry(t, testUser)
 				ms.On("Get", ctx, config.StorageBasePath+testUUID).Return(entry, nil)
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{testUUID}, nil)
 			},
 			wantErr:        true,
 			wantStatusCode: http.StatusUnprocessableEntity,
@@ -313,8 +309,6 @@ func TestBackend_PathAddress(t *testing.T) {
 			setupStorage: func(ms *MockStorage) {
 				entry := createUserStorageEntry(t, testUser)
 				ms.On("Get", ctx, config.StorageBasePath+testUUID).Return(entry, nil)
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{testUUID}, nil)
 			},
 			wantErr:        true,
 			wantStatusCode: http.StatusUnprocessableEntity,
@@ -403,7 +397,19 @@ func TestBackend_PathAddress_CoinTypeSpecific(t *testing.T) {
 		{
 			name:           "bitcoin coin type",
 			coinType:       slip44.Bitcoin,
-			expectedPath:   testDerivationPath,
+			expectedPath:   testBitcoinDerivationPath,
+			shouldOverride: false,
+		},
+		{
+			name:           "tron coin type",
+			coinType:       slip44.Tron,
+			expectedPath:   testTronDerivationPath,
+			shouldOverride: false,
+		},
+		{
+			name:           "solana coin type",
+			coinType:       slip44.Solana,
+			expectedPath:   testSolanaDerivationPath,
 			shouldOverride: false,
 		},
 	}
@@ -416,12 +422,10 @@ func TestBackend_PathAddress_CoinTypeSpecific(t *testing.T) {
 
 			entry := createUserStorageEntry(t, testUser)
 			mockStorage.On("Get", ctx, config.StorageBasePath+testUUID).Return(entry, nil)
-			// Mock List for UUID existence check
-			mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{testUUID}, nil)
 
 			fieldData := createFieldData(map[string]interface{}{
 				"uuid":     testUUID,
-				"path":     testDerivationPath,
+				"path":     tt.expectedPath,
 				"coinType": int(tt.coinType),
 				"isDev":    false,
 			})
@@ -430,7 +434,7 @@ func TestBackend_PathAddress_CoinTypeSpecific(t *testing.T) {
 				Storage: mockStorage,
 				Data: map[string]interface{}{
 					"uuid":     testUUID,
-					"path":     testDerivationPath,
+					"path":     tt.expectedPath,
 					"coinType": int(tt.coinType),
 					"isDev":    false,
 				},
@@ -440,13 +444,30 @@ func TestBackend_PathAddress_CoinTypeSpecific(t *testing.T) {
 			got, err := backend.pathAddress(ctx, req, fieldData)
 
 			// For unsupported coin types, we expect an error
-			// Currently only Ether is supported by the EVM adapter
-			if tt.coinType != slip44.Ether {
-				assert.Error(t, err)
-			} else {
+			// Currently Ether (EVM adapter), Tron, Bitcoin, Solana and Bitshares are supported
+			switch tt.coinType {
+			case slip44.Ether:
 				assert.NoError(t, err)
 				assert.NotNil(t, got)
 				assert.Contains(t, got.Data, "address")
+			case slip44.Tron:
+				require.NoError(t, err)
+				require.NotNil(t, got)
+				assert.Equal(t, testTronAddress, got.Data["address"])
+			case slip44.Bitcoin:
+				require.NoError(t, err)
+				require.NotNil(t, got)
+				assert.Contains(t, got.Data["address"], "bc1q")
+			case slip44.Solana:
+				require.NoError(t, err)
+				require.NotNil(t, got)
+				assert.NotEmpty(t, got.Data["address"])
+			case slip44.Bitshares:
+				require.NoError(t, err)
+				require.NotNil(t, got)
+				assert.Contains(t, got.Data["address"], "BTS")
+			default:
+				assert.Error(t, err)
 			}
 
 			mockStorage.AssertExpectations(t)
@@ -454,6 +475,51 @@ func TestBackend_PathAddress_CoinTypeSpecific(t *testing.T) {
 	}
 }
 
+func TestBackend_PathAddress_Tron(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+	storage := createPoliciesStorage(t)
+
+	tests := []struct {
+		name    string
+		path    string
+		isDev   bool
+		want    string
+		wantErr bool
+	}{
+		{name: "absolute path", path: testTronDerivationPath, want: testTronAddress},
+		{name: "relative path", path: "m/0'/0/0", want: testTronAddress},
+		{name: "development mode", path: testTronDerivationPath, isDev: true, want: testTronAddress},
+		{name: "other account", path: "m/44'/195'/1'/0/0"},
+		{name: "non tron path", path: testDerivationPath, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := map[string]interface{}{
+				"uuid":     signTestUUID,
+				"path":     tt.path,
+				"coinType": int(slip44.Tron),
+				"isDev":    tt.isDev,
+			}
+			resp, err := backend.pathAddress(ctx, &logical.Request{Storage: storage, Data: data}, createFieldData(data))
+			if tt.wantErr {
+				requireCode(t, err, http.StatusUnprocessableEntity)
+				return
+			}
+			require.NoError(t, err)
+
+			got := resp.Data["address"].(string)
+			assert.Regexp(t, "^T[1-9A-HJ-NP-Za-km-z]{33}$", got)
+			if tt.want != "" {
+				assert.Equal(t, tt.want, got)
+			} else {
+				assert.NotEqual(t, testTronAddress, got)
+			}
+		})
+	}
+}
+
 func TestBackend_PathAddress_EdgeCases(t *testing.T) {
 	ctx := context.Background()
 	backend := createTestBackend(t)
@@ -468,8 +534,8 @@ func TestBackend_PathAddress_EdgeCases(t *testing.T) {
 		}
 		fieldData := createFieldData(data)
 
-		// Mock the List call with nil context using mock.Anything
-		mockStorage.On("List", mock.Anything, config.StorageBasePath).Return([]string{}, assert.AnError)
+		// Mock the Get call with nil context using mock.Anything
+		mockStorage.On("Get", mock.Anything, config.StorageBasePath+testUUID).Return(nil, assert.AnError)
 
 		req := &logical.Request{
 			Storage: mockStorage,
@@ -520,8 +586,6 @@ func TestBackend_PathAddress_EdgeCases(t *testing.T) {
 		}
 		entry := createUserStorageEntry(t, testUser)
 		mockStorage.On("Get", ctx, config.StorageBasePath+testUUID).Return(entry, nil)
-		// Mock List for UUID existence check
-		mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{testUUID}, nil)
 
 		req := &logical.Request{
 			Storage: mockStorage,
@@ -550,8 +614,6 @@ func BenchmarkBackend_PathAddress(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		mockStorage := new(MockStorage)
 		mockStorage.On("Get", ctx, config.StorageBasePath+testUUID).Return(entry, nil)
-		// Mock List for UUID existence check
-		mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{testUUID}, nil)
 
 		data := map[string]interface{}{
 			"uuid":     testUUID,
@@ -569,3 +631,132 @@ func BenchmarkBackend_PathAddress(b *testing.B) {
 		_, _ = backend.pathAddress(ctx, req, fieldData)
 	}
 }
+
+func TestBackend_PathAddress_KeyCache(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+	backend.keyCache = keycache.New(0, 0)
+	storage := createPoliciesStorage(t)
+
+	writeConfig(t, backend, storage, map[string]interface{}{"keyCacheSize": 10, "keyCacheTTL": 60})
+
+	address := func(path string) string {
+		t.Helper()
+		data := map[string]interface{}{
+			"uuid":     signTestUUID,
+			"path":     path,
+			"coinType": int(slip44.Ether),
+			"isDev":    false,
+		}
+		resp, err := backend.pathAddress(ctx, &logical.Request{Storage: storage, Data: data}, createFieldData(data))
+		require.NoError(t, err)
+		return resp.Data["address"].(string)
+	}
+
+	want := address(testDerivationPath)
+	assert.Equal(t, 1, backend.keyCache.Len())
+	assert.Equal(t, want, address(testDerivationPath), "derived from the cached account key")
+
+	t.Run("keyed by account", func(t *testing.T) {
+		address("m/44'/60'/0'/0/1")
+		assert.Equal(t, 1, backend.keyCache.Len(), "same account")
+		address("m/44'/60'/1'/0/0")
+		assert.Equal(t, 2, backend.keyCache.Len())
+	})
+
+	t.Run("invalidated when the user is written", func(t *testing.T) {
+		backend.invalidate(ctx, config.StorageBasePath+signTestUUID)
+		assert.Equal(t, 0, backend.keyCache.Len())
+		assert.Equal(t, want, address(testDerivationPath))
+	})
+
+	t.Run("invalidated when the user is deleted", func(t *testing.T) {
+		data := map[string]interface{}{"uuid": signTestUUID, "soft": true}
+		_, err := backend.pathUsersDelete(ctx, &logical.Request{Storage: storage, Data: data},
+			createUsersFieldData(data))
+		require.NoError(t, err)
+		assert.Equal(t, 0, backend.keyCache.Len())
+	})
+
+	keychain, err := lib.NewSeedKeychain(make([]byte, 64)).AccountKeychain("m/44'/60'/0'")
+	require.NoError(t, err)
+
+	t.Run("purged on cleanup", func(t *testing.T) {
+		backend.keyCache.Put(signTestUUID, "m/44'/60'/0'", time.Time{}, keychain)
+		backend.clean(ctx)
+		assert.Equal(t, 0, backend.keyCache.Len())
+	})
+
+	t.Run("disabled by the mount configuration", func(t *testing.T) {
+		backend.keyCache.Put(signTestUUID, "m/44'/60'/0'", time.Time{}, keychain)
+		writeConfig(t, backend, storage, map[string]interface{}{"keyCacheSize": 0})
+		assert.False(t, backend.keyCache.Enabled())
+		assert.Equal(t, 0, backend.keyCache.Len())
+	})
+}
+
+func TestBackend_PathAddress_KeyCache_RelativePaths(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+	backend.keyCache = keycache.New(0, 0)
+	storage := createPoliciesStorage(t)
+
+	address := func(path string) string {
+		t.Helper()
+		data := map[string]interface{}{
+			"uuid":     signTestUUID,
+			"path":     path,
+			"coinType": int(slip44.Tron),
+			"isDev":    false,
+		}
+		resp, err := backend.pathAddress(ctx, &logical.Request{Storage: storage, Data: data}, createFieldData(data))
+		require.NoError(t, err, path)
+		return resp.Data["address"].(string)
+	}
+
+	// relative Tron paths are cached under the account of the path they resolve to
+	paths := []string{"m/0'/1'/2'", "m/0'/0/1", "m/44'/195'/0'/0/1"}
+	want := make([]string, 0, len(paths))
+	for _, path := range paths {
+		want = append(want, address(path))
+	}
+	assert.Equal(t, want[1], want[2])
+
+	writeConfig(t, backend, storage, map[string]interface{}{"keyCacheSize": 10, "keyCacheTTL": 60})
+	for i, path := range paths {
+		assert.Equal(t, want[i], address(path), path)
+	}
+	assert.Equal(t, 1, backend.keyCache.Len(), "one account, m/44'/195'/0'")
+}
+
+// BenchmarkBackend_PathAddress_KeyCache compares address derivation with the
+// key cache disabled and enabled
+func BenchmarkBackend_PathAddress_KeyCache(b *testing.B) {
+	ctx := context.Background()
+	entry := createUserStorageEntry(&testing.T{}, helpers.User{
+		UUID:       testUUID,
+		Mnemonic:   testMnemonic,
+		Passphrase: testPassphrase,
+	})
+	data := map[string]interface{}{
+		"uuid":     testUUID,
+		"path":     testDerivationPath,
+		"coinType": int(slip44.Ether),
+		"isDev":    false,
+	}
+
+	for name, maxEntries := range map[string]int{"disabled": 0, "enabled": 100} {
+		b.Run(name, func(b *testing.B) {
+			backend := createTestBackend(&testing.T{})
+			backend.keyCache = keycache.New(maxEntries, time.Minute)
+			storage := &logical.InmemStorage{}
+			require.NoError(b, storage.Put(ctx, entry))
+			req := &logical.Request{Storage: storage, Data: data}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = backend.pathAddress(ctx, req, createFieldData(data))
+			}
+		})
+	}
+}
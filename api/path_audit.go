@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/audit"
+)
+
+// maxAuditPageSize bounds the entries returned by one audit list request
+const maxAuditPageSize = 1000
+
+// appendAudit journals a signing request, rejected when signErr is set
+func (b *Backend) appendAudit(ctx context.Context, storage logical.Storage, entry *audit.Entry,
+	signErr error) error {
+	entry.Time = b.now()
+	entry.Outcome = audit.OutcomeSigned
+	if signErr != nil {
+		entry.Outcome = audit.OutcomeRejected
+		entry.Reason = signErr.Error()
+	}
+
+	b.auditLock.Lock()
+	defer b.auditLock.Unlock()
+
+	return audit.Append(ctx, storage, entry)
+}
+
+// pathAuditList corresponds to LIST audit.
+// Lists the sequence numbers of the journal entries following after, oldest first.
+func (b *Backend) pathAuditList(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_audit_list"))
+
+	after := d.Get("after").(int)
+	limit := d.Get("limit").(int)
+	if after < 0 || limit <= 0 || limit > maxAuditPageSize {
+		return nil, logical.CodedError(http.StatusUnprocessableEntity,
+			"after must be non-negative and limit between 1 and "+strconv.Itoa(maxAuditPageSize))
+	}
+
+	entries, next, err := audit.List(ctx, req.Storage, uint64(after), limit)
+	if err != nil {
+		backendLogger.Error("list audit entries", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+
+	keys := make([]string, 0, len(entries))
+	keyInfo := make(map[string]interface{}, len(entries))
+	for _, entry := range entries {
+		key := strconv.FormatUint(entry.Sequence, 10)
+		keys = append(keys, key)
+		keyInfo[key] = map[string]interface{}{
+			"time":     entry.Time.Format(time.RFC3339),
+			"uuid":     entry.UUID,
+			"coinType": entry.CoinType,
+			"outcome":  entry.Outcome,
+		}
+	}
+
+	resp := logical.ListResponseWithInfo(keys, keyInfo)
+	if next > 0 {
+		resp.Data["next"] = next
+	}
+	return resp, nil
+}
+
+// pathAuditRead corresponds to READ audit/<sequence>.
+func (b *Backend) pathAuditRead(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_audit_read"))
+
+	sequence := d.Get("sequence").(int)
+	if sequence <= 0 {
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, "sequence must be positive")
+	}
+
+	entry, err := audit.Get(ctx, req.Storage, uint64(sequence))
+	if errors.Is(err, audit.ErrEntryNotFound) {
+		return nil, logical.CodedError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		backendLogger.Error("get audit entry", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+
+	data := map[string]interface{}{
+		"sequence":    entry.Sequence,
+		"time":        entry.Time.Format(time.RFC3339Nano),
+		"requestId":   entry.RequestID,
+		"displayName": entry.DisplayName,
+		"uuid":        entry.UUID,
+		"coinType":    entry.CoinType,
+		"path":        entry.Path,
+		"outcome":     entry.Outcome,
+		"prevHash":    entry.PrevHash,
+		"hash":        entry.Hash,
+	}
+	for name, value := range map[string]string{
+		"method":         entry.Method,
+		"messageHash":    entry.MessageHash,
+		"reason":         entry.Reason,
+		"recipient":      entry.Recipient,
+		"amount":         entry.Amount,
+		"token":          entry.Token,
+		"tokenRecipient": entry.TokenRecipient,
+		"tokenAmount":    entry.TokenAmount,
+		"txHash":         entry.TxHash,
+	} {
+		if value != "" {
+			data[name] = value
+		}
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// pathAuditVerify corresponds to READ audit/verify.
+// Checks that no journal entry was altered, removed or reordered.
+func (b *Backend) pathAuditVerify(ctx context.Context, req *logical.Request,
+	_ *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_audit_verify"))
+
+	count, headHash, err := audit.Verify(ctx, req.Storage)
+	if err != nil && !errors.Is(err, audit.ErrChainBroken) {
+		backendLogger.Error("verify audit journal", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+
+	data := map[string]interface{}{
+		"valid":    err == nil,
+		"entries":  count,
+		"headHash": headHash,
+	}
+	if err != nil {
+		backendLogger.Error("audit journal altered", "error", err)
+		data["error"] = err.Error()
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
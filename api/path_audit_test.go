@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/api/audit"
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+// Helper function to create a proper framework.FieldData for audit endpoints
+func createAuditFieldData(data map[string]interface{}) *framework.FieldData {
+	schema := map[string]*framework.FieldSchema{
+		"after": {
+			Type:        framework.TypeInt,
+			Description: "Sequence number to list after",
+			Default:     0,
+		},
+		"limit": {
+			Type:        framework.TypeInt,
+			Description: "Maximum number of entries",
+			Default:     100,
+		},
+		"sequence": {
+			Type:        framework.TypeInt,
+			Description: "Sequence number of the entry",
+		},
+	}
+
+	return &framework.FieldData{
+		Raw:    data,
+		Schema: schema,
+	}
+}
+
+func TestBackend_PathSign_Audit(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+	backend.clock = func() time.Time { return time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC) }
+
+	storage := createPoliciesStorage(t)
+	sign := func(requestID string) error {
+		_, err := backend.pathSign(ctx, &logical.Request{ID: requestID, DisplayName: "token-ops", Storage: storage},
+			createSignFieldData(map[string]interface{}{
+				"uuid":     signTestUUID,
+				"path":     signTestDerivationPath,
+				"coinType": int(slip44.Ether),
+				"payload":  signTestPayload,
+			}))
+		return err
+	}
+
+	require.NoError(t, sign("request-1"))
+	writePolicy(t, backend, storage, map[string]interface{}{"uuid": signTestUUID,
+		"maxValues": map[string]interface{}{"60": "1"}})
+	require.Error(t, sign("request-2"))
+
+	list, err := backend.pathAuditList(ctx, &logical.Request{Storage: storage}, createAuditFieldData(nil))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, list.Data["keys"])
+	assert.NotContains(t, list.Data, "next")
+
+	signed, err := backend.pathAuditRead(ctx, &logical.Request{Storage: storage},
+		createAuditFieldData(map[string]interface{}{"sequence": 1}))
+	require.NoError(t, err)
+	assert.Equal(t, "request-1", signed.Data["requestId"])
+	assert.Equal(t, "token-ops", signed.Data["displayName"])
+	assert.Equal(t, signTestUUID, signed.Data["uuid"])
+	assert.Equal(t, uint16(slip44.Ether), signed.Data["coinType"])
+	assert.Equal(t, signTestDerivationPath, signed.Data["path"])
+	assert.Equal(t, "signed", signed.Data["outcome"])
+	assert.Equal(t, signTestRecipient, signed.Data["recipient"])
+	assert.Equal(t, "1000000000000000000", signed.Data["amount"])
+	assert.Regexp(t, "^0x[0-9a-f]{64}$", signed.Data["txHash"])
+
+	rejected, err := backend.pathAuditRead(ctx, &logical.Request{Storage: storage},
+		createAuditFieldData(map[string]interface{}{"sequence": 2}))
+	require.NoError(t, err)
+	assert.Equal(t, "rejected", rejected.Data["outcome"])
+	assert.Contains(t, rejected.Data["reason"], "transaction value exceeds the limit")
+	assert.Equal(t, signed.Data["hash"], rejected.Data["prevHash"])
+	assert.NotContains(t, rejected.Data, "txHash")
+
+	verify, err := backend.pathAuditVerify(ctx, &logical.Request{Storage: storage}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, true, verify.Data["valid"])
+	assert.Equal(t, uint64(2), verify.Data["entries"])
+	assert.Equal(t, rejected.Data["hash"], verify.Data["headHash"])
+
+	// rewrite the rejection as a signature
+	entry, err := storage.Get(ctx, config.AuditStoragePath+"entries/00000000000000000002")
+	require.NoError(t, err)
+	entry.Value = []byte(
+		`{"sequence":2,"outcome":"signed","prevHash":"` + rejected.Data["prevHash"].(string) + `"}`)
+	require.NoError(t, storage.Put(ctx, entry))
+
+	verify, err = backend.pathAuditVerify(ctx, &logical.Request{Storage: storage}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, false, verify.Data["valid"])
+	assert.Contains(t, verify.Data["error"], "entry 2 was altered")
+}
+
+// failingStorage fails the writes of the entries under prefix, when set
+type failingStorage struct {
+	logical.Storage
+	prefix string
+}
+
+func (s *failingStorage) Put(ctx context.Context, entry *logical.StorageEntry) error {
+	if s.prefix != "" && strings.HasPrefix(entry.Key, s.prefix) {
+		return errStorageUnavailable
+	}
+	return s.Storage.Put(ctx, entry)
+}
+
+var errStorageUnavailable = errors.New("storage unavailable")
+
+func TestBackend_PathSign_AuditFailure(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+	backend.clock = func() time.Time { return time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC) }
+
+	storage := &failingStorage{Storage: createPoliciesStorage(t), prefix: config.AuditStoragePath}
+	writePolicy(t, backend, storage, map[string]interface{}{
+		"uuid": signTestUUID,
+		"velocityLimits": []interface{}{map[string]interface{}{
+			"coinType": int(slip44.Ether), "window": "24h", "maxCount": 50,
+		}},
+	})
+	sign := func() (*logical.Response, error) {
+		return backend.pathSign(ctx, &logical.Request{Storage: storage}, createSignFieldData(map[string]interface{}{
+			"uuid":           signTestUUID,
+			"path":           signTestDerivationPath,
+			"coinType":       int(slip44.Ether),
+			"payload":        signTestPayload,
+			"idempotencyKey": "payout-1",
+		}))
+	}
+
+	// signatures that cannot be journaled are neither returned, stored for
+	// retries nor counted against velocity limits
+	resp, err := sign()
+	requireCode(t, err, http.StatusInternalServerError)
+	assert.Nil(t, resp)
+	keys, err := storage.List(ctx, config.IdempotencyStoragePath+signTestUUID+"/")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+	assert.Equal(t, 0, velocityUsedCount(t, backend, storage))
+
+	storage.prefix = ""
+	resp, err = sign()
+	require.NoError(t, err)
+	assert.Equal(t, false, resp.Data["replayed"])
+
+	assert.Equal(t, 1, velocityUsedCount(t, backend, storage))
+
+	entries, _, err := audit.List(ctx, storage, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestBackend_PathAudit(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+
+	t.Run("pagination", func(t *testing.T) {
+		storage := createPoliciesStorage(t)
+		for range 3 {
+			_, err := backend.pathSign(ctx, &logical.Request{Storage: storage},
+				createSignFieldData(map[string]interface{}{
+					"uuid":     signTestUUID,
+					"path":     signTestDerivationPath,
+					"coinType": int(slip44.Ether),
+					"payload":  signTestPayload,
+				}))
+			require.NoError(t, err)
+		}
+
+		list, err := backend.pathAuditList(ctx, &logical.Request{Storage: storage},
+			createAuditFieldData(map[string]interface{}{"limit": 2}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1", "2"}, list.Data["keys"])
+		assert.Equal(t, uint64(2), list.Data["next"])
+
+		list, err = backend.pathAuditList(ctx, &logical.Request{Storage: storage},
+			createAuditFieldData(map[string]interface{}{"after": 2, "limit": 2}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"3"}, list.Data["keys"])
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		_, err := backend.pathAuditList(ctx, &logical.Request{Storage: &logical.InmemStorage{}},
+			createAuditFieldData(map[string]interface{}{"limit": 5000}))
+		var codedErr logical.HTTPCodedError
+		require.ErrorAs(t, err, &codedErr)
+		assert.Equal(t, http.StatusUnprocessableEntity, codedErr.Code())
+	})
+
+	t.Run("unknown entry", func(t *testing.T) {
+		_, err := backend.pathAuditRead(ctx, &logical.Request{Storage: &logical.InmemStorage{}},
+			createAuditFieldData(map[string]interface{}{"sequence": 1}))
+		var codedErr logical.HTTPCodedError
+		require.ErrorAs(t, err, &codedErr)
+		assert.Equal(t, http.StatusNotFound, codedErr.Code())
+	})
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib"
+	"github.com/payment-system/dq-vault/lib/slip39"
+)
+
+// ErrInvalidShareGroup is returned for share groups not of the form <threshold>of<count>
+var ErrInvalidShareGroup = errors.New("share groups must be of the form <threshold>of<count>, e.g., 2of3")
+
+// pathUserBackup corresponds to POST users/<uuid>/backup.
+// Splits the mnemonic entropy of the user into SLIP-39 share groups.
+func (b *Backend) pathUserBackup(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_user_backup"))
+	if err := helpers.ValidateFields(req, d); err != nil {
+		backendLogger.Error("validate fields", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	uuid := d.Get("uuid").(string)
+	groupThreshold := d.Get("groupThreshold").(int)
+
+	groups, err := parseShareGroups(d.Get("groups").([]string))
+	if err != nil {
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	user, err := helpers.GetUser(ctx, req.Storage, uuid)
+	switch {
+	case errors.Is(err, helpers.ErrUUIDDoesNotExist):
+		return nil, logical.CodedError(http.StatusNotFound, err.Error())
+	case err != nil:
+		backendLogger.Error("get user", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	case user.IsDeleted():
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, helpers.ErrUserDeleted.Error())
+	}
+
+	// the shares hold the entropy of the mnemonic, encrypted with the passphrase
+	entropy, err := lib.MnemonicToEntropy(lib.NormalizeMnemonic(user.Mnemonic), user.Language)
+	if err != nil {
+		backendLogger.Error("mnemonic entropy", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+	shares, err := slip39.Split(entropy, user.Passphrase, groupThreshold, groups, slip39.DefaultIterationExponent)
+	clear(entropy)
+	if err != nil {
+		backendLogger.Error("split secret", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	backendLogger.Info("backup exported", "uuid", uuid, "groupThreshold", groupThreshold, "groups", len(groups))
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"uuid":           uuid,
+			"groupThreshold": groupThreshold,
+			"groups":         shares,
+		},
+	}, nil
+}
+
+// pathRecover corresponds to POST recover.
+// Recovers a mnemonic from SLIP-39 shares and registers a user with it, or
+// with a uuid verifies that the shares recover the keys of that user.
+func (b *Backend) pathRecover(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_recover"))
+	if err := helpers.ValidateFields(req, d); err != nil {
+		backendLogger.Error("validate fields", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	shares := d.Get("shares").([]string)
+	passphrase := lib.NormalizePassphrase(d.Get("passphrase").(string))
+	uuid := d.Get("uuid").(string)
+	username := d.Get("username").(string)
+	language := strings.ToLower(strings.TrimSpace(d.Get("language").(string)))
+
+	if !slices.Contains(lib.MnemonicLanguages(), language) {
+		return nil, logical.CodedError(http.StatusUnprocessableEntity,
+			fmt.Sprintf("%s: %q", lib.ErrUnsupportedLanguage, language))
+	}
+
+	entropy, err := slip39.Combine(shares, passphrase)
+	if err != nil {
+		backendLogger.Error("combine shares", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+	defer clear(entropy)
+
+	if uuid != "" {
+		return b.verifyRecovery(ctx, req, uuid, entropy, passphrase)
+	}
+
+	mnemonic, err := lib.EntropyToMnemonic(entropy, language)
+	if err != nil {
+		backendLogger.Error("mnemonic from entropy", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	// generate new random UUID
+	uuid = helpers.NewUUID()
+	for {
+		exists, err := helpers.UUIDExists(ctx, req, uuid)
+		if err != nil {
+			backendLogger.Error("uuid exists", "error", err)
+			return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+		}
+		if !exists {
+			break
+		}
+		uuid = helpers.NewUUID()
+	}
+
+	now := b.now()
+	user := &helpers.User{
+		Username:   username,
+		UUID:       uuid,
+		Mnemonic:   mnemonic,
+		Passphrase: passphrase,
+		Language:   language,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	store, err := logical.StorageEntryJSON(config.StorageBasePath+uuid, user)
+	if err != nil {
+		backendLogger.Error("create storage entry", "error", err)
+		return nil, logical.CodedError(http.StatusExpectationFailed, err.Error())
+	}
+	if err = req.Storage.Put(ctx, store); err != nil {
+		backendLogger.Error("put user information", "error", err)
+		return nil, logical.CodedError(http.StatusExpectationFailed, err.Error())
+	}
+
+	fingerprint, err := userFingerprint(user)
+	if err != nil {
+		backendLogger.Error("master key fingerprint", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	backendLogger.Info("user recovered", "uuid", uuid, "username", username)
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"uuid":        uuid,
+			"fingerprint": fingerprint,
+		},
+	}, nil
+}
+
+// verifyRecovery reports whether entropy and passphrase, recovered from
+// shares, are those of the user uuid
+func (b *Backend) verifyRecovery(ctx context.Context, req *logical.Request, uuid string, entropy []byte,
+	passphrase string) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_recover"))
+
+	user, err := helpers.GetUser(ctx, req.Storage, uuid)
+	switch {
+	case errors.Is(err, helpers.ErrUUIDDoesNotExist):
+		return nil, logical.CodedError(http.StatusNotFound, err.Error())
+	case err != nil:
+		backendLogger.Error("get user", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	userEntropy, err := lib.MnemonicToEntropy(lib.NormalizeMnemonic(user.Mnemonic), user.Language)
+	if err != nil {
+		backendLogger.Error("mnemonic entropy", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+	defer clear(userEntropy)
+
+	verified := subtle.ConstantTimeCompare(entropy, userEntropy) == 1 &&
+		subtle.ConstantTimeCompare([]byte(passphrase), []byte(user.Passphrase)) == 1
+
+	backendLogger.Info("backup verified", "uuid", uuid, "verified", verified)
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"uuid":     uuid,
+			"verified": verified,
+		},
+	}, nil
+}
+
+// parseShareGroups parses share groups of the form <threshold>of<count>
+func parseShareGroups(raw []string) ([]slip39.Group, error) {
+	groups := make([]slip39.Group, 0, len(raw))
+	for _, group := range raw {
+		threshold, count, ok := strings.Cut(strings.ToLower(strings.TrimSpace(group)), "of")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidShareGroup, group)
+		}
+		t, err := strconv.Atoi(threshold)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidShareGroup, group)
+		}
+		c, err := strconv.Atoi(count)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidShareGroup, group)
+		}
+		groups = append(groups, slip39.Group{Threshold: t, Count: c})
+	}
+	return groups, nil
+}
+
+// userFingerprint returns the hex BIP-32 master key fingerprint of user
+func userFingerprint(user *helpers.User) (string, error) {
+	seed, err := lib.SeedFromMnemonic(user.Mnemonic, user.Passphrase)
+	if err != nil {
+		return "", err
+	}
+	fingerprint, err := lib.MasterKeyFingerprint(seed)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(fingerprint), nil
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/lib"
+)
+
+// Helper function to create a proper framework.FieldData for users/<uuid>/backup endpoint
+func createBackupFieldData(data map[string]interface{}) *framework.FieldData {
+	schema := map[string]*framework.FieldSchema{
+		"uuid": {
+			Type:        framework.TypeString,
+			Description: "UUID of user",
+		},
+		"groupThreshold": {
+			Type:        framework.TypeInt,
+			Description: "Group threshold",
+			Default:     1,
+		},
+		"groups": {
+			Type:        framework.TypeCommaStringSlice,
+			Description: "Groups",
+			Default:     []string{"1of1"},
+		},
+	}
+
+	return &framework.FieldData{
+		Raw:    data,
+		Schema: schema,
+	}
+}
+
+// Helper function to create a proper framework.FieldData for recover endpoint
+func createRecoverFieldData(data map[string]interface{}) *framework.FieldData {
+	schema := map[string]*framework.FieldSchema{
+		"shares": {
+			Type:        framework.TypeCommaStringSlice,
+			Description: "Shares",
+		},
+		"passphrase": {
+			Type:        framework.TypeString,
+			Description: "Passphrase",
+			Default:     "",
+		},
+		"uuid": {
+			Type:        framework.TypeString,
+			Description: "UUID of user",
+			Default:     "",
+		},
+		"username": {
+			Type:        framework.TypeString,
+			Description: "Username",
+			Default:     "",
+		},
+		"language": {
+			Type:        framework.TypeString,
+			Description: "Language",
+			Default:     "english",
+		},
+	}
+
+	return &framework.FieldData{
+		Raw:    data,
+		Schema: schema,
+	}
+}
+
+func exportBackup(t *testing.T, backend *Backend, storage logical.Storage,
+	data map[string]interface{}) [][]string {
+	t.Helper()
+
+	resp, err := backend.pathUserBackup(context.Background(), &logical.Request{Storage: storage, Data: data},
+		createBackupFieldData(data))
+	require.NoError(t, err)
+	return resp.Data["groups"].([][]string)
+}
+
+func recoverShares(backend *Backend, storage logical.Storage,
+	data map[string]interface{}) (*logical.Response, error) {
+	return backend.pathRecover(context.Background(), &logical.Request{Storage: storage, Data: data},
+		createRecoverFieldData(data))
+}
+
+func TestBackend_PathBackup(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+	recoveredAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	backend.clock = func() time.Time { return recoveredAt }
+
+	t.Run("backup and recover", func(t *testing.T) {
+		storage := createPoliciesStorage(t)
+		groups := exportBackup(t, backend, storage, map[string]interface{}{
+			"uuid":           signTestUUID,
+			"groupThreshold": 2,
+			"groups":         "2of3,3of5",
+		})
+		require.Len(t, groups, 2)
+		assert.Len(t, groups[0], 3)
+		assert.Len(t, groups[1], 5)
+
+		shares := []string{groups[0][2], groups[0][0], groups[1][1], groups[1][4], groups[1][3]}
+
+		// verification against the original user
+		resp, err := recoverShares(backend, storage, map[string]interface{}{
+			"shares":     shares,
+			"passphrase": signTestPassphrase,
+			"uuid":       signTestUUID,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, true, resp.Data["verified"])
+
+		resp, err = recoverShares(backend, storage, map[string]interface{}{
+			"shares": shares,
+			"uuid":   signTestUUID,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, false, resp.Data["verified"])
+
+		// registration of a new user with the same keys
+		resp, err = recoverShares(backend, storage, map[string]interface{}{
+			"shares":     shares,
+			"passphrase": signTestPassphrase,
+			"username":   "recovered",
+		})
+		require.NoError(t, err)
+		uuid := resp.Data["uuid"].(string)
+		assert.NotEqual(t, signTestUUID, uuid)
+
+		recovered, err := helpers.GetUser(ctx, storage, uuid)
+		require.NoError(t, err)
+		assert.Equal(t, signTestValidMnemonic, recovered.Mnemonic)
+		assert.Equal(t, signTestPassphrase, recovered.Passphrase)
+		assert.Equal(t, "recovered", recovered.Username)
+		assert.Equal(t, recoveredAt, recovered.CreatedAt, "timestamped by the backend clock")
+		assert.Equal(t, recoveredAt, recovered.UpdatedAt)
+
+		original, err := backend.pathUsersRead(ctx, &logical.Request{Storage: storage},
+			createUsersFieldData(map[string]interface{}{"uuid": signTestUUID}))
+		require.NoError(t, err)
+		assert.Equal(t, original.Data["fingerprint"], resp.Data["fingerprint"])
+	})
+
+	t.Run("recover in another language", func(t *testing.T) {
+		storage := createPoliciesStorage(t)
+		groups := exportBackup(t, backend, storage, map[string]interface{}{"uuid": signTestUUID})
+
+		resp, err := recoverShares(backend, storage, map[string]interface{}{
+			"shares":     groups[0],
+			"passphrase": signTestPassphrase,
+			"language":   "spanish",
+		})
+		require.NoError(t, err)
+
+		recovered, err := helpers.GetUser(ctx, storage, resp.Data["uuid"].(string))
+		require.NoError(t, err)
+		assert.Equal(t, lib.LanguageSpanish, recovered.Language)
+		entropy, err := lib.MnemonicToEntropy(recovered.Mnemonic, lib.LanguageSpanish)
+		require.NoError(t, err)
+		assert.Equal(t, make([]byte, 16), entropy)
+	})
+
+	t.Run("passphrase is normalized", func(t *testing.T) {
+		storage := &logical.InmemStorage{}
+		data := map[string]interface{}{"mnemonic": signTestValidMnemonic, "passphrase": "\uff41baco"}
+		registered, err := backend.pathRegister(ctx, &logical.Request{Storage: storage, Data: data},
+			createRegisterFieldData(data))
+		require.NoError(t, err)
+		uuid := registered.Data["uuid"].(string)
+		groups := exportBackup(t, backend, storage, map[string]interface{}{"uuid": uuid})
+
+		// shares are encrypted with the NFKD form of the passphrase, ASCII for
+		// fullwidth letters, and recovered with either form
+		resp, err := recoverShares(backend, storage, map[string]interface{}{
+			"shares":     groups[0],
+			"passphrase": "\uff41baco",
+			"uuid":       uuid,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, true, resp.Data["verified"])
+	})
+
+	t.Run("insufficient shares", func(t *testing.T) {
+		storage := createPoliciesStorage(t)
+		groups := exportBackup(t, backend, storage, map[string]interface{}{
+			"uuid":   signTestUUID,
+			"groups": "2of3",
+		})
+
+		_, err := recoverShares(backend, storage, map[string]interface{}{
+			"shares":     groups[0][:1],
+			"passphrase": signTestPassphrase,
+		})
+		requireCode(t, err, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("invalid groups", func(t *testing.T) {
+		storage := createPoliciesStorage(t)
+		for _, data := range []map[string]interface{}{
+			{"uuid": signTestUUID, "groups": "2-3"},
+			{"uuid": signTestUUID, "groups": "3of2"},
+			{"uuid": signTestUUID, "groups": "1of3"},
+			{"uuid": signTestUUID, "groups": "2of3", "groupThreshold": 2},
+		} {
+			_, err := backend.pathUserBackup(ctx, &logical.Request{Storage: storage, Data: data},
+				createBackupFieldData(data))
+			requireCode(t, err, http.StatusUnprocessableEntity)
+		}
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		data := map[string]interface{}{"uuid": "unknown"}
+		_, err := backend.pathUserBackup(ctx, &logical.Request{Storage: &logical.InmemStorage{}, Data: data},
+			createBackupFieldData(data))
+		requireCode(t, err, http.StatusNotFound)
+	})
+}
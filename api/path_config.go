@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/logging"
+	"github.com/payment-system/dq-vault/api/mountconfig"
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+// loadMountConfig returns the configuration of the mount, loaded from storage
+// on first use and cached until it is written or invalidated
+func (b *Backend) loadMountConfig(ctx context.Context, storage logical.Storage) (*mountconfig.Config, error) {
+	if c := b.mountConfig.Load(); c != nil {
+		return c, nil
+	}
+
+	c, err := mountconfig.Load(ctx, storage)
+	if err != nil {
+		return nil, err
+	}
+	b.applyMountConfig(c)
+	return c, nil
+}
+
+// applyMountConfig caches c and sets the log level it configures, the level
+// of the log_level mount option when it has none
+func (b *Backend) applyMountConfig(c *mountconfig.Config) {
+	level := b.mountLogLevel
+	if c.LogLevel != "" {
+		// validated when written
+		level, _ = logging.ParseLevel(c.LogLevel)
+	}
+	b.logLevel.Set(level)
+	if b.keyCache != nil {
+		b.keyCache.Configure(c.KeyCacheSize, time.Duration(c.KeyCacheTTL)*time.Second)
+	}
+	b.mountConfig.Store(c)
+}
+
+// checkMountConfig loads the configuration of the mount and rejects requests
+// for disabled coin types or in disabled development mode
+func (b *Backend) checkMountConfig(ctx context.Context, storage logical.Storage, coinType int,
+	isDev bool) (*mountconfig.Config, error) {
+	c, err := b.loadMountConfig(ctx, storage)
+	if err != nil {
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+	if err := c.Allow(uint16(coinType), isDev); err != nil {
+		return nil, logical.CodedError(http.StatusForbidden, err.Error())
+	}
+	return c, nil
+}
+
+// invalidate drops the cached configuration, keyring and account keys when another
+// node writes them
+func (b *Backend) invalidate(_ context.Context, key string) {
+	switch {
+	case key == config.MountConfigStoragePath:
+		b.mountConfig.Store(nil)
+	case key == config.KeyringStoragePath && b.keyring != nil:
+		b.keyring.Invalidate()
+	case strings.HasPrefix(key, config.StorageBasePath) && b.keyCache != nil:
+		b.keyCache.Invalidate(strings.TrimPrefix(key, config.StorageBasePath))
+	}
+}
+
+// pathConfigRead corresponds to READ dq/config.
+func (b *Backend) pathConfigRead(ctx context.Context, req *logical.Request,
+	_ *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_config_read"))
+
+	c, err := mountconfig.Load(ctx, req.Storage)
+	if err != nil {
+		backendLogger.Error("load mount config", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+
+	return &logical.Response{
+		Data: mountConfigData(c),
+	}, nil
+}
+
+// pathConfigWrite corresponds to POST dq/config. Only the fields of the
+// request are changed.
+func (b *Backend) pathConfigWrite(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_config_write"))
+	if err := helpers.ValidateFields(req, d); err != nil {
+		backendLogger.Error("validate fields", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	c, err := mountconfig.Load(ctx, req.Storage)
+	if err != nil {
+		backendLogger.Error("load mount config", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+
+	if entropyLength, ok := d.GetOk("entropyLength"); ok {
+		c.EntropyLength = entropyLength.(int)
+	}
+	if maxBatchSize, ok := d.GetOk("maxBatchSize"); ok {
+		c.MaxBatchSize = maxBatchSize.(int)
+	}
+	if coinTypes, ok := d.GetOk("enabledCoinTypes"); ok {
+		c.EnabledCoinTypes = nil
+		for _, coinType := range coinTypes.([]int) {
+			if coinType < 0 || coinType > int(^uint16(0)) {
+				return nil, logical.CodedError(http.StatusUnprocessableEntity,
+					fmt.Errorf("%w: invalid coin type %d", mountconfig.ErrInvalidConfig, coinType).Error())
+			}
+			c.EnabledCoinTypes = append(c.EnabledCoinTypes, uint16(coinType))
+		}
+		slices.Sort(c.EnabledCoinTypes)
+		c.EnabledCoinTypes = slices.Compact(c.EnabledCoinTypes)
+	}
+	if templates, ok := d.GetOk("derivationTemplates"); ok {
+		c.DerivationTemplates, err = mountconfig.ParseDerivationTemplates(templates.(map[string]string))
+		if err != nil {
+			return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+		}
+	}
+	if devModeEnabled, ok := d.GetOk("devModeEnabled"); ok {
+		c.DevModeEnabled = devModeEnabled.(bool)
+	}
+	if logLevel, ok := d.GetOk("logLevel"); ok {
+		c.LogLevel = strings.ToUpper(strings.TrimSpace(logLevel.(string)))
+	}
+	if keyCacheSize, ok := d.GetOk("keyCacheSize"); ok {
+		c.KeyCacheSize = keyCacheSize.(int)
+	}
+	if keyCacheTTL, ok := d.GetOk("keyCacheTTL"); ok {
+		c.KeyCacheTTL = keyCacheTTL.(int)
+	}
+	if idempotencyTTL, ok := d.GetOk("idempotencyTTL"); ok {
+		c.IdempotencyTTL = idempotencyTTL.(int)
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	c.UpdatedAt = b.now()
+	if err := mountconfig.Save(ctx, req.Storage, c); err != nil {
+		backendLogger.Error("save mount config", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+	b.applyMountConfig(c)
+
+	backendLogger.Info("mount config updated")
+	return nil, nil
+}
+
+// pathConfigDelete corresponds to DELETE dq/config, restoring the defaults.
+func (b *Backend) pathConfigDelete(ctx context.Context, req *logical.Request,
+	_ *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_config_delete"))
+
+	if err := req.Storage.Delete(ctx, config.MountConfigStoragePath); err != nil {
+		backendLogger.Error("delete mount config", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+	b.applyMountConfig(mountconfig.Default())
+
+	backendLogger.Info("mount config reset")
+	return nil, nil
+}
+
+// mountConfigData returns the response data of a mount configuration
+func mountConfigData(c *mountconfig.Config) map[string]interface{} {
+	coinTypes := make([]int, 0, len(c.EnabledCoinTypes))
+	for _, coinType := range c.EnabledCoinTypes {
+		coinTypes = append(coinTypes, int(coinType))
+	}
+	templates := make(map[string]string, len(c.DerivationTemplates))
+	for coinType, template := range c.DerivationTemplates {
+		templates[strconv.Itoa(int(coinType))] = template
+	}
+
+	data := map[string]interface{}{
+		"entropyLength":       c.EntropyLength,
+		"maxBatchSize":        c.MaxBatchSize,
+		"enabledCoinTypes":    coinTypes,
+		"derivationTemplates": templates,
+		"devModeEnabled":      c.DevModeEnabled,
+		"logLevel":            c.LogLevel,
+		"keyCacheSize":        c.KeyCacheSize,
+		"keyCacheTTL":         c.KeyCacheTTL,
+		"idempotencyTTL":      c.IdempotencyTTL,
+	}
+	if !c.UpdatedAt.IsZero() {
+		data["updatedAt"] = c.UpdatedAt
+	}
+	return data
+}
+
+// requestDerivationPath returns the derivation path of a request: the Bitshares
+// path, the path of the request, or the derivation template of the coin type
+// at index 0 when the request has none
+func requestDerivationPath(c *mountconfig.Config, coinType int, path string) string {
+	if uint16(coinType) == slip44.Bitshares {
+		return config.BitsharesDerivationPath
+	}
+	if path == "" {
+		if defaultPath, ok := c.DerivationPath(uint16(coinType), 0); ok {
+			return defaultPath
+		}
+	}
+	return path
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib/adapter"
+)
+
+// Helper function to create a proper framework.FieldData for config endpoint
+func createConfigFieldData(data map[string]interface{}) *framework.FieldData {
+	schema := map[string]*framework.FieldSchema{
+		"entropyLength": {
+			Type:        framework.TypeInt,
+			Description: "Entropy length",
+		},
+		"maxBatchSize": {
+			Type:        framework.TypeInt,
+			Description: "Maximum batch size",
+		},
+		"enabledCoinTypes": {
+			Type:        framework.TypeCommaIntSlice,
+			Description: "Enabled coin types",
+		},
+		"derivationTemplates": {
+			Type:        framework.TypeKVPairs,
+			Description: "Derivation templates",
+		},
+		"devModeEnabled": {
+			Type:        framework.TypeBool,
+			Description: "Development mode enabled",
+		},
+		"logLevel": {
+			Type:        framework.TypeString,
+			Description: "Log level",
+		},
+		"keyCacheSize": {
+			Type:        framework.TypeInt,
+			Description: "Key cache size",
+		},
+		"keyCacheTTL": {
+			Type:        framework.TypeInt,
+			Description: "Key cache TTL",
+		},
+		"idempotencyTTL": {
+			Type:        framework.TypeInt,
+			Description: "Idempotency TTL",
+		},
+	}
+
+	return &framework.FieldData{
+		Raw:    data,
+		Schema: schema,
+	}
+}
+
+func writeConfig(t *testing.T, backend *Backend, storage logical.Storage, data map[string]interface{}) {
+	t.Helper()
+
+	resp, err := backend.pathConfigWrite(context.Background(), &logical.Request{Storage: storage, Data: data},
+		createConfigFieldData(data))
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+}
+
+func requireCode(t *testing.T, err error, code int) {
+	t.Helper()
+
+	var codedErr logical.HTTPCodedError
+	require.ErrorAs(t, err, &codedErr)
+	assert.Equal(t, code, codedErr.Code())
+}
+
+func TestBackend_PathConfig(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("defaults", func(t *testing.T) {
+		backend := createSignTestBackend(t)
+		resp, err := backend.pathConfigRead(ctx, &logical.Request{Storage: &logical.InmemStorage{}},
+			createConfigFieldData(map[string]interface{}{}))
+		require.NoError(t, err)
+		assert.Equal(t, config.Entropy, resp.Data["entropyLength"])
+		assert.Equal(t, config.MaxBatchSize, resp.Data["maxBatchSize"])
+		assert.Equal(t, []int{}, resp.Data["enabledCoinTypes"])
+		assert.Equal(t, true, resp.Data["devModeEnabled"])
+		assert.Equal(t, 0, resp.Data["keyCacheSize"])
+		assert.Equal(t, config.KeyCacheTTL, resp.Data["keyCacheTTL"])
+		assert.Equal(t, config.IdempotencyTTL, resp.Data["idempotencyTTL"])
+		assert.NotContains(t, resp.Data, "updatedAt")
+	})
+
+	t.Run("write, read and delete", func(t *testing.T) {
+		backend := createSignTestBackend(t)
+		storage := &logical.InmemStorage{}
+
+		writeConfig(t, backend, storage, map[string]interface{}{
+			"entropyLength":       128,
+			"enabledCoinTypes":    "60,0,60",
+			"derivationTemplates": map[string]interface{}{"60": "m/44'/60'/0'/0/%d"},
+			"logLevel":            "debug",
+		})
+		// only the fields of the request change
+		writeConfig(t, backend, storage, map[string]interface{}{"maxBatchSize": 10})
+
+		resp, err := backend.pathConfigRead(ctx, &logical.Request{Storage: storage},
+			createConfigFieldData(map[string]interface{}{}))
+		require.NoError(t, err)
+		assert.Equal(t, 128, resp.Data["entropyLength"])
+		assert.Equal(t, 10, resp.Data["maxBatchSize"])
+		assert.Equal(t, []int{0, 60}, resp.Data["enabledCoinTypes"])
+		assert.Equal(t, map[string]string{"60": "m/44'/60'/0'/0/%d"}, resp.Data["derivationTemplates"])
+		assert.Equal(t, config.Debug, resp.Data["logLevel"])
+		assert.Contains(t, resp.Data, "updatedAt")
+		assert.Equal(t, slog.LevelDebug, backend.logLevel.Level())
+
+		_, err = backend.pathConfigDelete(ctx, &logical.Request{Storage: storage},
+			createConfigFieldData(map[string]interface{}{}))
+		require.NoError(t, err)
+		assert.Equal(t, slog.LevelInfo, backend.logLevel.Level())
+
+		resp, err = backend.pathConfigRead(ctx, &logical.Request{Storage: storage},
+			createConfigFieldData(map[string]interface{}{}))
+		require.NoError(t, err)
+		assert.Equal(t, config.MaxBatchSize, resp.Data["maxBatchSize"])
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		backend := createSignTestBackend(t)
+		for _, data := range []map[string]interface{}{
+			{"entropyLength": 100},
+			{"maxBatchSize": config.MaxBatchSizeLimit + 1},
+			{"enabledCoinTypes": "-1"},
+			{"derivationTemplates": map[string]interface{}{"60": "m/44'/60'/0'/0/0"}},
+			{"derivationTemplates": map[string]interface{}{"eth": "m/44'/60'/0'/0/%d"}},
+			{"logLevel": "verbose"},
+			{"keyCacheSize": -1},
+			{"keyCacheSize": config.KeyCacheSizeLimit + 1},
+			{"keyCacheTTL": 0},
+			{"idempotencyTTL": 0},
+			{"idempotencyTTL": config.IdempotencyTTLLimit + 1},
+		} {
+			_, err := backend.pathConfigWrite(ctx, &logical.Request{Storage: &logical.InmemStorage{}, Data: data},
+				createConfigFieldData(data))
+			requireCode(t, err, http.StatusUnprocessableEntity)
+		}
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		backend := createSignTestBackend(t)
+		storage := &logical.InmemStorage{}
+
+		// another node writes the configuration
+		writeConfig(t, createSignTestBackend(t), storage, map[string]interface{}{"maxBatchSize": 5})
+
+		c, err := backend.loadMountConfig(ctx, storage)
+		require.NoError(t, err)
+		assert.Equal(t, config.MaxBatchSize, c.MaxBatchSize)
+
+		backend.invalidate(ctx, config.MountConfigStoragePath)
+		c, err = backend.loadMountConfig(ctx, storage)
+		require.NoError(t, err)
+		assert.Equal(t, 5, c.MaxBatchSize)
+	})
+}
+
+func TestBackend_PathConfig_Enforced(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+	storage := createPoliciesStorage(t)
+
+	writeConfig(t, backend, storage, map[string]interface{}{
+		"maxBatchSize":        2,
+		"enabledCoinTypes":    "60",
+		"derivationTemplates": map[string]interface{}{"60": "m/44'/60'/0'/0/%d"},
+		"devModeEnabled":      false,
+	})
+
+	batch := func(data map[string]interface{}) (*logical.Response, error) {
+		return backend.pathAddressBatch(ctx, &logical.Request{Storage: storage, Data: data},
+			createBatchFieldData(data))
+	}
+	batchData := func(coinType, count int, isDev bool) map[string]interface{} {
+		return map[string]interface{}{
+			"uuid":         signTestUUID,
+			"pathTemplate": "",
+			"coinType":     coinType,
+			"isDev":        isDev,
+			"startIndex":   0,
+			"count":        count,
+		}
+	}
+
+	t.Run("derivation template", func(t *testing.T) {
+		resp, err := batch(batchData(60, 2, false))
+		require.NoError(t, err)
+		addresses := resp.Data["addresses"].([]adapter.DerivedAddress)
+		require.Len(t, addresses, 2)
+		assert.Equal(t, "m/44'/60'/0'/0/0", addresses[0].Path)
+		assert.Equal(t, "m/44'/60'/0'/0/1", addresses[1].Path)
+	})
+
+	t.Run("max batch size", func(t *testing.T) {
+		_, err := batch(batchData(60, 3, false))
+		requireCode(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("disabled coin type", func(t *testing.T) {
+		_, err := batch(batchData(195, 1, false))
+		requireCode(t, err, http.StatusForbidden)
+	})
+
+	t.Run("disabled development mode", func(t *testing.T) {
+		_, err := batch(batchData(60, 1, true))
+		requireCode(t, err, http.StatusForbidden)
+	})
+
+	t.Run("default path", func(t *testing.T) {
+		address := func(path string) interface{} {
+			data := map[string]interface{}{
+				"uuid":     signTestUUID,
+				"path":     path,
+				"coinType": 60,
+				"isDev":    false,
+			}
+			resp, err := backend.pathAddress(ctx, &logical.Request{Storage: storage, Data: data},
+				createFieldData(data))
+			require.NoError(t, err)
+			return resp.Data["address"]
+		}
+		assert.Equal(t, address("m/44'/60'/0'/0/0"), address(""))
+	})
+}
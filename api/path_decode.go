@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net/http"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/policy"
+	"github.com/payment-system/dq-vault/lib"
+)
+
+// pathDecode corresponds to POST decode.
+// Describes a payload the way pathSign parses it, without loading or deriving any key.
+// With a uuid, it also reports whether the signing policy of the user would allow it.
+func (b *Backend) pathDecode(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_decode"))
+	if err := helpers.ValidateFields(req, d); err != nil {
+		backendLogger.Error("validate fields", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	coinType := d.Get("coinType").(int)
+	payload := d.Get("payload").(string)
+	isDev := d.Get("isDev").(bool)
+	uuid := d.Get("uuid").(string)
+
+	if _, err := b.checkMountConfig(ctx, req.Storage, coinType, isDev); err != nil {
+		backendLogger.Error("mount config", "error", err)
+		return nil, err
+	}
+
+	adapterInventory := b.adapters()
+
+	summary, err := adapterInventory.DecodeTransaction(uint16(coinType), payload, isDev)
+	if err != nil {
+		backendLogger.Error("decode transaction", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	data := describeTransaction(summary)
+
+	if uuid != "" {
+		allowed, reason, err := b.dryRunPolicy(ctx, req.Storage, uuid, uint16(coinType), summary)
+		if err != nil {
+			backendLogger.Error("signing policy", "error", err)
+			return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+		}
+		policyData := map[string]interface{}{
+			"uuid":    uuid,
+			"allowed": allowed,
+		}
+		if reason != "" {
+			policyData["reason"] = reason
+		}
+		data["policy"] = policyData
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// dryRunPolicy evaluates the signing policy of uuid, velocity limits included,
+// against summary without recording anything. It returns why the policy would
+// reject the transaction, if it would.
+func (b *Backend) dryRunPolicy(ctx context.Context, storage logical.Storage, uuid string, coinType uint16,
+	summary *lib.TransactionSummary) (allowed bool, reason string, err error) {
+	p, err := policy.Effective(ctx, storage, uuid)
+	if err != nil {
+		return false, "", err
+	}
+	if p == nil {
+		return true, "", nil
+	}
+
+	if err := p.Evaluate(coinType, summary); err != nil {
+		return false, err.Error(), nil
+	}
+
+	err = p.CheckVelocity(ctx, storage, uuid, coinType, summary, b.now())
+	if errors.Is(err, policy.ErrPolicyViolation) {
+		return false, err.Error(), nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	return true, "", nil
+}
+
+// describeTransaction returns the normalized description of a decoded payload.
+// The recipient is the destination of the first native transfer, the contract
+// for contract calls; token fields describe the first token transfer.
+func describeTransaction(summary *lib.TransactionSummary) map[string]interface{} {
+	transfers := make([]map[string]interface{}, 0, len(summary.Transfers))
+	for _, transfer := range summary.Transfers {
+		entry := map[string]interface{}{
+			"to":    transfer.To,
+			"value": bigString(transfer.Value),
+		}
+		if transfer.Token != "" {
+			entry["token"] = transfer.Token
+		}
+		transfers = append(transfers, entry)
+	}
+
+	data := map[string]interface{}{
+		"type":        summary.Type,
+		"nativeValue": summary.NativeValue().String(),
+		"transfers":   transfers,
+		"fee":         describeFee(summary.Fee),
+	}
+	if summary.ChainID != nil {
+		data["chainId"] = summary.ChainID.String()
+	}
+	if summary.Method != "" {
+		data["method"] = summary.Method
+	}
+
+	recipientSet, tokenSet := false, false
+	for _, transfer := range summary.Transfers {
+		if transfer.Token == "" && !recipientSet {
+			data["recipient"] = transfer.To
+			recipientSet = true
+		}
+		if transfer.Token != "" && !tokenSet {
+			data["token"] = transfer.Token
+			data["tokenRecipient"] = transfer.To
+			data["tokenAmount"] = bigString(transfer.Value)
+			tokenSet = true
+		}
+	}
+
+	return data
+}
+
+// describeFee returns the fee fields that apply to the transaction
+func describeFee(fee lib.Fee) map[string]interface{} {
+	data := make(map[string]interface{})
+	if fee.GasLimit > 0 {
+		data["gasLimit"] = fee.GasLimit
+	}
+	for name, value := range map[string]*big.Int{
+		"gasPrice":             fee.GasPrice,
+		"maxFeePerGas":         fee.MaxFeePerGas,
+		"maxPriorityFeePerGas": fee.MaxPriorityFeePerGas,
+		"feeLimit":             fee.FeeLimit,
+		"amount":               fee.Amount,
+	} {
+		if value != nil {
+			data[name] = value.String()
+		}
+	}
+	return data
+}
+
+func bigString(value *big.Int) string {
+	if value == nil {
+		return "0"
+	}
+	return value.String()
+}
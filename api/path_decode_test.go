@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+// Helper function to create a proper framework.FieldData for decode endpoint
+func createDecodeFieldData(data map[string]interface{}) *framework.FieldData {
+	schema := map[string]*framework.FieldSchema{
+		"uuid": {
+			Type:        framework.TypeString,
+			Description: "UUID of user",
+			Default:     "",
+		},
+		"coinType": {
+			Type:        framework.TypeInt,
+			Description: "Cointype of transaction",
+		},
+		"payload": {
+			Type:        framework.TypeString,
+			Description: "Raw transaction payload",
+		},
+		"isDev": {
+			Type:        framework.TypeBool,
+			Description: "Development mode",
+			Default:     false,
+		},
+	}
+
+	return &framework.FieldData{
+		Raw:    data,
+		Schema: schema,
+	}
+}
+
+func TestBackend_PathDecode(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+
+	const erc20TransferPayload = `{"type":2,"nonce":7,"value":0,"gasLimit":60000,"maxFeePerGas":30000000000,` +
+		`"maxPriorityFeePerGas":1000000000,"to":"0xdAC17F958D2ee523a2206206994597C13D831ec7",` +
+		`"data":"0xa9059cbb000000000000000000000000742d35cc6634c0532925a3b8d359a5c5119e32c8` +
+		`00000000000000000000000000000000000000000000000000000000000f4240","chainId":1}`
+
+	t.Run("ether transfer", func(t *testing.T) {
+		got, err := backend.pathDecode(ctx, &logical.Request{Storage: &logical.InmemStorage{}},
+			createDecodeFieldData(map[string]interface{}{
+				"coinType": int(slip44.Ether),
+				"payload":  signTestPayload,
+			}))
+		require.NoError(t, err)
+		assert.Equal(t, "Ether Transfer", got.Data["type"])
+		assert.Equal(t, "1", got.Data["chainId"])
+		assert.Equal(t, signTestRecipient, got.Data["recipient"])
+		assert.Equal(t, "1000000000000000000", got.Data["nativeValue"])
+		assert.Equal(t, map[string]interface{}{"gasLimit": uint64(21000), "gasPrice": "20000000000"},
+			got.Data["fee"])
+		assert.NotContains(t, got.Data, "token")
+		assert.NotContains(t, got.Data, "policy")
+	})
+
+	t.Run("erc20 transfer", func(t *testing.T) {
+		got, err := backend.pathDecode(ctx, &logical.Request{Storage: &logical.InmemStorage{}},
+			createDecodeFieldData(map[string]interface{}{
+				"coinType": int(slip44.Ether),
+				"payload":  erc20TransferPayload,
+			}))
+		require.NoError(t, err)
+		assert.Equal(t, "Contract Function Call", got.Data["type"])
+		assert.Equal(t, "transfer", got.Data["method"])
+		assert.Equal(t, "0xdAC17F958D2ee523a2206206994597C13D831ec7", got.Data["recipient"])
+		assert.Equal(t, "0", got.Data["nativeValue"])
+		assert.Equal(t, "0xdAC17F958D2ee523a2206206994597C13D831ec7", got.Data["token"])
+		// token recipients are reported with their EIP-55 checksum
+		assert.Equal(t, common.HexToAddress(signTestRecipient).Hex(), got.Data["tokenRecipient"])
+		assert.Equal(t, "1000000", got.Data["tokenAmount"])
+		assert.Equal(t, map[string]interface{}{
+			"gasLimit":             uint64(60000),
+			"maxFeePerGas":         "30000000000",
+			"maxPriorityFeePerGas": "1000000000",
+		}, got.Data["fee"])
+	})
+
+	t.Run("policy dry run", func(t *testing.T) {
+		storage := createPoliciesStorage(t)
+		writePolicy(t, backend, storage, map[string]interface{}{
+			"uuid":          signTestUUID,
+			"allowedTokens": "0x9858EfFD232B4033E47d90003D41EC34EcaEda94",
+		})
+
+		got, err := backend.pathDecode(ctx, &logical.Request{Storage: storage},
+			createDecodeFieldData(map[string]interface{}{
+				"uuid":     signTestUUID,
+				"coinType": int(slip44.Ether),
+				"payload":  erc20TransferPayload,
+			}))
+		require.NoError(t, err)
+		policyData := got.Data["policy"].(map[string]interface{})
+		assert.Equal(t, false, policyData["allowed"])
+		assert.Contains(t, policyData["reason"], "token contract is not allowed")
+
+		got, err = backend.pathDecode(ctx, &logical.Request{Storage: storage},
+			createDecodeFieldData(map[string]interface{}{
+				"uuid":     signTestUUID,
+				"coinType": int(slip44.Ether),
+				"payload":  signTestPayload,
+			}))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"uuid": signTestUUID, "allowed": true}, got.Data["policy"])
+	})
+
+	t.Run("undecodable payload", func(t *testing.T) {
+		_, err := backend.pathDecode(ctx, &logical.Request{Storage: &logical.InmemStorage{}},
+			createDecodeFieldData(map[string]interface{}{
+				"coinType": int(slip44.Bitshares),
+				"payload":  `{"transactionDigest":"01"}`,
+			}))
+		var codedErr logical.HTTPCodedError
+		require.ErrorAs(t, err, &codedErr)
+		assert.Equal(t, http.StatusUnprocessableEntity, codedErr.Code())
+	})
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/keyring"
+	"github.com/payment-system/dq-vault/config"
+)
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrKeyringDisabled = errors.New("no key-encryption key is configured, set the kek_type mount option")
+	ErrInvalidKEKType  = errors.New("kek_type must be file or transit")
+	ErrKEKOptionNotSet = errors.New("key-encryption key option is not set")
+)
+
+// newKeyWrapper returns the key wrapper of the kek_type mount option, nil when
+// it is not set
+func newKeyWrapper(options map[string]string) (keyring.KeyWrapper, error) {
+	switch options[config.KEKTypeOption] {
+	case "":
+		return nil, nil
+	case keyring.KEKFile:
+		path := options[config.KEKFileOption]
+		if path == "" {
+			return nil, fmt.Errorf("%w: %s", ErrKEKOptionNotSet, config.KEKFileOption)
+		}
+		return keyring.NewFileWrapper(path)
+	case keyring.KEKTransit:
+		key := options[config.TransitKeyOption]
+		if key == "" {
+			return nil, fmt.Errorf("%w: %s", ErrKEKOptionNotSet, config.TransitKeyOption)
+		}
+		mount := options[config.TransitMountOption]
+		if mount == "" {
+			mount = keyring.KEKTransit
+		}
+
+		// the token is read from VAULT_TOKEN
+		clientConfig := vaultapi.DefaultConfig()
+		if address := options[config.TransitAddressOption]; address != "" {
+			clientConfig.Address = address
+		}
+		client, err := vaultapi.NewClient(clientConfig)
+		if err != nil {
+			return nil, err
+		}
+		return keyring.NewTransitWrapper(client.Logical(), mount, key), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidKEKType, options[config.KEKTypeOption])
+	}
+}
+
+// setupKeyring sets up the keyring sealing the entries of users when the mount
+// is configured with a key-encryption key
+func (b *Backend) setupKeyring(conf *logical.BackendConfig) error {
+	if conf == nil {
+		return nil
+	}
+	wrapper, err := newKeyWrapper(conf.Config)
+	if err != nil || wrapper == nil {
+		return err
+	}
+	b.keyring = keyring.New(wrapper)
+	b.logger.Info("user entries sealed", "kek", wrapper.Name())
+	return nil
+}
+
+// sealPaths wraps the callbacks of every path so that the entries of users
+// are sealed with the keyring, when the mount has one
+func (b *Backend) sealPaths() {
+	for _, path := range b.Paths {
+		for operation, callback := range path.Callbacks {
+			path.Callbacks[operation] = b.seal(callback)
+		}
+	}
+}
+
+// seal runs callback with the request storage sealing the entries of users
+func (b *Backend) seal(callback framework.OperationFunc) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if b.keyring == nil || req.Storage == nil {
+			return callback(ctx, req, d)
+		}
+
+		sealed := *req
+		sealed.Storage = b.keyring.Storage(req.Storage, config.StorageBasePath,
+			b.logger.With(slog.String("component", "keyring")))
+		return callback(ctx, &sealed, d)
+	}
+}
+
+// clean zeroizes the key material held by the backend when it is unloaded
+func (b *Backend) clean(_ context.Context) {
+	if b.keyring != nil {
+		b.keyring.Invalidate()
+	}
+	if b.keyCache != nil {
+		b.keyCache.Purge()
+	}
+}
+
+// pathKeyringRead corresponds to READ dq/keyring, the key-encryption key and
+// the data-encryption key versions of the mount
+func (b *Backend) pathKeyringRead(ctx context.Context, req *logical.Request,
+	_ *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_keyring_read"))
+	if b.keyring == nil {
+		return nil, logical.CodedError(http.StatusBadRequest, ErrKeyringDisabled.Error())
+	}
+
+	info, err := b.keyring.Info(ctx, req.Storage)
+	if err != nil {
+		backendLogger.Error("load keyring", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+
+	return &logical.Response{
+		Data: keyringData(info),
+	}, nil
+}
+
+// pathKeyringRotate corresponds to POST dq/keyring/rotate. Adds a
+// data-encryption key version sealing the entries of users from now on; with
+// reencrypt, every entry is sealed again with it.
+func (b *Backend) pathKeyringRotate(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_keyring_rotate"))
+	if err := helpers.ValidateFields(req, d); err != nil {
+		backendLogger.Error("validate fields", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+	if b.keyring == nil {
+		return nil, logical.CodedError(http.StatusBadRequest, ErrKeyringDisabled.Error())
+	}
+
+	version, err := b.keyring.Rotate(ctx, req.Storage)
+	if err != nil {
+		backendLogger.Error("rotate keyring", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+	backendLogger.Info("keyring rotated", "version", version)
+
+	data := map[string]interface{}{
+		"version": version,
+	}
+	if d.Get("reencrypt").(bool) {
+		count, err := b.reencryptUsers(ctx, req.Storage)
+		if err != nil {
+			backendLogger.Error("reencrypt users", "error", err)
+			return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+		}
+		backendLogger.Info("users reencrypted", "count", count)
+		data["reencrypted"] = count
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// reencryptUsers reads every user entry through the sealing storage, which
+// seals them again with the latest key version, and returns their count.
+// storage may already be the sealing storage of the request.
+func (b *Backend) reencryptUsers(ctx context.Context, storage logical.Storage) (int, error) {
+	sealed := b.keyring.Storage(storage, config.StorageBasePath, b.logger.With(slog.String("component", "keyring")))
+	uuids, err := storage.List(ctx, config.StorageBasePath)
+	if err != nil {
+		return 0, err
+	}
+	for _, uuid := range uuids {
+		if _, err := sealed.Get(ctx, config.StorageBasePath+uuid); err != nil {
+			return 0, fmt.Errorf("reencrypt %s: %w", uuid, err)
+		}
+	}
+	return len(uuids), nil
+}
+
+// pathKeyringRewrap corresponds to POST dq/keyring/rewrap. Wraps every
+// data-encryption key again with the key-encryption key, e.g., after the
+// rotation of the transit key.
+func (b *Backend) pathKeyringRewrap(ctx context.Context, req *logical.Request,
+	_ *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_keyring_rewrap"))
+	if b.keyring == nil {
+		return nil, logical.CodedError(http.StatusBadRequest, ErrKeyringDisabled.Error())
+	}
+
+	if err := b.keyring.Rewrap(ctx, req.Storage); err != nil {
+		backendLogger.Error("rewrap keyring", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+	info, err := b.keyring.Info(ctx, req.Storage)
+	if err != nil {
+		backendLogger.Error("load keyring", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+	backendLogger.Info("keyring rewrapped", "kek", info.KEK, "versions", len(info.Versions))
+
+	return &logical.Response{
+		Data: keyringData(info),
+	}, nil
+}
+
+// keyringData returns the response data of info
+func keyringData(info *keyring.Info) map[string]interface{} {
+	versions := make([]map[string]interface{}, 0, len(info.Versions))
+	for _, version := range info.Versions {
+		versions = append(versions, map[string]interface{}{
+			"version":   version.Version,
+			"createdAt": version.CreatedAt,
+		})
+	}
+
+	data := map[string]interface{}{
+		"kek":           info.KEK,
+		"latestVersion": info.LatestVersion,
+		"versions":      versions,
+	}
+	if !info.RewrappedAt.IsZero() {
+		data["rewrappedAt"] = info.RewrappedAt
+	}
+	return data
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/api/keyring"
+	"github.com/payment-system/dq-vault/config"
+)
+
+// Helper function to create a proper framework.FieldData for keyring/rotate endpoint
+func createKeyringRotateFieldData(data map[string]interface{}) *framework.FieldData {
+	schema := map[string]*framework.FieldSchema{
+		"reencrypt": {
+			Type:        framework.TypeBool,
+			Description: "Reencrypt users",
+			Default:     false,
+		},
+	}
+
+	return &framework.FieldData{
+		Raw:    data,
+		Schema: schema,
+	}
+}
+
+// createKeyringTestBackend returns a backend sealing users with a key file
+func createKeyringTestBackend(t *testing.T) *Backend {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "kek")
+	require.NoError(t, os.WriteFile(path, []byte(hex.EncodeToString([]byte(strings.Repeat("k", 32)))), 0o600))
+
+	backend := createSignTestBackend(t)
+	require.NoError(t, backend.setupKeyring(&logical.BackendConfig{Config: map[string]string{
+		config.KEKTypeOption: keyring.KEKFile,
+		config.KEKFileOption: path,
+	}}))
+	require.NotNil(t, backend.keyring)
+	return backend
+}
+
+func rawUserValue(t *testing.T, storage logical.Storage, uuid string) []byte {
+	t.Helper()
+
+	entry, err := storage.Get(context.Background(), config.StorageBasePath+uuid)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	return entry.Value
+}
+
+func TestBackend_SetupKeyring(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]string
+		wantErr error
+		enabled bool
+	}{
+		{name: "disabled", options: map[string]string{}},
+		{name: "invalid type", options: map[string]string{config.KEKTypeOption: "hsm"}, wantErr: ErrInvalidKEKType},
+		{name: "file without path", options: map[string]string{config.KEKTypeOption: keyring.KEKFile},
+			wantErr: ErrKEKOptionNotSet},
+		{name: "transit without key", options: map[string]string{config.KEKTypeOption: keyring.KEKTransit},
+			wantErr: ErrKEKOptionNotSet},
+		{name: "transit", options: map[string]string{
+			config.KEKTypeOption:    keyring.KEKTransit,
+			config.TransitKeyOption: "dq-kek",
+		}, enabled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := createSignTestBackend(t)
+			err := backend.setupKeyring(&logical.BackendConfig{Config: tt.options})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.enabled, backend.keyring != nil)
+		})
+	}
+}
+
+func TestBackend_PathKeyring(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("disabled", func(t *testing.T) {
+		backend := createSignTestBackend(t)
+		storage := createPoliciesStorage(t)
+
+		_, err := backend.pathKeyringRead(ctx, &logical.Request{Storage: storage}, createKeyringRotateFieldData(nil))
+		requireCode(t, err, http.StatusBadRequest)
+		_, err = backend.pathKeyringRotate(ctx, &logical.Request{Storage: storage}, createKeyringRotateFieldData(nil))
+		requireCode(t, err, http.StatusBadRequest)
+		_, err = backend.pathKeyringRewrap(ctx, &logical.Request{Storage: storage}, createKeyringRotateFieldData(nil))
+		requireCode(t, err, http.StatusBadRequest)
+
+		// users are stored as they are
+		_, err = backend.seal(backend.pathUsersRead)(ctx, &logical.Request{Storage: storage},
+			createUsersFieldData(map[string]interface{}{"uuid": signTestUUID}))
+		require.NoError(t, err)
+		assert.Contains(t, string(rawUserValue(t, storage, signTestUUID)), signTestValidMnemonic)
+	})
+
+	backend := createKeyringTestBackend(t)
+	storage := createPoliciesStorage(t)
+
+	t.Run("plaintext users migrated on read", func(t *testing.T) {
+		resp, err := backend.seal(backend.pathUsersRead)(ctx, &logical.Request{Storage: storage},
+			createUsersFieldData(map[string]interface{}{"uuid": signTestUUID}))
+		require.NoError(t, err)
+		assert.Equal(t, signTestUUID, resp.Data["uuid"])
+
+		raw := rawUserValue(t, storage, signTestUUID)
+		assert.True(t, keyring.IsSealed(raw))
+		assert.NotContains(t, string(raw), "abandon")
+		assert.NotContains(t, string(raw), signTestPassphrase)
+	})
+
+	t.Run("registered users sealed", func(t *testing.T) {
+		data := map[string]interface{}{"mnemonic": signTestValidMnemonic, "passphrase": signTestPassphrase}
+		resp, err := backend.seal(backend.pathRegister)(ctx, &logical.Request{Storage: storage, Data: data},
+			createRegisterFieldData(data))
+		require.NoError(t, err)
+
+		raw := rawUserValue(t, storage, resp.Data["uuid"].(string))
+		assert.True(t, keyring.IsSealed(raw))
+		assert.NotContains(t, string(raw), "abandon")
+	})
+
+	t.Run("read", func(t *testing.T) {
+		resp, err := backend.pathKeyringRead(ctx, &logical.Request{Storage: storage}, createKeyringRotateFieldData(nil))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(resp.Data["kek"].(string), "file/"))
+		assert.Equal(t, 1, resp.Data["latestVersion"])
+		assert.Len(t, resp.Data["versions"], 1)
+	})
+
+	t.Run("rotate and reencrypt", func(t *testing.T) {
+		// handlers receive the sealing storage, see sealPaths
+		data := map[string]interface{}{"reencrypt": true}
+		resp, err := backend.seal(backend.pathKeyringRotate)(ctx, &logical.Request{Storage: storage, Data: data},
+			createKeyringRotateFieldData(data))
+		require.NoError(t, err)
+		assert.Equal(t, 2, resp.Data["version"])
+		assert.Equal(t, 2, resp.Data["reencrypted"])
+
+		_, version, err := backend.keyring.Open(ctx, storage, config.StorageBasePath+signTestUUID,
+			rawUserValue(t, storage, signTestUUID))
+		require.NoError(t, err)
+		assert.Equal(t, 2, version)
+	})
+
+	t.Run("rewrap", func(t *testing.T) {
+		resp, err := backend.pathKeyringRewrap(ctx, &logical.Request{Storage: storage},
+			createKeyringRotateFieldData(nil))
+		require.NoError(t, err)
+		assert.Equal(t, 2, resp.Data["latestVersion"])
+		assert.Contains(t, resp.Data, "rewrappedAt")
+	})
+
+	t.Run("reloaded after invalidation", func(t *testing.T) {
+		backend.invalidate(ctx, config.KeyringStoragePath)
+		backend.clean(ctx)
+
+		resp, err := backend.seal(backend.pathUsersRead)(ctx, &logical.Request{Storage: storage},
+			createUsersFieldData(map[string]interface{}{"uuid": signTestUUID}))
+		require.NoError(t, err)
+		assert.Equal(t, signTestUUID, resp.Data["uuid"])
+	})
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/metrics"
+)
+
+// pathMetrics corresponds to READ dq/metrics, the metrics of the mount in the
+// Prometheus text exposition format
+func (b *Backend) pathMetrics(_ context.Context, _ *logical.Request,
+	_ *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_metrics"))
+
+	text, err := b.metrics.Text()
+	if err != nil {
+		backendLogger.Error("gather metrics", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: metrics.ContentType,
+			logical.HTTPRawBody:     text,
+			logical.HTTPStatusCode:  http.StatusOK,
+		},
+	}, nil
+}
+
+// instrumentPaths wraps the callbacks of every path to count their requests
+// and errors
+func (b *Backend) instrumentPaths() {
+	for _, path := range b.Paths {
+		route := routeName(path.Pattern)
+		for operation, callback := range path.Callbacks {
+			path.Callbacks[operation] = b.instrument(route, callback)
+		}
+	}
+}
+
+// instrument counts the requests of callback under route, labelled with the
+// coin type of the request when the path has one
+func (b *Backend) instrument(route string, callback framework.OperationFunc) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		resp, err := callback(ctx, req, d)
+
+		coinType := ""
+		if _, ok := d.Schema["coinType"]; ok {
+			if value, ok, getErr := d.GetOkErr("coinType"); getErr == nil && ok {
+				coinType = metrics.CoinTypeLabel(value.(int))
+			}
+		}
+		b.metrics.ObserveRequest(route, coinType, err)
+		return resp, err
+	}
+}
+
+// routeName returns the metrics label of a path pattern: named groups are
+// replaced with their name, e.g., users/<uuid>, and the optional trailing
+// slash and anchor are dropped
+func routeName(pattern string) string {
+	var name strings.Builder
+	for idx := 0; idx < len(pattern); idx++ {
+		if !strings.HasPrefix(pattern[idx:], "(?P<") {
+			name.WriteByte(pattern[idx])
+			continue
+		}
+
+		end := strings.IndexByte(pattern[idx:], '>')
+		name.WriteString(pattern[idx+3 : idx+end+1])
+
+		// skip the group up to its closing parenthesis
+		depth := 0
+		for ; idx < len(pattern); idx++ {
+			if pattern[idx] == '(' {
+				depth++
+			} else if pattern[idx] == ')' {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(name.String(), "$"), "/?")
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/api/metrics"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+func TestRouteName(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "sign", want: "sign"},
+		{pattern: "address/batch", want: "address/batch"},
+		{pattern: "users/?$", want: "users"},
+		{pattern: "users/" + framework.GenericNameRegex("uuid"), want: "users/<uuid>"},
+		{pattern: "audit/(?P<sequence>[0-9]+)$", want: "audit/<sequence>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert.Equal(t, tt.want, routeName(tt.pattern))
+		})
+	}
+}
+
+// metricsText reads dq/metrics and returns the exposition text
+func metricsText(t *testing.T, backend *Backend) string {
+	t.Helper()
+
+	resp, err := backend.pathMetrics(context.Background(), &logical.Request{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, metrics.ContentType, resp.Data[logical.HTTPContentType])
+	assert.Equal(t, http.StatusOK, resp.Data[logical.HTTPStatusCode])
+	return string(resp.Data[logical.HTTPRawBody].([]byte))
+}
+
+func TestBackend_PathMetrics(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+	backend.metrics = metrics.New(prometheus.NewRegistry())
+
+	storage := createPoliciesStorage(t)
+	sign := backend.instrument("sign", backend.pathSign)
+	signData := map[string]interface{}{
+		"uuid":     signTestUUID,
+		"path":     signTestDerivationPath,
+		"coinType": int(slip44.Ether),
+		"payload":  signTestPayload,
+	}
+
+	_, err := sign(ctx, &logical.Request{Storage: storage}, createSignFieldData(signData))
+	require.NoError(t, err)
+
+	writePolicy(t, backend, storage, map[string]interface{}{"uuid": signTestUUID,
+		"maxValues": map[string]interface{}{"60": "1"}})
+	_, err = sign(ctx, &logical.Request{Storage: storage}, createSignFieldData(signData))
+	require.Error(t, err)
+
+	signData["coinType"] = 4242
+	_, err = sign(ctx, &logical.Request{Storage: storage}, createSignFieldData(signData))
+	require.Error(t, err)
+
+	text := metricsText(t, backend)
+	assert.Contains(t, text, `dq_requests_total{coin_type="60",path="sign"} 2`)
+	assert.Contains(t, text, `dq_requests_total{coin_type="other",path="sign"} 1`)
+	assert.Contains(t, text, `dq_errors_total{code="403",path="sign",reason="policy"} 1`)
+	assert.Contains(t, text, `dq_policy_rejections_total{coin_type="60"} 1`)
+	assert.Contains(t, text, `dq_sign_duration_seconds_count{coin_type="60"} 1`)
+}
+
+func TestNewBackend_Metrics(t *testing.T) {
+	ctx := context.Background()
+	backend := NewBackend(&logical.BackendConfig{})
+
+	_, err := backend.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "info",
+		Storage:   &logical.InmemStorage{},
+	})
+	require.NoError(t, err)
+
+	_, err = backend.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "users/unknown",
+		Storage:   &logical.InmemStorage{},
+	})
+	require.Error(t, err)
+
+	text := metricsText(t, backend)
+	assert.Contains(t, text, `dq_requests_total{coin_type="",path="info"} 1`)
+	assert.Contains(t, text, `dq_requests_total{coin_type="",path="users/<uuid>"} 1`)
+	assert.Contains(t, text, `dq_errors_total{code="404",path="users/<uuid>",reason="not_found"} 1`)
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/policy"
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib"
+	"github.com/payment-system/dq-vault/lib/adapter"
+)
+
+// pathPoliciesList corresponds to LIST policies.
+// Lists the uuids having a signing policy, and default when the global policy is set.
+func (b *Backend) pathPoliciesList(ctx context.Context, req *logical.Request,
+	_ *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_policies_list"))
+
+	names, err := req.Storage.List(ctx, config.PolicyStoragePath)
+	if err != nil {
+		backendLogger.Error("list", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+
+	return logical.ListResponse(names), nil
+}
+
+// pathPoliciesRead corresponds to READ policies/<uuid>.
+func (b *Backend) pathPoliciesRead(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_policies_read"))
+
+	uuid := d.Get("uuid").(string)
+
+	p, err := policy.Get(ctx, req.Storage, uuid)
+	if err != nil {
+		backendLogger.Error("get policy", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+	if p == nil {
+		return nil, logical.CodedError(http.StatusNotFound, "no policy for "+uuid)
+	}
+
+	maxValues := make(map[string]string, len(p.MaxValues))
+	for coinType, maxValue := range p.MaxValues {
+		maxValues[strconv.Itoa(int(coinType))] = maxValue.String()
+	}
+
+	data := map[string]interface{}{
+		"uuid":                uuid,
+		"allowedDestinations": p.AllowedDestinations,
+		"maxValues":           maxValues,
+		"allowedChainIds":     p.AllowedChainIDs,
+		"allowedTokens":       p.AllowedTokens,
+		"velocityLimits":      velocityLimitsData(p.VelocityLimits),
+		"updatedAt":           p.UpdatedAt.Format(time.RFC3339),
+	}
+	if p.MaxValue != nil {
+		data["maxValue"] = p.MaxValue.String()
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// pathPoliciesWrite corresponds to POST policies/<uuid>.
+// Replaces the signing policy of a user, or the global one for uuid default.
+func (b *Backend) pathPoliciesWrite(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_policies_write"))
+	if err := helpers.ValidateFields(req, d); err != nil {
+		backendLogger.Error("validate fields", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	uuid := d.Get("uuid").(string)
+
+	// policies of unknown users would never apply
+	if uuid != config.DefaultPolicyName {
+		_, err := helpers.GetUser(ctx, req.Storage, uuid)
+		if errors.Is(err, helpers.ErrUUIDDoesNotExist) {
+			return nil, logical.CodedError(http.StatusNotFound, err.Error())
+		}
+		if err != nil {
+			backendLogger.Error("get user", "error", err)
+			return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+		}
+	}
+
+	maxValues, err := policy.ParseMaxValues(d.Get("maxValues").(map[string]string))
+	if err != nil {
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	velocityLimits, err := policy.ParseVelocityLimits(d.Get("velocityLimits").([]interface{}))
+	if err != nil {
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	chainIDs := d.Get("allowedChainIds").([]int)
+	allowedChainIDs := make([]int64, 0, len(chainIDs))
+	for _, chainID := range chainIDs {
+		allowedChainIDs = append(allowedChainIDs, int64(chainID))
+	}
+
+	p := &policy.Policy{
+		AllowedDestinations: d.Get("allowedDestinations").([]string),
+		MaxValues:           maxValues,
+		AllowedChainIDs:     allowedChainIDs,
+		AllowedTokens:       d.Get("allowedTokens").([]string),
+		VelocityLimits:      velocityLimits,
+		UpdatedAt:           b.now(),
+	}
+	p.Normalize()
+
+	if err := policy.Put(ctx, req.Storage, uuid, p); err != nil {
+		backendLogger.Error("put policy", "error", err)
+		return nil, logical.CodedError(http.StatusExpectationFailed, err.Error())
+	}
+
+	backendLogger.Info("policy updated", "uuid", uuid)
+
+	return nil, nil
+}
+
+// pathPoliciesDelete corresponds to DELETE policies/<uuid>.
+// Users without a policy fall back to the global default.
+func (b *Backend) pathPoliciesDelete(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_policies_delete"))
+
+	uuid := d.Get("uuid").(string)
+
+	if err := req.Storage.Delete(ctx, config.PolicyStoragePath+uuid); err != nil {
+		backendLogger.Error("delete policy", "error", err)
+		return nil, logical.CodedError(http.StatusExpectationFailed, err.Error())
+	}
+
+	backendLogger.Info("policy deleted", "uuid", uuid)
+
+	return nil, nil
+}
+
+// enforcePolicy evaluates the signing policy of uuid, velocity limits
+// included, against payload before any key is derived. It returns the policy
+// the signature is recorded with, a nil policy when nothing is restricted, and
+// the decoded payload, nil when it cannot be decoded. Violations are reported
+// with http.StatusForbidden.
+func enforcePolicy(ctx context.Context, storage logical.Storage, adapterInventory *adapter.Inventory,
+	uuid string, coinType uint16, payload string, isDev bool,
+	now time.Time) (*policy.Policy, *lib.TransactionSummary, error) {
+	summary, decodeErr := adapterInventory.DecodeTransaction(coinType, payload, isDev)
+
+	p, err := policy.Effective(ctx, storage, uuid)
+	if err != nil {
+		return nil, summary, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+	if p == nil || !p.IsRestrictive() {
+		return nil, summary, nil
+	}
+
+	// payloads of chains without a decoder are only signed under unrestricted policies
+	if decodeErr != nil && !errors.Is(decodeErr, adapter.ErrDecodingUnsupported) {
+		return nil, nil, logical.CodedError(http.StatusUnprocessableEntity, decodeErr.Error())
+	}
+
+	if err := p.Evaluate(coinType, summary); err != nil {
+		return nil, summary, logical.CodedError(http.StatusForbidden, err.Error())
+	}
+
+	err = p.CheckVelocity(ctx, storage, uuid, coinType, summary, now)
+	if errors.Is(err, policy.ErrPolicyViolation) {
+		return nil, summary, logical.CodedError(http.StatusForbidden, err.Error())
+	}
+	if err != nil {
+		return nil, summary, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+
+	return p, summary, nil
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+// signTestRecipient is the recipient of signTestPayload
+const signTestRecipient = "0x742d35Cc6634C0532925a3b8D359A5C5119e32C8"
+
+// Helper function to create a proper framework.FieldData for policies/<uuid> endpoint
+func createPoliciesFieldData(data map[string]interface{}) *framework.FieldData {
+	schema := map[string]*framework.FieldSchema{
+		"uuid": {
+			Type:        framework.TypeString,
+			Description: "UUID of user",
+		},
+		"allowedDestinations": {
+			Type:        framework.TypeCommaStringSlice,
+			Description: "Allowed destinations",
+		},
+		"maxValues": {
+			Type:        framework.TypeKVPairs,
+			Description: "Maximum values",
+		},
+		"allowedChainIds": {
+			Type:        framework.TypeCommaIntSlice,
+			Description: "Allowed chain ids",
+		},
+		"allowedTokens": {
+			Type:        framework.TypeCommaStringSlice,
+			Description: "Allowed tokens",
+		},
+		"velocityLimits": {
+			Type:        framework.TypeSlice,
+			Description: "Velocity limits",
+		},
+	}
+
+	return &framework.FieldData{
+		Raw:    data,
+		Schema: schema,
+	}
+}
+
+// createPoliciesStorage returns an in-memory storage holding the sign test user
+func createPoliciesStorage(t *testing.T) logical.Storage {
+	t.Helper()
+
+	storage := &logical.InmemStorage{}
+	entry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
+	require.NoError(t, storage.Put(context.Background(), entry))
+	return storage
+}
+
+func writePolicy(t *testing.T, backend *Backend, storage logical.Storage, data map[string]interface{}) {
+	t.Helper()
+
+	resp, err := backend.pathPoliciesWrite(context.Background(), &logical.Request{Storage: storage, Data: data},
+		createPoliciesFieldData(data))
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+}
+
+func TestBackend_PathPolicies(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+
+	t.Run("write, read, list and delete", func(t *testing.T) {
+		storage := createPoliciesStorage(t)
+
+		writePolicy(t, backend, storage, map[string]interface{}{
+			"uuid":                signTestUUID,
+			"allowedDestinations": signTestRecipient,
+			"maxValues":           map[string]interface{}{"60": "1000000000000000000"},
+			"allowedChainIds":     "1,137",
+		})
+
+		got, err := backend.pathPoliciesRead(ctx, &logical.Request{Storage: storage},
+			createPoliciesFieldData(map[string]interface{}{"uuid": signTestUUID}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"0x742d35cc6634c0532925a3b8d359a5c5119e32c8"}, got.Data["allowedDestinations"])
+		assert.Equal(t, map[string]string{"60": "1000000000000000000"}, got.Data["maxValues"])
+		assert.NotContains(t, got.Data, "maxValue")
+		assert.Equal(t, []int64{1, 137}, got.Data["allowedChainIds"])
+
+		list, err := backend.pathPoliciesList(ctx, &logical.Request{Storage: storage}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{signTestUUID}, list.Data["keys"])
+
+		_, err = backend.pathPoliciesDelete(ctx, &logical.Request{Storage: storage},
+			createPoliciesFieldData(map[string]interface{}{"uuid": signTestUUID}))
+		require.NoError(t, err)
+
+		_, err = backend.pathPoliciesRead(ctx, &logical.Request{Storage: storage},
+			createPoliciesFieldData(map[string]interface{}{"uuid": signTestUUID}))
+		var codedErr logical.HTTPCodedError
+		require.ErrorAs(t, err, &codedErr)
+		assert.Equal(t, http.StatusNotFound, codedErr.Code())
+	})
+
+	t.Run("default policy needs no user", func(t *testing.T) {
+		storage := &logical.InmemStorage{}
+
+		writePolicy(t, backend, storage, map[string]interface{}{
+			"uuid":            config.DefaultPolicyName,
+			"allowedChainIds": "1",
+		})
+
+		p, err := storage.Get(ctx, config.PolicyStoragePath+config.DefaultPolicyName)
+		require.NoError(t, err)
+		assert.NotNil(t, p)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		data := map[string]interface{}{"uuid": "unknown", "maxValues": map[string]interface{}{"60": "1"}}
+		_, err := backend.pathPoliciesWrite(ctx, &logical.Request{Storage: &logical.InmemStorage{}, Data: data},
+			createPoliciesFieldData(data))
+		var codedErr logical.HTTPCodedError
+		require.ErrorAs(t, err, &codedErr)
+		assert.Equal(t, http.StatusNotFound, codedErr.Code())
+	})
+
+	t.Run("invalid max value", func(t *testing.T) {
+		data := map[string]interface{}{"uuid": signTestUUID, "maxValues": map[string]interface{}{"60": "1.5"}}
+		_, err := backend.pathPoliciesWrite(ctx, &logical.Request{Storage: createPoliciesStorage(t), Data: data},
+			createPoliciesFieldData(data))
+		var codedErr logical.HTTPCodedError
+		require.ErrorAs(t, err, &codedErr)
+		assert.Equal(t, http.StatusUnprocessableEntity, codedErr.Code())
+	})
+}
+
+func TestBackend_PathSign_Policy(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+
+	tests := []struct {
+		name       string
+		policyUUID string
+		policy     map[string]interface{}
+		coinType   int
+		payload    string
+		wantErrMsg string
+	}{
+		{
+			name:       "allowed by the user policy",
+			policyUUID: signTestUUID,
+			policy:     map[string]interface{}{"allowedDestinations": signTestRecipient, "allowedChainIds": "1"},
+		},
+		{
+			name:       "destination not allowed",
+			policyUUID: signTestUUID,
+			policy:     map[string]interface{}{"allowedDestinations": "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"},
+			wantErrMsg: "destination is not allowed",
+		},
+		{
+			name:       "value limit from the default policy",
+			policyUUID: config.DefaultPolicyName,
+			policy:     map[string]interface{}{"maxValues": map[string]interface{}{"60": "1000"}},
+			wantErrMsg: "transaction value exceeds the limit",
+		},
+		{
+			name:       "chain id not allowed",
+			policyUUID: signTestUUID,
+			policy:     map[string]interface{}{"allowedChainIds": "137"},
+			wantErrMsg: "chain id is not allowed",
+		},
+		{
+			name:       "undecodable payload under a restrictive policy",
+			policyUUID: signTestUUID,
+			policy:     map[string]interface{}{"allowedChainIds": "1"},
+			coinType:   int(slip44.Bitshares),
+			payload:    `{"transactionDigest":"0102030405060708091011121314151617181920212223242526272829303132"}`,
+			wantErrMsg: "payload cannot be checked against the policy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := createPoliciesStorage(t)
+			tt.policy["uuid"] = tt.policyUUID
+			writePolicy(t, backend, storage, tt.policy)
+
+			coinType, payload := int(slip44.Ether), signTestPayload
+			if tt.payload != "" {
+				coinType, payload = tt.coinType, tt.payload
+			}
+
+			got, err := backend.pathSign(ctx, &logical.Request{Storage: storage}, createSignFieldData(map[string]interface{}{
+				"uuid":     signTestUUID,
+				"path":     signTestDerivationPath,
+				"coinType": coinType,
+				"payload":  payload,
+			}))
+			if tt.wantErrMsg == "" {
+				require.NoError(t, err)
+				assert.NotEmpty(t, got.Data["signature"])
+				return
+			}
+
+			require.Error(t, err)
+			var codedErr logical.HTTPCodedError
+			require.ErrorAs(t, err, &codedErr)
+			assert.Equal(t, http.StatusForbidden, codedErr.Code())
+			assert.Contains(t, err.Error(), tt.wantErrMsg)
+		})
+	}
+}
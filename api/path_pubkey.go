@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/metrics"
+	"github.com/payment-system/dq-vault/lib/adapter"
+)
+
+// pathPubKey corresponds to POST pubkey.
+// Returns the public key of a derivation path for watch-only services.
+func (b *Backend) pathPubKey(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_pubkey"))
+	if err := helpers.ValidateFields(req, d); err != nil {
+		backendLogger.Error("validate fields", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	// UUID of user
+	uuid := d.Get("uuid").(string)
+
+	// derivation path
+	derivationPath := d.Get("path").(string)
+
+	// coin type of the key
+	coinType := d.Get("coinType").(int)
+
+	isDev := d.Get("isDev").(bool)
+
+	mountConfig, err := b.checkMountConfig(ctx, req.Storage, coinType, isDev)
+	if err != nil {
+		backendLogger.Error("mount config", "error", err)
+		return nil, err
+	}
+	derivationPath = requestDerivationPath(mountConfig, coinType, derivationPath)
+
+	backendLogger.Info("request", "path", derivationPath, "cointype", coinType)
+
+	// validate data provided and load the user
+	userInfo, err := helpers.LoadUser(ctx, req, uuid, derivationPath)
+	if err != nil {
+		backendLogger.Error("load user", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	// obtains blockchain adapater based on coinType
+	adapterInventory := b.adapters()
+
+	keys, release, err := b.userKeychain(userInfo, adapterInventory, coinType, derivationPath)
+	if err != nil {
+		backendLogger.Error("user keychain", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+	defer release()
+
+	// public key in the native format of the chain
+	start := time.Now()
+	publicKey, err := adapterInventory.DerivePublicKey(keys, uint16(coinType), derivationPath, isDev)
+	b.metrics.ObserveDerivation(coinType, metrics.DerivationPublicKey, time.Since(start))
+	if err != nil {
+		backendLogger.Error("derive public key", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	data := map[string]interface{}{
+		"publicKey": publicKey,
+	}
+
+	// secp256k1 chains also expose both SEC1 encodings
+	ecPublicKey, err := adapterInventory.DeriveECPublicKey(keys, uint16(coinType), derivationPath, isDev)
+	switch {
+	case errors.Is(err, adapter.ErrECPublicKeyUnsupported):
+	case err != nil:
+		backendLogger.Error("derive secp256k1 public key", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	default:
+		data["compressed"] = hex.EncodeToString(ecPublicKey.SerializeCompressed())
+		data["uncompressed"] = hex.EncodeToString(ecPublicKey.SerializeUncompressed())
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+func TestBackend_PathPubKey(t *testing.T) {
+	ctx := context.Background()
+	backend := createTestBackend(t)
+
+	testUser := helpers.User{
+		Mnemonic: testMnemonic,
+	}
+
+	tests := []struct {
+		name                  string
+		fieldData             map[string]interface{}
+		wantSEC1              bool
+		wantPrefix            string
+		wantErr               bool
+		wantStatusCode        int
+		wantErrMsg            string
+		publicKeyIsCompressed bool
+	}{
+		{
+			name: "ethereum public key",
+			fieldData: map[string]interface{}{
+				"uuid":     testUUID,
+				"path":     testDerivationPath,
+				"coinType": int(slip44.Ether),
+			},
+			wantSEC1:              true,
+			publicKeyIsCompressed: true,
+		},
+		{
+			name: "bitcoin public key",
+			fieldData: map[string]interface{}{
+				"uuid":     testUUID,
+				"path":     testBitcoinDerivationPath,
+				"coinType": int(slip44.Bitcoin),
+			},
+			wantSEC1:              true,
+			publicKeyIsCompressed: true,
+		},
+		{
+			name: "bitshares public key in development mode",
+			fieldData: map[string]interface{}{
+				"uuid":     testUUID,
+				"coinType": int(slip44.Bitshares),
+				"isDev":    true,
+			},
+			wantSEC1:   true,
+			wantPrefix: "TEST",
+		},
+		{
+			name: "solana public key has no SEC1 encoding",
+			fieldData: map[string]interface{}{
+				"uuid":     testUUID,
+				"path":     testSolanaDerivationPath,
+				"coinType": int(slip44.Solana),
+			},
+		},
+		{
+			name: "unsupported coin type",
+			fieldData: map[string]interface{}{
+				"uuid":     testUUID,
+				"path":     testDerivationPath,
+				"coinType": 99999,
+			},
+			wantErr:        true,
+			wantStatusCode: http.StatusUnprocessableEntity,
+			wantErrMsg:     "no adapter found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockStorage)
+			mockStorage.On("Get", ctx, config.StorageBasePath+testUUID).
+				Return(createUserStorageEntry(t, testUser), nil)
+
+			got, err := backend.pathPubKey(ctx, &logical.Request{Storage: mockStorage}, createFieldData(tt.fieldData))
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				var codedErr logical.HTTPCodedError
+				require.ErrorAs(t, err, &codedErr)
+				assert.Equal(t, tt.wantStatusCode, codedErr.Code())
+				assert.Contains(t, err.Error(), tt.wantErrMsg)
+				return
+			}
+			require.NoError(t, err)
+
+			publicKey, ok := got.Data["publicKey"].(string)
+			require.True(t, ok)
+			assert.True(t, strings.HasPrefix(publicKey, tt.wantPrefix))
+
+			if !tt.wantSEC1 {
+				assert.NotContains(t, got.Data, "compressed")
+				assert.NotContains(t, got.Data, "uncompressed")
+				return
+			}
+
+			compressed, ok := got.Data["compressed"].(string)
+			require.True(t, ok)
+			uncompressed, ok := got.Data["uncompressed"].(string)
+			require.True(t, ok)
+			assert.Len(t, compressed, 66)
+			assert.Len(t, uncompressed, 130)
+			assert.True(t, strings.HasPrefix(uncompressed, "04"))
+			// both encodings hold the same x coordinate
+			assert.Equal(t, compressed[2:], uncompressed[2:66])
+			if tt.publicKeyIsCompressed {
+				assert.Equal(t, compressed, publicKey)
+			}
+		})
+	}
+}
+
+func TestBackend_PathXpub(t *testing.T) {
+	ctx := context.Background()
+	backend := createTestBackend(t)
+
+	testUser := helpers.User{
+		Mnemonic: testMnemonic,
+	}
+
+	tests := []struct {
+		name       string
+		fieldData  map[string]interface{}
+		want       string
+		wantErr    bool
+		wantErrMsg string
+	}{
+		{
+			name: "bip84 account zpub",
+			fieldData: map[string]interface{}{
+				"uuid":     testUUID,
+				"path":     "m/84'/0'/0'",
+				"coinType": int(slip44.Bitcoin),
+			},
+			want: "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs",
+		},
+		{
+			name: "ethereum account xpub",
+			fieldData: map[string]interface{}{
+				"uuid":     testUUID,
+				"path":     "m/44'/60'/0'",
+				"coinType": int(slip44.Ether),
+			},
+			want: "xpub",
+		},
+		{
+			name: "address level path",
+			fieldData: map[string]interface{}{
+				"uuid":     testUUID,
+				"path":     testBitcoinDerivationPath,
+				"coinType": int(slip44.Bitcoin),
+			},
+			wantErr:    true,
+			wantErrMsg: "account level",
+		},
+		{
+			name: "solana has no extended public keys",
+			fieldData: map[string]interface{}{
+				"uuid":     testUUID,
+				"path":     "m/44'/501'/0'",
+				"coinType": int(slip44.Solana),
+			},
+			wantErr:    true,
+			wantErrMsg: "extended public keys are not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockStorage)
+			mockStorage.On("Get", ctx, config.StorageBasePath+testUUID).
+				Return(createUserStorageEntry(t, testUser), nil)
+
+			got, err := backend.pathXpub(ctx, &logical.Request{Storage: mockStorage}, createFieldData(tt.fieldData))
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				var codedErr logical.HTTPCodedError
+				require.ErrorAs(t, err, &codedErr)
+				assert.Equal(t, http.StatusUnprocessableEntity, codedErr.Code())
+				assert.Contains(t, err.Error(), tt.wantErrMsg)
+				return
+			}
+			require.NoError(t, err)
+
+			xpub, ok := got.Data["xpub"].(string)
+			require.True(t, ok)
+			assert.True(t, strings.HasPrefix(xpub, tt.want))
+			assert.Equal(t, tt.fieldData["path"], got.Data["path"])
+			assert.Equal(t, usersTestFingerprint, got.Data["fingerprint"])
+		})
+	}
+}
@@ -2,8 +2,11 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -25,16 +28,38 @@ func (b *Backend) pathRegister(ctx context.Context, req *logical.Request,
 	// obatin username
 	username := d.Get("username").(string)
 
-	// obtain mnemonic and passphrase of user
-	mnemonic := d.Get("mnemonic").(string)
-	passphrase := d.Get("passphrase").(string)
+	// obtain mnemonic and passphrase of user, in the normalized forms seeds
+	// are derived from
+	mnemonic := lib.NormalizeMnemonic(d.Get("mnemonic").(string))
+	passphrase := lib.NormalizePassphrase(d.Get("passphrase").(string))
 
-	// default entropy length
-	entropyLength := config.Entropy
+	// word list of the mnemonic
+	language := strings.ToLower(strings.TrimSpace(d.Get("language").(string)))
+	if !slices.Contains(lib.MnemonicLanguages(), language) {
+		backendLogger.Error("unsupported language", "language", language)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity,
+			fmt.Sprintf("%s: %q", lib.ErrUnsupportedLanguage, language))
+	}
+
+	// entropy length configured for the mount
+	mountConfig, err := b.loadMountConfig(ctx, req.Storage)
+	if err != nil {
+		backendLogger.Error("load mount config", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+	entropyLength := mountConfig.EntropyLength
 
 	// generate new random UUID
 	uuid := helpers.NewUUID()
-	for helpers.UUIDExists(ctx, req, uuid) {
+	for {
+		exists, err := helpers.UUIDExists(ctx, req, uuid)
+		if err != nil {
+			backendLogger.Error("uuid exists", "error", err)
+			return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+		}
+		if !exists {
+			break
+		}
 		uuid = helpers.NewUUID()
 	}
 
@@ -44,25 +69,29 @@ func (b *Backend) pathRegister(ctx context.Context, req *logical.Request,
 	if mnemonic == "" {
 		// generate new mnemonics if not provided by user
 		// obtain mnemonics from entropy
-		mnemonic, err = lib.MnemonicFromEntropy(entropyLength)
+		mnemonic, err = lib.NewMnemonic(entropyLength, language)
 		if err != nil {
 			backendLogger.Error("generate mnemonic", "error", err)
 			return nil, logical.CodedError(http.StatusExpectationFailed, err.Error())
 		}
 	}
 
-	// check if mnemonic is valid or not
-	if !lib.IsMnemonicValid(mnemonic) {
-		backendLogger.Error("invalid mnemonic", "mnemonic", mnemonic)
-		return nil, logical.CodedError(http.StatusExpectationFailed, "Invalid Mnemonic")
+	// check if mnemonic is valid or not, errors never echo the phrase
+	if err = lib.ValidateMnemonic(mnemonic, language); err != nil {
+		backendLogger.Error("invalid mnemonic", "error", err)
+		return nil, logical.CodedError(http.StatusExpectationFailed, "Invalid Mnemonic: "+err.Error())
 	}
 
 	// create object to store user information
+	now := b.now()
 	user := &helpers.User{
 		Username:   username,
 		UUID:       uuid,
 		Mnemonic:   mnemonic,
 		Passphrase: passphrase,
+		Language:   language,
+		CreatedAt:  now,
+		UpdatedAt:  now,
 	}
 
 	// creates strorage entry with user JSON encoded value
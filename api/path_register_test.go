@@ -2,11 +2,14 @@ package api
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -15,7 +18,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/mountconfig"
 	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib"
 )
 
 // Test constants for register tests
@@ -70,6 +75,11 @@ func createRegisterFieldData(data map[string]interface{}) *framework.FieldData {
 			Type:        framework.TypeString,
 			Description: "Passphrase for mnemonic",
 		},
+		"language": {
+			Type:        framework.TypeString,
+			Description: "BIP-39 word list of the mnemonic",
+			Default:     "english",
+		},
 	}
 
 	return &framework.FieldData{
@@ -81,9 +91,12 @@ func createRegisterFieldData(data map[string]interface{}) *framework.FieldData {
 // Helper function to create test backend for register tests
 func createRegisterTestBackend(_ *testing.T) *Backend {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	return &Backend{
+	b := &Backend{
 		logger: logger,
 	}
+	// the storage mocks only expect user entries
+	b.mountConfig.Store(mountconfig.Default())
+	return b
 }
 
 // Mock functions for external dependencies
@@ -113,8 +126,8 @@ func TestBackend_PathRegister(t *testing.T) {
 				"passphrase": regTestPassphrase,
 			},
 			setupStorage: func(ms *MockStorageRegister) {
-				// Mock List for UUID existence check - return empty list (UUID doesn't exist)
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{}, nil)
+				// Mock Get for UUID existence check - return no entry (UUID doesn't exist)
+				ms.On("Get", ctx, mock.AnythingOfType("string")).Return(nil, nil)
 				// Mock Put for storing user data
 				ms.On("Put", ctx, mock.AnythingOfType("*logical.StorageEntry")).Return(nil)
 			},
@@ -133,8 +146,8 @@ func TestBackend_PathRegister(t *testing.T) {
 				"passphrase": regTestPassphrase,
 			},
 			setupStorage: func(ms *MockStorageRegister) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{}, nil)
+				// Mock Get for UUID existence check
+				ms.On("Get", ctx, mock.AnythingOfType("string")).Return(nil, nil)
 				// Mock Put for storing user data
 				ms.On("Put", ctx, mock.AnythingOfType("*logical.StorageEntry")).Return(nil)
 			},
@@ -153,8 +166,8 @@ func TestBackend_PathRegister(t *testing.T) {
 				"passphrase": "",
 			},
 			setupStorage: func(ms *MockStorageRegister) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{}, nil)
+				// Mock Get for UUID existence check
+				ms.On("Get", ctx, mock.AnythingOfType("string")).Return(nil, nil)
 				// Mock Put for storing user data
 				ms.On("Put", ctx, mock.AnythingOfType("*logical.StorageEntry")).Return(nil)
 			},
@@ -173,7 +186,7 @@ func TestBackend_PathRegister(t *testing.T) {
 			},
 			setupStorage: func(ms *MockStorageRegister) {
 				// Function proceeds to completion even without username
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{}, nil)
+				ms.On("Get", ctx, mock.AnythingOfType("string")).Return(nil, nil)
 				ms.On("Put", ctx, mock.AnythingOfType("*logical.StorageEntry")).Return(nil)
 			},
 			want: &logical.Response{
@@ -191,8 +204,8 @@ func TestBackend_PathRegister(t *testing.T) {
 				"passphrase": regTestPassphrase,
 			},
 			setupStorage: func(ms *MockStorageRegister) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{}, nil)
+				// Mock Get for UUID existence check
+				ms.On("Get", ctx, mock.AnythingOfType("string")).Return(nil, nil)
 			},
 			wantErr:        true,
 			wantStatusCode: http.StatusExpectationFailed,
@@ -206,8 +219,8 @@ func TestBackend_PathRegister(t *testing.T) {
 				"passphrase": regTestPassphrase,
 			},
 			setupStorage: func(ms *MockStorageRegister) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{}, nil)
+				// Mock Get for UUID existence check
+				ms.On("Get", ctx, mock.AnythingOfType("string")).Return(nil, nil)
 				// Mock Put to return error
 				ms.On("Put", ctx, mock.AnythingOfType("*logical.StorageEntry")).Return(assert.AnError)
 			},
@@ -222,8 +235,8 @@ func TestBackend_PathRegister(t *testing.T) {
 				"passphrase": regTestPassphrase,
 			},
 			setupStorage: func(ms *MockStorageRegister) {
-				// Mock List to return empty list (no collision for simplicity)
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{}, nil)
+				// Mock Get to return no entry (no collision for simplicity)
+				ms.On("Get", ctx, mock.AnythingOfType("string")).Return(nil, nil)
 				// Mock Put for storing user data
 				ms.On("Put", ctx, mock.AnythingOfType("*logical.StorageEntry")).Return(nil)
 			},
@@ -235,24 +248,19 @@ func TestBackend_PathRegister(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "storage list error during UUID check",
+			name: "storage get error during UUID check",
 			fieldData: map[string]interface{}{
 				"username":   regTestUsername,
 				"mnemonic":   regTestValidMnemonic,
 				"passphrase": regTestPassphrase,
 			},
 			setupStorage: func(ms *MockStorageRegister) {
-				// Mock List to return error - function should continue despite error
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{}, assert.AnError)
-				// Function continues to Put even when List fails
-				ms.On("Put", ctx, mock.AnythingOfType("*logical.StorageEntry")).Return(nil)
-			},
-			want: &logical.Response{
-				Data: map[string]interface{}{
-					"uuid": mock.AnythingOfType("string"),
-				},
+				// a uuid that cannot be checked is not registered
+				ms.On("Get", ctx, mock.AnythingOfType("string")).Return(nil, assert.AnError)
 			},
-			wantErr: false, // Function succeeds even when List fails
+			wantErr:        true,
+			wantStatusCode: http.StatusInternalServerError,
+			wantErrMsg:     assert.AnError.Error(),
 		},
 		{
 			name: "empty username",
@@ -263,7 +271,7 @@ func TestBackend_PathRegister(t *testing.T) {
 			},
 			setupStorage: func(ms *MockStorageRegister) {
 				// Function proceeds to completion even with empty username
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{}, nil)
+				ms.On("Get", ctx, mock.AnythingOfType("string")).Return(nil, nil)
 				ms.On("Put", ctx, mock.AnythingOfType("*logical.StorageEntry")).Return(nil)
 			},
 			want: &logical.Response{
@@ -357,7 +365,7 @@ func TestBackend_PathRegister_MnemonicGeneration(t *testing.T) {
 			backend := createRegisterTestBackend(t)
 
 			// Setup storage expectations
-			mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{}, nil)
+			mockStorage.On("Get", ctx, mock.AnythingOfType("string")).Return(nil, nil)
 			mockStorage.On("Put", ctx, mock.AnythingOfType("*logical.StorageEntry")).Return(nil)
 
 			fieldData := createRegisterFieldData(map[string]interface{}{
@@ -390,10 +398,12 @@ func TestBackend_PathRegister_StorageContent(t *testing.T) {
 	ctx := context.Background()
 	mockStorage := new(MockStorageRegister)
 	backend := createRegisterTestBackend(t)
+	registeredAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	backend.clock = func() time.Time { return registeredAt }
 
 	// Capture the storage entry to verify its content
 	var capturedEntry *logical.StorageEntry
-	mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{}, nil)
+	mockStorage.On("Get", ctx, mock.AnythingOfType("string")).Return(nil, nil)
 	mockStorage.On("Put", ctx, mock.AnythingOfType("*logical.StorageEntry")).Run(func(args mock.Arguments) {
 		capturedEntry = args.Get(1).(*logical.StorageEntry)
 	}).Return(nil)
@@ -430,6 +440,9 @@ func TestBackend_PathRegister_StorageContent(t *testing.T) {
 	assert.Equal(t, regTestPassphrase, storedUser.Passphrase)
 	assert.NotEmpty(t, storedUser.UUID)
 	assert.True(t, storedUser.UUID != "")
+	assert.Equal(t, registeredAt, storedUser.CreatedAt, "timestamped by the backend clock")
+	assert.Equal(t, storedUser.CreatedAt, storedUser.UpdatedAt)
+	assert.False(t, storedUser.IsDeleted())
 
 	// Verify storage path
 	expectedPath := config.StorageBasePath + storedUser.UUID
@@ -452,7 +465,7 @@ func TestBackend_PathRegister_EdgeCases(t *testing.T) {
 		fieldData := createRegisterFieldData(data)
 
 		// Mock with nil context using mock.Anything
-		mockStorage.On("List", mock.Anything, config.StorageBasePath).Return([]string{}, nil)
+		mockStorage.On("Get", mock.Anything, mock.AnythingOfType("string")).Return(nil, nil)
 		mockStorage.On("Put", mock.Anything, mock.AnythingOfType("*logical.StorageEntry")).Return(nil)
 
 		req := &logical.Request{
@@ -480,7 +493,7 @@ func TestBackend_PathRegister_EdgeCases(t *testing.T) {
 		}
 		fieldData := createRegisterFieldData(data)
 
-		mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{}, nil)
+		mockStorage.On("Get", ctx, mock.AnythingOfType("string")).Return(nil, nil)
 		mockStorage.On("Put", ctx, mock.AnythingOfType("*logical.StorageEntry")).Return(nil)
 
 		req := &logical.Request{
@@ -506,7 +519,7 @@ func TestBackend_PathRegister_EdgeCases(t *testing.T) {
 		}
 		fieldData := createRegisterFieldData(data)
 
-		mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{}, nil)
+		mockStorage.On("Get", ctx, mock.AnythingOfType("string")).Return(nil, nil)
 		mockStorage.On("Put", ctx, mock.AnythingOfType("*logical.StorageEntry")).Return(nil)
 
 		req := &logical.Request{
@@ -529,7 +542,7 @@ func BenchmarkBackend_PathRegister(b *testing.B) {
 
 	for i := 0; i < b.N; i++ {
 		mockStorage := new(MockStorageRegister)
-		mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{}, nil)
+		mockStorage.On("Get", ctx, mock.AnythingOfType("string")).Return(nil, nil)
 		mockStorage.On("Put", ctx, mock.AnythingOfType("*logical.StorageEntry")).Return(nil)
 
 		data := map[string]interface{}{
@@ -547,3 +560,66 @@ func BenchmarkBackend_PathRegister(b *testing.B) {
 		_, _ = backend.pathRegister(ctx, req, fieldData)
 	}
 }
+
+func TestBackend_PathRegister_Languages(t *testing.T) {
+	ctx := context.Background()
+	backend := createRegisterTestBackend(t)
+
+	register := func(data map[string]interface{}) (*helpers.User, error) {
+		storage := &logical.InmemStorage{}
+		resp, err := backend.pathRegister(ctx, &logical.Request{Storage: storage, Data: data},
+			createRegisterFieldData(data))
+		if err != nil {
+			return nil, err
+		}
+		return helpers.GetUser(ctx, storage, resp.Data["uuid"].(string))
+	}
+
+	t.Run("generated in language", func(t *testing.T) {
+		user, err := register(map[string]interface{}{"language": "Japanese"})
+		require.NoError(t, err)
+		assert.Equal(t, lib.LanguageJapanese, user.Language)
+		assert.NoError(t, lib.ValidateMnemonic(user.Mnemonic, lib.LanguageJapanese))
+	})
+
+	t.Run("imported mnemonic is normalized", func(t *testing.T) {
+		user, err := register(map[string]interface{}{
+			"mnemonic": "  " + strings.ReplaceAll(regTestValidMnemonic, " ", "　 ") + "\n",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, regTestValidMnemonic, user.Mnemonic)
+		assert.Equal(t, lib.LanguageEnglish, user.Language)
+	})
+
+	t.Run("passphrase is normalized", func(t *testing.T) {
+		// first Japanese test vector of BIP-39, its passphrase is not in NFKD
+		user, err := register(map[string]interface{}{
+			"mnemonic":   strings.Repeat("あいこくしん\u3000", 11) + "あおぞら",
+			"passphrase": "㍍ガバヴァぱばぐゞちぢ十人十色",
+			"language":   "japanese",
+		})
+		require.NoError(t, err)
+		seed, err := lib.SeedFromMnemonic(user.Mnemonic, user.Passphrase)
+		require.NoError(t, err)
+		assert.Equal(t, "a262d6fb6122ecf45be09c50492b31f92e9beb7d9a845987a02cefda57a15f9c"+
+			"467a17872029a9e92299b5cbdf306e3a0ee620245cbd508959b6cb7ca637bd55", hex.EncodeToString(seed))
+	})
+
+	t.Run("mnemonic of another language", func(t *testing.T) {
+		_, err := register(map[string]interface{}{"mnemonic": regTestValidMnemonic, "language": "french"})
+		requireCode(t, err, http.StatusExpectationFailed)
+		assert.Contains(t, err.Error(), "position 1")
+		assert.NotContains(t, err.Error(), "abandon")
+	})
+
+	t.Run("bad checksum", func(t *testing.T) {
+		_, err := register(map[string]interface{}{"mnemonic": strings.Repeat("abandon ", 12)})
+		requireCode(t, err, http.StatusExpectationFailed)
+		assert.ErrorContains(t, err, lib.ErrMnemonicChecksum.Error())
+	})
+
+	t.Run("unsupported language", func(t *testing.T) {
+		_, err := register(map[string]interface{}{"language": "klingon"})
+		requireCode(t, err, http.StatusUnprocessableEntity)
+	})
+}
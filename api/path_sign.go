@@ -2,20 +2,21 @@ package api
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/audit"
 	"github.com/payment-system/dq-vault/api/helpers"
-	"github.com/payment-system/dq-vault/config"
-	"github.com/payment-system/dq-vault/lib"
+	"github.com/payment-system/dq-vault/api/idempotency"
 	"github.com/payment-system/dq-vault/lib/adapter"
-	"github.com/payment-system/dq-vault/lib/slip44"
 )
 
 func (b *Backend) pathSign(ctx context.Context, req *logical.Request,
-	d *framework.FieldData) (*logical.Response, error) {
+	d *framework.FieldData) (resp *logical.Response, retErr error) {
 	backendLogger := b.logger.With(slog.String("op", "path_sign"))
 	if err := helpers.ValidateFields(req, d); err != nil {
 		backendLogger.Error("validate fields", "error", err)
@@ -38,52 +39,127 @@ func (b *Backend) pathSign(ctx context.Context, req *logical.Request,
 
 	isDev := d.Get("isDev").(bool)
 
-	if uint16(coinType) == slip44.Bitshares {
-		derivationPath = config.BitsharesDerivationPath
+	// retries with the same key return the signature of the first request
+	idempotencyKey := d.Get("idempotencyKey").(string)
+	if err := idempotency.ValidateKey(idempotencyKey); err != nil {
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
 	}
 
-	backendLogger.Info("request", "path", derivationPath, "cointype", coinType, "payload", payload)
+	mountConfig, err := b.checkMountConfig(ctx, req.Storage, coinType, isDev)
+	if err != nil {
+		backendLogger.Error("mount config", "error", err)
+		return nil, err
+	}
+	derivationPath = requestDerivationPath(mountConfig, coinType, derivationPath)
 
-	// validate data provided
-	if err := helpers.ValidateData(ctx, req, uuid, derivationPath); err != nil {
-		backendLogger.Error("validate data", "error", err)
-		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	backendLogger.Info("request", "path", derivationPath, "cointype", coinType)
+
+	// every signing request of a valid form is journaled, signed or rejected
+	auditEntry := &audit.Entry{
+		RequestID:   req.ID,
+		DisplayName: req.DisplayName,
+		UUID:        uuid,
+		CoinType:    uint16(coinType),
+		Path:        derivationPath,
 	}
+	journaled := false
+	defer func() {
+		if journaled {
+			return
+		}
+		if err := b.appendAudit(ctx, req.Storage, auditEntry, retErr); err != nil {
+			backendLogger.Error("append audit entry", "error", err)
+		}
+	}()
 
-	// path where user data is stored in vault
-	path := config.StorageBasePath + uuid
-	entry, err := req.Storage.Get(ctx, path)
+	// validate data provided and load the user
+	userInfo, err := helpers.LoadUser(ctx, req, uuid, derivationPath)
 	if err != nil {
-		backendLogger.Error("get", "error", err)
+		backendLogger.Error("load user", "error", err)
 		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
 	}
 
-	// obtain mnemonic, passphrase of user
-	var userInfo helpers.User
-	err = entry.DecodeJSON(&userInfo)
-	if err != nil {
-		backendLogger.Error("decode json", "error", err)
-		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	// obtains blockchain adapater based on coinType
+	adapterInventory := b.adapters()
+
+	// velocity limits are checked and consumed atomically per user
+	unlock := b.lockUser(uuid)
+	defer unlock()
+
+	request := signRequest{
+		UUID:     uuid,
+		Path:     derivationPath,
+		CoinType: coinType,
+		Payload:  payload,
+		IsDev:    isDev,
+	}
+	var requestHash string
+	if idempotencyKey != "" {
+		if requestHash, err = idempotency.RequestHash(request); err != nil {
+			return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+		}
+		record, err := idempotency.Get(ctx, req.Storage, uuid, idempotencyKey, b.now())
+		if err != nil {
+			backendLogger.Error("load idempotency record", "error", err)
+			return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+		}
+		if record != nil {
+			if record.RequestHash != requestHash {
+				backendLogger.Error("idempotency key", "error", idempotency.ErrKeyReused)
+				return nil, logical.CodedError(http.StatusConflict, idempotency.ErrKeyReused.Error())
+			}
+			// replays release a signature that was already journaled
+			journaled = true
+			backendLogger.Info("signature replayed", "uuid", uuid)
+			return signResponse(record.Signature, true), nil
+		}
 	}
 
-	// obtain seed from mnemonic and passphrase
-	seed, err := lib.SeedFromMnemonic(userInfo.Mnemonic, userInfo.Passphrase)
+	keychains := b.userKeychains(userInfo, adapterInventory)
+	defer keychains.release()
 
-	// obtains blockchain adapater based on coinType
-	adapterInventory := adapter.GetInventory(backendLogger)
+	// the velocity consumed is only stored once the signature is released
+	staged := newStagedStorage(req.Storage)
+	txHex, err := b.signTransaction(ctx, staged, backendLogger, adapterInventory, auditEntry, request, keychains)
 	if err != nil {
-		backendLogger.Error("get adapter", "error", err)
-		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+		return nil, err
 	}
 
-	// creates signature from raw transaction payload
-	txHex, err := adapterInventory.CreateSignedTransaction(seed, uint16(coinType), derivationPath, payload, isDev)
-	if err != nil {
-		backendLogger.Error("create signature", "error", err)
-		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	// signatures are only released, and stored for retries, once journaled
+	journaled = true
+	if err := b.appendAudit(ctx, req.Storage, auditEntry, nil); err != nil {
+		backendLogger.Error("append audit entry", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
 	}
 
-	backendLogger.Info("signature", "signature", txHex)
+	if idempotencyKey != "" {
+		now := b.now()
+		err := idempotency.Put(ctx, req.Storage, uuid, idempotencyKey, &idempotency.Record{
+			RequestHash: requestHash,
+			Signature:   txHex,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(time.Duration(mountConfig.IdempotencyTTL) * time.Second),
+		})
+		if err != nil {
+			backendLogger.Error("store idempotency record", "error", err)
+			return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	if err := staged.commit(ctx); err != nil {
+		backendLogger.Error("record velocity", "error", err)
+		// retries must sign again rather than replay an unreleased signature
+		if idempotencyKey != "" {
+			if err := idempotency.DeleteRecord(ctx, req.Storage, uuid, idempotencyKey); err != nil {
+				backendLogger.Error("delete idempotency record", "error", err)
+			}
+		}
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+
+	if idempotencyKey != "" {
+		return signResponse(txHex, false), nil
+	}
 
 	// Returns signature as output
 	return &logical.Response{
@@ -92,3 +168,91 @@ func (b *Backend) pathSign(ctx context.Context, req *logical.Request,
 		},
 	}, nil
 }
+
+// signResponse returns the response of a signing request with an idempotency
+// key, replayed when the signature was produced by an earlier request
+func signResponse(signature string, replayed bool) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"signature": signature,
+			"replayed":  replayed,
+		},
+	}
+}
+
+// signRequest is a transaction to sign, the request of dq/sign or an item of dq/sign/batch
+type signRequest struct {
+	UUID     string `json:"uuid"`
+	Path     string `json:"path"`
+	CoinType int    `json:"coinType"`
+	Payload  string `json:"payload"`
+	IsDev    bool   `json:"isDev"`
+}
+
+// signTransaction signs the payload of r under the signing policy of the user,
+// whose lock the caller holds. Velocity limits are checked and consumed in
+// storage, which callers stage until the signature is released; keys are only
+// derived once the policy allowed the transaction.
+func (b *Backend) signTransaction(ctx context.Context, storage logical.Storage, backendLogger *slog.Logger,
+	adapterInventory *adapter.Inventory, auditEntry *audit.Entry, r signRequest,
+	keychains *userKeychains) (string, error) {
+	now := b.now()
+
+	// evaluate the signing policy before any key is derived
+	signingPolicy, summary, err := enforcePolicy(ctx, storage, adapterInventory, r.UUID, uint16(r.CoinType),
+		r.Payload, r.IsDev, now)
+	auditEntry.SetTransaction(summary)
+	if err != nil {
+		backendLogger.Error("signing policy", "error", err)
+		var codedErr logical.HTTPCodedError
+		if errors.As(err, &codedErr) && codedErr.Code() == http.StatusForbidden {
+			b.metrics.PolicyRejected(r.CoinType)
+		}
+		return "", err
+	}
+
+	keys, err := keychains.get(r.CoinType, r.Path)
+	if err != nil {
+		backendLogger.Error("user keychain", "error", err)
+		return "", logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	// creates signature from raw transaction payload
+	start := time.Now()
+	txHex, err := adapterInventory.CreateSignedTransaction(keys, uint16(r.CoinType), r.Path, r.Payload, r.IsDev)
+	b.metrics.ObserveSign(r.CoinType, time.Since(start))
+	if err != nil {
+		backendLogger.Error("create signature", "error", err)
+		return "", logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	if signingPolicy != nil {
+		err := signingPolicy.RecordVelocity(ctx, storage, r.UUID, uint16(r.CoinType), summary, now)
+		if err != nil {
+			backendLogger.Error("record velocity", "error", err)
+			return "", logical.CodedError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	txHash, err := adapterInventory.TransactionHash(uint16(r.CoinType), r.Payload, txHex, r.IsDev)
+	if err != nil && !errors.Is(err, adapter.ErrTransactionHashUnsupported) {
+		backendLogger.Warn("transaction hash", "error", err)
+	}
+	auditEntry.TxHash = txHash
+
+	backendLogger.Info("transaction signed", "uuid", r.UUID, "txHash", txHash)
+
+	return txHex, nil
+}
+
+// periodic deletes the expired idempotency records of signing requests
+func (b *Backend) periodic(ctx context.Context, req *logical.Request) error {
+	pruned, err := idempotency.Prune(ctx, req.Storage, b.now())
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		b.logger.Debug("idempotency records pruned", "count", pruned)
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/audit"
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/lib/adapter"
+)
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrInvalidBatchItem = errors.New("invalid batch item")
+	ErrBatchAborted     = errors.New("batch aborted, another item failed")
+)
+
+// signBatchItem is an item of a dq/sign/batch request and its outcome
+type signBatchItem struct {
+	signRequest
+	index      int
+	auditEntry *audit.Entry
+	signature  string
+	err        error
+}
+
+// parseSignBatchItems parses the items field of a batch request. Each element
+// is an object, or its JSON encoding, such as
+// {"uuid": "...", "path": "m/44'/60'/0'/0/0", "coinType": 60, "payload": "..."}.
+func parseSignBatchItems(raw []interface{}) ([]*signBatchItem, error) {
+	items := make([]*signBatchItem, 0, len(raw))
+	for idx, element := range raw {
+		encoded, ok := element.(string)
+		if !ok {
+			data, err := json.Marshal(element)
+			if err != nil {
+				return nil, fmt.Errorf("%w %d: %w", ErrInvalidBatchItem, idx, err)
+			}
+			encoded = string(data)
+		}
+
+		item := &signBatchItem{index: idx}
+		decoder := json.NewDecoder(bytes.NewReader([]byte(encoded)))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&item.signRequest); err != nil {
+			return nil, fmt.Errorf("%w %d: %w", ErrInvalidBatchItem, idx, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// pathSignBatch corresponds to POST dq/sign/batch. Signs every item like
+// dq/sign, loading each user and deriving its keys once. Items are evaluated
+// independently; with atomic, no signature is returned, nor velocity consumed,
+// unless every item is signed.
+func (b *Backend) pathSignBatch(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_sign_batch"))
+	if err := helpers.ValidateFields(req, d); err != nil {
+		backendLogger.Error("validate fields", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	items, err := parseSignBatchItems(d.Get("items").([]interface{}))
+	if err != nil {
+		backendLogger.Error("parse items", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+	atomic := d.Get("atomic").(bool)
+
+	mountConfig, err := b.loadMountConfig(ctx, req.Storage)
+	if err != nil {
+		backendLogger.Error("load mount config", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+	if len(items) == 0 || len(items) > mountConfig.MaxBatchSize {
+		return nil, logical.CodedError(http.StatusBadRequest,
+			fmt.Sprintf("items must hold between 1 and %d transactions", mountConfig.MaxBatchSize))
+	}
+
+	backendLogger.Info("request", "items", len(items), "atomic", atomic)
+
+	// every item is journaled, signed or rejected
+	failed := false
+	for _, item := range items {
+		if _, item.err = b.checkMountConfig(ctx, req.Storage, item.CoinType, item.IsDev); item.err == nil {
+			item.Path = requestDerivationPath(mountConfig, item.CoinType, item.Path)
+		}
+		item.auditEntry = &audit.Entry{
+			RequestID:   req.ID,
+			DisplayName: req.DisplayName,
+			UUID:        item.UUID,
+			CoinType:    uint16(item.CoinType),
+			Path:        item.Path,
+		}
+		failed = failed || item.err != nil
+	}
+
+	// the velocity consumed by atomic batches is only stored once every item is signed
+	velocityStorage := req.Storage
+	var staged *stagedStorage
+	if atomic {
+		staged = newStagedStorage(req.Storage)
+		velocityStorage = staged
+	}
+
+	adapterInventory := b.adapters()
+
+	// atomic batches lock every user up front and hold the locks until the
+	// velocity is stored, other batches lock one user at a time
+	groups := make(map[string][]*signBatchItem)
+	for _, item := range items {
+		groups[item.UUID] = append(groups[item.UUID], item)
+	}
+	uuids := make([]string, 0, len(groups))
+	for uuid := range groups {
+		uuids = append(uuids, uuid)
+	}
+	slices.Sort(uuids)
+
+	if atomic {
+		unlock := b.lockUsers(uuids)
+		defer unlock()
+	}
+	for _, uuid := range uuids {
+		if atomic && failed {
+			break
+		}
+		unlock := func() {}
+		if !atomic {
+			unlock = b.lockUser(uuid)
+		}
+		failed = b.signBatchGroup(ctx, req, velocityStorage, backendLogger, adapterInventory, groups[uuid],
+			atomic) || failed
+		unlock()
+	}
+
+	if atomic && failed {
+		for _, item := range items {
+			if item.err == nil {
+				item.signature = ""
+				item.err = logical.CodedError(http.StatusFailedDependency, ErrBatchAborted.Error())
+			}
+		}
+	} else if atomic {
+		if err := staged.commit(ctx); err != nil {
+			backendLogger.Error("record velocity", "error", err)
+			return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// signatures are only released once journaled
+	for _, item := range items {
+		if err := b.appendAudit(ctx, req.Storage, item.auditEntry, item.err); err != nil {
+			backendLogger.Error("append audit entry", "error", err, "index", item.index)
+			if atomic {
+				return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+			}
+			if item.err == nil {
+				item.signature = ""
+				item.err = logical.CodedError(http.StatusInternalServerError, err.Error())
+			}
+		}
+	}
+
+	return &logical.Response{
+		Data: signBatchData(items),
+	}, nil
+}
+
+// signBatchGroup signs the items of one user, whose lock the caller holds,
+// loading the user and deriving its keys once. It reports whether an item
+// failed; with stopOnError, the items following a failure are left unsigned.
+func (b *Backend) signBatchGroup(ctx context.Context, req *logical.Request, storage logical.Storage,
+	backendLogger *slog.Logger, adapterInventory *adapter.Inventory, items []*signBatchItem,
+	stopOnError bool) bool {
+	var (
+		user      *helpers.User
+		loadErr   error
+		keychains *userKeychains
+	)
+	defer func() {
+		if keychains != nil {
+			keychains.release()
+		}
+	}()
+
+	failed := false
+	for _, item := range items {
+		if failed && stopOnError {
+			break
+		}
+		if item.err == nil && item.Path == "" {
+			item.err = logical.CodedError(http.StatusUnprocessableEntity, helpers.ErrInvalidPath.Error())
+		}
+		if item.err != nil {
+			failed = true
+			continue
+		}
+
+		// the user is loaded by its first item
+		if user == nil && loadErr == nil {
+			if user, loadErr = helpers.LoadUser(ctx, req, item.UUID, item.Path); loadErr == nil {
+				keychains = b.userKeychains(user, adapterInventory)
+			}
+		}
+		if loadErr != nil {
+			backendLogger.Error("load user", "error", loadErr, "index", item.index)
+			item.err = logical.CodedError(http.StatusUnprocessableEntity, loadErr.Error())
+		} else {
+			item.signature, item.err = b.signTransaction(ctx, storage, backendLogger.With(slog.Int("index", item.index)),
+				adapterInventory, item.auditEntry, item.signRequest, keychains)
+		}
+		failed = failed || item.err != nil
+	}
+	return failed
+}
+
+// signBatchData returns the response data of the items of a batch, in request order
+func signBatchData(items []*signBatchItem) map[string]interface{} {
+	results := make([]map[string]interface{}, 0, len(items))
+	signed := 0
+	for _, item := range items {
+		result := map[string]interface{}{
+			"index": item.index,
+			"uuid":  item.UUID,
+		}
+		if item.err != nil {
+			code := http.StatusInternalServerError
+			var codedErr logical.HTTPCodedError
+			if errors.As(item.err, &codedErr) {
+				code = codedErr.Code()
+			}
+			result["error"] = map[string]interface{}{
+				"code":    code,
+				"message": item.err.Error(),
+			}
+		} else {
+			result["signature"] = item.signature
+			signed++
+		}
+		results = append(results, result)
+	}
+
+	return map[string]interface{}{
+		"results": results,
+		"signed":  signed,
+		"failed":  len(items) - signed,
+	}
+}
+
+// stagedStorage buffers the writes to a storage until they are committed.
+// Reads see the buffered writes, lists do not.
+type stagedStorage struct {
+	logical.Storage
+	writes map[string]*logical.StorageEntry
+	keys   []string
+}
+
+func newStagedStorage(storage logical.Storage) *stagedStorage {
+	return &stagedStorage{
+		Storage: storage,
+		writes:  make(map[string]*logical.StorageEntry),
+	}
+}
+
+func (s *stagedStorage) Get(ctx context.Context, key string) (*logical.StorageEntry, error) {
+	if entry, ok := s.writes[key]; ok {
+		return entry, nil
+	}
+	return s.Storage.Get(ctx, key)
+}
+
+func (s *stagedStorage) Put(_ context.Context, entry *logical.StorageEntry) error {
+	s.stage(entry.Key, entry)
+	return nil
+}
+
+func (s *stagedStorage) Delete(_ context.Context, key string) error {
+	s.stage(key, nil)
+	return nil
+}
+
+// stage buffers the write of entry at key, a deletion when entry is nil
+func (s *stagedStorage) stage(key string, entry *logical.StorageEntry) {
+	if _, ok := s.writes[key]; !ok {
+		s.keys = append(s.keys, key)
+	}
+	s.writes[key] = entry
+}
+
+// commit applies the buffered writes to the storage, in order
+func (s *stagedStorage) commit(ctx context.Context) error {
+	for _, key := range s.keys {
+		var err error
+		if entry := s.writes[key]; entry != nil {
+			err = s.Storage.Put(ctx, entry)
+		} else {
+			err = s.Storage.Delete(ctx, key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/api/audit"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+func createSignBatchFieldData(data map[string]interface{}) *framework.FieldData {
+	schema := map[string]*framework.FieldSchema{
+		"items": {
+			Type:        framework.TypeSlice,
+			Description: "Transactions to sign",
+		},
+		"atomic": {
+			Type:        framework.TypeBool,
+			Description: "All or nothing",
+		},
+	}
+
+	return &framework.FieldData{
+		Raw:    data,
+		Schema: schema,
+	}
+}
+
+// signBatchTestItem returns a batch item sending 1 ETH with nonce
+func signBatchTestItem(uuid, nonce string) map[string]interface{} {
+	return map[string]interface{}{
+		"uuid":     uuid,
+		"path":     signTestDerivationPath,
+		"coinType": int(slip44.Ether),
+		"payload":  strings.Replace(signTestPayload, `"nonce":42`, `"nonce":`+nonce, 1),
+	}
+}
+
+func signBatch(t *testing.T, backend *Backend, storage logical.Storage,
+	data map[string]interface{}) (*logical.Response, error) {
+	t.Helper()
+	return backend.pathSignBatch(context.Background(), &logical.Request{Storage: storage, Data: data},
+		createSignBatchFieldData(data))
+}
+
+func TestBackend_PathSignBatch(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+	storage := createPoliciesStorage(t)
+
+	invalidPayload := signBatchTestItem(signTestUUID, "44")
+	invalidPayload["payload"] = signTestMalformedPayload
+
+	resp, err := signBatch(t, backend, storage, map[string]interface{}{
+		"items": []interface{}{
+			signBatchTestItem(signTestUUID, "42"),
+			signBatchTestItem("unknown-uuid", "42"),
+			invalidPayload,
+			signBatchTestItem(signTestUUID, "43"),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.Data["signed"])
+	assert.Equal(t, 2, resp.Data["failed"])
+
+	results := resp.Data["results"].([]map[string]interface{})
+	require.Len(t, results, 4)
+	for i, result := range results {
+		assert.Equal(t, i, result["index"])
+	}
+
+	// signatures equal the ones of dq/sign
+	single, err := backend.pathSign(ctx, &logical.Request{Storage: createPoliciesStorage(t)},
+		createSignFieldData(signBatchTestItem(signTestUUID, "42")))
+	require.NoError(t, err)
+	assert.Equal(t, single.Data["signature"], results[0]["signature"])
+	assert.NotEmpty(t, results[3]["signature"])
+	assert.NotEqual(t, results[0]["signature"], results[3]["signature"])
+
+	assert.Equal(t, http.StatusUnprocessableEntity, results[1]["error"].(map[string]interface{})["code"])
+	assert.NotContains(t, results[1], "signature")
+	assert.Equal(t, http.StatusUnprocessableEntity, results[2]["error"].(map[string]interface{})["code"])
+
+	// every item is journaled
+	entries, _, err := audit.List(ctx, storage, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	outcomes := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		outcomes = append(outcomes, entry.Outcome)
+	}
+	assert.Equal(t, []string{audit.OutcomeSigned, audit.OutcomeRejected, audit.OutcomeRejected,
+		audit.OutcomeSigned}, outcomes)
+}
+
+func TestBackend_PathSignBatch_Atomic(t *testing.T) {
+	backend := createSignTestBackend(t)
+
+	// a 1.5 ETH velocity limit lets one 1 ETH item through
+	velocityPolicy := map[string]interface{}{
+		"uuid": signTestUUID,
+		"velocityLimits": []interface{}{map[string]interface{}{
+			"coinType": int(slip44.Ether), "window": "24h", "maxValue": "1500000000000000000",
+		}},
+	}
+	usedCount := func(t *testing.T, storage logical.Storage) int {
+		t.Helper()
+		resp, err := backend.pathVelocityRead(context.Background(), &logical.Request{Storage: storage},
+			createVelocityFieldData(map[string]interface{}{"uuid": signTestUUID}))
+		require.NoError(t, err)
+		return resp.Data["limits"].([]map[string]interface{})[0]["usedCount"].(int)
+	}
+
+	t.Run("independent items", func(t *testing.T) {
+		storage := createPoliciesStorage(t)
+		writePolicy(t, backend, storage, velocityPolicy)
+
+		resp, err := signBatch(t, backend, storage, map[string]interface{}{
+			"items": []interface{}{signBatchTestItem(signTestUUID, "42"), signBatchTestItem(signTestUUID, "43")},
+		})
+		require.NoError(t, err)
+		results := resp.Data["results"].([]map[string]interface{})
+		assert.NotEmpty(t, results[0]["signature"])
+		assert.Equal(t, http.StatusForbidden, results[1]["error"].(map[string]interface{})["code"])
+		assert.Equal(t, 1, usedCount(t, storage))
+	})
+
+	t.Run("aborted", func(t *testing.T) {
+		storage := createPoliciesStorage(t)
+		writePolicy(t, backend, storage, velocityPolicy)
+
+		resp, err := signBatch(t, backend, storage, map[string]interface{}{
+			"items":  []interface{}{signBatchTestItem(signTestUUID, "42"), signBatchTestItem(signTestUUID, "43")},
+			"atomic": true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 0, resp.Data["signed"])
+		results := resp.Data["results"].([]map[string]interface{})
+		assert.Equal(t, http.StatusFailedDependency, results[0]["error"].(map[string]interface{})["code"])
+		assert.NotContains(t, results[0], "signature")
+		assert.Equal(t, http.StatusForbidden, results[1]["error"].(map[string]interface{})["code"])
+
+		// no velocity is consumed
+		assert.Equal(t, 0, usedCount(t, storage))
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		storage := createPoliciesStorage(t)
+		velocityPolicy["velocityLimits"] = []interface{}{map[string]interface{}{
+			"coinType": int(slip44.Ether), "window": "24h", "maxValue": "2000000000000000000",
+		}}
+		writePolicy(t, backend, storage, velocityPolicy)
+
+		resp, err := signBatch(t, backend, storage, map[string]interface{}{
+			"items":  []interface{}{signBatchTestItem(signTestUUID, "42"), signBatchTestItem(signTestUUID, "43")},
+			"atomic": true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, resp.Data["signed"])
+		assert.Equal(t, 2, usedCount(t, storage))
+	})
+}
+
+func TestBackend_PathSignBatch_Validation(t *testing.T) {
+	backend := createSignTestBackend(t)
+	storage := createPoliciesStorage(t)
+	writeConfig(t, backend, storage, map[string]interface{}{"maxBatchSize": 2})
+
+	tests := []struct {
+		name     string
+		items    []interface{}
+		wantCode int
+	}{
+		{
+			name:     "no items",
+			items:    []interface{}{},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "too many items",
+			items: []interface{}{signBatchTestItem(signTestUUID, "1"), signBatchTestItem(signTestUUID, "2"),
+				signBatchTestItem(signTestUUID, "3")},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unknown field",
+			items:    []interface{}{map[string]interface{}{"uuid": signTestUUID, "amount": 1}},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "malformed item",
+			items:    []interface{}{"{not json"},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := signBatch(t, backend, storage, map[string]interface{}{"items": tt.items})
+			requireCode(t, err, tt.wantCode)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/audit"
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/policy"
+)
+
+// pathSignMessage corresponds to POST sign/message.
+// Signs an off-chain message (EIP-191 personal_sign or EIP-712 typed data).
+// Users under a restrictive signing policy cannot sign messages, as messages
+// such as permits authorize transfers the policy cannot check.
+func (b *Backend) pathSignMessage(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (resp *logical.Response, retErr error) {
+	backendLogger := b.logger.With(slog.String("op", "path_sign_message"))
+	if err := helpers.ValidateFields(req, d); err != nil {
+		backendLogger.Error("validate fields", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	// UUID of user which want to sign message
+	uuid := d.Get("uuid").(string)
+
+	// derivation path
+	derivationPath := d.Get("path").(string)
+
+	// coin type of the signing key
+	coinType := d.Get("coinType").(int)
+
+	// signing method, personal_sign or eth_signTypedData_v4
+	method := d.Get("method").(string)
+
+	// message text / hex, or typed data JSON
+	message := d.Get("message").(string)
+
+	mountConfig, err := b.checkMountConfig(ctx, req.Storage, coinType, false)
+	if err != nil {
+		backendLogger.Error("mount config", "error", err)
+		return nil, err
+	}
+	derivationPath = requestDerivationPath(mountConfig, coinType, derivationPath)
+
+	backendLogger.Info("request", "path", derivationPath, "cointype", coinType, "method", method)
+
+	if message == "" {
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, "provide a message to sign")
+	}
+
+	// every message signing request of a valid form is journaled, signed or rejected
+	messageHash := sha256.Sum256([]byte(message))
+	auditEntry := &audit.Entry{
+		RequestID:   req.ID,
+		DisplayName: req.DisplayName,
+		UUID:        uuid,
+		CoinType:    uint16(coinType),
+		Path:        derivationPath,
+		Method:      method,
+		MessageHash: hex.EncodeToString(messageHash[:]),
+	}
+	defer func() {
+		if err := b.appendAudit(ctx, req.Storage, auditEntry, retErr); err != nil {
+			backendLogger.Error("append audit entry", "error", err)
+			// signatures are only released once journaled
+			if retErr == nil {
+				resp, retErr = nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+			}
+		}
+	}()
+
+	// validate data provided and load the user
+	userInfo, err := helpers.LoadUser(ctx, req, uuid, derivationPath)
+	if err != nil {
+		backendLogger.Error("load user", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	// evaluate the signing policy before any key is derived
+	signingPolicy, err := policy.Effective(ctx, req.Storage, uuid)
+	if err != nil {
+		backendLogger.Error("get policy", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+	if signingPolicy != nil {
+		if err := signingPolicy.EvaluateMessage(); err != nil {
+			backendLogger.Error("signing policy", "error", err)
+			b.metrics.PolicyRejected(coinType)
+			return nil, logical.CodedError(http.StatusForbidden, err.Error())
+		}
+	}
+
+	// obtains blockchain adapater based on coinType
+	adapterInventory := b.adapters()
+
+	keys, release, err := b.userKeychain(userInfo, adapterInventory, coinType, derivationPath)
+	if err != nil {
+		backendLogger.Error("user keychain", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+	defer release()
+
+	signature, err := adapterInventory.SignMessage(keys, uint16(coinType), derivationPath, method, message)
+	if err != nil {
+		backendLogger.Error("sign message", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	// Returns signature as output
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"signature": signature,
+		},
+	}, nil
+}
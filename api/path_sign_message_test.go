@@ -0,0 +1,217 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/api/audit"
+	"github.com/payment-system/dq-vault/lib"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+// Helper function to create a proper framework.FieldData for sign/message endpoint
+func createSignMessageFieldData(data map[string]interface{}) *framework.FieldData {
+	schema := map[string]*framework.FieldSchema{
+		"uuid": {
+			Type:        framework.TypeString,
+			Description: "UUID of user",
+		},
+		"path": {
+			Type:        framework.TypeString,
+			Description: "Derivation path",
+		},
+		"coinType": {
+			Type:        framework.TypeInt,
+			Description: "Coin type",
+		},
+		"method": {
+			Type:        framework.TypeString,
+			Description: "Signing method",
+			Default:     lib.MessageMethodPersonalSign,
+		},
+		"message": {
+			Type:        framework.TypeString,
+			Description: "Message to sign",
+		},
+	}
+
+	return &framework.FieldData{
+		Raw:    data,
+		Schema: schema,
+	}
+}
+
+func TestBackend_PathSignMessage(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name           string
+		fieldData      map[string]interface{}
+		wantErr        bool
+		wantStatusCode int
+		wantErrMsg     string
+	}{
+		{
+			name: "personal_sign with default method",
+			fieldData: map[string]interface{}{
+				"uuid":     signTestUUID,
+				"path":     signTestDerivationPath,
+				"coinType": int(slip44.Ether),
+				"message":  "hello",
+			},
+		},
+		{
+			name: "eth_signTypedData_v4",
+			fieldData: map[string]interface{}{
+				"uuid":     signTestUUID,
+				"path":     signTestDerivationPath,
+				"coinType": int(slip44.Ether),
+				"method":   lib.MessageMethodSignTypedDataV4,
+				"message": `{"types":{"EIP712Domain":[{"name":"name","type":"string"}],` +
+					`"Greeting":[{"name":"text","type":"string"}]},"primaryType":"Greeting",` +
+					`"domain":{"name":"dq-vault"},"message":{"text":"hello"}}`,
+			},
+		},
+		{
+			name: "unsupported method",
+			fieldData: map[string]interface{}{
+				"uuid":     signTestUUID,
+				"path":     signTestDerivationPath,
+				"coinType": int(slip44.Ether),
+				"method":   "eth_sign",
+				"message":  "hello",
+			},
+			wantErr:        true,
+			wantStatusCode: http.StatusUnprocessableEntity,
+			wantErrMsg:     "unsupported message signing method",
+		},
+		{
+			name: "coin type without message signing",
+			fieldData: map[string]interface{}{
+				"uuid":     signTestUUID,
+				"path":     testBitcoinDerivationPath,
+				"coinType": int(slip44.Bitcoin),
+				"message":  "hello",
+			},
+			wantErr:        true,
+			wantStatusCode: http.StatusUnprocessableEntity,
+			wantErrMsg:     "message signing is not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := createSignTestBackend(t)
+
+			req := &logical.Request{
+				Storage: createPoliciesStorage(t),
+				Data:    tt.fieldData,
+			}
+
+			got, err := backend.pathSignMessage(ctx, req, createSignMessageFieldData(tt.fieldData))
+
+			if tt.wantErr {
+				require.Error(t, err)
+				var codedErr logical.HTTPCodedError
+				require.ErrorAs(t, err, &codedErr)
+				assert.Equal(t, tt.wantStatusCode, codedErr.Code())
+				assert.Contains(t, err.Error(), tt.wantErrMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			signature, ok := got.Data["signature"].(string)
+			require.True(t, ok)
+			sig, err := hexutil.Decode(signature)
+			require.NoError(t, err)
+			assert.Len(t, sig, crypto.SignatureLength)
+		})
+	}
+}
+
+func TestBackend_PathSignMessage_RecoversSigner(t *testing.T) {
+	ctx := context.Background()
+	storage := createPoliciesStorage(t)
+	backend := createSignTestBackend(t)
+
+	data := map[string]interface{}{
+		"uuid":     signTestUUID,
+		"path":     signTestDerivationPath,
+		"coinType": int(slip44.Ether),
+		"message":  "hello",
+	}
+	req := &logical.Request{
+		Storage: storage,
+		Data:    data,
+	}
+	got, err := backend.pathSignMessage(ctx, req, createSignMessageFieldData(data))
+	require.NoError(t, err)
+
+	// signer must match the address endpoint for the same user and path
+	addrData := map[string]interface{}{
+		"uuid":     signTestUUID,
+		"path":     signTestDerivationPath,
+		"coinType": int(slip44.Ether),
+		"isDev":    false,
+	}
+	addrReq := &logical.Request{
+		Storage: storage,
+		Data:    addrData,
+	}
+	addr, err := backend.pathAddress(ctx, addrReq, createFieldData(addrData))
+	require.NoError(t, err)
+
+	sig, err := hexutil.Decode(got.Data["signature"].(string))
+	require.NoError(t, err)
+	sig[crypto.RecoveryIDOffset] -= 27
+	pubKey, err := crypto.SigToPub(accounts.TextHash([]byte("hello")), sig)
+	require.NoError(t, err)
+	assert.Equal(t, addr.Data["address"], crypto.PubkeyToAddress(*pubKey).Hex())
+}
+
+func TestBackend_PathSignMessage_Policy(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+	storage := createPoliciesStorage(t)
+
+	data := map[string]interface{}{
+		"uuid":     signTestUUID,
+		"path":     signTestDerivationPath,
+		"coinType": int(slip44.Ether),
+		"message":  "hello",
+	}
+	signMessage := func() error {
+		_, err := backend.pathSignMessage(ctx, &logical.Request{Storage: storage, Data: data},
+			createSignMessageFieldData(data))
+		return err
+	}
+
+	// a chain id list cannot restrict messages, they are refused all the same
+	writePolicy(t, backend, storage, map[string]interface{}{"uuid": signTestUUID, "allowedChainIds": "1"})
+	err := signMessage()
+	requireCode(t, err, http.StatusForbidden)
+	assert.Contains(t, err.Error(), "messages cannot be checked against the policy")
+
+	_, err = backend.pathPoliciesDelete(ctx, &logical.Request{Storage: storage},
+		createPoliciesFieldData(map[string]interface{}{"uuid": signTestUUID}))
+	require.NoError(t, err)
+	require.NoError(t, signMessage())
+
+	// both requests are journaled
+	entries, _, err := audit.List(ctx, storage, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, audit.OutcomeRejected, entries[0].Outcome)
+	assert.Equal(t, audit.OutcomeSigned, entries[1].Outcome)
+	assert.Equal(t, lib.MessageMethodPersonalSign, entries[1].Method)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", entries[1].MessageHash)
+}
@@ -1,20 +1,45 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
-
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fbsobreira/gotron-sdk/pkg/address"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
 
+	"github.com/payment-system/dq-vault/api/audit"
 	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/mountconfig"
 	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib"
 	"github.com/payment-system/dq-vault/lib/slip44"
 )
 
@@ -25,6 +50,7 @@ const (
 	signTestValidMnemonic    = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
 	signTestPassphrase       = "test-passphrase"
 	signTestPayload          = `{"nonce":42,"value":1000000000000000000,"gasLimit":21000,"gasPrice":20000000000,"to":"0x742d35Cc6634C0532925a3b8D359A5C5119e32C8","data":"0x","chainId":1}`
+	signTestTestnetPayload   = `{"nonce":42,"value":1000000000000000000,"gasLimit":21000,"gasPrice":20000000000,"to":"0x742d35Cc6634C0532925a3b8D359A5C5119e32C8","data":"0x","chainId":11155111}`
 	signTestInvalidPayload   = `{"invalid": "json"}`
 	signTestMalformedPayload = `{invalid json}`
 )
@@ -34,6 +60,19 @@ type MockStorageSign struct {
 	mock.Mock
 }
 
+// newMockStorageSign returns a MockStorageSign holding no signing policies
+// and accepting audit journal entries
+func newMockStorageSign() *MockStorageSign {
+	ms := new(MockStorageSign)
+	ms.On("Get", mock.Anything, mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, config.PolicyStoragePath) || strings.HasPrefix(key, config.AuditStoragePath)
+	})).Return(nil, nil).Maybe()
+	ms.On("Put", mock.Anything, mock.MatchedBy(func(entry *logical.StorageEntry) bool {
+		return strings.HasPrefix(entry.Key, config.AuditStoragePath)
+	})).Return(nil).Maybe()
+	return ms
+}
+
 func (m *MockStorageSign) List(ctx context.Context, prefix string) ([]string, error) {
 	args := m.Called(ctx, prefix)
 	return args.Get(0).([]string), args.Error(1)
@@ -80,6 +119,10 @@ func createSignFieldData(data map[string]interface{}) *framework.FieldData {
 			Type:        framework.TypeBool,
 			Description: "Development mode flag",
 		},
+		"idempotencyKey": {
+			Type:        framework.TypeString,
+			Description: "Idempotency key",
+		},
 	}
 
 	return &framework.FieldData{
@@ -91,9 +134,12 @@ func createSignFieldData(data map[string]interface{}) *framework.FieldData {
 // Helper function to create test backend for sign tests
 func createSignTestBackend(_ *testing.T) *Backend {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	return &Backend{
+	b := &Backend{
 		logger: logger,
 	}
+	// the storage mocks only expect user entries
+	b.mountConfig.Store(mountconfig.Default())
+	return b
 }
 
 // Helper function to create user storage entry
@@ -134,8 +180,6 @@ func TestBackend_PathSign(t *testing.T) {
 				"isDev":    false,
 			},
 			setupStorage: func(ms *MockStorageSign) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
 				// Mock Get for retrieving user data
 				userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
 				ms.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
@@ -153,12 +197,10 @@ func TestBackend_PathSign(t *testing.T) {
 				"uuid":     signTestUUID,
 				"path":     signTestDerivationPath,
 				"coinType": int(slip44.Ether),
-				"payload":  signTestPayload,
+				"payload":  signTestTestnetPayload,
 				"isDev":    true,
 			},
 			setupStorage: func(ms *MockStorageSign) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
 				// Mock Get for retrieving user data
 				userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
 				ms.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
@@ -170,6 +212,23 @@ func TestBackend_PathSign(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "development mode rejects mainnet chain id",
+			fieldData: map[string]interface{}{
+				"uuid":     signTestUUID,
+				"path":     signTestDerivationPath,
+				"coinType": int(slip44.Ether),
+				"payload":  signTestPayload,
+				"isDev":    true,
+			},
+			setupStorage: func(ms *MockStorageSign) {
+				userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
+				ms.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
+			},
+			wantErr:        true,
+			wantStatusCode: http.StatusUnprocessableEntity,
+			wantErrMsg:     "mainnet chain id is not allowed in development mode",
+		},
 		{
 			name: "bitshares coin type with path override",
 			fieldData: map[string]interface{}{
@@ -180,15 +239,33 @@ func TestBackend_PathSign(t *testing.T) {
 				"isDev":    false,
 			},
 			setupStorage: func(ms *MockStorageSign) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
 				// Mock Get for retrieving user data
 				userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
 				ms.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
 			},
-			wantErr:        true, // Bitshares doesn't have adapter
+			wantErr:        true, // ethereum payload has no transaction digest
 			wantStatusCode: http.StatusUnprocessableEntity,
 		},
+		{
+			name: "bitshares transaction digest",
+			fieldData: map[string]interface{}{
+				"uuid":     signTestUUID,
+				"path":     signTestDerivationPath,
+				"coinType": int(slip44.Bitshares),
+				"payload":  `{"transactionDigest": "` + strings.Repeat("ab", 32) + `"}`,
+				"isDev":    false,
+			},
+			setupStorage: func(ms *MockStorageSign) {
+				userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
+				ms.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
+			},
+			want: &logical.Response{
+				Data: map[string]interface{}{
+					"signature": mock.AnythingOfType("string"),
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "missing uuid field",
 			fieldData: map[string]interface{}{
@@ -226,13 +303,11 @@ func TestBackend_PathSign(t *testing.T) {
 				"isDev":   false,
 			},
 			setupStorage: func(ms *MockStorageSign) {
-				// Mock List for UUID existence check since coinType defaults to 0
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
 				// Mock Get for retrieving user data
 				userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
 				ms.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
 			},
-			wantErr:        true, // Bitcoin (coinType 0) doesn't have adapter
+			wantErr:        true, // Bitcoin (coinType 0) rejects the ethereum path
 			wantStatusCode: http.StatusUnprocessableEntity,
 		},
 		{
@@ -244,8 +319,6 @@ func TestBackend_PathSign(t *testing.T) {
 				"isDev":    false,
 			},
 			setupStorage: func(ms *MockStorageSign) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
 				// Mock Get for retrieving user data
 				userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
 				ms.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
@@ -295,8 +368,8 @@ func TestBackend_PathSign(t *testing.T) {
 				"isDev":    false,
 			},
 			setupStorage: func(ms *MockStorageSign) {
-				// Mock List to return empty list (UUID doesn't exist)
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{}, nil)
+				// Mock Get to return no entry (UUID doesn't exist)
+				ms.On("Get", ctx, config.StorageBasePath+"nonexistent-uuid").Return(nil, nil)
 			},
 			wantErr:        true,
 			wantStatusCode: http.StatusUnprocessableEntity,
@@ -312,8 +385,6 @@ func TestBackend_PathSign(t *testing.T) {
 				"isDev":    false,
 			},
 			setupStorage: func(ms *MockStorageSign) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
 				// Mock Get to return error
 				ms.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(nil, assert.AnError)
 			},
@@ -330,8 +401,6 @@ func TestBackend_PathSign(t *testing.T) {
 				"isDev":    false,
 			},
 			setupStorage: func(ms *MockStorageSign) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
 				// Mock Get to return invalid JSON
 				invalidEntry := &logical.StorageEntry{
 					Key:   config.StorageBasePath + signTestUUID,
@@ -352,8 +421,6 @@ func TestBackend_PathSign(t *testing.T) {
 				"isDev":    false,
 			},
 			setupStorage: func(ms *MockStorageSign) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
 				// Mock Get with empty mnemonic
 				userEntry := createUserStorageEntrySign(signTestUUID, "test-user", "", signTestPassphrase)
 				ms.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
@@ -371,8 +438,6 @@ func TestBackend_PathSign(t *testing.T) {
 				"isDev":    false,
 			},
 			setupStorage: func(ms *MockStorageSign) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
 				// Mock Get for retrieving user data
 				userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
 				ms.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
@@ -390,8 +455,6 @@ func TestBackend_PathSign(t *testing.T) {
 				"isDev":    false,
 			},
 			setupStorage: func(ms *MockStorageSign) {
-				// Mock List for UUID existence check
-				ms.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
 				// Mock Get for retrieving user data
 				userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
 				ms.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
@@ -404,7 +467,7 @@ func TestBackend_PathSign(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup
-			mockStorage := new(MockStorageSign)
+			mockStorage := newMockStorageSign()
 			backend := createSignTestBackend(t)
 
 			// Setup storage expectations
@@ -471,12 +534,12 @@ func TestBackend_PathSign_CoinTypeSpecific(t *testing.T) {
 			wantErr:  false,
 		},
 		{
-			name:     "bitcoin_not_supported",
+			name:     "bitcoin_rejects_ethereum_path",
 			coinType: int(slip44.Bitcoin),
 			wantErr:  true,
 		},
 		{
-			name:     "bitshares_not_supported",
+			name:     "bitshares_rejects_ethereum_payload",
 			coinType: int(slip44.Bitshares),
 			wantErr:  true,
 		},
@@ -484,11 +547,10 @@ func TestBackend_PathSign_CoinTypeSpecific(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockStorage := new(MockStorageSign)
+			mockStorage := newMockStorageSign()
 			backend := createSignTestBackend(t)
 
 			// Setup storage expectations
-			mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
 			userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
 			mockStorage.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
 
@@ -513,8 +575,7 @@ func TestBackend_PathSign_CoinTypeSpecific(t *testing.T) {
 
 			got, err := backend.pathSign(ctx, req, fieldData)
 
-			// For unsupported coin types, we expect an error
-			// Currently only Ether is supported by the EVM adapter
+			// For unsupported coin types or mismatched paths, we expect an error
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -560,11 +621,10 @@ func TestBackend_PathSign_PayloadValidation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockStorage := new(MockStorageSign)
+			mockStorage := newMockStorageSign()
 			backend := createSignTestBackend(t)
 
 			// Setup storage expectations
-			mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
 			userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
 			mockStorage.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
 
@@ -607,7 +667,7 @@ func TestBackend_PathSign_EdgeCases(t *testing.T) {
 	backend := createSignTestBackend(t)
 
 	t.Run("nil_context", func(t *testing.T) {
-		mockStorage := new(MockStorageSign)
+		mockStorage := newMockStorageSign()
 		data := map[string]interface{}{
 			"uuid":     signTestUUID,
 			"path":     signTestDerivationPath,
@@ -618,7 +678,7 @@ func TestBackend_PathSign_EdgeCases(t *testing.T) {
 		fieldData := createSignFieldData(data)
 
 		// Mock with nil context using mock.Anything
-		mockStorage.On("List", mock.Anything, config.StorageBasePath).Return([]string{}, assert.AnError)
+		mockStorage.On("Get", mock.Anything, config.StorageBasePath+signTestUUID).Return(nil, assert.AnError)
 
 		req := &logical.Request{
 			Storage: mockStorage,
@@ -632,7 +692,7 @@ func TestBackend_PathSign_EdgeCases(t *testing.T) {
 	})
 
 	t.Run("very_long_derivation_path", func(t *testing.T) {
-		mockStorage := new(MockStorageSign)
+		mockStorage := newMockStorageSign()
 		longPath := "m/44'/60'/0'/0/" + string(make([]byte, 1000))
 		for i := range longPath[14:] {
 			longPath = longPath[:14+i] + "1" + longPath[14+i+1:]
@@ -647,7 +707,6 @@ func TestBackend_PathSign_EdgeCases(t *testing.T) {
 		}
 		fieldData := createSignFieldData(data)
 
-		mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
 		userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
 		mockStorage.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
 
@@ -663,7 +722,7 @@ func TestBackend_PathSign_EdgeCases(t *testing.T) {
 	})
 
 	t.Run("large_payload", func(t *testing.T) {
-		mockStorage := new(MockStorageSign)
+		mockStorage := newMockStorageSign()
 		largePayload := `{"nonce":42,"value":1000000000000000000,"gasLimit":21000,"gasPrice":20000000000,"to":"0x742d35Cc6634C0532925a3b8D359A5C5119e32C8","data":"0x` + string(make([]byte, 10000)) + `","chainId":1}`
 
 		data := map[string]interface{}{
@@ -675,7 +734,6 @@ func TestBackend_PathSign_EdgeCases(t *testing.T) {
 		}
 		fieldData := createSignFieldData(data)
 
-		mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
 		userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
 		mockStorage.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
 
@@ -700,8 +758,7 @@ func BenchmarkBackend_PathSign(b *testing.B) {
 	backend := createSignTestBackend(&testing.T{})
 
 	for i := 0; i < b.N; i++ {
-		mockStorage := new(MockStorageSign)
-		mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{signTestUUID}, nil)
+		mockStorage := newMockStorageSign()
 		userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
 		mockStorage.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
 
@@ -722,3 +779,417 @@ func BenchmarkBackend_PathSign(b *testing.B) {
 		_, _ = backend.pathSign(ctx, req, fieldData)
 	}
 }
+
+// Helper function to build a hex encoded Tron raw transaction carrying a single contract
+func createTronPayload(t *testing.T, contractType core.Transaction_Contract_ContractType,
+	contract proto.Message) string {
+	t.Helper()
+
+	param, err := anypb.New(contract)
+	require.NoError(t, err)
+
+	raw := &core.TransactionRaw{
+		RefBlockBytes: []byte{0x00, 0x01},
+		RefBlockHash:  []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+		Expiration:    1700000060000,
+		Timestamp:     1700000000000,
+		FeeLimit:      100000000,
+		Contract: []*core.Transaction_Contract{
+			{
+				Type:      contractType,
+				Parameter: param,
+			},
+		},
+	}
+
+	rawBytes, err := proto.Marshal(raw)
+	require.NoError(t, err)
+	return hex.EncodeToString(rawBytes)
+}
+
+func TestBackend_PathSign_Tron(t *testing.T) {
+	ctx := context.Background()
+
+	const tronDerivationPath = "m/44'/195'/0'/0/0"
+
+	ownerAddress := append([]byte{0x41}, bytes.Repeat([]byte{0x11}, 20)...)
+	toAddress := append([]byte{0x41}, bytes.Repeat([]byte{0x22}, 20)...)
+	tokenAddress := append([]byte{0x41}, bytes.Repeat([]byte{0x33}, 20)...)
+
+	// transfer(address,uint256) call data for TRC-20 tokens
+	trc20Data := common.FromHex("0xa9059cbb" +
+		"000000000000000000000000" + hex.EncodeToString(toAddress[1:]) +
+		"00000000000000000000000000000000000000000000000000000000000f4240")
+
+	transferPayload := createTronPayload(t, core.Transaction_Contract_TransferContract,
+		&core.TransferContract{
+			OwnerAddress: ownerAddress,
+			ToAddress:    toAddress,
+			Amount:       1000000,
+		})
+	triggerPayload := createTronPayload(t, core.Transaction_Contract_TriggerSmartContract,
+		&core.TriggerSmartContract{
+			OwnerAddress:    ownerAddress,
+			ContractAddress: tokenAddress,
+			Data:            trc20Data,
+		})
+	unsupportedPayload := createTronPayload(t, core.Transaction_Contract_FreezeBalanceContract,
+		&core.FreezeBalanceContract{
+			OwnerAddress:  ownerAddress,
+			FrozenBalance: 1000000,
+		})
+
+	tests := []struct {
+		name    string
+		path    string
+		payload string
+		wantErr bool
+	}{
+		{
+			name:    "trx transfer contract",
+			path:    tronDerivationPath,
+			payload: transferPayload,
+			wantErr: false,
+		},
+		{
+			name:    "trc20 trigger smart contract",
+			path:    tronDerivationPath,
+			payload: triggerPayload,
+			wantErr: false,
+		},
+		{
+			name:    "relative tron path",
+			path:    "m/0'/0/0",
+			payload: transferPayload,
+			wantErr: false,
+		},
+		{
+			name:    "unsupported contract type",
+			path:    tronDerivationPath,
+			payload: unsupportedPayload,
+			wantErr: true,
+		},
+		{
+			name:    "non tron derivation path",
+			path:    signTestDerivationPath,
+			payload: transferPayload,
+			wantErr: true,
+		},
+		{
+			name:    "invalid hex payload",
+			path:    tronDerivationPath,
+			payload: "not-hex",
+			wantErr: true,
+		},
+		{
+			name:    "ethereum payload",
+			path:    tronDerivationPath,
+			payload: signTestPayload,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := newMockStorageSign()
+			backend := createSignTestBackend(t)
+
+			userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
+			mockStorage.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
+
+			data := map[string]interface{}{
+				"uuid":     signTestUUID,
+				"path":     tt.path,
+				"coinType": int(slip44.Tron),
+				"payload":  tt.payload,
+				"isDev":    false,
+			}
+			fieldData := createSignFieldData(data)
+
+			req := &logical.Request{
+				Storage: mockStorage,
+				Data:    data,
+			}
+
+			got, err := backend.pathSign(ctx, req, fieldData)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, got)
+
+				signature, ok := got.Data["signature"].(string)
+				require.True(t, ok)
+
+				// Tron signatures are 65 bytes r||s||v over the SHA-256 of the raw transaction
+				sigBytes, err := hex.DecodeString(signature)
+				require.NoError(t, err)
+				require.Len(t, sigBytes, 65)
+
+				rawBytes, err := hex.DecodeString(tt.payload)
+				require.NoError(t, err)
+				digest := sha256.Sum256(rawBytes)
+				publicKey, err := crypto.SigToPub(digest[:], sigBytes)
+				require.NoError(t, err)
+				assert.Equal(t, testTronAddress, address.PubkeyToAddress(*publicKey).String(),
+					"signed by the key of the address of the path")
+			}
+
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+// Helper function to build a base64 PSBT spending one P2WPKH output of the test user
+func createBitcoinPSBTPayload(t *testing.T, derivationPath string) string {
+	t.Helper()
+
+	seed, err := lib.SeedFromMnemonic(signTestValidMnemonic, signTestPassphrase)
+	require.NoError(t, err)
+	fingerprint, err := lib.MasterKeyFingerprint(seed)
+	require.NoError(t, err)
+	privateKey, err := lib.NewSeedKeychain(seed).DerivePrivateKey(derivationPath)
+	require.NoError(t, err)
+
+	pubKey := privateKey.PubKey().SerializeCompressed()
+	address, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(address)
+	require.NoError(t, err)
+
+	packet, err := psbt.New([]*wire.OutPoint{wire.NewOutPoint(&chainhash.Hash{0x01}, 0)},
+		[]*wire.TxOut{wire.NewTxOut(90000, pkScript)}, 2, 0, []uint32{wire.MaxTxInSequenceNum})
+	require.NoError(t, err)
+
+	updater, err := psbt.NewUpdater(packet)
+	require.NoError(t, err)
+	require.NoError(t, updater.AddInWitnessUtxo(wire.NewTxOut(100000, pkScript), 0))
+	require.NoError(t, updater.AddInBip32Derivation(binary.LittleEndian.Uint32(fingerprint),
+		[]uint32{hdkeychain.HardenedKeyStart + 84, hdkeychain.HardenedKeyStart, hdkeychain.HardenedKeyStart, 0, 0},
+		pubKey, 0))
+
+	encoded, err := packet.B64Encode()
+	require.NoError(t, err)
+	return encoded
+}
+
+func TestBackend_PathSign_PSBT(t *testing.T) {
+	ctx := context.Background()
+
+	const bitcoinDerivationPath = "m/84'/0'/0'/0/0"
+	psbtPayload := createBitcoinPSBTPayload(t, bitcoinDerivationPath)
+
+	tests := []struct {
+		name     string
+		coinType int
+		path     string
+		wantErr  bool
+	}{
+		{
+			name:     "bitcoin psbt signed for owned input",
+			coinType: int(slip44.Bitcoin),
+			path:     "m/84'/0'/0'",
+			wantErr:  false,
+		},
+		{
+			name:     "bitcoin psbt outside of derivation path",
+			coinType: int(slip44.Bitcoin),
+			path:     "m/44'/0'/0'",
+			wantErr:  true,
+		},
+		{
+			name:     "psbt for account based coin type",
+			coinType: int(slip44.Ether),
+			path:     signTestDerivationPath,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := newMockStorageSign()
+			backend := createSignTestBackend(t)
+
+			userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
+			mockStorage.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
+
+			data := map[string]interface{}{
+				"uuid":     signTestUUID,
+				"path":     tt.path,
+				"coinType": tt.coinType,
+				"payload":  psbtPayload,
+				"isDev":    false,
+			}
+			req := &logical.Request{
+				Storage: mockStorage,
+				Data:    data,
+			}
+
+			got, err := backend.pathSign(ctx, req, createSignFieldData(data))
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+
+				signed, ok := got.Data["signature"].(string)
+				require.True(t, ok)
+				packet, err := psbt.NewFromRawBytes(strings.NewReader(signed), true)
+				require.NoError(t, err)
+				assert.Len(t, packet.Inputs[0].PartialSigs, 1)
+			}
+
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBackend_PathSign_Solana(t *testing.T) {
+	ctx := context.Background()
+	mockStorage := newMockStorageSign()
+	backend := createSignTestBackend(t)
+
+	userEntry := createUserStorageEntrySign(signTestUUID, "test-user", signTestValidMnemonic, signTestPassphrase)
+	mockStorage.On("Get", ctx, config.StorageBasePath+signTestUUID).Return(userEntry, nil)
+
+	seed, err := lib.SeedFromMnemonic(signTestValidMnemonic, signTestPassphrase)
+	require.NoError(t, err)
+	key, err := lib.DeriveEd25519PrivateKey(seed, testSolanaDerivationPath)
+	require.NoError(t, err)
+
+	// legacy message with a single signer and an empty instruction list
+	message := []byte{1, 0, 0, 1}
+	message = append(message, key.Public().(ed25519.PublicKey)...)
+	message = append(message, make([]byte, 32)...)
+	message = append(message, 0)
+	payload, err := json.Marshal(lib.SolanaRawTx{Message: base64.StdEncoding.EncodeToString(message)})
+	require.NoError(t, err)
+
+	data := map[string]interface{}{
+		"uuid":     signTestUUID,
+		"path":     testSolanaDerivationPath,
+		"coinType": int(slip44.Solana),
+		"payload":  string(payload),
+		"isDev":    false,
+	}
+	req := &logical.Request{
+		Storage: mockStorage,
+		Data:    data,
+	}
+
+	got, err := backend.pathSign(ctx, req, createSignFieldData(data))
+	require.NoError(t, err)
+
+	var signed lib.SolanaSignedTx
+	require.NoError(t, json.Unmarshal([]byte(got.Data["signature"].(string)), &signed))
+	tx, err := base64.StdEncoding.DecodeString(signed.Transaction)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(key.Public().(ed25519.PublicKey), message, tx[1:1+ed25519.SignatureSize]))
+	assert.Equal(t, message, tx[1+ed25519.SignatureSize:])
+
+	mockStorage.AssertExpectations(t)
+}
+
+// velocityUsedCount returns the count of signatures consumed from the first
+// velocity limit of the test user
+func velocityUsedCount(t *testing.T, backend *Backend, storage logical.Storage) int {
+	t.Helper()
+
+	resp, err := backend.pathVelocityRead(context.Background(), &logical.Request{Storage: storage},
+		createVelocityFieldData(map[string]interface{}{"uuid": signTestUUID}))
+	require.NoError(t, err)
+	return resp.Data["limits"].([]map[string]interface{})[0]["usedCount"].(int)
+}
+
+func TestBackend_PathSign_Idempotency(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	backend := createSignTestBackend(t)
+	backend.clock = func() time.Time { return now }
+
+	storage := createPoliciesStorage(t)
+	writePolicy(t, backend, storage, map[string]interface{}{
+		"uuid": signTestUUID,
+		"velocityLimits": []interface{}{map[string]interface{}{
+			"coinType": int(slip44.Ether), "window": "24h", "maxCount": 50,
+		}},
+	})
+
+	sign := func(payload, key string) (*logical.Response, error) {
+		return backend.pathSign(ctx, &logical.Request{Storage: storage}, createSignFieldData(map[string]interface{}{
+			"uuid":           signTestUUID,
+			"path":           signTestDerivationPath,
+			"coinType":       int(slip44.Ether),
+			"payload":        payload,
+			"idempotencyKey": key,
+		}))
+	}
+	usedCount := func() int {
+		return velocityUsedCount(t, backend, storage)
+	}
+
+	first, err := sign(signTestPayload, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, false, first.Data["replayed"])
+
+	// a retry returns the stored signature without signing again
+	retry, err := sign(signTestPayload, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, first.Data["signature"], retry.Data["signature"])
+	assert.Equal(t, true, retry.Data["replayed"])
+	assert.Equal(t, 1, usedCount())
+
+	entries, _, err := audit.List(ctx, storage, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	t.Run("different payload", func(t *testing.T) {
+		_, err := sign(signTestTestnetPayload, "payout-1")
+		requireCode(t, err, http.StatusConflict)
+		assert.Equal(t, 1, usedCount())
+	})
+
+	t.Run("key too long", func(t *testing.T) {
+		_, err := sign(signTestPayload, strings.Repeat("k", config.IdempotencyKeyMaxLength+1))
+		requireCode(t, err, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		writeConfig(t, backend, storage, map[string]interface{}{"idempotencyTTL": 60})
+		second, err := sign(signTestPayload, "payout-2")
+		require.NoError(t, err)
+
+		now = now.Add(time.Minute)
+		require.NoError(t, backend.periodic(ctx, &logical.Request{Storage: storage}))
+		keys, err := storage.List(ctx, config.IdempotencyStoragePath+signTestUUID+"/")
+		require.NoError(t, err)
+		assert.Len(t, keys, 1, "the record of payout-2 is pruned")
+
+		again, err := sign(signTestPayload, "payout-2")
+		require.NoError(t, err)
+		assert.Equal(t, false, again.Data["replayed"])
+		assert.Equal(t, second.Data["signature"], again.Data["signature"])
+	})
+
+	t.Run("velocity not stored", func(t *testing.T) {
+		used := usedCount()
+		failing := &failingStorage{Storage: storage, prefix: config.VelocityStoragePath}
+		_, err := backend.pathSign(ctx, &logical.Request{Storage: failing}, createSignFieldData(map[string]interface{}{
+			"uuid":           signTestUUID,
+			"path":           signTestDerivationPath,
+			"coinType":       int(slip44.Ether),
+			"payload":        signTestPayload,
+			"idempotencyKey": "payout-3",
+		}))
+		requireCode(t, err, http.StatusInternalServerError)
+		assert.Equal(t, used, usedCount())
+
+		// the retry signs again rather than replay the unreleased signature
+		retry, err := sign(signTestPayload, "payout-3")
+		require.NoError(t, err)
+		assert.Equal(t, false, retry.Data["replayed"])
+		assert.Equal(t, used+1, usedCount())
+	})
+}
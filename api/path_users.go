@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/idempotency"
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib"
+)
+
+// pathUsersList corresponds to LIST users.
+// Lists the UUIDs of all registered users, soft deleted ones included.
+func (b *Backend) pathUsersList(ctx context.Context, req *logical.Request,
+	_ *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_users_list"))
+
+	uuids, err := req.Storage.List(ctx, config.StorageBasePath)
+	if err != nil {
+		backendLogger.Error("list", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+
+	return logical.ListResponse(uuids), nil
+}
+
+// pathUsersRead corresponds to READ users/<uuid>.
+// Returns user metadata, the mnemonic and passphrase are never returned.
+func (b *Backend) pathUsersRead(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_users_read"))
+
+	uuid := d.Get("uuid").(string)
+
+	user, err := helpers.GetUser(ctx, req.Storage, uuid)
+	if errors.Is(err, helpers.ErrUUIDDoesNotExist) {
+		return nil, logical.CodedError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		backendLogger.Error("get user", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	// users registered before languages were recorded have English mnemonics
+	language := user.Language
+	if language == "" {
+		language = lib.LanguageEnglish
+	}
+
+	data := map[string]interface{}{
+		"uuid":          uuid,
+		"username":      user.Username,
+		"hasPassphrase": user.Passphrase != "",
+		"language":      language,
+		"deleted":       user.IsDeleted(),
+	}
+
+	// users registered before timestamps were recorded have none
+	if !user.CreatedAt.IsZero() {
+		data["createdAt"] = user.CreatedAt.Format(time.RFC3339)
+	}
+	if !user.UpdatedAt.IsZero() {
+		data["updatedAt"] = user.UpdatedAt.Format(time.RFC3339)
+	}
+	if user.IsDeleted() {
+		data["deletedAt"] = user.DeletedAt.Format(time.RFC3339)
+	}
+
+	// BIP-32 master key fingerprint, identifies the wallet in PSBTs and xpubs
+	fingerprint, err := userFingerprint(user)
+	if err != nil {
+		backendLogger.Error("master key fingerprint", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+	data["fingerprint"] = fingerprint
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// pathUsersDelete corresponds to DELETE users/<uuid>.
+// Removes the user, or with soft=true keeps a tombstone of it whose keys can no longer be used.
+func (b *Backend) pathUsersDelete(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_users_delete"))
+	if err := helpers.ValidateFields(req, d); err != nil {
+		backendLogger.Error("validate fields", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	uuid := d.Get("uuid").(string)
+	soft := d.Get("soft").(bool)
+
+	user, err := helpers.GetUser(ctx, req.Storage, uuid)
+	if errors.Is(err, helpers.ErrUUIDDoesNotExist) {
+		return nil, logical.CodedError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		backendLogger.Error("get user", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	// cached keys must not outlive their user
+	if b.keyCache != nil {
+		defer b.keyCache.Invalidate(uuid)
+	}
+
+	if !soft {
+		if err := req.Storage.Delete(ctx, config.StorageBasePath+uuid); err != nil {
+			backendLogger.Error("delete user", "error", err)
+			return nil, logical.CodedError(http.StatusExpectationFailed, err.Error())
+		}
+		// the signing policy, velocity ledger and idempotency records of the user go with it
+		if err := req.Storage.Delete(ctx, config.PolicyStoragePath+uuid); err != nil {
+			backendLogger.Error("delete policy", "error", err)
+			return nil, logical.CodedError(http.StatusExpectationFailed, err.Error())
+		}
+		if err := req.Storage.Delete(ctx, config.VelocityStoragePath+uuid); err != nil {
+			backendLogger.Error("delete velocity ledger", "error", err)
+			return nil, logical.CodedError(http.StatusExpectationFailed, err.Error())
+		}
+		if err := idempotency.Delete(ctx, req.Storage, uuid); err != nil {
+			backendLogger.Error("delete idempotency records", "error", err)
+			return nil, logical.CodedError(http.StatusExpectationFailed, err.Error())
+		}
+		backendLogger.Info("user deleted", "uuid", uuid)
+		return nil, nil
+	}
+
+	if user.IsDeleted() {
+		return nil, nil
+	}
+
+	now := b.now()
+	user.DeletedAt = &now
+	user.UpdatedAt = now
+
+	store, err := logical.StorageEntryJSON(config.StorageBasePath+uuid, user)
+	if err != nil {
+		backendLogger.Error("create storage entry", "error", err)
+		return nil, logical.CodedError(http.StatusExpectationFailed, err.Error())
+	}
+	if err := req.Storage.Put(ctx, store); err != nil {
+		backendLogger.Error("put user information", "error", err)
+		return nil, logical.CodedError(http.StatusExpectationFailed, err.Error())
+	}
+
+	backendLogger.Info("user soft deleted", "uuid", uuid)
+
+	return nil, nil
+}
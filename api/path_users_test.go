@@ -0,0 +1,307 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+// Test constants for users tests
+const (
+	usersTestUUID     = "test-uuid-123"
+	usersTestUsername = "test-user"
+	// master key fingerprint of the "abandon ... about" mnemonic without passphrase
+	usersTestFingerprint = "73c5da0a"
+)
+
+// Helper function to create a proper framework.FieldData for users/<uuid> endpoint
+func createUsersFieldData(data map[string]interface{}) *framework.FieldData {
+	schema := map[string]*framework.FieldSchema{
+		"uuid": {
+			Type:        framework.TypeString,
+			Description: "UUID of user",
+		},
+		"soft": {
+			Type:        framework.TypeBool,
+			Description: "Soft delete",
+		},
+	}
+
+	return &framework.FieldData{
+		Raw:    data,
+		Schema: schema,
+	}
+}
+
+func createUsersStorageEntry(t *testing.T, user helpers.User) *logical.StorageEntry {
+	t.Helper()
+
+	data, err := json.Marshal(user)
+	require.NoError(t, err)
+	return &logical.StorageEntry{
+		Key:   config.StorageBasePath + user.UUID,
+		Value: data,
+	}
+}
+
+func testUsersUser() helpers.User {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return helpers.User{
+		Username:  usersTestUsername,
+		UUID:      usersTestUUID,
+		Mnemonic:  testMnemonic,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+}
+
+func TestBackend_PathUsersList(t *testing.T) {
+	ctx := context.Background()
+	backend := createTestBackend(t)
+
+	t.Run("lists uuids", func(t *testing.T) {
+		mockStorage := new(MockStorage)
+		mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{"a", "b"}, nil)
+
+		got, err := backend.pathUsersList(ctx, &logical.Request{Storage: mockStorage}, createUsersFieldData(nil))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, got.Data["keys"])
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		mockStorage := new(MockStorage)
+		mockStorage.On("List", ctx, config.StorageBasePath).Return([]string{}, errors.New("storage error"))
+
+		_, err := backend.pathUsersList(ctx, &logical.Request{Storage: mockStorage}, createUsersFieldData(nil))
+		require.Error(t, err)
+		var codedErr logical.HTTPCodedError
+		require.ErrorAs(t, err, &codedErr)
+		assert.Equal(t, http.StatusInternalServerError, codedErr.Code())
+	})
+}
+
+func TestBackend_PathUsersRead(t *testing.T) {
+	ctx := context.Background()
+	backend := createTestBackend(t)
+
+	deletedUser := testUsersUser()
+	deletedAt := deletedUser.CreatedAt.Add(time.Hour)
+	deletedUser.DeletedAt = &deletedAt
+	deletedUser.UpdatedAt = deletedAt
+
+	legacyUser := testUsersUser()
+	legacyUser.CreatedAt = time.Time{}
+	legacyUser.UpdatedAt = time.Time{}
+	legacyUser.Passphrase = testPassphrase
+
+	tests := []struct {
+		name           string
+		user           *helpers.User
+		want           map[string]interface{}
+		wantStatusCode int
+	}{
+		{
+			name: "active user",
+			user: func() *helpers.User { u := testUsersUser(); return &u }(),
+			want: map[string]interface{}{
+				"uuid":          usersTestUUID,
+				"username":      usersTestUsername,
+				"hasPassphrase": false,
+				"language":      "english",
+				"deleted":       false,
+				"createdAt":     "2024-01-02T03:04:05Z",
+				"updatedAt":     "2024-01-02T03:04:05Z",
+				"fingerprint":   usersTestFingerprint,
+			},
+		},
+		{
+			name: "soft deleted user",
+			user: &deletedUser,
+			want: map[string]interface{}{
+				"uuid":          usersTestUUID,
+				"username":      usersTestUsername,
+				"hasPassphrase": false,
+				"language":      "english",
+				"deleted":       true,
+				"createdAt":     "2024-01-02T03:04:05Z",
+				"updatedAt":     "2024-01-02T04:04:05Z",
+				"deletedAt":     "2024-01-02T04:04:05Z",
+				"fingerprint":   usersTestFingerprint,
+			},
+		},
+		{
+			name: "user without timestamps",
+			user: &legacyUser,
+		},
+		{
+			name:           "unknown user",
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockStorage)
+			if tt.user != nil {
+				mockStorage.On("Get", ctx, config.StorageBasePath+usersTestUUID).
+					Return(createUsersStorageEntry(t, *tt.user), nil)
+			} else {
+				mockStorage.On("Get", ctx, config.StorageBasePath+usersTestUUID).Return(nil, nil)
+			}
+
+			data := map[string]interface{}{"uuid": usersTestUUID}
+			got, err := backend.pathUsersRead(ctx, &logical.Request{Storage: mockStorage}, createUsersFieldData(data))
+
+			if tt.wantStatusCode != 0 {
+				require.Error(t, err)
+				var codedErr logical.HTTPCodedError
+				require.ErrorAs(t, err, &codedErr)
+				assert.Equal(t, tt.wantStatusCode, codedErr.Code())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotContains(t, got.Data, "mnemonic")
+			assert.NotContains(t, got.Data, "passphrase")
+			if tt.want != nil {
+				assert.Equal(t, tt.want, got.Data)
+			} else {
+				assert.NotContains(t, got.Data, "createdAt")
+				assert.Equal(t, true, got.Data["hasPassphrase"])
+			}
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBackend_PathUsersDelete(t *testing.T) {
+	ctx := context.Background()
+	backend := createTestBackend(t)
+
+	t.Run("hard delete", func(t *testing.T) {
+		mockStorage := new(MockStorage)
+		mockStorage.On("Get", ctx, config.StorageBasePath+usersTestUUID).
+			Return(createUsersStorageEntry(t, testUsersUser()), nil)
+		mockStorage.On("Delete", ctx, config.StorageBasePath+usersTestUUID).Return(nil)
+		mockStorage.On("Delete", ctx, config.PolicyStoragePath+usersTestUUID).Return(nil)
+		mockStorage.On("Delete", ctx, config.VelocityStoragePath+usersTestUUID).Return(nil)
+		mockStorage.On("List", ctx, config.IdempotencyStoragePath+usersTestUUID+"/").Return([]string{}, nil)
+
+		data := map[string]interface{}{"uuid": usersTestUUID}
+		got, err := backend.pathUsersDelete(ctx, &logical.Request{Storage: mockStorage}, createUsersFieldData(data))
+		require.NoError(t, err)
+		assert.Nil(t, got)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("soft delete keeps a tombstone", func(t *testing.T) {
+		deletedAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+		backend.clock = func() time.Time { return deletedAt }
+		defer func() { backend.clock = nil }()
+
+		mockStorage := new(MockStorage)
+		mockStorage.On("Get", ctx, config.StorageBasePath+usersTestUUID).
+			Return(createUsersStorageEntry(t, testUsersUser()), nil)
+
+		var stored helpers.User
+		mockStorage.On("Put", ctx, mock.AnythingOfType("*logical.StorageEntry")).
+			Run(func(args mock.Arguments) {
+				entry := args.Get(1).(*logical.StorageEntry)
+				assert.Equal(t, config.StorageBasePath+usersTestUUID, entry.Key)
+				require.NoError(t, entry.DecodeJSON(&stored))
+			}).Return(nil)
+
+		data := map[string]interface{}{"uuid": usersTestUUID, "soft": true}
+		_, err := backend.pathUsersDelete(ctx, &logical.Request{Storage: mockStorage}, createUsersFieldData(data))
+		require.NoError(t, err)
+
+		assert.True(t, stored.IsDeleted())
+		assert.Equal(t, testMnemonic, stored.Mnemonic)
+		assert.Equal(t, deletedAt, *stored.DeletedAt, "timestamped by the backend clock")
+		assert.Equal(t, *stored.DeletedAt, stored.UpdatedAt)
+		assert.True(t, stored.CreatedAt.Before(stored.UpdatedAt))
+		mockStorage.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("soft delete of a deleted user is a no-op", func(t *testing.T) {
+		user := testUsersUser()
+		deletedAt := time.Now().UTC()
+		user.DeletedAt = &deletedAt
+
+		mockStorage := new(MockStorage)
+		mockStorage.On("Get", ctx, config.StorageBasePath+usersTestUUID).
+			Return(createUsersStorageEntry(t, user), nil)
+
+		data := map[string]interface{}{"uuid": usersTestUUID, "soft": true}
+		_, err := backend.pathUsersDelete(ctx, &logical.Request{Storage: mockStorage}, createUsersFieldData(data))
+		require.NoError(t, err)
+		mockStorage.AssertNotCalled(t, "Put", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockStorage := new(MockStorage)
+		mockStorage.On("Get", ctx, config.StorageBasePath+usersTestUUID).Return(nil, nil)
+
+		data := map[string]interface{}{"uuid": usersTestUUID}
+		_, err := backend.pathUsersDelete(ctx, &logical.Request{Storage: mockStorage}, createUsersFieldData(data))
+		require.Error(t, err)
+		var codedErr logical.HTTPCodedError
+		require.ErrorAs(t, err, &codedErr)
+		assert.Equal(t, http.StatusNotFound, codedErr.Code())
+	})
+}
+
+func TestBackend_DeletedUserCannotSign(t *testing.T) {
+	ctx := context.Background()
+	backend := createTestBackend(t)
+
+	user := testUsersUser()
+	deletedAt := time.Now().UTC()
+	user.DeletedAt = &deletedAt
+
+	mockStorage := new(MockStorage)
+	mockStorage.On("Get", ctx, config.StorageBasePath+usersTestUUID).Return(createUsersStorageEntry(t, user), nil)
+	// the rejected signing request is journaled
+	mockStorage.On("Get", ctx, config.AuditStoragePath+"head").Return(nil, nil)
+	mockStorage.On("Put", ctx, mock.MatchedBy(func(entry *logical.StorageEntry) bool {
+		return strings.HasPrefix(entry.Key, config.AuditStoragePath)
+	})).Return(nil)
+
+	addressData := map[string]interface{}{
+		"uuid":     usersTestUUID,
+		"path":     testDerivationPath,
+		"coinType": int(slip44.Ether),
+		"isDev":    false,
+	}
+	_, err := backend.pathAddress(ctx, &logical.Request{Storage: mockStorage, Data: addressData},
+		createFieldData(addressData))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), helpers.ErrUserDeleted.Error())
+
+	signData := map[string]interface{}{
+		"uuid":     usersTestUUID,
+		"path":     testDerivationPath,
+		"coinType": int(slip44.Ether),
+		"payload":  signTestPayload,
+		"isDev":    false,
+	}
+	_, err = backend.pathSign(ctx, &logical.Request{Storage: mockStorage, Data: signData},
+		createSignFieldData(signData))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), helpers.ErrUserDeleted.Error())
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/policy"
+)
+
+// pathVelocityRead corresponds to READ velocity/<uuid>.
+// Returns the consumption of the velocity limits of the policy applying to the user.
+func (b *Backend) pathVelocityRead(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_velocity_read"))
+
+	uuid := d.Get("uuid").(string)
+
+	_, err := helpers.GetUser(ctx, req.Storage, uuid)
+	if errors.Is(err, helpers.ErrUUIDDoesNotExist) {
+		return nil, logical.CodedError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		backendLogger.Error("get user", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	p, err := policy.Effective(ctx, req.Storage, uuid)
+	if err != nil {
+		backendLogger.Error("get policy", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+	if p == nil {
+		p = &policy.Policy{}
+	}
+
+	usages, err := p.VelocityUsage(ctx, req.Storage, uuid, b.now())
+	if err != nil {
+		backendLogger.Error("velocity usage", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+
+	limits := make([]map[string]interface{}, 0, len(usages))
+	for _, usage := range usages {
+		data := velocityLimitData(usage.Limit)
+		data["usedValue"] = usage.Value.String()
+		data["usedCount"] = usage.Count
+		if usage.Remaining != nil {
+			data["remainingValue"] = usage.Remaining.String()
+		}
+		if usage.Limit.MaxCount > 0 {
+			data["remainingCount"] = max(usage.Limit.MaxCount-usage.Count, 0)
+		}
+		if !usage.ResetsAt.IsZero() {
+			data["resetsAt"] = usage.ResetsAt.Format(time.RFC3339)
+		}
+		limits = append(limits, data)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"uuid":   uuid,
+			"limits": limits,
+		},
+	}, nil
+}
+
+// velocityLimitsData returns the response form of velocity limits
+func velocityLimitsData(limits []policy.VelocityLimit) []map[string]interface{} {
+	data := make([]map[string]interface{}, 0, len(limits))
+	for _, limit := range limits {
+		data = append(data, velocityLimitData(limit))
+	}
+	return data
+}
+
+func velocityLimitData(limit policy.VelocityLimit) map[string]interface{} {
+	data := map[string]interface{}{
+		"coinType": limit.CoinType,
+		"window":   limit.Window.String(),
+	}
+	if limit.Token != "" {
+		data["token"] = limit.Token
+	}
+	if limit.MaxValue != nil {
+		data["maxValue"] = limit.MaxValue.String()
+	}
+	if limit.MaxCount > 0 {
+		data["maxCount"] = limit.MaxCount
+	}
+	return data
+}
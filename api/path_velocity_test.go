@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+// Helper function to create a proper framework.FieldData for velocity/<uuid> endpoint
+func createVelocityFieldData(data map[string]interface{}) *framework.FieldData {
+	schema := map[string]*framework.FieldSchema{
+		"uuid": {
+			Type:        framework.TypeString,
+			Description: "UUID of user",
+		},
+	}
+
+	return &framework.FieldData{
+		Raw:    data,
+		Schema: schema,
+	}
+}
+
+func TestBackend_PathSign_Velocity(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	backend := createSignTestBackend(t)
+	backend.clock = func() time.Time { return now }
+
+	storage := createPoliciesStorage(t)
+	writePolicy(t, backend, storage, map[string]interface{}{
+		"uuid": signTestUUID,
+		"velocityLimits": []interface{}{map[string]interface{}{
+			"coinType": int(slip44.Ether), "window": "24h", "maxValue": "1500000000000000000", "maxCount": 50,
+		}},
+	})
+
+	sign := func() error {
+		_, err := backend.pathSign(ctx, &logical.Request{Storage: storage}, createSignFieldData(map[string]interface{}{
+			"uuid":     signTestUUID,
+			"path":     signTestDerivationPath,
+			"coinType": int(slip44.Ether),
+			"payload":  signTestPayload,
+		}))
+		return err
+	}
+
+	// signTestPayload sends 1 ETH, a second one exceeds the 1.5 ETH limit
+	require.NoError(t, sign())
+
+	err := sign()
+	var codedErr logical.HTTPCodedError
+	require.ErrorAs(t, err, &codedErr)
+	assert.Equal(t, http.StatusForbidden, codedErr.Code())
+	assert.Contains(t, err.Error(), "velocity limit exceeded")
+
+	got, err := backend.pathVelocityRead(ctx, &logical.Request{Storage: storage},
+		createVelocityFieldData(map[string]interface{}{"uuid": signTestUUID}))
+	require.NoError(t, err)
+	limits := got.Data["limits"].([]map[string]interface{})
+	require.Len(t, limits, 1)
+	assert.Equal(t, "1000000000000000000", limits[0]["usedValue"])
+	assert.Equal(t, 1, limits[0]["usedCount"])
+	assert.Equal(t, "500000000000000000", limits[0]["remainingValue"])
+	assert.Equal(t, 49, limits[0]["remainingCount"])
+	assert.Equal(t, "2026-01-02T12:00:00Z", limits[0]["resetsAt"])
+
+	// the first signature leaves the window a day later
+	now = now.Add(24 * time.Hour)
+	require.NoError(t, sign())
+}
+
+func TestBackend_PathVelocityRead(t *testing.T) {
+	ctx := context.Background()
+	backend := createSignTestBackend(t)
+
+	t.Run("no policy", func(t *testing.T) {
+		got, err := backend.pathVelocityRead(ctx, &logical.Request{Storage: createPoliciesStorage(t)},
+			createVelocityFieldData(map[string]interface{}{"uuid": signTestUUID}))
+		require.NoError(t, err)
+		assert.Empty(t, got.Data["limits"])
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		_, err := backend.pathVelocityRead(ctx, &logical.Request{Storage: &logical.InmemStorage{}},
+			createVelocityFieldData(map[string]interface{}{"uuid": "unknown"}))
+		var codedErr logical.HTTPCodedError
+		require.ErrorAs(t, err, &codedErr)
+		assert.Equal(t, http.StatusNotFound, codedErr.Code())
+	})
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/api/helpers"
+	"github.com/payment-system/dq-vault/api/metrics"
+)
+
+// pathXpub corresponds to POST xpub.
+// Returns the account-level extended public key of a user, downstream
+// services derive receive addresses from it without calling the signer.
+func (b *Backend) pathXpub(ctx context.Context, req *logical.Request,
+	d *framework.FieldData) (*logical.Response, error) {
+	backendLogger := b.logger.With(slog.String("op", "path_xpub"))
+	if err := helpers.ValidateFields(req, d); err != nil {
+		backendLogger.Error("validate fields", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	// UUID of user
+	uuid := d.Get("uuid").(string)
+
+	// account-level derivation path, e.g. m/84'/0'/0'
+	derivationPath := d.Get("path").(string)
+
+	// coin type of the account
+	coinType := d.Get("coinType").(int)
+
+	isDev := d.Get("isDev").(bool)
+
+	if _, err := b.checkMountConfig(ctx, req.Storage, coinType, isDev); err != nil {
+		backendLogger.Error("mount config", "error", err)
+		return nil, err
+	}
+
+	backendLogger.Info("request", "path", derivationPath, "cointype", coinType)
+
+	// validate data provided and load the user
+	userInfo, err := helpers.LoadUser(ctx, req, uuid, derivationPath)
+	if err != nil {
+		backendLogger.Error("load user", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	// obtains blockchain adapater based on coinType
+	adapterInventory := b.adapters()
+
+	keys, release, err := b.userKeychain(userInfo, adapterInventory, coinType, derivationPath)
+	if err != nil {
+		backendLogger.Error("user keychain", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+	defer release()
+
+	start := time.Now()
+	xpub, err := adapterInventory.DeriveExtendedPublicKey(keys, uint16(coinType), derivationPath, isDev)
+	b.metrics.ObserveDerivation(coinType, metrics.DerivationXpub, time.Since(start))
+	if err != nil {
+		backendLogger.Error("derive extended public key", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	// master fingerprint lets wallets build key origin descriptors
+	fingerprint, err := keys.MasterKeyFingerprint()
+	if err != nil {
+		backendLogger.Error("master key fingerprint", "error", err)
+		return nil, logical.CodedError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"xpub":        xpub,
+			"path":        derivationPath,
+			"fingerprint": hex.EncodeToString(fingerprint),
+		},
+	}, nil
+}
@@ -0,0 +1,243 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib"
+)
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrPolicyViolation       = errors.New("signing policy violation")
+	ErrDestinationNotAllowed = errors.New("destination is not allowed")
+	ErrValueLimitExceeded    = errors.New("transaction value exceeds the limit")
+	ErrChainIDNotAllowed     = errors.New("chain id is not allowed")
+	ErrTokenNotAllowed       = errors.New("token contract is not allowed")
+	ErrContractNotAllowed    = errors.New("contract is not an allowed token")
+	ErrPayloadNotDecodable   = errors.New("payload cannot be checked against the policy")
+	ErrMessageNotAllowed     = errors.New("messages cannot be checked against the policy")
+	ErrInvalidMaxValue       = errors.New("maxValue must be a non-negative integer")
+	ErrInvalidCoinType       = errors.New("invalid coin type")
+)
+
+// Policy restricts the transactions signed with the keys of a user.
+//
+// Empty settings leave the corresponding property unrestricted. MaxValues
+// bound the sum of the native transfers of a transaction per coin type, in
+// the smallest unit of the chain (wei, sun, satoshi). AllowedTokens also
+// restricts the contracts transactions may call to the allowed tokens.
+// AllowedChainIDs only applies to chains whose transactions carry a chain id.
+// VelocityLimits bound the signatures over time, see CheckVelocity.
+type Policy struct {
+	AllowedDestinations []string            `json:"allowedDestinations,omitempty"`
+	MaxValues           map[uint16]*big.Int `json:"maxValues,omitempty"`
+	// MaxValue is the limit of policies written before MaxValues, applying to
+	// every coin type until the policy is written again
+	MaxValue        *big.Int        `json:"maxValue,omitempty"`
+	AllowedChainIDs []int64         `json:"allowedChainIds,omitempty"`
+	AllowedTokens   []string        `json:"allowedTokens,omitempty"`
+	VelocityLimits  []VelocityLimit `json:"velocityLimits,omitempty"`
+	UpdatedAt       time.Time       `json:"updatedAt"`
+}
+
+// IsRestrictive reports whether the policy restricts any transaction property
+// or the signing velocity
+func (p *Policy) IsRestrictive() bool {
+	return len(p.AllowedDestinations) > 0 || len(p.MaxValues) > 0 || p.MaxValue != nil ||
+		len(p.AllowedChainIDs) > 0 || len(p.AllowedTokens) > 0 || len(p.VelocityLimits) > 0
+}
+
+// EvaluateMessage checks an off-chain message signing against the policy.
+// Messages, e.g., EIP-2612 permits, can authorize transfers the policy cannot
+// check, they are only accepted by policies that restrict nothing.
+func (p *Policy) EvaluateMessage() error {
+	if p.IsRestrictive() {
+		return fmt.Errorf("%w: %w", ErrPolicyViolation, ErrMessageNotAllowed)
+	}
+	return nil
+}
+
+// restrictsTransaction reports whether Evaluate can reject a transaction of coinType
+func (p *Policy) restrictsTransaction(coinType uint16) bool {
+	return len(p.AllowedDestinations) > 0 || p.maxValue(coinType) != nil ||
+		len(p.AllowedChainIDs) > 0 || len(p.AllowedTokens) > 0
+}
+
+// maxValue returns the native value limit of coinType, nil when unlimited
+func (p *Policy) maxValue(coinType uint16) *big.Int {
+	if maxValue, ok := p.MaxValues[coinType]; ok {
+		return maxValue
+	}
+	return p.MaxValue
+}
+
+// Normalize canonicalises the addresses of the policy. Hex addresses (EVM)
+// are compared case-insensitively, base58 and bech32 addresses as given.
+func (p *Policy) Normalize() {
+	for idx, destination := range p.AllowedDestinations {
+		p.AllowedDestinations[idx] = normalizeAddress(destination)
+	}
+	for idx, token := range p.AllowedTokens {
+		p.AllowedTokens[idx] = normalizeAddress(token)
+	}
+}
+
+// Evaluate checks a decoded transaction of coinType against the policy. A nil
+// summary stands for a payload that could not be decoded, it is only accepted
+// by policies that restrict no transaction property.
+func (p *Policy) Evaluate(coinType uint16, summary *lib.TransactionSummary) error {
+	if !p.restrictsTransaction(coinType) {
+		return nil
+	}
+	if summary == nil {
+		return fmt.Errorf("%w: %w", ErrPolicyViolation, ErrPayloadNotDecodable)
+	}
+
+	if len(p.AllowedChainIDs) > 0 && summary.ChainID != nil {
+		if !summary.ChainID.IsInt64() || !slices.Contains(p.AllowedChainIDs, summary.ChainID.Int64()) {
+			return fmt.Errorf("%w: %w: %s", ErrPolicyViolation, ErrChainIDNotAllowed, summary.ChainID)
+		}
+	}
+
+	if maxValue := p.maxValue(coinType); maxValue != nil {
+		if value := summary.NativeValue(); value.Cmp(maxValue) > 0 {
+			return fmt.Errorf("%w: %w: %s > %s", ErrPolicyViolation, ErrValueLimitExceeded, value, maxValue)
+		}
+	}
+
+	// token contracts called without value are checked through their token transfer
+	tokenCalls := make(map[string]bool)
+	for _, transfer := range summary.Transfers {
+		if transfer.Token != "" {
+			tokenCalls[normalizeAddress(transfer.Token)] = true
+		}
+	}
+
+	for _, transfer := range summary.Transfers {
+		if err := p.evaluateTransfer(transfer, tokenCalls); err != nil {
+			return fmt.Errorf("%w: %w", ErrPolicyViolation, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Policy) evaluateTransfer(transfer lib.Transfer, tokenCalls map[string]bool) error {
+	to := normalizeAddress(transfer.To)
+
+	if transfer.Token != "" {
+		token := normalizeAddress(transfer.Token)
+		if len(p.AllowedTokens) > 0 && !slices.Contains(p.AllowedTokens, token) {
+			return fmt.Errorf("%w: %s", ErrTokenNotAllowed, transfer.Token)
+		}
+		return p.evaluateDestination(to)
+	}
+
+	// contracts are called through methods outside of the token ABI too, e.g.,
+	// approve or multicall, whose effects the summary does not describe. Token
+	// transfers are rejected by their own transfer, see above.
+	if transfer.Contract && !tokenCalls[to] && len(p.AllowedTokens) > 0 && !slices.Contains(p.AllowedTokens, to) {
+		return fmt.Errorf("%w: %s", ErrContractNotAllowed, transfer.To)
+	}
+
+	if tokenCalls[to] && (transfer.Value == nil || transfer.Value.Sign() == 0) {
+		return nil
+	}
+	return p.evaluateDestination(to)
+}
+
+func (p *Policy) evaluateDestination(to string) error {
+	if len(p.AllowedDestinations) == 0 {
+		return nil
+	}
+	// contract creations have no destination to allow
+	if to == "" {
+		return fmt.Errorf("%w: contract creation", ErrDestinationNotAllowed)
+	}
+	if !slices.Contains(p.AllowedDestinations, to) {
+		return fmt.Errorf("%w: %s", ErrDestinationNotAllowed, to)
+	}
+	return nil
+}
+
+func normalizeAddress(address string) string {
+	address = strings.TrimSpace(address)
+	if strings.HasPrefix(strings.ToLower(address), "0x") {
+		return strings.ToLower(address)
+	}
+	return address
+}
+
+// ParseMaxValue parses a decimal value limit, an empty string means no limit
+func ParseMaxValue(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	maxValue, ok := new(big.Int).SetString(value, 10)
+	if !ok || maxValue.Sign() < 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidMaxValue, value)
+	}
+	return maxValue, nil
+}
+
+// ParseMaxValues parses native value limits keyed by coin type
+func ParseMaxValues(raw map[string]string) (map[uint16]*big.Int, error) {
+	maxValues := make(map[uint16]*big.Int, len(raw))
+	for key, value := range raw {
+		coinType, err := strconv.ParseUint(strings.TrimSpace(key), 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCoinType, key)
+		}
+		maxValue, err := ParseMaxValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		if maxValue != nil {
+			maxValues[uint16(coinType)] = maxValue
+		}
+	}
+	return maxValues, nil
+}
+
+// Get loads the policy stored under name, a user uuid or DefaultPolicyName.
+// It returns nil when no policy is stored.
+func Get(ctx context.Context, storage logical.Storage, name string) (*Policy, error) {
+	entry, err := storage.Get(ctx, config.PolicyStoragePath+name)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+
+	var policy Policy
+	if err := entry.DecodeJSON(&policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Put stores the policy under name
+func Put(ctx context.Context, storage logical.Storage, name string, policy *Policy) error {
+	entry, err := logical.StorageEntryJSON(config.PolicyStoragePath+name, policy)
+	if err != nil {
+		return err
+	}
+	return storage.Put(ctx, entry)
+}
+
+// Effective returns the policy applying to uuid: its own policy, or the global
+// default when it has none. It returns nil when neither exists.
+func Effective(ctx context.Context, storage logical.Storage, uuid string) (*Policy, error) {
+	policy, err := Get(ctx, storage, uuid)
+	if err != nil || policy != nil {
+		return policy, err
+	}
+	return Get(ctx, storage, config.DefaultPolicyName)
+}
@@ -0,0 +1,254 @@
+package policy
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib"
+)
+
+const (
+	testDestination = "0x742d35Cc6634C0532925a3b8D359A5C5119e32C8"
+	testToken       = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+	testOther       = "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"
+)
+
+func nativeTransfer(to string, value int64, chainID int64) *lib.TransactionSummary {
+	return &lib.TransactionSummary{
+		ChainID:   big.NewInt(chainID),
+		Transfers: []lib.Transfer{{To: to, Value: big.NewInt(value)}},
+	}
+}
+
+func tokenTransfer(token, to string, amount int64) *lib.TransactionSummary {
+	return &lib.TransactionSummary{
+		ChainID: big.NewInt(1),
+		Transfers: []lib.Transfer{
+			{To: token, Value: big.NewInt(0)},
+			{To: to, Value: big.NewInt(amount), Token: token},
+		},
+	}
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	restrictive := &Policy{
+		AllowedDestinations: []string{testDestination},
+		MaxValues:           map[uint16]*big.Int{60: big.NewInt(1000)},
+		AllowedChainIDs:     []int64{1, 137},
+		AllowedTokens:       []string{testToken},
+	}
+	restrictive.Normalize()
+	tokensOnly := &Policy{AllowedTokens: []string{testToken}}
+	tokensOnly.Normalize()
+
+	tests := []struct {
+		name     string
+		policy   *Policy
+		coinType uint16
+		summary  *lib.TransactionSummary
+		wantErr  error
+	}{
+		{
+			name:    "unrestricted policy accepts anything",
+			policy:  &Policy{},
+			summary: nativeTransfer(testOther, 1e18, 56),
+		},
+		{
+			name:    "unrestricted policy accepts undecodable payloads",
+			policy:  &Policy{},
+			summary: nil,
+		},
+		{
+			name:    "allowed transfer",
+			policy:  restrictive,
+			summary: nativeTransfer(testDestination, 1000, 137),
+		},
+		{
+			name:    "destinations are compared case-insensitively",
+			policy:  restrictive,
+			summary: nativeTransfer("0x742D35CC6634C0532925A3B8D359A5C5119E32C8", 1, 1),
+		},
+		{
+			name:    "destination not allowed",
+			policy:  restrictive,
+			summary: nativeTransfer(testOther, 1, 1),
+			wantErr: ErrDestinationNotAllowed,
+		},
+		{
+			name:    "value above the limit",
+			policy:  restrictive,
+			summary: nativeTransfer(testDestination, 1001, 1),
+			wantErr: ErrValueLimitExceeded,
+		},
+		{
+			name:     "value limit of another coin type",
+			policy:   restrictive,
+			coinType: 195,
+			summary:  nativeTransfer(testDestination, 1001, 1),
+		},
+		{
+			name:     "legacy value limit applies to every coin type",
+			policy:   &Policy{MaxValue: big.NewInt(1000)},
+			coinType: 195,
+			summary:  nativeTransfer(testDestination, 1001, 1),
+			wantErr:  ErrValueLimitExceeded,
+		},
+		{
+			name:    "chain id not allowed",
+			policy:  restrictive,
+			summary: nativeTransfer(testDestination, 1, 56),
+			wantErr: ErrChainIDNotAllowed,
+		},
+		{
+			name:   "chain id list ignores chains without chain id",
+			policy: restrictive,
+			summary: &lib.TransactionSummary{
+				Transfers: []lib.Transfer{{To: testDestination, Value: big.NewInt(1)}},
+			},
+		},
+		{
+			name:    "allowed token transfer",
+			policy:  restrictive,
+			summary: tokenTransfer(testToken, testDestination, 1e9),
+		},
+		{
+			name:    "token not allowed",
+			policy:  restrictive,
+			summary: tokenTransfer(testOther, testDestination, 1),
+			wantErr: ErrTokenNotAllowed,
+		},
+		{
+			name:    "token recipient not allowed",
+			policy:  restrictive,
+			summary: tokenTransfer(testToken, testOther, 1),
+			wantErr: ErrDestinationNotAllowed,
+		},
+		{
+			name:   "value sent along a token call",
+			policy: restrictive,
+			summary: &lib.TransactionSummary{
+				ChainID: big.NewInt(1),
+				Transfers: []lib.Transfer{
+					{To: testToken, Value: big.NewInt(1)},
+					{To: testDestination, Value: big.NewInt(1), Token: testToken},
+				},
+			},
+			wantErr: ErrDestinationNotAllowed,
+		},
+		{
+			name:   "call of a contract outside the allowed tokens",
+			policy: tokensOnly,
+			summary: &lib.TransactionSummary{
+				ChainID:   big.NewInt(1),
+				Transfers: []lib.Transfer{{To: testOther, Value: big.NewInt(0), Contract: true}},
+			},
+			wantErr: ErrContractNotAllowed,
+		},
+		{
+			name:   "call of an allowed token outside its transfer methods",
+			policy: tokensOnly,
+			summary: &lib.TransactionSummary{
+				ChainID:   big.NewInt(1),
+				Transfers: []lib.Transfer{{To: testToken, Value: big.NewInt(0), Contract: true}},
+			},
+		},
+		{
+			name:    "contract creation with a destination allowlist",
+			policy:  restrictive,
+			summary: nativeTransfer("", 0, 1),
+			wantErr: ErrDestinationNotAllowed,
+		},
+		{
+			name:    "undecodable payload under a restrictive policy",
+			policy:  restrictive,
+			summary: nil,
+			wantErr: ErrPayloadNotDecodable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coinType := tt.coinType
+			if coinType == 0 {
+				coinType = 60
+			}
+			err := tt.policy.Evaluate(coinType, tt.summary)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, ErrPolicyViolation)
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPolicy_EvaluateMessage(t *testing.T) {
+	assert.NoError(t, (&Policy{}).EvaluateMessage())
+
+	err := (&Policy{VelocityLimits: []VelocityLimit{{CoinType: 60, Window: time.Hour, MaxCount: 1}}}).EvaluateMessage()
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	assert.ErrorIs(t, err, ErrMessageNotAllowed)
+}
+
+func TestParseMaxValue(t *testing.T) {
+	value, err := ParseMaxValue("")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	value, err = ParseMaxValue("1000000000000000000000")
+	require.NoError(t, err)
+	assert.Equal(t, "1000000000000000000000", value.String())
+
+	_, err = ParseMaxValue("-1")
+	assert.ErrorIs(t, err, ErrInvalidMaxValue)
+
+	_, err = ParseMaxValue("1e18")
+	assert.ErrorIs(t, err, ErrInvalidMaxValue)
+}
+
+func TestParseMaxValues(t *testing.T) {
+	values, err := ParseMaxValues(map[string]string{" 60 ": " 1000 ", "195": ""})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint16]*big.Int{60: big.NewInt(1000)}, values)
+
+	_, err = ParseMaxValues(map[string]string{"eth": "1000"})
+	assert.ErrorIs(t, err, ErrInvalidCoinType)
+
+	_, err = ParseMaxValues(map[string]string{"70000": "1000"})
+	assert.ErrorIs(t, err, ErrInvalidCoinType)
+
+	_, err = ParseMaxValues(map[string]string{"60": "-1"})
+	assert.ErrorIs(t, err, ErrInvalidMaxValue)
+}
+
+func TestEffective(t *testing.T) {
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+
+	got, err := Effective(ctx, storage, "user")
+	require.NoError(t, err)
+	assert.Nil(t, got, "no policy at all")
+
+	defaultPolicy := &Policy{AllowedChainIDs: []int64{1}}
+	require.NoError(t, Put(ctx, storage, config.DefaultPolicyName, defaultPolicy))
+
+	got, err = Effective(ctx, storage, "user")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, got.AllowedChainIDs, "falls back to the default policy")
+
+	userPolicy := &Policy{MaxValue: big.NewInt(5)}
+	require.NoError(t, Put(ctx, storage, "user", userPolicy))
+
+	got, err = Effective(ctx, storage, "user")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(5), got.MaxValue, "own policy replaces the default")
+	assert.Empty(t, got.AllowedChainIDs)
+}
@@ -0,0 +1,303 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib"
+)
+
+// nativeAsset names the native coin of a coin type in velocity ledgers
+const nativeAsset = "native"
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrVelocityLimitExceeded = errors.New("velocity limit exceeded")
+	ErrInvalidVelocityLimit  = errors.New("invalid velocity limit")
+)
+
+// VelocityLimit bounds the signatures of one asset of a coin type over a
+// rolling window: the native coin when Token is empty, otherwise the
+// ERC-20/TRC-20 token contract Token. MaxValue bounds the summed value in the
+// smallest unit of the asset, MaxCount the number of signed transactions.
+// A zero MaxCount or nil MaxValue leaves that dimension unrestricted.
+type VelocityLimit struct {
+	CoinType uint16        `json:"coinType"`
+	Token    string        `json:"token,omitempty"`
+	Window   time.Duration `json:"window"`
+	MaxValue *big.Int      `json:"maxValue,omitempty"`
+	MaxCount int           `json:"maxCount,omitempty"`
+}
+
+// velocityLimitInput is the request form of a VelocityLimit
+type velocityLimitInput struct {
+	CoinType uint16 `json:"coinType"`
+	Token    string `json:"token"`
+	Window   string `json:"window"`
+	MaxValue string `json:"maxValue"`
+	MaxCount int    `json:"maxCount"`
+}
+
+// ParseVelocityLimits parses the velocityLimits field of a policy request.
+// Each element is an object, or its JSON encoding, such as
+// {"coinType": 60, "window": "24h", "maxValue": "10000000000000000000", "maxCount": 50}.
+func ParseVelocityLimits(raw []interface{}) ([]VelocityLimit, error) {
+	limits := make([]VelocityLimit, 0, len(raw))
+	for idx, element := range raw {
+		encoded, ok := element.(string)
+		if !ok {
+			data, err := json.Marshal(element)
+			if err != nil {
+				return nil, fmt.Errorf("%w %d: %w", ErrInvalidVelocityLimit, idx, err)
+			}
+			encoded = string(data)
+		}
+
+		var input velocityLimitInput
+		if err := json.Unmarshal([]byte(encoded), &input); err != nil {
+			return nil, fmt.Errorf("%w %d: %w", ErrInvalidVelocityLimit, idx, err)
+		}
+
+		window, err := time.ParseDuration(input.Window)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("%w %d: window must be a positive duration such as 24h", ErrInvalidVelocityLimit, idx)
+		}
+		maxValue, err := ParseMaxValue(input.MaxValue)
+		if err != nil {
+			return nil, fmt.Errorf("%w %d: %w", ErrInvalidVelocityLimit, idx, err)
+		}
+		if input.MaxCount < 0 || (input.MaxCount == 0 && maxValue == nil) {
+			return nil, fmt.Errorf("%w %d: set maxValue or a positive maxCount", ErrInvalidVelocityLimit, idx)
+		}
+
+		limits = append(limits, VelocityLimit{
+			CoinType: input.CoinType,
+			Token:    normalizeAddress(input.Token),
+			Window:   window,
+			MaxValue: maxValue,
+			MaxCount: input.MaxCount,
+		})
+	}
+	return limits, nil
+}
+
+// asset returns the ledger key of the asset of the limit
+func (l *VelocityLimit) asset() string {
+	token := l.Token
+	if token == "" {
+		token = nativeAsset
+	}
+	return strconv.FormatUint(uint64(l.CoinType), 10) + "/" + token
+}
+
+// amount returns the value a transaction moves of the asset of the limit and
+// whether the limit applies to it: native limits apply to transactions moving
+// native value, token limits to the transfers of their token. Payloads that
+// could not be decoded count towards transaction limits only.
+func (l *VelocityLimit) amount(summary *lib.TransactionSummary) (*big.Int, bool, error) {
+	if summary == nil {
+		if l.MaxValue != nil {
+			return nil, false, ErrPayloadNotDecodable
+		}
+		return new(big.Int), true, nil
+	}
+
+	if l.Token == "" {
+		// token transfers and calls without value move no native coin
+		value := summary.NativeValue()
+		return value, value.Sign() > 0, nil
+	}
+
+	total, applies := new(big.Int), false
+	for _, transfer := range summary.Transfers {
+		if transfer.Token != "" && normalizeAddress(transfer.Token) == l.Token && transfer.Value != nil {
+			total.Add(total, transfer.Value)
+			applies = true
+		}
+	}
+	return total, applies, nil
+}
+
+// velocityEvent is a signed transaction recorded in a velocity ledger
+type velocityEvent struct {
+	At    time.Time `json:"at"`
+	Value *big.Int  `json:"value"`
+}
+
+// velocityLedger holds the recent signatures of a user per asset
+type velocityLedger map[string][]velocityEvent
+
+// VelocityUsage is the consumption of a velocity limit within its current window
+type VelocityUsage struct {
+	Limit     VelocityLimit
+	Value     *big.Int
+	Count     int
+	ResetsAt  time.Time
+	Remaining *big.Int
+}
+
+func loadLedger(ctx context.Context, storage logical.Storage, uuid string) (velocityLedger, error) {
+	entry, err := storage.Get(ctx, config.VelocityStoragePath+uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger := make(velocityLedger)
+	if entry == nil {
+		return ledger, nil
+	}
+	if err := entry.DecodeJSON(&ledger); err != nil {
+		return nil, err
+	}
+	return ledger, nil
+}
+
+// usage sums the events of the asset of limit within the window ending at now
+func (l velocityLedger) usage(limit VelocityLimit, now time.Time) VelocityUsage {
+	usage := VelocityUsage{Limit: limit, Value: new(big.Int)}
+
+	since := now.Add(-limit.Window)
+	for _, event := range l[limit.asset()] {
+		if !event.At.After(since) {
+			continue
+		}
+		usage.Count++
+		if event.Value != nil {
+			usage.Value.Add(usage.Value, event.Value)
+		}
+		// the window frees up when its oldest event expires
+		if usage.ResetsAt.IsZero() {
+			usage.ResetsAt = event.At.Add(limit.Window)
+		}
+	}
+
+	if limit.MaxValue != nil {
+		usage.Remaining = new(big.Int).Sub(limit.MaxValue, usage.Value)
+		if usage.Remaining.Sign() < 0 {
+			usage.Remaining.SetInt64(0)
+		}
+	}
+	return usage
+}
+
+// CheckVelocity reports whether signing summary for coinType at now keeps the
+// user within the velocity limits of the policy. Violations wrap ErrPolicyViolation.
+func (p *Policy) CheckVelocity(ctx context.Context, storage logical.Storage, uuid string, coinType uint16,
+	summary *lib.TransactionSummary, now time.Time) error {
+	if len(p.VelocityLimits) == 0 {
+		return nil
+	}
+
+	ledger, err := loadLedger(ctx, storage, uuid)
+	if err != nil {
+		return err
+	}
+
+	for _, limit := range p.VelocityLimits {
+		if limit.CoinType != coinType {
+			continue
+		}
+		value, applies, err := limit.amount(summary)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrPolicyViolation, err)
+		}
+		if !applies {
+			continue
+		}
+
+		usage := ledger.usage(limit, now)
+		if limit.MaxCount > 0 && usage.Count+1 > limit.MaxCount {
+			return fmt.Errorf("%w: %w: %d transactions of %s per %s", ErrPolicyViolation, ErrVelocityLimitExceeded,
+				limit.MaxCount, limit.asset(), limit.Window)
+		}
+		if limit.MaxValue != nil && new(big.Int).Add(usage.Value, value).Cmp(limit.MaxValue) > 0 {
+			return fmt.Errorf("%w: %w: %s of %s per %s", ErrPolicyViolation, ErrVelocityLimitExceeded,
+				limit.MaxValue, limit.asset(), limit.Window)
+		}
+	}
+	return nil
+}
+
+// RecordVelocity adds a signed transaction to the ledger of the user. Events
+// older than the longest window of their asset are dropped. Callers serialise
+// CheckVelocity, signing and RecordVelocity per user.
+func (p *Policy) RecordVelocity(ctx context.Context, storage logical.Storage, uuid string, coinType uint16,
+	summary *lib.TransactionSummary, now time.Time) error {
+	if len(p.VelocityLimits) == 0 {
+		return nil
+	}
+
+	ledger, err := loadLedger(ctx, storage, uuid)
+	if err != nil {
+		return err
+	}
+
+	recorded := make(map[string]bool)
+	for _, limit := range p.VelocityLimits {
+		if limit.CoinType != coinType || recorded[limit.asset()] {
+			continue
+		}
+		value, applies, err := limit.amount(summary)
+		if err != nil {
+			return err
+		}
+		if !applies {
+			continue
+		}
+		recorded[limit.asset()] = true
+		ledger[limit.asset()] = append(ledger[limit.asset()], velocityEvent{At: now, Value: value})
+	}
+
+	ledger.prune(p.VelocityLimits, now)
+
+	entry, err := logical.StorageEntryJSON(config.VelocityStoragePath+uuid, ledger)
+	if err != nil {
+		return err
+	}
+	return storage.Put(ctx, entry)
+}
+
+// prune drops the events no limit of their asset looks at anymore
+func (l velocityLedger) prune(limits []VelocityLimit, now time.Time) {
+	windows := make(map[string]time.Duration)
+	for _, limit := range limits {
+		windows[limit.asset()] = max(windows[limit.asset()], limit.Window)
+	}
+
+	for asset, events := range l {
+		since := now.Add(-windows[asset])
+		kept := events[:0]
+		for _, event := range events {
+			if event.At.After(since) {
+				kept = append(kept, event)
+			}
+		}
+		if len(kept) == 0 {
+			delete(l, asset)
+			continue
+		}
+		l[asset] = kept
+	}
+}
+
+// VelocityUsage returns the consumption of every velocity limit of the policy at now
+func (p *Policy) VelocityUsage(ctx context.Context, storage logical.Storage, uuid string,
+	now time.Time) ([]VelocityUsage, error) {
+	ledger, err := loadLedger(ctx, storage, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]VelocityUsage, 0, len(p.VelocityLimits))
+	for _, limit := range p.VelocityLimits {
+		usages = append(usages, ledger.usage(limit, now))
+	}
+	return usages, nil
+}
@@ -0,0 +1,239 @@
+package policy
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib"
+)
+
+const (
+	testUUID     = "velocity-user"
+	testCoinType = 60
+)
+
+var testEpoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestParseVelocityLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     []interface{}
+		want    []VelocityLimit
+		wantErr bool
+	}{
+		{
+			name: "object",
+			raw: []interface{}{map[string]interface{}{
+				"coinType": 60, "window": "24h", "maxValue": "10000000000000000000", "maxCount": 50,
+			}},
+			want: []VelocityLimit{{
+				CoinType: 60, Window: 24 * time.Hour, MaxValue: big.NewInt(0).Mul(big.NewInt(10), big.NewInt(1e18)),
+				MaxCount: 50,
+			}},
+		},
+		{
+			name: "json encoded token limit",
+			raw:  []interface{}{`{"coinType": 60, "token": "` + testToken + `", "window": "1h", "maxCount": 5}`},
+			want: []VelocityLimit{{
+				CoinType: 60, Token: "0xdac17f958d2ee523a2206206994597c13d831ec7", Window: time.Hour, MaxCount: 5,
+			}},
+		},
+		{
+			name:    "missing window",
+			raw:     []interface{}{map[string]interface{}{"coinType": 60, "maxCount": 1}},
+			wantErr: true,
+		},
+		{
+			name:    "negative window",
+			raw:     []interface{}{map[string]interface{}{"coinType": 60, "window": "-1h", "maxCount": 1}},
+			wantErr: true,
+		},
+		{
+			name:    "no limit",
+			raw:     []interface{}{map[string]interface{}{"coinType": 60, "window": "24h"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid max value",
+			raw:     []interface{}{map[string]interface{}{"coinType": 60, "window": "24h", "maxValue": "-1"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			raw:     []interface{}{"{"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVelocityLimits(tt.raw)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidVelocityLimit)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPolicy_CheckVelocity(t *testing.T) {
+	ctx := context.Background()
+
+	p := &Policy{VelocityLimits: []VelocityLimit{
+		{CoinType: testCoinType, Window: 24 * time.Hour, MaxValue: big.NewInt(10), MaxCount: 3},
+		{CoinType: testCoinType, Token: "0xdac17f958d2ee523a2206206994597c13d831ec7", Window: time.Hour,
+			MaxValue: big.NewInt(100)},
+	}}
+
+	t.Run("value accumulates within the window", func(t *testing.T) {
+		storage := &logical.InmemStorage{}
+		require.NoError(t, p.RecordVelocity(ctx, storage, testUUID, testCoinType,
+			nativeTransfer(testDestination, 6, 1), testEpoch))
+
+		later := testEpoch.Add(time.Hour)
+		err := p.CheckVelocity(ctx, storage, testUUID, testCoinType, nativeTransfer(testDestination, 5, 1), later)
+		require.ErrorIs(t, err, ErrPolicyViolation)
+		require.ErrorIs(t, err, ErrVelocityLimitExceeded)
+
+		assert.NoError(t, p.CheckVelocity(ctx, storage, testUUID, testCoinType,
+			nativeTransfer(testDestination, 4, 1), later))
+	})
+
+	t.Run("window rolls over", func(t *testing.T) {
+		storage := &logical.InmemStorage{}
+		require.NoError(t, p.RecordVelocity(ctx, storage, testUUID, testCoinType,
+			nativeTransfer(testDestination, 10, 1), testEpoch))
+
+		assert.Error(t, p.CheckVelocity(ctx, storage, testUUID, testCoinType,
+			nativeTransfer(testDestination, 1, 1), testEpoch.Add(24*time.Hour-time.Second)))
+		assert.NoError(t, p.CheckVelocity(ctx, storage, testUUID, testCoinType,
+			nativeTransfer(testDestination, 1, 1), testEpoch.Add(24*time.Hour)))
+	})
+
+	t.Run("transaction count", func(t *testing.T) {
+		storage := &logical.InmemStorage{}
+		for i := range 3 {
+			now := testEpoch.Add(time.Duration(i) * time.Minute)
+			summary := nativeTransfer(testDestination, 1, 1)
+			require.NoError(t, p.CheckVelocity(ctx, storage, testUUID, testCoinType, summary, now))
+			require.NoError(t, p.RecordVelocity(ctx, storage, testUUID, testCoinType, summary, now))
+		}
+
+		err := p.CheckVelocity(ctx, storage, testUUID, testCoinType, nativeTransfer(testDestination, 1, 1),
+			testEpoch.Add(time.Hour))
+		assert.ErrorIs(t, err, ErrVelocityLimitExceeded)
+	})
+
+	t.Run("native limits skip transactions without native value", func(t *testing.T) {
+		storage := &logical.InmemStorage{}
+		for i := range 4 {
+			now := testEpoch.Add(time.Duration(i) * time.Minute)
+			for _, summary := range []*lib.TransactionSummary{
+				tokenTransfer(testToken, testDestination, 1),
+				nativeTransfer(testDestination, 0, 1),
+			} {
+				require.NoError(t, p.CheckVelocity(ctx, storage, testUUID, testCoinType, summary, now))
+				require.NoError(t, p.RecordVelocity(ctx, storage, testUUID, testCoinType, summary, now))
+			}
+		}
+
+		usages, err := p.VelocityUsage(ctx, storage, testUUID, testEpoch.Add(30*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 0, usages[0].Count, "native")
+		assert.Equal(t, 4, usages[1].Count, "token")
+	})
+
+	t.Run("token limits count token transfers only", func(t *testing.T) {
+		storage := &logical.InmemStorage{}
+		require.NoError(t, p.RecordVelocity(ctx, storage, testUUID, testCoinType,
+			tokenTransfer(testToken, testDestination, 90), testEpoch))
+
+		err := p.CheckVelocity(ctx, storage, testUUID, testCoinType, tokenTransfer(testToken, testDestination, 20),
+			testEpoch.Add(time.Minute))
+		assert.ErrorIs(t, err, ErrVelocityLimitExceeded)
+
+		// other tokens and native transfers are not bounded by the token limit
+		assert.NoError(t, p.CheckVelocity(ctx, storage, testUUID, testCoinType,
+			tokenTransfer(testOther, testDestination, 1000), testEpoch.Add(time.Minute)))
+	})
+
+	t.Run("other coin types are not limited", func(t *testing.T) {
+		storage := &logical.InmemStorage{}
+		assert.NoError(t, p.CheckVelocity(ctx, storage, testUUID, 195, nativeTransfer(testDestination, 1000, 1),
+			testEpoch))
+		require.NoError(t, p.RecordVelocity(ctx, storage, testUUID, 195, nativeTransfer(testDestination, 1000, 1),
+			testEpoch))
+
+		entry, err := storage.Get(ctx, config.VelocityStoragePath+testUUID)
+		require.NoError(t, err)
+		require.NotNil(t, entry)
+		assert.JSONEq(t, `{}`, string(entry.Value))
+	})
+
+	t.Run("undecodable payloads fail value limits", func(t *testing.T) {
+		err := p.CheckVelocity(ctx, &logical.InmemStorage{}, testUUID, testCoinType, nil, testEpoch)
+		assert.ErrorIs(t, err, ErrPayloadNotDecodable)
+
+		countOnly := &Policy{VelocityLimits: []VelocityLimit{{CoinType: 1, Window: time.Hour, MaxCount: 1}}}
+		storage := &logical.InmemStorage{}
+		require.NoError(t, countOnly.CheckVelocity(ctx, storage, testUUID, 1, nil, testEpoch))
+		require.NoError(t, countOnly.RecordVelocity(ctx, storage, testUUID, 1, nil, testEpoch))
+		assert.ErrorIs(t, countOnly.CheckVelocity(ctx, storage, testUUID, 1, nil, testEpoch),
+			ErrVelocityLimitExceeded)
+	})
+}
+
+func TestPolicy_RecordVelocity_Prunes(t *testing.T) {
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+
+	p := &Policy{VelocityLimits: []VelocityLimit{
+		{CoinType: testCoinType, Window: time.Hour, MaxCount: 10},
+	}}
+
+	require.NoError(t, p.RecordVelocity(ctx, storage, testUUID, testCoinType,
+		nativeTransfer(testDestination, 1, 1), testEpoch))
+	require.NoError(t, p.RecordVelocity(ctx, storage, testUUID, testCoinType,
+		nativeTransfer(testDestination, 2, 1), testEpoch.Add(2*time.Hour)))
+
+	ledger, err := loadLedger(ctx, storage, testUUID)
+	require.NoError(t, err)
+	require.Len(t, ledger["60/native"], 1)
+	assert.Equal(t, big.NewInt(2), ledger["60/native"][0].Value)
+}
+
+func TestPolicy_VelocityUsage(t *testing.T) {
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+
+	p := &Policy{VelocityLimits: []VelocityLimit{
+		{CoinType: testCoinType, Window: 24 * time.Hour, MaxValue: big.NewInt(10), MaxCount: 5},
+	}}
+
+	require.NoError(t, p.RecordVelocity(ctx, storage, testUUID, testCoinType,
+		nativeTransfer(testDestination, 4, 1), testEpoch))
+	require.NoError(t, p.RecordVelocity(ctx, storage, testUUID, testCoinType,
+		nativeTransfer(testDestination, 3, 1), testEpoch.Add(time.Hour)))
+
+	usages, err := p.VelocityUsage(ctx, storage, testUUID, testEpoch.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, usages, 1)
+	assert.Equal(t, big.NewInt(7), usages[0].Value)
+	assert.Equal(t, 2, usages[0].Count)
+	assert.Equal(t, big.NewInt(3), usages[0].Remaining)
+	assert.Equal(t, testEpoch.Add(24*time.Hour), usages[0].ResetsAt)
+
+	usages, err = p.VelocityUsage(ctx, storage, "unknown", testEpoch)
+	require.NoError(t, err)
+	assert.Zero(t, usages[0].Count)
+	assert.True(t, usages[0].ResetsAt.IsZero())
+}
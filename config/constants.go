@@ -10,12 +10,96 @@ const (
 
 	// BitsharesDerivationPath used to hard code BTS to some derivation path.
 	BitsharesDerivationPath = "m/44'/69'/69'/69/69"
+
+	// PolicyStoragePath base path where signing policies are stored in vault
+	// Example: <PolicyStoragePath>/<user-uuid>
+	PolicyStoragePath = "policies/"
+
+	// VelocityStoragePath base path where the velocity ledgers of users are stored in vault
+	// Example: <VelocityStoragePath>/<user-uuid>
+	VelocityStoragePath = "velocity/"
+
+	// AuditStoragePath base path of the signing audit journal in vault
+	// Example: <AuditStoragePath>entries/<sequence>, <AuditStoragePath>head
+	AuditStoragePath = "audit/"
+
+	// DefaultPolicyName names the global policy applied to users without their own
+	DefaultPolicyName = "default"
+
+	// MountConfigStoragePath is where the configuration of the mount is stored in vault
+	MountConfigStoragePath = "config"
+
+	// IdempotencyStoragePath base path where the outcomes of idempotent signing requests are stored in vault
+	// Example: <IdempotencyStoragePath><user-uuid>/<key-hash>
+	IdempotencyStoragePath = "idempotency/"
+
+	// IdempotencyExpiryStoragePath base path where idempotency records are indexed by the hour they expire in
+	// Example: <IdempotencyExpiryStoragePath><20060102T15>/<user-uuid>/<key-hash>
+	IdempotencyExpiryStoragePath = "idempotency-expiry/"
+
+	// KeyringStoragePath is where the wrapped data-encryption keys of the mount are stored in vault
+	KeyringStoragePath = "keyring"
+
+	// MaxBatchSize is the default maximum count of an address batch
+	MaxBatchSize = 1000
+
+	// MaxBatchSizeLimit bounds the maximum batch size a mount can be configured with
+	MaxBatchSizeLimit = 10000
+
+	// KeyCacheTTL is the default time in seconds account keys are held by the key cache
+	KeyCacheTTL = 300
+
+	// KeyCacheSizeLimit bounds the key cache size a mount can be configured with
+	KeyCacheSizeLimit = 100000
+
+	// KeyCacheTTLLimit bounds the key cache TTL, in seconds, a mount can be configured with
+	KeyCacheTTLLimit = 86400
+
+	// IdempotencyTTL is the default time in seconds the outcomes of idempotent signing requests are kept
+	IdempotencyTTL = 86400
+
+	// IdempotencyTTLLimit bounds the idempotency TTL, in seconds, a mount can be configured with
+	IdempotencyTTLLimit = 604800
+
+	// IdempotencyKeyMaxLength bounds the length of idempotency keys
+	IdempotencyKeyMaxLength = 255
 )
 
 // supported log levels
 const (
 	Info  = "INFO"
+	Warn  = "WARN"
 	Error = "ERROR"
 	Debug = "DEBUG"
 	Fatal = "FATAL"
 )
+
+// mount options, set with vault secrets enable -options=<option>=<value>
+const (
+	// LogLevelOption sets the log level of the mount, INFO by default
+	LogLevelOption = "log_level"
+
+	// LogRedactKeysOption lists attribute keys to redact from the logs of the
+	// mount, comma separated, in addition to the default ones
+	LogRedactKeysOption = "log_redact_keys"
+
+	// KEKTypeOption selects the key-encryption key wrapping the keys that seal
+	// the secrets of users: file or transit. Secrets are stored in plaintext
+	// when it is not set
+	KEKTypeOption = "kek_type"
+
+	// KEKFileOption is the path of the file holding the key-encryption key of
+	// the file type, 32 bytes raw, hex or base64 encoded
+	KEKFileOption = "kek_file"
+
+	// TransitMountOption is the mount path of the transit secrets engine of the
+	// transit type, transit by default
+	TransitMountOption = "transit_mount"
+
+	// TransitKeyOption names the transit key of the transit type
+	TransitKeyOption = "transit_key"
+
+	// TransitAddressOption is the address of the Vault server of the transit
+	// type, VAULT_ADDR by default. The token is read from VAULT_TOKEN
+	TransitAddressOption = "transit_address"
+)
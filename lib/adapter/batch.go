@@ -0,0 +1,195 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/payment-system/dq-vault/lib"
+)
+
+// indexVerb stands for the address index in derivation templates
+const indexVerb = "%d"
+
+// ErrBatchIndexOutOfRange is returned for batches past the last child index
+var ErrBatchIndexOutOfRange = errors.New("batch indexes must be between 0 and 2147483647")
+
+// publicKeyEncoder is implemented by adapters whose public keys and addresses
+// only depend on the secp256k1 public key of a path, batches of their
+// addresses derive the parent key once and its children from it
+type publicKeyEncoder interface {
+	pathResolver
+	EncodePublicKey(publicKey *btcec.PublicKey, derivationPath string, isDev bool) (string, string, error)
+}
+
+// DerivedAddress is an address of a batch
+type DerivedAddress struct {
+	Index     int    `json:"index"`
+	Path      string `json:"path"`
+	Address   string `json:"address"`
+	PublicKey string `json:"publicKey"`
+}
+
+// DeriveAddressBatch derives the addresses of template, e.g., m/44'/60'/0'/0/%d,
+// at the count indexes from start, ordered by index. Templates whose last
+// component is the index derive their parent once; the children are derived
+// over a worker pool.
+func (i *Inventory) DeriveAddressBatch(ctx context.Context, keys *lib.Keychain, coinType uint16, template string,
+	start, count int, isDev bool) ([]DerivedAddress, error) {
+	logger := i.logger.With(slog.String("op", "derive_address_batch"), slog.Uint64("coinType", uint64(coinType)))
+	logger.Info("Deriving address batch", "template", template, "start", start, "count", count)
+
+	adapter := i.getProvider(coinType)
+	if adapter == nil {
+		logger.Error("No adapter found for coin type", "coinType", coinType)
+		return nil, ErrNoAdapterFound
+	}
+	if start < 0 || count < 0 || start > hdkeychain.HardenedKeyStart-count {
+		return nil, ErrBatchIndexOutOfRange
+	}
+
+	addresses := make([]DerivedAddress, count)
+	for n := range addresses {
+		addresses[n].Index = start + n
+		addresses[n].Path = template
+		if strings.Contains(template, indexVerb) {
+			addresses[n].Path = fmt.Sprintf(template, start+n)
+		}
+	}
+
+	derive := func(address *DerivedAddress) error {
+		var err error
+		if address.PublicKey, err = adapter.DerivePublicKey(keys, address.Path, isDev); err != nil {
+			return err
+		}
+		address.Address, err = adapter.DeriveAddress(keys, address.Path, isDev)
+		return err
+	}
+
+	encoder, ok := adapter.(publicKeyEncoder)
+	parentPath, hardened, ok := childTemplate(template, ok)
+	if ok && count > 0 {
+		// the adapter resolves the paths it derives, e.g., relative Tron paths
+		parentPath, ok = resolveParentPath(encoder, parentPath, addresses[0].Path)
+	}
+	if ok {
+		parent, err := keys.DeriveExtendedKey(parentPath)
+		if err != nil {
+			logger.Error("Failed to derive parent key", "error", err)
+			return nil, err
+		}
+		defer parent.Zero()
+
+		// neutering memoizes the public key of the parent, which its children
+		// derived concurrently read; non hardened children are derived from it
+		public, err := parent.Neuter()
+		if err != nil {
+			return nil, err
+		}
+		if !hardened {
+			parent = public
+		}
+
+		derive = func(address *DerivedAddress) error {
+			index := uint32(address.Index)
+			if hardened {
+				index += hdkeychain.HardenedKeyStart
+			}
+			child, err := parent.Derive(index)
+			if err != nil {
+				return err
+			}
+			defer child.Zero()
+
+			publicKey, err := child.ECPubKey()
+			if err != nil {
+				return err
+			}
+			address.PublicKey, address.Address, err = encoder.EncodePublicKey(publicKey, address.Path, isDev)
+			return err
+		}
+	}
+
+	if err := deriveConcurrently(ctx, addresses, derive); err != nil {
+		logger.Error("Failed to derive address batch", "error", err)
+		return nil, err
+	}
+
+	logger.Debug("Address batch derived successfully", "count", count)
+
+	return addresses, nil
+}
+
+// childTemplate returns the parent path of an absolute template whose last
+// component, and only that one, is the index, and whether the index is
+// hardened. ok is false for other templates or when encodable is false.
+func childTemplate(template string, encodable bool) (parentPath string, hardened, ok bool) {
+	if !encodable || !strings.HasPrefix(template, "m/") || strings.Count(template, "%") != 1 {
+		return "", false, false
+	}
+
+	parentPath, last, _ := strings.Cut(template, "/"+indexVerb)
+	if parentPath == "m" {
+		return "", false, false
+	}
+	switch strings.TrimSpace(last) {
+	case "":
+		return parentPath, false, true
+	case "'":
+		return parentPath, true, true
+	default:
+		return "", false, false
+	}
+}
+
+// resolveParentPath returns the absolute path encoder derives the parent
+// parentPath of path at, false when the resolved path of path is not a child
+// of it, in which case the batch is derived path by path
+func resolveParentPath(encoder pathResolver, parentPath, path string) (string, bool) {
+	resolved, err := encoder.ResolveDerivationPath(path)
+	child := strings.TrimPrefix(path, parentPath)
+	if err != nil || !strings.HasPrefix(resolved, "m/") || !strings.HasSuffix(resolved, child) {
+		return "", false
+	}
+	return strings.TrimSuffix(resolved, child), true
+}
+
+// deriveConcurrently runs derive on every address over a pool of workers,
+// stopping at the first error or when ctx is done
+func deriveConcurrently(ctx context.Context, addresses []DerivedAddress, derive func(*DerivedAddress) error) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(addresses)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range jobs {
+				if err := derive(&addresses[n]); err != nil {
+					cancel(fmt.Errorf("index %d: %w", addresses[n].Index, err))
+				}
+			}
+		}()
+	}
+
+feed:
+	for n := range addresses {
+		select {
+		case jobs <- n:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return context.Cause(ctx)
+}
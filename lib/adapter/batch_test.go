@@ -0,0 +1,165 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/lib"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+const batchTestMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func batchTestSeed(t testing.TB) []byte {
+	seed, err := lib.SeedFromMnemonic(batchTestMnemonic, "")
+	require.NoError(t, err)
+	return seed
+}
+
+func batchTestInventory() *Inventory {
+	return NewInventory(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// deriveSerially derives the addresses of a batch path by path
+func deriveSerially(i *Inventory, seed []byte, coinType uint16, template string, start, count int,
+	isDev bool) ([]DerivedAddress, error) {
+	addresses := make([]DerivedAddress, 0, count)
+	for index := start; index < start+count; index++ {
+		path := template
+		if template != "m/44'/69'/69'/69/69" {
+			path = fmt.Sprintf(template, index)
+		}
+		publicKey, err := i.DerivePublicKey(lib.NewSeedKeychain(seed), coinType, path, isDev)
+		if err != nil {
+			return nil, err
+		}
+		address, err := i.DeriveAddress(lib.NewSeedKeychain(seed), coinType, path, isDev)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, DerivedAddress{Index: index, Path: path, Address: address, PublicKey: publicKey})
+	}
+	return addresses, nil
+}
+
+func TestInventory_DeriveAddressBatch(t *testing.T) {
+	ctx := context.Background()
+	seed := batchTestSeed(t)
+	inventory := batchTestInventory()
+
+	tests := []struct {
+		name     string
+		coinType uint16
+		template string
+		isDev    bool
+	}{
+		{"evm", slip44.Ether, "m/44'/60'/0'/0/%d", false},
+		{"evm hardened", slip44.Ether, "m/44'/60'/0'/%d'", false},
+		{"tron", slip44.Tron, "m/44'/195'/0'/0/%d", false},
+		{"tron relative", slip44.Tron, "m/0'/0/%d", false},
+		{"tron relative hardened", slip44.Tron, "m/0'/0/%d'", false},
+		{"bitcoin p2pkh", slip44.Bitcoin, "m/44'/0'/0'/0/%d", false},
+		{"bitcoin p2sh-p2wpkh", slip44.Bitcoin, "m/49'/0'/0'/0/%d", false},
+		{"bitcoin p2wpkh", slip44.Bitcoin, "m/84'/0'/0'/0/%d", false},
+		{"bitcoin p2tr", slip44.Bitcoin, "m/86'/0'/0'/0/%d", false},
+		{"bitcoin testnet", slip44.Bitcoin, "m/84'/1'/0'/0/%d", true},
+		{"bitshares", slip44.Bitshares, "m/44'/69'/69'/69/69", false},
+		{"solana", slip44.Solana, "m/44'/501'/%d'/0'", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := deriveSerially(inventory, seed, tt.coinType, tt.template, 5, 4, tt.isDev)
+			require.NoError(t, err)
+
+			got, err := inventory.DeriveAddressBatch(ctx, lib.NewSeedKeychain(seed), tt.coinType, tt.template, 5, 4, tt.isDev)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestInventory_DeriveAddressBatch_Errors(t *testing.T) {
+	ctx := context.Background()
+	seed := batchTestSeed(t)
+	inventory := batchTestInventory()
+
+	t.Run("no adapter", func(t *testing.T) {
+		_, err := inventory.DeriveAddressBatch(ctx, lib.NewSeedKeychain(seed), 9999, "m/44'/9999'/0'/0/%d", 0, 1, false)
+		assert.ErrorIs(t, err, ErrNoAdapterFound)
+	})
+
+	t.Run("index out of range", func(t *testing.T) {
+		_, err := inventory.DeriveAddressBatch(ctx, lib.NewSeedKeychain(seed), slip44.Ether, "m/44'/60'/0'/0/%d", 1<<31-1, 2, false)
+		assert.ErrorIs(t, err, ErrBatchIndexOutOfRange)
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		_, err := inventory.DeriveAddressBatch(ctx, lib.NewSeedKeychain(seed), slip44.Bitcoin, "m/45'/0'/0'/0/%d", 0, 2, false)
+		assert.Error(t, err)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := inventory.DeriveAddressBatch(ctx, lib.NewSeedKeychain(seed), slip44.Ether, "m/44'/60'/0'/0/%d", 0, 100, false)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestChildTemplate(t *testing.T) {
+	tests := []struct {
+		template   string
+		parentPath string
+		hardened   bool
+		ok         bool
+	}{
+		{"m/44'/60'/0'/0/%d", "m/44'/60'/0'/0", false, true},
+		{"m/44'/60'/0'/%d'", "m/44'/60'/0'", true, true},
+		{"m/44'/501'/%d'/0'", "", false, false},
+		{"m/%d", "", false, false},
+		{"44'/60'/0'/0/%d", "", false, false},
+		{"m/44'/69'/69'/69/69", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			parentPath, hardened, ok := childTemplate(tt.template, true)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.parentPath, parentPath)
+			assert.Equal(t, tt.hardened, hardened)
+		})
+	}
+}
+
+// BenchmarkDeriveAddressBatch compares a batch derived path by path with one
+// deriving its parent once over a worker pool
+func BenchmarkDeriveAddressBatch(b *testing.B) {
+	const (
+		template = "m/44'/60'/0'/0/%d"
+		count    = 100
+	)
+	ctx := context.Background()
+	seed := batchTestSeed(b)
+	inventory := batchTestInventory()
+
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := deriveSerially(inventory, seed, slip44.Ether, template, 0, count, false); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := inventory.DeriveAddressBatch(ctx, lib.NewSeedKeychain(seed), slip44.Ether, template, 0, count, false); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
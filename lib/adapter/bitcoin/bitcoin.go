@@ -0,0 +1,469 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/payment-system/dq-vault/lib"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+const (
+	// BIP-44/49/84/86 purpose values selecting the address type
+	purposeP2PKH     = 44
+	purposeP2SHP2WPK = 49
+	purposeP2WPKH    = 84
+	purposeP2TR      = 86
+
+	// a path needs at least m / purpose' / coin_type'
+	minPathComponents = 3
+	txVersion         = 2
+)
+
+// addressType is the output script type derived from the path purpose
+type addressType int
+
+const (
+	addressTypeP2PKH addressType = iota
+	addressTypeP2SHP2WPKH
+	addressTypeP2WPKH
+	addressTypeP2TR
+)
+
+// Adapter represents a Bitcoin blockchain adapter
+type Adapter struct {
+	logger             *slog.Logger
+	availableCoinTypes []uint16
+}
+
+// NewBitcoinAdapter creates a new Bitcoin adapter instance
+func NewBitcoinAdapter(logger *slog.Logger) *Adapter {
+	return &Adapter{
+		logger: logger,
+		availableCoinTypes: []uint16{
+			slip44.Bitcoin,
+			slip44.TestNet,
+		},
+	}
+}
+
+// CanDo checks if this adapter can handle the given coin type
+func (b *Adapter) CanDo(coinType uint16) bool {
+	return slices.Contains(b.availableCoinTypes, coinType)
+}
+
+// parseDerivationPath returns the address type selected by the purpose of the
+// path and the network selected by its coin type.
+// Only absolute paths (m/purpose'/coin_type'/...) are accepted.
+func (*Adapter) parseDerivationPath(path string) (addressType, *chaincfg.Params, error) {
+	components := strings.Split(path, "/")
+	if len(components) < minPathComponents || strings.TrimSpace(components[0]) != "m" {
+		return 0, nil, ErrInvalidDerivationPath
+	}
+
+	purpose, err := parseHardenedComponent(components[1])
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var addrType addressType
+	switch purpose {
+	case purposeP2PKH:
+		addrType = addressTypeP2PKH
+	case purposeP2SHP2WPK:
+		addrType = addressTypeP2SHP2WPKH
+	case purposeP2WPKH:
+		addrType = addressTypeP2WPKH
+	case purposeP2TR:
+		addrType = addressTypeP2TR
+	default:
+		return 0, nil, fmt.Errorf("%w: %d", ErrUnsupportedPurpose, purpose)
+	}
+
+	coinType, err := parseHardenedComponent(components[2])
+	if err != nil {
+		return 0, nil, err
+	}
+
+	switch coinType {
+	case uint64(slip44.Bitcoin):
+		return addrType, &chaincfg.MainNetParams, nil
+	case uint64(slip44.TestNet):
+		return addrType, &chaincfg.TestNet3Params, nil
+	default:
+		return 0, nil, fmt.Errorf("%w: %d", ErrUnsupportedCoinType, coinType)
+	}
+}
+
+func parseHardenedComponent(component string) (uint64, error) {
+	component = strings.TrimSpace(component)
+	if !strings.HasSuffix(component, "'") {
+		return 0, ErrInvalidDerivationPath
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSuffix(component, "'"), 10, 31)
+	if err != nil {
+		return 0, ErrInvalidDerivationPath
+	}
+	return value, nil
+}
+
+// deriveKeyForPath derives the key of derivationPath and the address type and
+// network it is used with. Development mode always selects testnet.
+func (b *Adapter) deriveKeyForPath(keys *lib.Keychain, derivationPath string, isDev bool) (
+	*btcec.PrivateKey, addressType, *chaincfg.Params, error) {
+	addrType, params, err := b.parseDerivationPath(derivationPath)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if isDev {
+		params = &chaincfg.TestNet3Params
+	}
+
+	privateKey, err := keys.DerivePrivateKey(derivationPath)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	return privateKey, addrType, params, nil
+}
+
+// addressForKey builds the address of the given type paying to pubKey
+func addressForKey(pubKey *btcec.PublicKey, addrType addressType,
+	params *chaincfg.Params) (btcutil.Address, error) {
+	pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+
+	switch addrType {
+	case addressTypeP2PKH:
+		return btcutil.NewAddressPubKeyHash(pubKeyHash, params)
+
+	case addressTypeP2SHP2WPKH:
+		redeemScript, err := p2wpkhScript(pubKeyHash)
+		if err != nil {
+			return nil, err
+		}
+		return btcutil.NewAddressScriptHash(redeemScript, params)
+
+	case addressTypeP2WPKH:
+		return btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+
+	case addressTypeP2TR:
+		// BIP-86: key path only output, committing to an empty script tree
+		outputKey := txscript.ComputeTaprootKeyNoScript(pubKey)
+		return btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), params)
+
+	default:
+		return nil, ErrUnsupportedPurpose
+	}
+}
+
+// p2wpkhScript returns the version 0 witness program for a public key hash
+func p2wpkhScript(pubKeyHash []byte) ([]byte, error) {
+	return txscript.NewScriptBuilder().
+		AddOp(txscript.OP_0).
+		AddData(pubKeyHash).
+		Script()
+}
+
+// DerivePrivateKey derives a private key from the given keychain and derivation path
+// and returns it in WIF format
+func (b *Adapter) DerivePrivateKey(keys *lib.Keychain, derivationPath string, isDev bool) (string, error) {
+	logger := b.logger.With(slog.String("op", "derive_private_key"), slog.String("derivationPath", derivationPath))
+	logger.Info("Deriving private key")
+
+	privateKey, _, params, err := b.deriveKeyForPath(keys, derivationPath, isDev)
+	if err != nil {
+		logger.Error("Failed to derive private key", "error", err)
+		return "", err
+	}
+
+	wif, err := btcutil.NewWIF(privateKey, params, true)
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info("Private key derived successfully")
+
+	return wif.String(), nil
+}
+
+// DerivePublicKey derives a compressed public key from the given keychain and derivation path
+func (b *Adapter) DerivePublicKey(keys *lib.Keychain, derivationPath string, isDev bool) (string, error) {
+	logger := b.logger.With(slog.String("op", "derive_public_key"), slog.String("derivationPath", derivationPath))
+	logger.Info("Deriving public key")
+
+	privateKey, _, _, err := b.deriveKeyForPath(keys, derivationPath, isDev)
+	if err != nil {
+		logger.Error("Failed to derive public key", "error", err)
+		return "", err
+	}
+
+	publicKeyHex := hex.EncodeToString(privateKey.PubKey().SerializeCompressed())
+	logger.Debug("Public key derived successfully", "publicKey", publicKeyHex)
+
+	return publicKeyHex, nil
+}
+
+// DeriveECPublicKey derives the secp256k1 public key of derivationPath
+func (b *Adapter) DeriveECPublicKey(keys *lib.Keychain, derivationPath string, isDev bool) (*btcec.PublicKey, error) {
+	privateKey, _, _, err := b.deriveKeyForPath(keys, derivationPath, isDev)
+	if err != nil {
+		return nil, err
+	}
+
+	return privateKey.PubKey(), nil
+}
+
+// ResolveDerivationPath returns the absolute path the keys of derivationPath
+// are derived at, derivationPath itself once its purpose is checked
+func (b *Adapter) ResolveDerivationPath(derivationPath string) (string, error) {
+	if _, _, err := b.parseDerivationPath(derivationPath); err != nil {
+		return "", err
+	}
+	return derivationPath, nil
+}
+
+// EncodePublicKey returns the compressed hex public key of publicKey, the key
+// of derivationPath, and its address of the type of the path purpose
+func (b *Adapter) EncodePublicKey(publicKey *btcec.PublicKey, derivationPath string,
+	isDev bool) (string, string, error) {
+	addrType, params, err := b.parseDerivationPath(derivationPath)
+	if err != nil {
+		return "", "", err
+	}
+	if isDev {
+		params = &chaincfg.TestNet3Params
+	}
+
+	address, err := addressForKey(publicKey, addrType, params)
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(publicKey.SerializeCompressed()), address.EncodeAddress(), nil
+}
+
+// DeriveAddress derives an address from the given keychain and derivation path.
+// The address type follows the purpose of the path (44, 49, 84 or 86).
+func (b *Adapter) DeriveAddress(keys *lib.Keychain, derivationPath string, isDev bool) (string, error) {
+	logger := b.logger.With(slog.String("op", "derive_address"), slog.String("derivationPath", derivationPath))
+	logger.Info("Deriving address")
+
+	privateKey, addrType, params, err := b.deriveKeyForPath(keys, derivationPath, isDev)
+	if err != nil {
+		logger.Error("Failed to derive address", "error", err)
+		return "", err
+	}
+
+	address, err := addressForKey(privateKey.PubKey(), addrType, params)
+	if err != nil {
+		logger.Error("Failed to derive address", "error", err)
+		return "", err
+	}
+
+	logger.Debug("Address derived successfully", "address", address.EncodeAddress())
+
+	return address.EncodeAddress(), nil
+}
+
+// createRawTransaction builds the unsigned transaction described by payloadString.
+// ownScript is the scriptPubKey of the signing key, every input must spend it.
+func (b *Adapter) createRawTransaction(payloadString string, ownScript []byte,
+	params *chaincfg.Params) (*wire.MsgTx, *txscript.MultiPrevOutFetcher, error) {
+	logger := b.logger.With(slog.String("op", "create_raw_transaction"))
+	logger.Info("Creating raw transaction")
+
+	var payload lib.BitcoinRawTx
+	if err := json.Unmarshal([]byte(payloadString), &payload); err != nil ||
+		reflect.DeepEqual(payload, lib.BitcoinRawTx{}) {
+		return nil, nil, fmt.Errorf("unable to decode payload: %w", ErrInvalidPayloadData)
+	}
+
+	if len(payload.Inputs) == 0 || len(payload.Outputs) == 0 {
+		return nil, nil, ErrInvalidPayloadData
+	}
+
+	tx := wire.NewMsgTx(txVersion)
+	tx.LockTime = payload.LockTime
+
+	// a final sequence number disables the lock time
+	sequence := uint32(wire.MaxTxInSequenceNum)
+	if payload.LockTime != 0 {
+		sequence = wire.MaxTxInSequenceNum - 1
+	}
+
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, len(payload.Inputs))
+
+	var totalIn int64
+	for _, input := range payload.Inputs {
+		hash, err := chainhash.NewHashFromStr(input.Txhash)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: txhash %q", ErrInvalidPayloadData, input.Txhash)
+		}
+		if input.Amount <= 0 {
+			return nil, nil, fmt.Errorf("%w: input amount must be positive", ErrInvalidPayloadData)
+		}
+
+		script := ownScript
+		if input.Script != "" {
+			script, err = hex.DecodeString(input.Script)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w: input script", ErrInvalidPayloadData)
+			}
+			if !bytes.Equal(script, ownScript) {
+				return nil, nil, ErrInputScriptMismatch
+			}
+		}
+
+		outPoint := wire.NewOutPoint(hash, input.Vout)
+		if _, ok := prevOuts[*outPoint]; ok {
+			return nil, nil, fmt.Errorf("%w: duplicate input %s", ErrInvalidPayloadData, outPoint)
+		}
+		prevOuts[*outPoint] = wire.NewTxOut(input.Amount, script)
+
+		txIn := wire.NewTxIn(outPoint, nil, nil)
+		txIn.Sequence = sequence
+		tx.AddTxIn(txIn)
+
+		totalIn += input.Amount
+	}
+
+	var totalOut int64
+	for _, output := range payload.Outputs {
+		address, err := btcutil.DecodeAddress(output.Address, params)
+		if err != nil || !address.IsForNet(params) {
+			return nil, nil, fmt.Errorf("%w: %s", ErrInvalidAddress, output.Address)
+		}
+		if output.Amount <= 0 {
+			return nil, nil, fmt.Errorf("%w: output amount must be positive", ErrInvalidPayloadData)
+		}
+
+		pkScript, err := txscript.PayToAddrScript(address)
+		if err != nil {
+			return nil, nil, err
+		}
+		tx.AddTxOut(wire.NewTxOut(output.Amount, pkScript))
+
+		totalOut += output.Amount
+	}
+
+	if totalOut > totalIn {
+		return nil, nil, ErrInsufficientInputs
+	}
+
+	logger.Info("validate payload", "inputs", len(tx.TxIn), "outputs", len(tx.TxOut), "fee", totalIn-totalOut)
+
+	return tx, txscript.NewMultiPrevOutFetcher(prevOuts), nil
+}
+
+// CreateSignedTransaction signs every input of the payload with the key of
+// derivationPath and returns the serialized transaction hex, ready for broadcast.
+func (b *Adapter) CreateSignedTransaction(keys *lib.Keychain, derivationPath, payload string, isDev bool) (string, error) {
+	logger := b.logger.With(slog.String("op", "create_signed_transaction"), slog.String("derivationPath", derivationPath))
+	logger.Info("Creating signed transaction")
+
+	privateKey, addrType, params, err := b.deriveKeyForPath(keys, derivationPath, isDev)
+	if err != nil {
+		logger.Error("Failed to derive private key", "error", err)
+		return "", err
+	}
+
+	address, err := addressForKey(privateKey.PubKey(), addrType, params)
+	if err != nil {
+		return "", err
+	}
+
+	ownScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return "", err
+	}
+
+	tx, prevOutFetcher, err := b.createRawTransaction(payload, ownScript, params)
+	if err != nil {
+		logger.Error("Failed to create raw transaction", "error", err)
+		return "", err
+	}
+
+	sigHashes := txscript.NewTxSigHashes(tx, prevOutFetcher)
+	for idx, txIn := range tx.TxIn {
+		prevOut := prevOutFetcher.FetchPrevOutput(txIn.PreviousOutPoint)
+		if err := signInput(tx, sigHashes, idx, prevOut, addrType, privateKey); err != nil {
+			logger.Error("Failed to sign input", "error", err, "index", idx)
+			return "", err
+		}
+	}
+
+	var signedTxBuff bytes.Buffer
+	if err := tx.Serialize(&signedTxBuff); err != nil {
+		return "", err
+	}
+	txHex := hex.EncodeToString(signedTxBuff.Bytes())
+
+	logger.Info("Signed transaction created successfully", "txHash", tx.TxHash().String())
+
+	return txHex, nil
+}
+
+// signInput fills the signature script and/or witness of input idx
+func signInput(tx *wire.MsgTx, sigHashes *txscript.TxSigHashes, idx int, prevOut *wire.TxOut,
+	addrType addressType, privateKey *btcec.PrivateKey) error {
+	switch addrType {
+	case addressTypeP2PKH:
+		sigScript, err := txscript.SignatureScript(tx, idx, prevOut.PkScript, txscript.SigHashAll, privateKey, true)
+		if err != nil {
+			return err
+		}
+		tx.TxIn[idx].SignatureScript = sigScript
+
+	case addressTypeP2SHP2WPKH:
+		redeemScript, err := p2wpkhScript(btcutil.Hash160(privateKey.PubKey().SerializeCompressed()))
+		if err != nil {
+			return err
+		}
+		witness, err := txscript.WitnessSignature(tx, sigHashes, idx, prevOut.Value, redeemScript,
+			txscript.SigHashAll, privateKey, true)
+		if err != nil {
+			return err
+		}
+		sigScript, err := txscript.NewScriptBuilder().AddData(redeemScript).Script()
+		if err != nil {
+			return err
+		}
+		tx.TxIn[idx].SignatureScript = sigScript
+		tx.TxIn[idx].Witness = witness
+
+	case addressTypeP2WPKH:
+		witness, err := txscript.WitnessSignature(tx, sigHashes, idx, prevOut.Value, prevOut.PkScript,
+			txscript.SigHashAll, privateKey, true)
+		if err != nil {
+			return err
+		}
+		tx.TxIn[idx].Witness = witness
+
+	case addressTypeP2TR:
+		witness, err := txscript.TaprootWitnessSignature(tx, sigHashes, idx, prevOut.Value, prevOut.PkScript,
+			txscript.SigHashDefault, privateKey)
+		if err != nil {
+			return err
+		}
+		tx.TxIn[idx].Witness = witness
+
+	default:
+		return ErrUnsupportedPurpose
+	}
+
+	return nil
+}
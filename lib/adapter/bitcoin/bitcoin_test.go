@@ -0,0 +1,406 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+
+	"github.com/payment-system/dq-vault/lib"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+const (
+	testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	testTxHash   = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+)
+
+var (
+	testSeed = bip39.NewSeed(testMnemonic, "")
+	logger   = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+)
+
+func TestBitcoinAdapter_CanDo(t *testing.T) {
+	adapter := NewBitcoinAdapter(logger)
+
+	tests := []struct {
+		name     string
+		coinType uint16
+		expected bool
+	}{
+		{name: "bitcoin supported", coinType: slip44.Bitcoin, expected: true},
+		{name: "testnet supported", coinType: slip44.TestNet, expected: true},
+		{name: "ethereum not supported", coinType: slip44.Ether, expected: false},
+		{name: "tron not supported", coinType: slip44.Tron, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, adapter.CanDo(tt.coinType))
+		})
+	}
+}
+
+// Test vectors from BIP-44/49/84/86 for the "abandon ... about" mnemonic
+func TestBitcoinAdapter_DeriveAddress(t *testing.T) {
+	adapter := NewBitcoinAdapter(logger)
+
+	tests := []struct {
+		name           string
+		derivationPath string
+		expected       string
+		expectedError  error
+	}{
+		{
+			name:           "bip44 p2pkh",
+			derivationPath: "m/44'/0'/0'/0/0",
+			expected:       "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA",
+		},
+		{
+			name:           "bip49 p2sh-p2wpkh",
+			derivationPath: "m/49'/0'/0'/0/0",
+			expected:       "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf",
+		},
+		{
+			name:           "bip84 p2wpkh",
+			derivationPath: "m/84'/0'/0'/0/0",
+			expected:       "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu",
+		},
+		{
+			name:           "bip86 p2tr",
+			derivationPath: "m/86'/0'/0'/0/0",
+			expected:       "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+		},
+		{
+			name:           "testnet bip44 p2pkh",
+			derivationPath: "m/44'/1'/0'/0/0",
+			expected:       "mkpZhYtJu2r87Js3pDiWJDmPte2NRZ8bJV",
+		},
+		{
+			name:           "testnet bip84 p2wpkh",
+			derivationPath: "m/84'/1'/0'/0/0",
+			expected:       "tb1q6rz28mcfaxtmd6v789l9rrlrusdprr9pqcpvkl",
+		},
+		{
+			name:           "unsupported purpose",
+			derivationPath: "m/45'/0'/0'/0/0",
+			expectedError:  ErrUnsupportedPurpose,
+		},
+		{
+			name:           "ethereum coin type",
+			derivationPath: "m/44'/60'/0'/0/0",
+			expectedError:  ErrUnsupportedCoinType,
+		},
+		{
+			name:           "non hardened purpose",
+			derivationPath: "m/84/0'/0'/0/0",
+			expectedError:  ErrInvalidDerivationPath,
+		},
+		{
+			name:           "relative path",
+			derivationPath: "0'/0/0",
+			expectedError:  ErrInvalidDerivationPath,
+		},
+		{
+			name:           "empty path",
+			derivationPath: "",
+			expectedError:  ErrInvalidDerivationPath,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, err := adapter.DeriveAddress(lib.NewSeedKeychain(testSeed), tt.derivationPath, false)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Empty(t, address)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, address)
+		})
+	}
+}
+
+func TestBitcoinAdapter_DeriveKeys(t *testing.T) {
+	adapter := NewBitcoinAdapter(logger)
+
+	t.Run("private key in wif format", func(t *testing.T) {
+		privateKey, err := adapter.DerivePrivateKey(lib.NewSeedKeychain(testSeed), "m/84'/0'/0'/0/0", false)
+		require.NoError(t, err)
+		assert.Equal(t, "KyZpNDKnfs94vbrwhJneDi77V6jF64PWPF8x5cdJb8ifgg2DUc9d", privateKey)
+
+		wif, err := btcutil.DecodeWIF(privateKey)
+		require.NoError(t, err)
+		assert.True(t, wif.IsForNet(&chaincfg.MainNetParams))
+	})
+
+	t.Run("testnet private key", func(t *testing.T) {
+		privateKey, err := adapter.DerivePrivateKey(lib.NewSeedKeychain(testSeed), "m/84'/1'/0'/0/0", false)
+		require.NoError(t, err)
+
+		wif, err := btcutil.DecodeWIF(privateKey)
+		require.NoError(t, err)
+		assert.True(t, wif.IsForNet(&chaincfg.TestNet3Params))
+	})
+
+	t.Run("development mode selects testnet", func(t *testing.T) {
+		privateKey, err := adapter.DerivePrivateKey(lib.NewSeedKeychain(testSeed), "m/84'/0'/0'/0/0", true)
+		require.NoError(t, err)
+
+		wif, err := btcutil.DecodeWIF(privateKey)
+		require.NoError(t, err)
+		assert.True(t, wif.IsForNet(&chaincfg.TestNet3Params))
+
+		address, err := adapter.DeriveAddress(lib.NewSeedKeychain(testSeed), "m/84'/0'/0'/0/0", true)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(address, "tb1q"))
+	})
+
+	t.Run("compressed public key", func(t *testing.T) {
+		publicKey, err := adapter.DerivePublicKey(lib.NewSeedKeychain(testSeed), "m/84'/0'/0'/0/0", false)
+		require.NoError(t, err)
+		assert.Equal(t, "0330d54fd0dd420a6e5f8d3624f5f3482cae350f79d5f0753bf5beef9c2d91af3c", publicKey)
+	})
+}
+
+// createPayload builds a BitcoinRawTx JSON payload
+func createPayload(t *testing.T, inputs []map[string]interface{}, outputs []map[string]interface{},
+	lockTime uint32) string {
+	t.Helper()
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"inputs":   inputs,
+		"outputs":  outputs,
+		"lockTime": lockTime,
+	})
+	require.NoError(t, err)
+	return string(payload)
+}
+
+// verifyTransaction executes the script of every input against its previous output
+func verifyTransaction(t *testing.T, txHex string, prevOuts map[wire.OutPoint]*wire.TxOut) *wire.MsgTx {
+	t.Helper()
+
+	rawTx, err := hex.DecodeString(txHex)
+	require.NoError(t, err)
+
+	var tx wire.MsgTx
+	require.NoError(t, tx.Deserialize(bytes.NewReader(rawTx)))
+
+	fetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	sigHashes := txscript.NewTxSigHashes(&tx, fetcher)
+	for idx, txIn := range tx.TxIn {
+		prevOut := fetcher.FetchPrevOutput(txIn.PreviousOutPoint)
+		require.NotNil(t, prevOut)
+
+		vm, err := txscript.NewEngine(prevOut.PkScript, &tx, idx, txscript.StandardVerifyFlags,
+			nil, sigHashes, prevOut.Value, fetcher)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute(), "input %d failed verification", idx)
+	}
+	return &tx
+}
+
+func TestBitcoinAdapter_CreateSignedTransaction(t *testing.T) {
+	adapter := NewBitcoinAdapter(logger)
+
+	paths := []struct {
+		name           string
+		derivationPath string
+		destination    string
+	}{
+		{name: "p2pkh", derivationPath: "m/44'/0'/0'/0/0", destination: "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+		{name: "p2sh-p2wpkh", derivationPath: "m/49'/0'/0'/0/0", destination: "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"},
+		{name: "p2wpkh", derivationPath: "m/84'/0'/0'/0/0", destination: "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf"},
+		{
+			name:           "p2tr",
+			derivationPath: "m/86'/0'/0'/0/0",
+			destination:    "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+		},
+		{name: "testnet p2wpkh", derivationPath: "m/84'/1'/0'/0/0", destination: "mkpZhYtJu2r87Js3pDiWJDmPte2NRZ8bJV"},
+	}
+
+	for _, tt := range paths {
+		t.Run(tt.name, func(t *testing.T) {
+			ownAddress, err := adapter.DeriveAddress(lib.NewSeedKeychain(testSeed), tt.derivationPath, false)
+			require.NoError(t, err)
+
+			_, params, err := adapter.parseDerivationPath(tt.derivationPath)
+			require.NoError(t, err)
+			decoded, err := btcutil.DecodeAddress(ownAddress, params)
+			require.NoError(t, err)
+			ownScript, err := txscript.PayToAddrScript(decoded)
+			require.NoError(t, err)
+
+			payload := createPayload(t,
+				[]map[string]interface{}{
+					{"txhash": testTxHash, "vout": 0, "amount": 100000, "script": hex.EncodeToString(ownScript)},
+					{"txhash": testTxHash, "vout": 1, "amount": 50000},
+				},
+				[]map[string]interface{}{
+					{"address": tt.destination, "amount": 120000},
+					{"address": ownAddress, "amount": 29000},
+				}, 0)
+
+			txHex, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(testSeed), tt.derivationPath, payload, false)
+			require.NoError(t, err)
+
+			hash, err := chainhash.NewHashFromStr(testTxHash)
+			require.NoError(t, err)
+			tx := verifyTransaction(t, txHex, map[wire.OutPoint]*wire.TxOut{
+				*wire.NewOutPoint(hash, 0): wire.NewTxOut(100000, ownScript),
+				*wire.NewOutPoint(hash, 1): wire.NewTxOut(50000, ownScript),
+			})
+
+			assert.Len(t, tx.TxIn, 2)
+			assert.Len(t, tx.TxOut, 2)
+			assert.Equal(t, int64(120000), tx.TxOut[0].Value)
+			assert.Equal(t, uint32(wire.MaxTxInSequenceNum), tx.TxIn[0].Sequence)
+		})
+	}
+
+	t.Run("lock time enables sequence", func(t *testing.T) {
+		payload := createPayload(t,
+			[]map[string]interface{}{{"txhash": testTxHash, "vout": 0, "amount": 100000}},
+			[]map[string]interface{}{{"address": "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA", "amount": 90000}},
+			800000)
+
+		txHex, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(testSeed), "m/84'/0'/0'/0/0", payload, false)
+		require.NoError(t, err)
+
+		rawTx, err := hex.DecodeString(txHex)
+		require.NoError(t, err)
+		var tx wire.MsgTx
+		require.NoError(t, tx.Deserialize(bytes.NewReader(rawTx)))
+		assert.Equal(t, uint32(800000), tx.LockTime)
+		assert.Equal(t, uint32(wire.MaxTxInSequenceNum-1), tx.TxIn[0].Sequence)
+	})
+}
+
+func TestBitcoinAdapter_CreateSignedTransaction_Errors(t *testing.T) {
+	adapter := NewBitcoinAdapter(logger)
+
+	const derivationPath = "m/84'/0'/0'/0/0"
+	validInput := map[string]interface{}{"txhash": testTxHash, "vout": 0, "amount": 100000}
+	validOutput := map[string]interface{}{"address": "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA", "amount": 90000}
+
+	tests := []struct {
+		name           string
+		derivationPath string
+		payload        string
+		expectedError  error
+	}{
+		{
+			name:           "malformed json",
+			derivationPath: derivationPath,
+			payload:        `{invalid json}`,
+			expectedError:  ErrInvalidPayloadData,
+		},
+		{
+			name:           "empty payload",
+			derivationPath: derivationPath,
+			payload:        `{}`,
+			expectedError:  ErrInvalidPayloadData,
+		},
+		{
+			name:           "no inputs",
+			derivationPath: derivationPath,
+			payload:        createPayload(t, nil, []map[string]interface{}{validOutput}, 0),
+			expectedError:  ErrInvalidPayloadData,
+		},
+		{
+			name:           "no outputs",
+			derivationPath: derivationPath,
+			payload:        createPayload(t, []map[string]interface{}{validInput}, nil, 0),
+			expectedError:  ErrInvalidPayloadData,
+		},
+		{
+			name:           "invalid txhash",
+			derivationPath: derivationPath,
+			payload: createPayload(t,
+				[]map[string]interface{}{{"txhash": "zz", "vout": 0, "amount": 100000}},
+				[]map[string]interface{}{validOutput}, 0),
+			expectedError: ErrInvalidPayloadData,
+		},
+		{
+			name:           "missing input amount",
+			derivationPath: derivationPath,
+			payload: createPayload(t,
+				[]map[string]interface{}{{"txhash": testTxHash, "vout": 0}},
+				[]map[string]interface{}{validOutput}, 0),
+			expectedError: ErrInvalidPayloadData,
+		},
+		{
+			name:           "duplicate input",
+			derivationPath: derivationPath,
+			payload: createPayload(t,
+				[]map[string]interface{}{validInput, validInput},
+				[]map[string]interface{}{validOutput}, 0),
+			expectedError: ErrInvalidPayloadData,
+		},
+		{
+			name:           "foreign input script",
+			derivationPath: derivationPath,
+			payload: createPayload(t,
+				[]map[string]interface{}{{
+					"txhash": testTxHash, "vout": 0, "amount": 100000,
+					"script": "0014000102030405060708090a0b0c0d0e0f10111213",
+				}},
+				[]map[string]interface{}{validOutput}, 0),
+			expectedError: ErrInputScriptMismatch,
+		},
+		{
+			name:           "testnet output on mainnet",
+			derivationPath: derivationPath,
+			payload: createPayload(t,
+				[]map[string]interface{}{validInput},
+				[]map[string]interface{}{{"address": "tb1q6rz28mcfaxtmd6v789l9rrlrusdprr9pqcpvkl", "amount": 90000}}, 0),
+			expectedError: ErrInvalidAddress,
+		},
+		{
+			name:           "zero output amount",
+			derivationPath: derivationPath,
+			payload: createPayload(t,
+				[]map[string]interface{}{validInput},
+				[]map[string]interface{}{{"address": "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA", "amount": 0}}, 0),
+			expectedError: ErrInvalidPayloadData,
+		},
+		{
+			name:           "outputs exceed inputs",
+			derivationPath: derivationPath,
+			payload: createPayload(t,
+				[]map[string]interface{}{validInput},
+				[]map[string]interface{}{{"address": "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA", "amount": 100001}}, 0),
+			expectedError: ErrInsufficientInputs,
+		},
+		{
+			name:           "invalid derivation path",
+			derivationPath: "m/44'/60'/0'/0/0",
+			payload:        createPayload(t, []map[string]interface{}{validInput}, []map[string]interface{}{validOutput}, 0),
+			expectedError:  ErrUnsupportedCoinType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txHex, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(testSeed), tt.derivationPath, tt.payload, false)
+			assert.ErrorIs(t, err, tt.expectedError)
+			assert.Empty(t, txHex)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/payment-system/dq-vault/lib"
+)
+
+const (
+	// PSBTBase64Magic is the base64 encoding of the PSBT magic bytes "psbt\xff",
+	// the prefix of base64 encoded PSBT payloads
+	PSBTBase64Magic = "cHNidP8"
+
+	// transaction types reported by DecodeTransaction
+	txTypeTransfer = "UTXO Transfer"
+	txTypePSBT     = "PSBT"
+)
+
+// DecodeTransaction extracts the outputs of a JSON payload or a base64 encoded
+// PSBT without deriving any key. Addresses are returned in their canonical
+// encoding. PSBT output scripts are encoded for mainnet, or testnet in
+// development mode. Change outputs are reported like any other output. The fee
+// is reported when every input carries its amount.
+func (b *Adapter) DecodeTransaction(payload string, isDev bool) (*lib.TransactionSummary, error) {
+	logger := b.logger.With(slog.String("op", "decode_transaction"))
+	logger.Info("Decoding transaction")
+
+	params := &chaincfg.MainNetParams
+	if isDev {
+		params = &chaincfg.TestNet3Params
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(payload), PSBTBase64Magic) {
+		return decodePSBTOutputs(strings.TrimSpace(payload), params)
+	}
+
+	var rawTx lib.BitcoinRawTx
+	if err := json.Unmarshal([]byte(payload), &rawTx); err != nil ||
+		reflect.DeepEqual(rawTx, lib.BitcoinRawTx{}) {
+		return nil, fmt.Errorf("unable to decode payload: %w", ErrInvalidPayloadData)
+	}
+	if len(rawTx.Outputs) == 0 {
+		return nil, ErrInvalidPayloadData
+	}
+
+	summary := &lib.TransactionSummary{Type: txTypeTransfer}
+	outputsTotal := int64(0)
+	for _, output := range rawTx.Outputs {
+		address, err := decodeAnyNetAddress(output.Address, params)
+		if err != nil {
+			return nil, err
+		}
+		if output.Amount <= 0 {
+			return nil, fmt.Errorf("%w: output amount must be positive", ErrInvalidPayloadData)
+		}
+
+		summary.Transfers = append(summary.Transfers, lib.Transfer{
+			To:    address.EncodeAddress(),
+			Value: big.NewInt(output.Amount),
+		})
+		outputsTotal += output.Amount
+	}
+
+	inputsTotal := int64(0)
+	for _, input := range rawTx.Inputs {
+		if input.Amount <= 0 {
+			return summary, nil
+		}
+		inputsTotal += input.Amount
+	}
+	if len(rawTx.Inputs) > 0 && inputsTotal >= outputsTotal {
+		summary.Fee.Amount = big.NewInt(inputsTotal - outputsTotal)
+	}
+
+	return summary, nil
+}
+
+// decodePSBTOutputs reports the outputs of the unsigned transaction of a PSBT
+func decodePSBTOutputs(payload string, params *chaincfg.Params) (*lib.TransactionSummary, error) {
+	packet, err := psbt.NewFromRawBytes(strings.NewReader(payload), true)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPSBT, err)
+	}
+
+	summary := &lib.TransactionSummary{Type: txTypePSBT}
+	for idx, txOut := range packet.UnsignedTx.TxOut {
+		_, addresses, _, err := txscript.ExtractPkScriptAddrs(txOut.PkScript, params)
+		if err != nil || len(addresses) != 1 {
+			return nil, fmt.Errorf("%w: output %d", ErrUnsupportedScript, idx)
+		}
+
+		summary.Transfers = append(summary.Transfers, lib.Transfer{
+			To:    addresses[0].EncodeAddress(),
+			Value: big.NewInt(txOut.Value),
+		})
+	}
+
+	// inputs without UTXO information leave the fee unknown
+	if fee, err := packet.GetTxFee(); err == nil && fee >= 0 {
+		summary.Fee.Amount = big.NewInt(int64(fee))
+	}
+
+	return summary, nil
+}
+
+// decodeAnyNetAddress decodes a mainnet or testnet address, preferring params.
+// Testnet paths (coin type 1') are signed for testnet outside development mode.
+func decodeAnyNetAddress(encoded string, params *chaincfg.Params) (btcutil.Address, error) {
+	for _, net := range []*chaincfg.Params{params, &chaincfg.MainNetParams, &chaincfg.TestNet3Params} {
+		address, err := btcutil.DecodeAddress(encoded, net)
+		if err == nil && address.IsForNet(net) {
+			return address, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, encoded)
+}
+
+// TransactionHash returns the id of a transaction signed by
+// CreateSignedTransaction, or of the unsigned transaction of a signed PSBT.
+// Witnesses are not part of it, so segwit ids are final once the PSBT is finalised.
+func (b *Adapter) TransactionHash(_ string, signedTx string, _ bool) (string, error) {
+	if strings.HasPrefix(signedTx, PSBTBase64Magic) {
+		packet, err := psbt.NewFromRawBytes(strings.NewReader(signedTx), true)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrInvalidPSBT, err)
+		}
+		return packet.UnsignedTx.TxHash().String(), nil
+	}
+
+	data, err := hex.DecodeString(signedTx)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidPayloadData, err)
+	}
+
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidPayloadData, err)
+	}
+	return tx.TxHash().String(), nil
+}
@@ -0,0 +1,144 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/lib"
+)
+
+// first BIP-84 receive address of the test mnemonic
+const testP2WPKHAddress = "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"
+
+func TestBitcoinAdapter_DecodeTransaction(t *testing.T) {
+	adapter := NewBitcoinAdapter(logger)
+
+	input := map[string]interface{}{"txhash": testTxHash, "vout": 0, "amount": 100000}
+
+	tests := []struct {
+		name    string
+		payload string
+		isDev   bool
+		want    *lib.TransactionSummary
+		wantErr error
+	}{
+		{
+			name: "outputs in canonical encoding",
+			payload: createPayload(t, []map[string]interface{}{input}, []map[string]interface{}{
+				{"address": strings.ToUpper(testP2WPKHAddress), "amount": 60000},
+				{"address": "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "amount": 30000},
+			}, 0),
+			want: &lib.TransactionSummary{
+				Type: txTypeTransfer,
+				Transfers: []lib.Transfer{
+					{To: testP2WPKHAddress, Value: big.NewInt(60000)},
+					{To: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Value: big.NewInt(30000)},
+				},
+				Fee: lib.Fee{Amount: big.NewInt(10000)},
+			},
+		},
+		{
+			name: "testnet output",
+			payload: createPayload(t, []map[string]interface{}{input}, []map[string]interface{}{
+				{"address": "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", "amount": 1000},
+			}, 0),
+			isDev: true,
+			want: &lib.TransactionSummary{
+				Type: txTypeTransfer,
+				Transfers: []lib.Transfer{
+					{To: "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", Value: big.NewInt(1000)},
+				},
+				Fee: lib.Fee{Amount: big.NewInt(99000)},
+			},
+		},
+		{
+			name: "inputs without amount leave the fee unknown",
+			payload: createPayload(t, []map[string]interface{}{{"txhash": testTxHash, "vout": 0}},
+				[]map[string]interface{}{{"address": testP2WPKHAddress, "amount": 1000}}, 0),
+			want: &lib.TransactionSummary{
+				Type:      txTypeTransfer,
+				Transfers: []lib.Transfer{{To: testP2WPKHAddress, Value: big.NewInt(1000)}},
+			},
+		},
+		{
+			name: "invalid output address",
+			payload: createPayload(t, []map[string]interface{}{input}, []map[string]interface{}{
+				{"address": "not-an-address", "amount": 1000},
+			}, 0),
+			wantErr: ErrInvalidAddress,
+		},
+		{
+			name:    "invalid payload",
+			payload: `{"invalid": "json"}`,
+			wantErr: ErrInvalidPayloadData,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := adapter.DecodeTransaction(tt.payload, tt.isDev)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("psbt outputs", func(t *testing.T) {
+		fixture := createPSBTFixture(t)
+
+		got, err := adapter.DecodeTransaction(fixture.encode(t), false)
+		require.NoError(t, err)
+		assert.Equal(t, &lib.TransactionSummary{
+			Type:      txTypePSBT,
+			Transfers: []lib.Transfer{{To: testP2WPKHAddress, Value: big.NewInt(240000)}},
+			Fee:       lib.Fee{Amount: big.NewInt(10000)},
+		}, got)
+	})
+}
+
+func TestBitcoinAdapter_TransactionHash(t *testing.T) {
+	adapter := NewBitcoinAdapter(logger)
+
+	t.Run("signed transaction", func(t *testing.T) {
+		payload := createPayload(t,
+			[]map[string]interface{}{{"txhash": testTxHash, "vout": 0, "amount": 100000}},
+			[]map[string]interface{}{{"address": "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA", "amount": 90000}}, 0)
+		txHex, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(testSeed), "m/84'/0'/0'/0/0", payload, false)
+		require.NoError(t, err)
+
+		rawTx, err := hex.DecodeString(txHex)
+		require.NoError(t, err)
+		var tx wire.MsgTx
+		require.NoError(t, tx.Deserialize(bytes.NewReader(rawTx)))
+
+		got, err := adapter.TransactionHash(payload, txHex, false)
+		require.NoError(t, err)
+		assert.Equal(t, tx.TxHash().String(), got)
+	})
+
+	t.Run("signed psbt", func(t *testing.T) {
+		fixture := createPSBTFixture(t)
+		signed, err := adapter.SignPartialTransaction(lib.NewSeedKeychain(testSeed), psbtAccounts[0], fixture.encode(t))
+		require.NoError(t, err)
+
+		got, err := adapter.TransactionHash(fixture.encode(t), signed, false)
+		require.NoError(t, err)
+		assert.Equal(t, fixture.packet.UnsignedTx.TxHash().String(), got)
+	})
+
+	t.Run("invalid transaction", func(t *testing.T) {
+		_, err := adapter.TransactionHash("", "zz", false)
+		assert.ErrorIs(t, err, ErrInvalidPayloadData)
+	})
+}
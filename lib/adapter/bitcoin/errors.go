@@ -0,0 +1,22 @@
+package bitcoin
+
+import "errors"
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrInvalidDerivationPath = errors.New("invalid derivation path")
+	ErrUnsupportedPurpose    = errors.New("unsupported derivation path purpose")
+	ErrUnsupportedCoinType   = errors.New("unsupported coin type in derivation path")
+	ErrInvalidPayloadData    = errors.New("invalid payload data")
+	ErrInvalidAddress        = errors.New("invalid output address")
+	ErrInsufficientInputs    = errors.New("outputs exceed inputs")
+	ErrInputScriptMismatch   = errors.New("input script is not spendable by the derived key")
+	ErrInvalidPSBT           = errors.New("invalid psbt")
+	ErrMissingUtxo           = errors.New("psbt input is missing its previous output")
+	ErrUnsupportedScript     = errors.New("unsupported input script")
+	ErrNoOwnedInputs         = errors.New("no psbt input can be signed with this key")
+	// ErrUnsupportedPSBTVersion is returned for PSBTs other than version 0,
+	// such as version 2 PSBTs (BIP-370)
+	ErrUnsupportedPSBTVersion = errors.New("unsupported psbt version")
+	ErrPSBTScopeNotAccount    = errors.New("psbt derivation path must lie within an account")
+)
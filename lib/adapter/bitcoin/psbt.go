@@ -0,0 +1,349 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/payment-system/dq-vault/lib"
+)
+
+const (
+	// psbtGlobalVersion is the key type of PSBT_GLOBAL_VERSION (BIP-370)
+	psbtGlobalVersion = 0xfb
+	psbtVersionLength = 4
+)
+
+// psbtMagic starts every serialized PSBT
+var psbtMagic = []byte("psbt\xff")
+
+// SignPartialTransaction adds signatures to a base64 encoded PSBT (BIP-174).
+//
+// Inputs are matched through their BIP-32 derivation records: only records
+// carrying the master fingerprint of keys, whose public key matches the key
+// derived from keys and whose path lies under derivationPath are signed.
+// derivationPath must lie within a Bitcoin account, m/purpose'/coin_type'/account',
+// so a PSBT cannot select keys of other coins or accounts.
+// Inputs owned by other signers are left untouched. The updated PSBT is
+// returned base64 encoded. Only version 0 PSBTs are supported.
+func (b *Adapter) SignPartialTransaction(keys *lib.Keychain, derivationPath, payload string) (string, error) {
+	logger := b.logger.With(slog.String("op", "sign_partial_transaction"), slog.String("derivationPath", derivationPath))
+	logger.Info("Signing partial transaction")
+
+	scope := strings.TrimSpace(derivationPath)
+	if _, ok := lib.AccountPath(scope); !ok {
+		return "", fmt.Errorf("%w: %s", ErrPSBTScopeNotAccount, scope)
+	}
+	if _, _, err := b.parseDerivationPath(scope); err != nil {
+		return "", err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidPSBT, err)
+	}
+	version, err := psbtVersion(raw)
+	if err != nil {
+		return "", err
+	}
+	if version != 0 {
+		return "", fmt.Errorf("%w: %d", ErrUnsupportedPSBTVersion, version)
+	}
+
+	packet, err := psbt.NewFromRawBytes(bytes.NewReader(raw), false)
+	if err != nil {
+		logger.Error("Failed to decode psbt", "error", err)
+		return "", fmt.Errorf("%w: %w", ErrInvalidPSBT, err)
+	}
+
+	fingerprintBytes, err := keys.MasterKeyFingerprint()
+	if err != nil {
+		return "", err
+	}
+	fingerprint := binary.LittleEndian.Uint32(fingerprintBytes)
+
+	prevOuts, err := psbtPrevOuts(packet)
+	if err != nil {
+		return "", err
+	}
+	fetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	sigHashes := txscript.NewTxSigHashes(packet.UnsignedTx, fetcher)
+
+	updater, err := psbt.NewUpdater(packet)
+	if err != nil {
+		return "", err
+	}
+
+	signer := &psbtSigner{
+		keys:        keys,
+		scope:       scope,
+		fingerprint: fingerprint,
+		packet:      packet,
+		updater:     updater,
+		sigHashes:   sigHashes,
+		fetcher:     fetcher,
+	}
+
+	signed := 0
+	for idx := range packet.Inputs {
+		n, err := signer.signInput(idx)
+		if err != nil {
+			logger.Error("Failed to sign input", "error", err, "index", idx)
+			return "", err
+		}
+		signed += n
+	}
+
+	if signed == 0 {
+		return "", ErrNoOwnedInputs
+	}
+
+	signedPSBT, err := packet.B64Encode()
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info("Partial transaction signed successfully", "signatures", signed,
+		"txHash", packet.UnsignedTx.TxHash().String())
+
+	return signedPSBT, nil
+}
+
+// psbtVersion returns the PSBT_GLOBAL_VERSION of a serialized PSBT, 0 when
+// the global map has no version
+func psbtVersion(raw []byte) (uint32, error) {
+	if !bytes.HasPrefix(raw, psbtMagic) {
+		return 0, ErrInvalidPSBT
+	}
+
+	r := bytes.NewReader(raw[len(psbtMagic):])
+	for {
+		key, err := wire.ReadVarBytes(r, 0, psbt.MaxPsbtKeyLength, "psbt key")
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrInvalidPSBT, err)
+		}
+		// the global map ends with an empty key
+		if len(key) == 0 {
+			return 0, nil
+		}
+		value, err := wire.ReadVarBytes(r, 0, psbt.MaxPsbtValueLength, "psbt value")
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrInvalidPSBT, err)
+		}
+		if key[0] == psbtGlobalVersion {
+			if len(key) != 1 || len(value) != psbtVersionLength {
+				return 0, ErrInvalidPSBT
+			}
+			return binary.LittleEndian.Uint32(value), nil
+		}
+	}
+}
+
+// psbtPrevOuts collects the previous output of every input of the packet
+func psbtPrevOuts(packet *psbt.Packet) (map[wire.OutPoint]*wire.TxOut, error) {
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, len(packet.Inputs))
+
+	for idx, pInput := range packet.Inputs {
+		outPoint := packet.UnsignedTx.TxIn[idx].PreviousOutPoint
+
+		switch {
+		case pInput.WitnessUtxo != nil:
+			prevOuts[outPoint] = pInput.WitnessUtxo
+
+		case pInput.NonWitnessUtxo != nil:
+			if pInput.NonWitnessUtxo.TxHash() != outPoint.Hash ||
+				int(outPoint.Index) >= len(pInput.NonWitnessUtxo.TxOut) {
+				return nil, fmt.Errorf("%w: input %d", ErrInvalidPSBT, idx)
+			}
+			prevOuts[outPoint] = pInput.NonWitnessUtxo.TxOut[outPoint.Index]
+
+		default:
+			return nil, fmt.Errorf("%w: input %d", ErrMissingUtxo, idx)
+		}
+	}
+
+	return prevOuts, nil
+}
+
+// psbtSigner holds the state shared by the inputs of one PSBT signing request
+type psbtSigner struct {
+	keys        *lib.Keychain
+	scope       string
+	fingerprint uint32
+	packet      *psbt.Packet
+	updater     *psbt.Updater
+	sigHashes   *txscript.TxSigHashes
+	fetcher     *txscript.MultiPrevOutFetcher
+}
+
+// ownedKey derives the key of a derivation record and reports whether it belongs to the keychain
+func (s *psbtSigner) ownedKey(fingerprint uint32, bip32Path []uint32) (*btcec.PrivateKey, bool, error) {
+	if fingerprint != s.fingerprint {
+		return nil, false, nil
+	}
+
+	path := formatDerivationPath(bip32Path)
+	if path != s.scope && !strings.HasPrefix(path, s.scope+"/") {
+		return nil, false, nil
+	}
+
+	privateKey, err := s.keys.DerivePrivateKey(path)
+	if err != nil {
+		return nil, false, err
+	}
+	return privateKey, true, nil
+}
+
+// signInput adds the signatures the keychain can produce for input idx and
+// returns how many were added
+func (s *psbtSigner) signInput(idx int) (int, error) {
+	pInput := &s.packet.Inputs[idx]
+	if len(pInput.FinalScriptSig) > 0 || len(pInput.FinalScriptWitness) > 0 {
+		return 0, nil
+	}
+
+	prevOut := s.fetcher.FetchPrevOutput(s.packet.UnsignedTx.TxIn[idx].PreviousOutPoint)
+
+	if txscript.IsPayToTaproot(prevOut.PkScript) {
+		return s.signTaprootInput(idx, pInput, prevOut)
+	}
+
+	signed := 0
+	for _, derivation := range pInput.Bip32Derivation {
+		privateKey, owned, err := s.ownedKey(derivation.MasterKeyFingerprint, derivation.Bip32Path)
+		if err != nil {
+			return 0, err
+		}
+		if !owned {
+			continue
+		}
+		pubKey := privateKey.PubKey().SerializeCompressed()
+		if !bytes.Equal(pubKey, derivation.PubKey) || hasPartialSig(pInput, pubKey) {
+			continue
+		}
+
+		sig, err := s.ecdsaSignature(idx, pInput, prevOut, privateKey)
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err := s.updater.Sign(idx, sig, pubKey, nil, nil); err != nil {
+			return 0, err
+		}
+		signed++
+	}
+
+	return signed, nil
+}
+
+// ecdsaSignature signs a segwit v0 or legacy input, picking the script the
+// signature commits to from the witness script, redeem script or previous output
+func (s *psbtSigner) ecdsaSignature(idx int, pInput *psbt.PInput, prevOut *wire.TxOut,
+	privateKey *btcec.PrivateKey) ([]byte, error) {
+	hashType := pInput.SighashType
+	if hashType == 0 {
+		hashType = txscript.SigHashAll
+	}
+
+	tx := s.packet.UnsignedTx
+	switch {
+	case len(pInput.WitnessScript) > 0:
+		return txscript.RawTxInWitnessSignature(tx, s.sigHashes, idx, prevOut.Value,
+			pInput.WitnessScript, hashType, privateKey)
+
+	case len(pInput.RedeemScript) > 0 && txscript.IsPayToWitnessPubKeyHash(pInput.RedeemScript):
+		return txscript.RawTxInWitnessSignature(tx, s.sigHashes, idx, prevOut.Value,
+			pInput.RedeemScript, hashType, privateKey)
+
+	case len(pInput.RedeemScript) > 0:
+		return txscript.RawTxInSignature(tx, idx, pInput.RedeemScript, hashType, privateKey)
+
+	case txscript.IsPayToWitnessPubKeyHash(prevOut.PkScript):
+		return txscript.RawTxInWitnessSignature(tx, s.sigHashes, idx, prevOut.Value,
+			prevOut.PkScript, hashType, privateKey)
+
+	case txscript.IsPayToPubKeyHash(prevOut.PkScript):
+		return txscript.RawTxInSignature(tx, idx, prevOut.PkScript, hashType, privateKey)
+
+	default:
+		return nil, fmt.Errorf("%w: input %d", ErrUnsupportedScript, idx)
+	}
+}
+
+// signTaprootInput adds a key path signature (BIP-86 style) to a taproot input
+func (s *psbtSigner) signTaprootInput(idx int, pInput *psbt.PInput, prevOut *wire.TxOut) (int, error) {
+	if len(pInput.TaprootKeySpendSig) > 0 {
+		return 0, nil
+	}
+
+	for _, derivation := range pInput.TaprootBip32Derivation {
+		// script path spends are not supported
+		if len(derivation.LeafHashes) > 0 {
+			continue
+		}
+
+		privateKey, owned, err := s.ownedKey(derivation.MasterKeyFingerprint, derivation.Bip32Path)
+		if err != nil {
+			return 0, err
+		}
+		if !owned || !bytes.Equal(schnorr.SerializePubKey(privateKey.PubKey()), derivation.XOnlyPubKey) {
+			continue
+		}
+
+		// the output must commit to our key as internal key
+		outputKey := txscript.ComputeTaprootOutputKey(privateKey.PubKey(), pInput.TaprootMerkleRoot)
+		pkScript, err := txscript.PayToTaprootScript(outputKey)
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.Equal(pkScript, prevOut.PkScript) {
+			continue
+		}
+
+		sig, err := txscript.RawTxInTaprootSignature(s.packet.UnsignedTx, s.sigHashes, idx, prevOut.Value,
+			prevOut.PkScript, pInput.TaprootMerkleRoot, pInput.SighashType, privateKey)
+		if err != nil {
+			return 0, err
+		}
+
+		pInput.TaprootKeySpendSig = sig
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+func hasPartialSig(pInput *psbt.PInput, pubKey []byte) bool {
+	for _, partialSig := range pInput.PartialSigs {
+		if bytes.Equal(partialSig.PubKey, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatDerivationPath renders a BIP-32 path the way it is written in requests, e.g. m/84'/0'/0'/0/1
+func formatDerivationPath(path []uint32) string {
+	var sb strings.Builder
+	sb.WriteString("m")
+	for _, index := range path {
+		sb.WriteString("/")
+		if index >= hdkeychain.HardenedKeyStart {
+			sb.WriteString(strconv.FormatUint(uint64(index-hdkeychain.HardenedKeyStart), 10))
+			sb.WriteString("'")
+			continue
+		}
+		sb.WriteString(strconv.FormatUint(uint64(index), 10))
+	}
+	return sb.String()
+}
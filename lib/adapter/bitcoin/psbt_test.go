@@ -0,0 +1,328 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/lib"
+)
+
+const (
+	psbtP2WPKHPath = "m/84'/0'/0'/0/0"
+	psbtP2TRPath   = "m/86'/0'/0'/0/0"
+	psbtP2PKHPath  = "m/44'/0'/0'/0/0"
+)
+
+// psbtAccounts are the accounts of the inputs of the fixture owned by testSeed
+var psbtAccounts = []string{"m/84'/0'/0'", "m/86'/0'/0'", "m/44'/0'/0'"}
+
+// psbtFixture is a PSBT spending three inputs owned by testSeed and one foreign input
+type psbtFixture struct {
+	packet     *psbt.Packet
+	prevOuts   map[wire.OutPoint]*wire.TxOut
+	foreignKey *btcec.PrivateKey
+}
+
+func mustParsePath(t *testing.T, path string) []uint32 {
+	t.Helper()
+
+	components := strings.Split(path, "/")[1:]
+	result := make([]uint32, 0, len(components))
+	for _, component := range components {
+		if value, err := parseHardenedComponent(component); err == nil {
+			result = append(result, uint32(value)+hdkeychain.HardenedKeyStart)
+			continue
+		}
+		index, err := strconv.ParseUint(component, 10, 31)
+		require.NoError(t, err)
+		result = append(result, uint32(index))
+	}
+	return result
+}
+
+func derivePSBTKey(t *testing.T, path string) *btcec.PrivateKey {
+	t.Helper()
+
+	privateKey, err := lib.NewSeedKeychain(testSeed).DerivePrivateKey(path)
+	require.NoError(t, err)
+	return privateKey
+}
+
+func createPSBTFixture(t *testing.T) *psbtFixture {
+	t.Helper()
+
+	fingerprintBytes, err := lib.MasterKeyFingerprint(testSeed)
+	require.NoError(t, err)
+	fingerprint := binary.LittleEndian.Uint32(fingerprintBytes)
+
+	p2wpkhKey := derivePSBTKey(t, psbtP2WPKHPath)
+	p2trKey := derivePSBTKey(t, psbtP2TRPath)
+	p2pkhKey := derivePSBTKey(t, psbtP2PKHPath)
+	foreignKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	ownScript, err := p2wpkhScript(btcutil.Hash160(p2wpkhKey.PubKey().SerializeCompressed()))
+	require.NoError(t, err)
+	foreignScript, err := p2wpkhScript(btcutil.Hash160(foreignKey.PubKey().SerializeCompressed()))
+	require.NoError(t, err)
+	p2trScript, err := txscript.PayToTaprootScript(txscript.ComputeTaprootKeyNoScript(p2trKey.PubKey()))
+	require.NoError(t, err)
+	p2pkhAddress, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(p2pkhKey.PubKey().SerializeCompressed()),
+		&chaincfg.MainNetParams)
+	require.NoError(t, err)
+	p2pkhScript, err := txscript.PayToAddrScript(p2pkhAddress)
+	require.NoError(t, err)
+
+	// previous transaction funding the legacy input
+	fundingTx := wire.NewMsgTx(txVersion)
+	fundingTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, 0), nil, nil))
+	fundingTx.AddTxOut(wire.NewTxOut(40000, p2pkhScript))
+
+	hash, err := chainhash.NewHashFromStr(testTxHash)
+	require.NoError(t, err)
+	fundingHash := fundingTx.TxHash()
+
+	outPoints := []*wire.OutPoint{
+		wire.NewOutPoint(hash, 0),
+		wire.NewOutPoint(hash, 1),
+		wire.NewOutPoint(hash, 2),
+		wire.NewOutPoint(&fundingHash, 0),
+	}
+	prevOuts := map[wire.OutPoint]*wire.TxOut{
+		*outPoints[0]: wire.NewTxOut(100000, ownScript),
+		*outPoints[1]: wire.NewTxOut(60000, foreignScript),
+		*outPoints[2]: wire.NewTxOut(50000, p2trScript),
+		*outPoints[3]: fundingTx.TxOut[0],
+	}
+
+	packet, err := psbt.New(outPoints, []*wire.TxOut{wire.NewTxOut(240000, ownScript)}, txVersion, 0,
+		[]uint32{wire.MaxTxInSequenceNum, wire.MaxTxInSequenceNum, wire.MaxTxInSequenceNum, wire.MaxTxInSequenceNum})
+	require.NoError(t, err)
+
+	updater, err := psbt.NewUpdater(packet)
+	require.NoError(t, err)
+
+	require.NoError(t, updater.AddInWitnessUtxo(prevOuts[*outPoints[0]], 0))
+	require.NoError(t, updater.AddInBip32Derivation(fingerprint, mustParsePath(t, psbtP2WPKHPath),
+		p2wpkhKey.PubKey().SerializeCompressed(), 0))
+
+	require.NoError(t, updater.AddInWitnessUtxo(prevOuts[*outPoints[1]], 1))
+	require.NoError(t, updater.AddInBip32Derivation(fingerprint+1, mustParsePath(t, psbtP2WPKHPath),
+		foreignKey.PubKey().SerializeCompressed(), 1))
+
+	require.NoError(t, updater.AddInWitnessUtxo(prevOuts[*outPoints[2]], 2))
+	packet.Inputs[2].TaprootInternalKey = schnorr.SerializePubKey(p2trKey.PubKey())
+	packet.Inputs[2].TaprootBip32Derivation = []*psbt.TaprootBip32Derivation{{
+		XOnlyPubKey:          schnorr.SerializePubKey(p2trKey.PubKey()),
+		MasterKeyFingerprint: fingerprint,
+		Bip32Path:            mustParsePath(t, psbtP2TRPath),
+	}}
+
+	require.NoError(t, updater.AddInNonWitnessUtxo(fundingTx, 3))
+	require.NoError(t, updater.AddInBip32Derivation(fingerprint, mustParsePath(t, psbtP2PKHPath),
+		p2pkhKey.PubKey().SerializeCompressed(), 3))
+
+	return &psbtFixture{
+		packet:     packet,
+		prevOuts:   prevOuts,
+		foreignKey: foreignKey,
+	}
+}
+
+func (f *psbtFixture) encode(t *testing.T) string {
+	t.Helper()
+
+	encoded, err := f.packet.B64Encode()
+	require.NoError(t, err)
+	return encoded
+}
+
+// signAccounts signs encoded within each account of psbtAccounts in turn
+func signAccounts(t *testing.T, adapter *Adapter, encoded string) string {
+	t.Helper()
+
+	for _, account := range psbtAccounts {
+		signed, err := adapter.SignPartialTransaction(lib.NewSeedKeychain(testSeed), account, encoded)
+		require.NoError(t, err, account)
+		encoded = signed
+	}
+	return encoded
+}
+
+// withGlobals serializes globals, each a key and its value, after the
+// unsigned transaction of the global map of packet
+func withGlobals(t *testing.T, packet *psbt.Packet, globals ...[]byte) string {
+	t.Helper()
+
+	var raw bytes.Buffer
+	require.NoError(t, packet.Serialize(&raw))
+	var tx bytes.Buffer
+	require.NoError(t, packet.UnsignedTx.Serialize(&tx))
+
+	// magic, then the key and value of the unsigned transaction
+	offset := len(psbtMagic) + 2 + wire.VarIntSerializeSize(uint64(tx.Len())) + tx.Len()
+	var out bytes.Buffer
+	out.Write(raw.Bytes()[:offset])
+	for _, global := range globals {
+		require.NoError(t, wire.WriteVarBytes(&out, 0, global))
+	}
+	out.Write(raw.Bytes()[offset:])
+	return base64.StdEncoding.EncodeToString(out.Bytes())
+}
+
+func decodePSBT(t *testing.T, encoded string) *psbt.Packet {
+	t.Helper()
+
+	packet, err := psbt.NewFromRawBytes(strings.NewReader(encoded), true)
+	require.NoError(t, err)
+	return packet
+}
+
+func TestBitcoinAdapter_SignPartialTransaction(t *testing.T) {
+	adapter := NewBitcoinAdapter(logger)
+
+	t.Run("signs only owned inputs", func(t *testing.T) {
+		fixture := createPSBTFixture(t)
+
+		packet := decodePSBT(t, signAccounts(t, adapter, fixture.encode(t)))
+		assert.Len(t, packet.Inputs[0].PartialSigs, 1)
+		assert.Empty(t, packet.Inputs[1].PartialSigs)
+		assert.Len(t, packet.Inputs[2].TaprootKeySpendSig, schnorr.SignatureSize)
+		assert.Len(t, packet.Inputs[3].PartialSigs, 1)
+		assert.False(t, packet.IsComplete())
+	})
+
+	t.Run("signatures are valid once the foreign input is signed", func(t *testing.T) {
+		fixture := createPSBTFixture(t)
+		packet := decodePSBT(t, signAccounts(t, adapter, fixture.encode(t)))
+
+		// co-signer adds its signature to the foreign input
+		fetcher := txscript.NewMultiPrevOutFetcher(fixture.prevOuts)
+		sigHashes := txscript.NewTxSigHashes(packet.UnsignedTx, fetcher)
+		foreignPrevOut := packet.Inputs[1].WitnessUtxo
+		sig, err := txscript.RawTxInWitnessSignature(packet.UnsignedTx, sigHashes, 1, foreignPrevOut.Value,
+			foreignPrevOut.PkScript, txscript.SigHashAll, fixture.foreignKey)
+		require.NoError(t, err)
+		updater, err := psbt.NewUpdater(packet)
+		require.NoError(t, err)
+		_, err = updater.Sign(1, sig, fixture.foreignKey.PubKey().SerializeCompressed(), nil, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, psbt.MaybeFinalizeAll(packet))
+		finalTx, err := psbt.Extract(packet)
+		require.NoError(t, err)
+
+		for idx, txIn := range finalTx.TxIn {
+			prevOut := fixture.prevOuts[txIn.PreviousOutPoint]
+			vm, err := txscript.NewEngine(prevOut.PkScript, finalTx, idx, txscript.StandardVerifyFlags,
+				nil, sigHashes, prevOut.Value, fetcher)
+			require.NoError(t, err)
+			require.NoError(t, vm.Execute(), "input %d failed verification", idx)
+		}
+	})
+
+	t.Run("derivation path limits the signed inputs", func(t *testing.T) {
+		fixture := createPSBTFixture(t)
+
+		signed, err := adapter.SignPartialTransaction(lib.NewSeedKeychain(testSeed), "m/84'/0'/0'", fixture.encode(t))
+		require.NoError(t, err)
+
+		packet := decodePSBT(t, signed)
+		assert.Len(t, packet.Inputs[0].PartialSigs, 1)
+		assert.Empty(t, packet.Inputs[2].TaprootKeySpendSig)
+		assert.Empty(t, packet.Inputs[3].PartialSigs)
+	})
+
+	t.Run("already signed inputs are skipped", func(t *testing.T) {
+		fixture := createPSBTFixture(t)
+
+		signed, err := adapter.SignPartialTransaction(lib.NewSeedKeychain(testSeed), psbtAccounts[0], fixture.encode(t))
+		require.NoError(t, err)
+
+		_, err = adapter.SignPartialTransaction(lib.NewSeedKeychain(testSeed), psbtAccounts[0], signed)
+		assert.ErrorIs(t, err, ErrNoOwnedInputs)
+	})
+
+	t.Run("explicit version 0", func(t *testing.T) {
+		fixture := createPSBTFixture(t)
+		encoded := withGlobals(t, fixture.packet, []byte{psbtGlobalVersion}, []byte{0, 0, 0, 0})
+
+		signed, err := adapter.SignPartialTransaction(lib.NewSeedKeychain(testSeed), psbtAccounts[0], encoded)
+		require.NoError(t, err)
+		assert.Len(t, decodePSBT(t, signed).Inputs[0].PartialSigs, 1)
+	})
+}
+
+func TestBitcoinAdapter_SignPartialTransaction_Errors(t *testing.T) {
+	adapter := NewBitcoinAdapter(logger)
+
+	t.Run("invalid psbt", func(t *testing.T) {
+		_, err := adapter.SignPartialTransaction(lib.NewSeedKeychain(testSeed), psbtAccounts[0], "cHNidP8invalid")
+		assert.ErrorIs(t, err, ErrInvalidPSBT)
+	})
+
+	t.Run("version 2", func(t *testing.T) {
+		// global map of a BIP-370 PSBT: tx version, input and output counts, psbt version
+		raw := append([]byte{}, psbtMagic...)
+		raw = append(raw, 1, 0x02, 4, 2, 0, 0, 0, 1, 0x04, 1, 1, 1, 0x05, 1, 1, 1, psbtGlobalVersion, 4, 2, 0, 0, 0, 0)
+
+		_, err := adapter.SignPartialTransaction(lib.NewSeedKeychain(testSeed), psbtAccounts[0],
+			base64.StdEncoding.EncodeToString(raw))
+		assert.ErrorIs(t, err, ErrUnsupportedPSBTVersion)
+	})
+
+	t.Run("scope above an account", func(t *testing.T) {
+		fixture := createPSBTFixture(t)
+
+		// derivation records of the PSBT must not select keys of other coins or accounts
+		for _, scope := range []string{"m", "m/84'/0'", "84'/0'/0'", "m/84'/0'/0"} {
+			_, err := adapter.SignPartialTransaction(lib.NewSeedKeychain(testSeed), scope, fixture.encode(t))
+			assert.ErrorIs(t, err, ErrPSBTScopeNotAccount, scope)
+		}
+	})
+
+	t.Run("scope of another coin", func(t *testing.T) {
+		fixture := createPSBTFixture(t)
+
+		_, err := adapter.SignPartialTransaction(lib.NewSeedKeychain(testSeed), "m/84'/60'/0'", fixture.encode(t))
+		assert.ErrorIs(t, err, ErrUnsupportedCoinType)
+	})
+
+	t.Run("missing previous output", func(t *testing.T) {
+		fixture := createPSBTFixture(t)
+		fixture.packet.Inputs[0].WitnessUtxo = nil
+
+		_, err := adapter.SignPartialTransaction(lib.NewSeedKeychain(testSeed), psbtAccounts[0], fixture.encode(t))
+		assert.ErrorIs(t, err, ErrMissingUtxo)
+	})
+
+	t.Run("seed owns no input", func(t *testing.T) {
+		fixture := createPSBTFixture(t)
+
+		_, err := adapter.SignPartialTransaction(lib.NewSeedKeychain([]byte("another seed for the vault user")), psbtAccounts[0],
+			fixture.encode(t))
+		assert.ErrorIs(t, err, ErrNoOwnedInputs)
+	})
+}
+
+func TestFormatDerivationPath(t *testing.T) {
+	assert.Equal(t, "m", formatDerivationPath(nil))
+	assert.Equal(t, psbtP2WPKHPath, formatDerivationPath(mustParsePath(t, psbtP2WPKHPath)))
+	assert.Equal(t, "m/86'/1'/2'/1/7", formatDerivationPath(mustParsePath(t, "m/86'/1'/2'/1/7")))
+}
@@ -0,0 +1,220 @@
+package bitshares
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/payment-system/dq-vault/lib"
+	"github.com/payment-system/dq-vault/lib/rfc6979"
+	"github.com/payment-system/dq-vault/lib/slip44"
+	"golang.org/x/crypto/ripemd160" //nolint:staticcheck // Graphene key checksums are RIPEMD-160
+)
+
+const (
+	// AddressPrefix is the prefix of Bitshares public keys
+	AddressPrefix = "BTS"
+	// TestnetAddressPrefix is the prefix of public keys on the Bitshares testnet
+	TestnetAddressPrefix = "TEST"
+
+	checksumLength = 4
+	scalarLength   = 32
+
+	// compactSigMagicOffset is added to the recovery id of compact signatures,
+	// compactSigCompPubKey flags a compressed public key
+	compactSigMagicOffset = 27
+	compactSigCompPubKey  = 4
+	maxRecoveryID         = 4
+
+	// maxSigningAttempts bounds the nonce loop, about one signature in four is canonical
+	maxSigningAttempts = 256
+)
+
+// Adapter represents a Bitshares blockchain adapter
+//
+// The handlers always derive Bitshares keys on config.BitsharesDerivationPath.
+type Adapter struct {
+	logger *slog.Logger
+}
+
+// NewBitsharesAdapter creates a new Bitshares adapter instance
+func NewBitsharesAdapter(logger *slog.Logger) *Adapter {
+	return &Adapter{
+		logger: logger,
+	}
+}
+
+// CanDo checks if this adapter can handle the given coin type
+func (*Adapter) CanDo(coinType uint16) bool {
+	return coinType == slip44.Bitshares
+}
+
+// DerivePrivateKey derives a private key from the given keychain and derivation path
+// and returns it in the uncompressed WIF format used by Graphene wallets
+func (b *Adapter) DerivePrivateKey(keys *lib.Keychain, derivationPath string, isDev bool) (string, error) {
+	logger := b.logger.With(slog.String("op", "derive_private_key"), slog.String("derivationPath", derivationPath))
+	logger.Info("Deriving private key")
+
+	privateKey, err := keys.DerivePrivateKey(derivationPath)
+	if err != nil {
+		logger.Error("Failed to derive private key", "error", err)
+		return "", err
+	}
+
+	wif, err := btcutil.NewWIF(privateKey, &chaincfg.MainNetParams, false)
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info("Private key derived successfully")
+
+	return wif.String(), nil
+}
+
+// DerivePublicKey derives the BTS prefixed public key from the given keychain and
+// derivation path, development mode selects the TEST prefix of the testnet
+func (b *Adapter) DerivePublicKey(keys *lib.Keychain, derivationPath string, isDev bool) (string, error) {
+	logger := b.logger.With(slog.String("op", "derive_public_key"), slog.String("derivationPath", derivationPath))
+	logger.Info("Deriving public key")
+
+	privateKey, err := keys.DerivePrivateKey(derivationPath)
+	if err != nil {
+		logger.Error("Failed to derive public key", "error", err)
+		return "", err
+	}
+
+	prefix := AddressPrefix
+	if isDev {
+		prefix = TestnetAddressPrefix
+	}
+
+	publicKey := encodePublicKey(privateKey.PubKey(), prefix)
+	logger.Debug("Public key derived successfully", "publicKey", publicKey)
+
+	return publicKey, nil
+}
+
+// DeriveECPublicKey derives the secp256k1 public key of derivationPath
+func (b *Adapter) DeriveECPublicKey(keys *lib.Keychain, derivationPath string, isDev bool) (*btcec.PublicKey, error) {
+	privateKey, err := keys.DerivePrivateKey(derivationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return privateKey.PubKey(), nil
+}
+
+// ResolveDerivationPath returns the absolute path the keys of derivationPath
+// are derived at, derivationPath itself
+func (b *Adapter) ResolveDerivationPath(derivationPath string) (string, error) {
+	return derivationPath, nil
+}
+
+// EncodePublicKey returns the BTS prefixed public key of publicKey, which is
+// also its address
+func (b *Adapter) EncodePublicKey(publicKey *btcec.PublicKey, _ string, isDev bool) (string, string, error) {
+	prefix := AddressPrefix
+	if isDev {
+		prefix = TestnetAddressPrefix
+	}
+
+	encoded := encodePublicKey(publicKey, prefix)
+	return encoded, encoded, nil
+}
+
+// DeriveAddress returns the BTS prefixed public key, Bitshares accounts are
+// named on chain and authorised by public keys rather than addresses
+func (b *Adapter) DeriveAddress(keys *lib.Keychain, derivationPath string, isDev bool) (string, error) {
+	return b.DerivePublicKey(keys, derivationPath, isDev)
+}
+
+// encodePublicKey encodes pubKey as prefix + base58(compressed key || ripemd160(compressed key)[:4])
+func encodePublicKey(pubKey *btcec.PublicKey, prefix string) string {
+	compressed := pubKey.SerializeCompressed()
+
+	hasher := ripemd160.New()
+	hasher.Write(compressed)
+	checksum := hasher.Sum(nil)[:checksumLength]
+
+	return prefix + base58.Encode(append(compressed, checksum...))
+}
+
+// isCanonical reports whether a compact signature is canonical for Graphene:
+// r and s must both encode to exactly 32 bytes in DER, without padding
+func isCanonical(sig []byte) bool {
+	r, s := sig[1:1+scalarLength], sig[1+scalarLength:]
+	return r[0]&0x80 == 0 && (r[0] != 0 || r[1]&0x80 != 0) &&
+		s[0]&0x80 == 0 && (s[0] != 0 || s[1]&0x80 != 0)
+}
+
+// signCompact signs digest with the RFC6979 signer, increasing the nonce until
+// the signature is canonical, and returns the 65 byte compact signature
+func signCompact(privateKey *btcec.PrivateKey, digest []byte) ([]byte, error) {
+	for nonce := range maxSigningAttempts {
+		r, s, err := rfc6979.SignECDSA(privateKey.ToECDSA(), digest, sha256.New, nonce)
+		if err != nil {
+			return nil, err
+		}
+
+		sig := make([]byte, 1+2*scalarLength)
+		r.FillBytes(sig[1 : 1+scalarLength])
+		s.FillBytes(sig[1+scalarLength:])
+		if !isCanonical(sig) {
+			continue
+		}
+
+		// find the recovery id yielding our public key
+		for recoveryID := range maxRecoveryID {
+			sig[0] = byte(compactSigMagicOffset + compactSigCompPubKey + recoveryID)
+			pubKey, _, err := ecdsa.RecoverCompact(sig, digest)
+			if err == nil && pubKey.IsEqual(privateKey.PubKey()) {
+				return sig, nil
+			}
+		}
+		return nil, ErrRecoveryParamNotFound
+	}
+
+	return nil, ErrNoCanonicalSignature
+}
+
+// CreateSignedTransaction signs the transactionDigest of the payload and
+// returns the hex encoded 65 byte compact signature. The digest already
+// commits to the chain id, so development mode changes nothing.
+func (b *Adapter) CreateSignedTransaction(keys *lib.Keychain, derivationPath, payload string, _ bool) (string, error) {
+	logger := b.logger.With(slog.String("op", "create_signed_transaction"), slog.String("derivationPath", derivationPath))
+	logger.Info("Creating signed transaction")
+
+	var rawTx lib.BitsharesRawTx
+	if err := json.Unmarshal([]byte(payload), &rawTx); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidPayloadData, err)
+	}
+
+	digest, err := hex.DecodeString(strings.TrimPrefix(rawTx.TransactionDigest, "0x"))
+	if err != nil || len(digest) != sha256.Size {
+		return "", ErrInvalidDigest
+	}
+
+	privateKey, err := keys.DerivePrivateKey(derivationPath)
+	if err != nil {
+		logger.Error("Failed to derive private key", "error", err)
+		return "", err
+	}
+
+	sig, err := signCompact(privateKey, digest)
+	if err != nil {
+		logger.Error("Failed to sign digest", "error", err)
+		return "", err
+	}
+
+	logger.Info("Signed transaction created successfully")
+
+	return hex.EncodeToString(sig), nil
+}
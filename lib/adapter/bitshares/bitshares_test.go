@@ -0,0 +1,153 @@
+package bitshares
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+
+	"github.com/payment-system/dq-vault/config"
+	"github.com/payment-system/dq-vault/lib"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+const (
+	testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	// Graphene key pair from the Bitshares / EOS documentation
+	testWIF       = "5KQwrPbwdL6PhXujxW37FSSQZ1JiwsST4cqQzDeyXtP79zkvFD3"
+	testPublicKey = "BTS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"
+)
+
+var (
+	testSeed = bip39.NewSeed(testMnemonic, "")
+	logger   = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+)
+
+func TestBitsharesAdapter_CanDo(t *testing.T) {
+	adapter := NewBitsharesAdapter(logger)
+
+	assert.True(t, adapter.CanDo(slip44.Bitshares))
+	assert.False(t, adapter.CanDo(slip44.Ether))
+	assert.False(t, adapter.CanDo(slip44.Bitcoin))
+}
+
+func TestEncodePublicKey(t *testing.T) {
+	wif, err := btcutil.DecodeWIF(testWIF)
+	require.NoError(t, err)
+
+	assert.Equal(t, testPublicKey, encodePublicKey(wif.PrivKey.PubKey(), AddressPrefix))
+}
+
+func TestBitsharesAdapter_DeriveKeys(t *testing.T) {
+	adapter := NewBitsharesAdapter(logger)
+
+	privateKey, err := adapter.DerivePrivateKey(lib.NewSeedKeychain(testSeed), config.BitsharesDerivationPath, false)
+	require.NoError(t, err)
+	wif, err := btcutil.DecodeWIF(privateKey)
+	require.NoError(t, err)
+	assert.False(t, wif.CompressPubKey)
+
+	publicKey, err := adapter.DerivePublicKey(lib.NewSeedKeychain(testSeed), config.BitsharesDerivationPath, false)
+	require.NoError(t, err)
+	assert.Equal(t, encodePublicKey(wif.PrivKey.PubKey(), AddressPrefix), publicKey)
+
+	address, err := adapter.DeriveAddress(lib.NewSeedKeychain(testSeed), config.BitsharesDerivationPath, false)
+	require.NoError(t, err)
+	assert.Equal(t, publicKey, address)
+	assert.True(t, strings.HasPrefix(address, AddressPrefix))
+
+	_, err = adapter.DeriveAddress(lib.NewSeedKeychain(testSeed), "/invalid", false)
+	assert.Error(t, err)
+
+	// development mode uses the testnet prefix for the same key
+	devAddress, err := adapter.DeriveAddress(lib.NewSeedKeychain(testSeed), config.BitsharesDerivationPath, true)
+	require.NoError(t, err)
+	assert.Equal(t, encodePublicKey(wif.PrivKey.PubKey(), TestnetAddressPrefix), devAddress)
+	assert.True(t, strings.HasPrefix(devAddress, TestnetAddressPrefix))
+}
+
+func TestBitsharesAdapter_CreateSignedTransaction(t *testing.T) {
+	adapter := NewBitsharesAdapter(logger)
+
+	privateKey, err := lib.NewSeedKeychain(testSeed).DerivePrivateKey(config.BitsharesDerivationPath)
+	require.NoError(t, err)
+
+	for i := range 32 {
+		digest := sha256.Sum256([]byte{byte(i)})
+
+		got, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(testSeed), config.BitsharesDerivationPath,
+			`{"transactionDigest": "`+hex.EncodeToString(digest[:])+`"}`, false)
+		require.NoError(t, err)
+
+		sig, err := hex.DecodeString(got)
+		require.NoError(t, err)
+		require.Len(t, sig, 65)
+		assert.True(t, isCanonical(sig))
+		assert.GreaterOrEqual(t, sig[0], byte(31))
+		assert.LessOrEqual(t, sig[0], byte(34))
+
+		pubKey, compressed, err := ecdsa.RecoverCompact(sig, digest[:])
+		require.NoError(t, err)
+		assert.True(t, compressed)
+		assert.True(t, pubKey.IsEqual(privateKey.PubKey()))
+	}
+}
+
+func TestBitsharesAdapter_CreateSignedTransaction_Deterministic(t *testing.T) {
+	adapter := NewBitsharesAdapter(logger)
+	payload := `{"transactionDigest": "` + hex.EncodeToString(make([]byte, sha256.Size)) + `"}`
+
+	first, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(testSeed), config.BitsharesDerivationPath, payload, false)
+	require.NoError(t, err)
+	second, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(testSeed), config.BitsharesDerivationPath, payload, false)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+}
+
+func TestBitsharesAdapter_CreateSignedTransaction_Errors(t *testing.T) {
+	adapter := NewBitsharesAdapter(logger)
+
+	tests := []struct {
+		name          string
+		payload       string
+		expectedError error
+	}{
+		{name: "invalid json", payload: "{invalid json}", expectedError: ErrInvalidPayloadData},
+		{name: "missing digest", payload: `{}`, expectedError: ErrInvalidDigest},
+		{name: "invalid hex", payload: `{"transactionDigest": "zz"}`, expectedError: ErrInvalidDigest},
+		{name: "short digest", payload: `{"transactionDigest": "abcd"}`, expectedError: ErrInvalidDigest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(testSeed), config.BitsharesDerivationPath, tt.payload, false)
+			assert.ErrorIs(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestIsCanonical(t *testing.T) {
+	scalar := func(first, second byte) []byte {
+		b := make([]byte, scalarLength)
+		b[0], b[1] = first, second
+		return b
+	}
+	sig := func(r, s []byte) []byte {
+		return append(append([]byte{31}, r...), s...)
+	}
+
+	assert.True(t, isCanonical(sig(scalar(0x01, 0), scalar(0x7f, 0))))
+	assert.True(t, isCanonical(sig(scalar(0, 0x80), scalar(0x01, 0))))
+	assert.False(t, isCanonical(sig(scalar(0x80, 0), scalar(0x01, 0))))
+	assert.False(t, isCanonical(sig(scalar(0x01, 0), scalar(0x80, 0))))
+	assert.False(t, isCanonical(sig(scalar(0, 0x7f), scalar(0x01, 0))))
+	assert.False(t, isCanonical(sig(scalar(0x01, 0), scalar(0, 0x01))))
+}
@@ -0,0 +1,11 @@
+package bitshares
+
+import "errors"
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrInvalidPayloadData    = errors.New("invalid payload data")
+	ErrInvalidDigest         = errors.New("transaction digest must be 32 hex encoded bytes")
+	ErrNoCanonicalSignature  = errors.New("no canonical signature found")
+	ErrRecoveryParamNotFound = errors.New("could not compute signature recovery parameter")
+)
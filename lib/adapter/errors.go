@@ -3,5 +3,11 @@ package adapter
 import "errors"
 
 var (
-	ErrNoAdapterFound = errors.New("no adapter found")
+	ErrNoAdapterFound               = errors.New("no adapter found")
+	ErrPartialSigningUnsupported    = errors.New("partially signed transactions are not supported for this coin type")
+	ErrMessageSigningUnsupported    = errors.New("message signing is not supported for this coin type")
+	ErrECPublicKeyUnsupported       = errors.New("secp256k1 public keys are not supported for this coin type")
+	ErrExtendedPublicKeyUnsupported = errors.New("extended public keys are not supported for this coin type")
+	ErrDecodingUnsupported          = errors.New("transaction decoding is not supported for this coin type")
+	ErrTransactionHashUnsupported   = errors.New("transaction hashes are not supported for this coin type")
 )
@@ -0,0 +1,130 @@
+package evm
+
+import (
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/payment-system/dq-vault/lib"
+)
+
+// tokenCallRecipient maps the ERC-20 methods moving tokens to the index of
+// their recipient argument, the amount is always the last argument.
+// An approval is reported as a transfer to the spender.
+var tokenCallRecipient = map[string]int{
+	"transfer":     0,
+	"transferFrom": 1,
+	"approve":      0,
+}
+
+// DecodeTransaction extracts the chain id, recipient, value and fees of a
+// payload with the validation of createRawTransaction, without deriving any
+// key. ERC-20 transfer, transferFrom and approve calls are reported as token
+// transfers of the called contract. Calls of other methods are reported with
+// the hex encoded method selector.
+func (e *EthereumAdapter) DecodeTransaction(payloadString string, isDev bool) (*lib.TransactionSummary, error) {
+	logger := e.logger.With(slog.String("op", "decode_transaction"))
+	logger.Info("Decoding transaction")
+
+	payload, txType, _, err := e.parsePayload(payloadString, isDev)
+	if err != nil {
+		return nil, err
+	}
+
+	value := payload.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	summary := &lib.TransactionSummary{
+		Type:    txType,
+		ChainID: payload.ChainID,
+		Transfers: []lib.Transfer{
+			{To: payload.To, Value: value},
+		},
+		Fee: lib.Fee{
+			GasLimit:             payload.GasLimit,
+			GasPrice:             payload.GasPrice,
+			MaxFeePerGas:         payload.MaxFeePerGas,
+			MaxPriorityFeePerGas: payload.MaxPriorityFeePerGas,
+		},
+	}
+
+	// contract creations and plain transfers carry no token movement
+	if payload.To == "" || payload.Data == "" || payload.Data == "0x" {
+		return summary, nil
+	}
+
+	data, err := hexutil.Decode(payload.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayloadData, err)
+	}
+	summary.Transfers[0].Contract = true
+
+	transfer, method, ok, err := decodeTokenCall(data)
+	if err != nil {
+		return nil, err
+	}
+	summary.Method = method
+	if ok {
+		transfer.Token = payload.To
+		summary.Transfers = append(summary.Transfers, transfer)
+	}
+
+	return summary, nil
+}
+
+// decodeTokenCall decodes the method name, recipient and amount of an ERC-20
+// call. ok is false for calls of other methods, whose method is the hex
+// encoded selector when unknown to the ERC-20 ABI.
+func decodeTokenCall(data []byte) (transfer lib.Transfer, methodName string, ok bool, err error) {
+	if len(data) < methodIDLength {
+		return lib.Transfer{}, "", false, nil
+	}
+
+	erc20, err := abi.JSON(strings.NewReader(ERC20ABI))
+	if err != nil {
+		return lib.Transfer{}, "", false, err
+	}
+
+	method, err := erc20.MethodById(data[:methodIDLength])
+	if err != nil {
+		return lib.Transfer{}, hexutil.Encode(data[:methodIDLength]), false, nil
+	}
+	recipientIndex, ok := tokenCallRecipient[method.Name]
+	if !ok {
+		return lib.Transfer{}, method.Name, false, nil
+	}
+
+	args, err := method.Inputs.Unpack(data[methodIDLength:])
+	if err != nil {
+		return lib.Transfer{}, "", false, fmt.Errorf("%w: %s arguments: %w", ErrInvalidPayloadData, method.Name, err)
+	}
+
+	recipient, okRecipient := args[recipientIndex].(common.Address)
+	amount, okAmount := args[len(args)-1].(*big.Int)
+	if !okRecipient || !okAmount {
+		return lib.Transfer{}, "", false, fmt.Errorf("%w: %s arguments", ErrInvalidPayloadData, method.Name)
+	}
+
+	return lib.Transfer{To: recipient.Hex(), Value: amount}, method.Name, true, nil
+}
+
+// TransactionHash returns the hash of a transaction signed by CreateSignedTransaction
+func (e *EthereumAdapter) TransactionHash(_ string, signedTx string, _ bool) (string, error) {
+	data, err := hexutil.Decode(signedTx)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidPayloadData, err)
+	}
+
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidPayloadData, err)
+	}
+	return tx.Hash().Hex(), nil
+}
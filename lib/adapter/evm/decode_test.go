@@ -0,0 +1,211 @@
+package evm
+
+import (
+	"encoding/hex"
+	"log/slog"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/lib"
+)
+
+const (
+	testRecipient = "0x742d35Cc6634C0532925a3b8D359A5C5119e32C8"
+	testToken     = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+)
+
+func packERC20Call(t *testing.T, method string, args ...interface{}) string {
+	t.Helper()
+
+	erc20, err := abi.JSON(strings.NewReader(ERC20ABI))
+	require.NoError(t, err)
+	data, err := erc20.Pack(method, args...)
+	require.NoError(t, err)
+	return hexutil.Encode(data)
+}
+
+func TestEthereumAdapter_DecodeTransaction(t *testing.T) {
+	adapter := NewEthereumAdapter(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	recipient := common.HexToAddress(testRecipient)
+	owner := common.HexToAddress(expectedAddress)
+
+	tests := []struct {
+		name    string
+		payload string
+		isDev   bool
+		want    *lib.TransactionSummary
+		wantErr error
+	}{
+		{
+			name: "ether transfer",
+			payload: `{"nonce":1,"value":1000,"gasLimit":21000,"gasPrice":1,
+				"to":"` + testRecipient + `","data":"0x","chainId":1}`,
+			want: &lib.TransactionSummary{
+				Type:      "Ether Transfer",
+				ChainID:   big.NewInt(1),
+				Transfers: []lib.Transfer{{To: testRecipient, Value: big.NewInt(1000)}},
+				Fee:       lib.Fee{GasLimit: 21000, GasPrice: big.NewInt(1)},
+			},
+		},
+		{
+			name: "erc20 transfer",
+			payload: `{"type":2,"nonce":1,"value":0,"gasLimit":60000,"maxFeePerGas":2,"maxPriorityFeePerGas":1,
+				"to":"` + testToken + `","data":"` + packERC20Call(t, "transfer", recipient, big.NewInt(5000)) + `","chainId":137}`,
+			want: &lib.TransactionSummary{
+				Type:    "Contract Function Call",
+				ChainID: big.NewInt(137),
+				Method:  "transfer",
+				Transfers: []lib.Transfer{
+					{To: testToken, Value: big.NewInt(0), Contract: true},
+					{To: recipient.Hex(), Value: big.NewInt(5000), Token: testToken},
+				},
+				Fee: lib.Fee{GasLimit: 60000, MaxFeePerGas: big.NewInt(2), MaxPriorityFeePerGas: big.NewInt(1)},
+			},
+		},
+		{
+			name: "erc20 transferFrom",
+			payload: `{"nonce":1,"gasLimit":60000,"gasPrice":1,"to":"` + testToken + `",
+				"data":"` + packERC20Call(t, "transferFrom", owner, recipient, big.NewInt(7)) + `","chainId":1}`,
+			want: &lib.TransactionSummary{
+				Type:    "Contract Function Call",
+				ChainID: big.NewInt(1),
+				Method:  "transferFrom",
+				Transfers: []lib.Transfer{
+					{To: testToken, Value: big.NewInt(0), Contract: true},
+					{To: recipient.Hex(), Value: big.NewInt(7), Token: testToken},
+				},
+				Fee: lib.Fee{GasLimit: 60000, GasPrice: big.NewInt(1)},
+			},
+		},
+		{
+			name: "erc20 approve reports the spender",
+			payload: `{"nonce":1,"gasLimit":60000,"gasPrice":1,"to":"` + testToken + `",
+				"data":"` + packERC20Call(t, "approve", recipient, big.NewInt(9)) + `","chainId":1}`,
+			want: &lib.TransactionSummary{
+				Type:    "Contract Function Call",
+				ChainID: big.NewInt(1),
+				Method:  "approve",
+				Transfers: []lib.Transfer{
+					{To: testToken, Value: big.NewInt(0), Contract: true},
+					{To: recipient.Hex(), Value: big.NewInt(9), Token: testToken},
+				},
+				Fee: lib.Fee{GasLimit: 60000, GasPrice: big.NewInt(1)},
+			},
+		},
+		{
+			name: "other contract call",
+			payload: `{"nonce":1,"value":3,"gasLimit":60000,"gasPrice":1,"to":"` + testToken + `",
+				"data":"` + packERC20Call(t, "balanceOf", owner) + `","chainId":1}`,
+			want: &lib.TransactionSummary{
+				Type:      "Contract Function Call",
+				ChainID:   big.NewInt(1),
+				Method:    "balanceOf",
+				Transfers: []lib.Transfer{{To: testToken, Value: big.NewInt(3), Contract: true}},
+				Fee:       lib.Fee{GasLimit: 60000, GasPrice: big.NewInt(1)},
+			},
+		},
+		{
+			name: "unknown method selector",
+			payload: `{"nonce":1,"gasLimit":60000,"gasPrice":1,"to":"` + testToken + `",
+				"data":"0xdeadbeef","chainId":1}`,
+			want: &lib.TransactionSummary{
+				Type:      "Contract Function Call",
+				ChainID:   big.NewInt(1),
+				Method:    "0xdeadbeef",
+				Transfers: []lib.Transfer{{To: testToken, Value: big.NewInt(0), Contract: true}},
+				Fee:       lib.Fee{GasLimit: 60000, GasPrice: big.NewInt(1)},
+			},
+		},
+		{
+			name:    "contract creation",
+			payload: `{"nonce":1,"gasLimit":500000,"gasPrice":1,"data":"0x6080","chainId":1}`,
+			want: &lib.TransactionSummary{
+				Type:      "Contract Creation",
+				ChainID:   big.NewInt(1),
+				Transfers: []lib.Transfer{{To: "", Value: big.NewInt(0)}},
+				Fee:       lib.Fee{GasLimit: 500000, GasPrice: big.NewInt(1)},
+			},
+		},
+		{
+			name: "truncated erc20 arguments",
+			payload: `{"nonce":1,"gasLimit":60000,"gasPrice":1,"to":"` + testToken + `",
+				"data":"0xa9059cbb0000","chainId":1}`,
+			wantErr: ErrInvalidPayloadData,
+		},
+		{
+			name:    "invalid payload",
+			payload: `{"invalid": "json"}`,
+			wantErr: ErrInvalidPayloadData,
+		},
+		{
+			name: "testnet chain id in development mode",
+			payload: `{"nonce":1,"value":1000,"gasLimit":21000,"gasPrice":1,
+				"to":"` + testRecipient + `","data":"0x","chainId":11155111}`,
+			isDev: true,
+			want: &lib.TransactionSummary{
+				Type:      "Ether Transfer",
+				ChainID:   big.NewInt(11155111),
+				Transfers: []lib.Transfer{{To: testRecipient, Value: big.NewInt(1000)}},
+				Fee:       lib.Fee{GasLimit: 21000, GasPrice: big.NewInt(1)},
+			},
+		},
+		{
+			name: "mainnet chain id in development mode",
+			payload: `{"nonce":1,"value":1000,"gasLimit":21000,"gasPrice":1,
+				"to":"` + testRecipient + `","data":"0x","chainId":1}`,
+			isDev:   true,
+			wantErr: ErrMainnetChainID,
+		},
+		{
+			name: "invalid access list",
+			payload: `{"type":1,"nonce":1,"value":1000,"gasLimit":21000,"gasPrice":1,
+				"to":"` + testRecipient + `","data":"0x","chainId":1,
+				"accessList":[{"address":"` + testToken + `","storageKeys":["0x01"]}]}`,
+			wantErr: ErrInvalidAccessList,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := adapter.DecodeTransaction(tt.payload, tt.isDev)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEthereumAdapter_TransactionHash(t *testing.T) {
+	adapter := NewEthereumAdapter(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	seed, err := hex.DecodeString(testSeedHex)
+	require.NoError(t, err)
+
+	payload := `{"nonce":1,"value":1000,"gasLimit":21000,"gasPrice":1,"to":"` + testRecipient + `","chainId":1}`
+	signedTx, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(seed), testDerivationPath, payload, false)
+	require.NoError(t, err)
+
+	// legacy transaction hashes are the Keccak-256 of their RLP encoding
+	signedBytes, err := hexutil.Decode(signedTx)
+	require.NoError(t, err)
+
+	got, err := adapter.TransactionHash(payload, signedTx, false)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256Hash(signedBytes).Hex(), got)
+
+	_, err = adapter.TransactionHash(payload, "0x1234", false)
+	assert.ErrorIs(t, err, ErrInvalidPayloadData)
+}
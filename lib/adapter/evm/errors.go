@@ -5,4 +5,10 @@ import "errors"
 var (
 	ErrInvalidECDSAPublicKey = errors.New("invalid ECDSA public key")
 	ErrInvalidPayloadData    = errors.New("invalid payload data")
+	ErrInvalidAccessList     = errors.New("invalid access list")
+	ErrMainnetChainID        = errors.New("mainnet chain id is not allowed in development mode")
+
+	ErrUnsupportedMessageMethod = errors.New("unsupported message signing method")
+	ErrInvalidMessage           = errors.New("invalid message")
+	ErrInvalidTypedData         = errors.New("invalid typed data")
 )
@@ -1,7 +1,6 @@
 package evm
 
 import (
-	"bytes"
 	"crypto/ecdsa"
 	"encoding/json"
 	"fmt"
@@ -11,6 +10,7 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -20,14 +20,16 @@ import (
 )
 
 const (
-	// maskingLength is the number of characters to show at the end of masked keys
-	maskingLength = 4
+	// methodIDLength is the length of the selector prefixing contract call data
+	methodIDLength = 4
 )
 
 type EthereumAdapter struct {
 	logger             *slog.Logger
 	availableCoinTypes []uint16
 	zeroAddress        string
+	// mainnetChainIDs are rejected when signing in development mode
+	mainnetChainIDs []int64
 }
 
 func NewEthereumAdapter(logger *slog.Logger) *EthereumAdapter {
@@ -42,6 +44,14 @@ func NewEthereumAdapter(logger *slog.Logger) *EthereumAdapter {
 			slip44.Harmony,
 		},
 		zeroAddress: "0x0000000000000000000000000000000000000000",
+		mainnetChainIDs: []int64{
+			1,          // Ethereum
+			56,         // BNB Smart Chain
+			137,        // Polygon
+			250,        // Fantom
+			43114,      // Avalanche C-Chain
+			1666600000, // Harmony
+		},
 	}
 }
 
@@ -49,11 +59,11 @@ func (e *EthereumAdapter) CanDo(coinType uint16) bool {
 	return slices.Contains(e.availableCoinTypes, coinType)
 }
 
-func (e *EthereumAdapter) DerivePrivateKey(seed []byte, derivationPath string, isDev bool) (string, error) {
+func (e *EthereumAdapter) DerivePrivateKey(keys *lib.Keychain, derivationPath string, isDev bool) (string, error) {
 	logger := e.logger.With(slog.String("op", "derive_private_key"), slog.String("derivationPath", derivationPath))
 	logger.Info("Deriving private key")
 
-	btcecPrivateKey, err := lib.DerivePrivateKey(seed, derivationPath, isDev)
+	btcecPrivateKey, err := keys.DerivePrivateKey(derivationPath)
 	if err != nil {
 		logger.Error("Failed to derive private key", "error", err)
 		return "", err
@@ -62,17 +72,16 @@ func (e *EthereumAdapter) DerivePrivateKey(seed []byte, derivationPath string, i
 	privateKey := crypto.FromECDSA(btcecPrivateKey.ToECDSA())
 	privateKeyStr := hexutil.Encode(privateKey)[2:]
 
-	maskedKey := strings.Repeat("*", len(privateKeyStr)-maskingLength) + privateKeyStr[len(privateKeyStr)-maskingLength:]
-	logger.Info("Private key derived successfully", "privateKey", maskedKey)
+	logger.Debug("Private key derived successfully")
 
 	return privateKeyStr, nil
 }
 
-func (e *EthereumAdapter) DerivePublicKey(seed []byte, derivationPath string, isDev bool) (string, error) {
+func (e *EthereumAdapter) DerivePublicKey(keys *lib.Keychain, derivationPath string, isDev bool) (string, error) {
 	logger := e.logger.With(slog.String("op", "derive_public_key"), slog.String("derivationPath", derivationPath))
 	logger.Info("Deriving public key")
 
-	prvKey, err := e.DerivePrivateKey(seed, derivationPath, isDev)
+	prvKey, err := e.DerivePrivateKey(keys, derivationPath, isDev)
 	if err != nil {
 		logger.Error("Failed to derive private key", "error", err)
 		return "", err
@@ -90,17 +99,39 @@ func (e *EthereumAdapter) DerivePublicKey(seed []byte, derivationPath string, is
 	publicKeyBytes := crypto.CompressPubkey(publicKeyECDSA)
 	publicKeyStr := hexutil.Encode(publicKeyBytes)[2:]
 
-	maskedKey := strings.Repeat("*", len(publicKeyStr)-maskingLength) + publicKeyStr[len(publicKeyStr)-maskingLength:]
-	logger.Info("Public key derived successfully", "publicKey", maskedKey)
+	logger.Debug("Public key derived successfully", "publicKey", publicKeyStr)
 
 	return publicKeyStr, nil
 }
 
-func (e *EthereumAdapter) DeriveAddress(seed []byte, derivationPath string, isDev bool) (string, error) {
+// DeriveECPublicKey derives the secp256k1 public key of derivationPath
+func (e *EthereumAdapter) DeriveECPublicKey(keys *lib.Keychain, derivationPath string, isDev bool) (*btcec.PublicKey, error) {
+	privateKey, err := keys.DerivePrivateKey(derivationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return privateKey.PubKey(), nil
+}
+
+// ResolveDerivationPath returns the absolute path the keys of derivationPath
+// are derived at, derivationPath itself
+func (e *EthereumAdapter) ResolveDerivationPath(derivationPath string) (string, error) {
+	return derivationPath, nil
+}
+
+// EncodePublicKey returns the compressed hex public key and the checksummed
+// address of publicKey, the key of derivationPath
+func (e *EthereumAdapter) EncodePublicKey(publicKey *btcec.PublicKey, _ string, _ bool) (string, string, error) {
+	publicKeyECDSA := publicKey.ToECDSA()
+	return hexutil.Encode(crypto.CompressPubkey(publicKeyECDSA))[2:], crypto.PubkeyToAddress(*publicKeyECDSA).Hex(), nil
+}
+
+func (e *EthereumAdapter) DeriveAddress(keys *lib.Keychain, derivationPath string, isDev bool) (string, error) {
 	logger := e.logger.With(slog.String("op", "derive_address"), slog.String("derivationPath", derivationPath))
 	logger.Info("Deriving address")
 
-	prvKey, err := e.DerivePrivateKey(seed, derivationPath, isDev)
+	prvKey, err := e.DerivePrivateKey(keys, derivationPath, isDev)
 	if err != nil {
 		logger.Error("Failed to derive private key", "error", err)
 		return "", err
@@ -117,16 +148,44 @@ func (e *EthereumAdapter) DeriveAddress(seed []byte, derivationPath string, isDe
 	}
 
 	address := crypto.PubkeyToAddress(*publicKeyECDSA).Hex()
-	logger.Info("Address derived successfully", "address", address)
+	logger.Debug("Address derived successfully", "address", address)
 
 	return address, nil
 }
 
+// validateFees checks the fee fields required by the transaction type
+func validateFees(payload lib.EthereumRawTx) bool {
+	switch payload.Type {
+	case types.LegacyTxType:
+		return payload.GasPrice != nil && payload.GasPrice.Sign() >= 0 &&
+			payload.MaxFeePerGas == nil && payload.MaxPriorityFeePerGas == nil &&
+			len(payload.AccessList) == 0
+
+	case types.AccessListTxType:
+		return payload.GasPrice != nil && payload.GasPrice.Sign() >= 0 &&
+			payload.MaxFeePerGas == nil && payload.MaxPriorityFeePerGas == nil
+
+	case types.DynamicFeeTxType:
+		return payload.GasPrice == nil &&
+			payload.MaxFeePerGas != nil && payload.MaxFeePerGas.Sign() >= 0 &&
+			payload.MaxPriorityFeePerGas != nil && payload.MaxPriorityFeePerGas.Sign() >= 0 &&
+			payload.MaxPriorityFeePerGas.Cmp(payload.MaxFeePerGas) <= 0
+
+	default:
+		return false
+	}
+}
+
 func validatePayload(payload lib.EthereumRawTx, zeroAddress string) (isValid bool, txType string) {
-	// Value, chainId, GasPrice should not be negative
-	if payload.ChainID.Cmp(big.NewInt(0)) == -1 ||
-		payload.Value.Cmp(big.NewInt(0)) == -1 ||
-		payload.GasPrice.Cmp(big.NewInt(0)) == -1 {
+	// Value, chainId and fees should not be negative
+	if payload.ChainID == nil || payload.ChainID.Sign() < 0 ||
+		(payload.Value != nil && payload.Value.Sign() < 0) ||
+		!validateFees(payload) {
+		return false, ""
+	}
+
+	// typed transactions are always replay protected
+	if payload.Type != types.LegacyTxType && payload.ChainID.Sign() == 0 {
 		return false, ""
 	}
 
@@ -141,7 +200,7 @@ func validatePayload(payload lib.EthereumRawTx, zeroAddress string) (isValid boo
 			return false, ""
 		}
 		transactionType := "Ether Transfer"
-		if payload.Data != "" {
+		if payload.Data != "" && payload.Data != "0x" {
 			transactionType = "Contract Function Call"
 		}
 
@@ -150,39 +209,126 @@ func validatePayload(payload lib.EthereumRawTx, zeroAddress string) (isValid boo
 	return false, ""
 }
 
-func (e *EthereumAdapter) createRawTransaction(payloadString string) (*types.Transaction, *big.Int, error) {
-	logger := e.logger.With(slog.String("op", "create_raw_transaction"))
-	logger.Info("Creating raw transaction")
+// buildAccessList converts the payload access list, validating addresses and storage keys
+func buildAccessList(tuples []lib.EthereumAccessTuple) (types.AccessList, error) {
+	accessList := make(types.AccessList, 0, len(tuples))
+	for _, tuple := range tuples {
+		if !common.IsHexAddress(tuple.Address) {
+			return nil, fmt.Errorf("%w: address %q", ErrInvalidAccessList, tuple.Address)
+		}
+
+		storageKeys := make([]common.Hash, 0, len(tuple.StorageKeys))
+		for _, key := range tuple.StorageKeys {
+			keyBytes, err := hexutil.Decode(key)
+			if err != nil || len(keyBytes) != common.HashLength {
+				return nil, fmt.Errorf("%w: storage key %q", ErrInvalidAccessList, key)
+			}
+			storageKeys = append(storageKeys, common.BytesToHash(keyBytes))
+		}
+
+		accessList = append(accessList, types.AccessTuple{
+			Address:     common.HexToAddress(tuple.Address),
+			StorageKeys: storageKeys,
+		})
+	}
+	return accessList, nil
+}
 
+// parsePayload decodes and validates payloadString, and returns the payload
+// with its transaction type and access list. It is shared by signing and
+// decoding, so policies are evaluated against the payloads that can be signed.
+// In development mode transactions for mainnet chain IDs are rejected.
+func (e *EthereumAdapter) parsePayload(payloadString string,
+	isDev bool) (*lib.EthereumRawTx, string, types.AccessList, error) {
 	var payload lib.EthereumRawTx
 	if err := json.Unmarshal([]byte(payloadString), &payload); err != nil ||
 		reflect.DeepEqual(payload, lib.EthereumRawTx{}) {
-		return nil, nil, fmt.Errorf("unable to decode payload=[%v]: %w", payloadString, err)
+		return nil, "", nil, fmt.Errorf("unable to decode payload: %w", ErrInvalidPayloadData)
 	}
 
 	// validate payload data
 	valid, txType := validatePayload(payload, e.zeroAddress)
 	if !valid {
-		return nil, nil, ErrInvalidPayloadData
+		return nil, "", nil, ErrInvalidPayloadData
 	}
 
-	logger.Info("validate payload", "txType", txType)
+	if isDev && payload.ChainID.IsInt64() && slices.Contains(e.mainnetChainIDs, payload.ChainID.Int64()) {
+		return nil, "", nil, fmt.Errorf("%w: %s", ErrMainnetChainID, payload.ChainID)
+	}
+
+	accessList, err := buildAccessList(payload.AccessList)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return &payload, txType, accessList, nil
+}
+
+// createRawTransaction builds the unsigned transaction of payloadString.
+// In development mode transactions for mainnet chain IDs are rejected.
+func (e *EthereumAdapter) createRawTransaction(payloadString string,
+	isDev bool) (*types.Transaction, *big.Int, error) {
+	logger := e.logger.With(slog.String("op", "create_raw_transaction"))
+	logger.Info("Creating raw transaction")
+
+	payload, txType, accessList, err := e.parsePayload(payloadString, isDev)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// contract creation transactions have no recipient
+	var to *common.Address
+	if payload.To != "" {
+		address := common.HexToAddress(payload.To)
+		to = &address
+	}
+
+	logger.Info("validate payload", "txType", txType, "envelope", payload.Type)
 	// create raw transaction from payload data
-	return types.NewTransaction(
-		payload.Nonce,
-		common.HexToAddress(payload.To),
-		payload.Value,
-		payload.GasLimit,
-		payload.GasPrice,
-		common.FromHex(payload.Data),
-	), payload.ChainID, nil
+	var txData types.TxData
+	switch payload.Type {
+	case types.AccessListTxType:
+		txData = &types.AccessListTx{
+			ChainID:    payload.ChainID,
+			Nonce:      payload.Nonce,
+			GasPrice:   payload.GasPrice,
+			Gas:        payload.GasLimit,
+			To:         to,
+			Value:      payload.Value,
+			Data:       common.FromHex(payload.Data),
+			AccessList: accessList,
+		}
+	case types.DynamicFeeTxType:
+		txData = &types.DynamicFeeTx{
+			ChainID:    payload.ChainID,
+			Nonce:      payload.Nonce,
+			GasTipCap:  payload.MaxPriorityFeePerGas,
+			GasFeeCap:  payload.MaxFeePerGas,
+			Gas:        payload.GasLimit,
+			To:         to,
+			Value:      payload.Value,
+			Data:       common.FromHex(payload.Data),
+			AccessList: accessList,
+		}
+	default:
+		txData = &types.LegacyTx{
+			Nonce:    payload.Nonce,
+			GasPrice: payload.GasPrice,
+			Gas:      payload.GasLimit,
+			To:       to,
+			Value:    payload.Value,
+			Data:     common.FromHex(payload.Data),
+		}
+	}
+
+	return types.NewTx(txData), payload.ChainID, nil
 }
 
-func (e *EthereumAdapter) CreateSignedTransaction(seed []byte, derivationPath, payload string) (string, error) {
+func (e *EthereumAdapter) CreateSignedTransaction(keys *lib.Keychain, derivationPath, payload string,
+	isDev bool) (string, error) {
 	logger := e.logger.With(slog.String("op", "create_signed_transaction"), slog.String("derivationPath", derivationPath))
 	logger.Info("Creating signed transaction")
 
-	prvKey, err := e.DerivePrivateKey(seed, derivationPath, false)
+	prvKey, err := e.DerivePrivateKey(keys, derivationPath, isDev)
 	if err != nil {
 		logger.Error("Failed to derive private key", "error", err)
 		return "", err
@@ -193,23 +339,25 @@ func (e *EthereumAdapter) CreateSignedTransaction(seed []byte, derivationPath, p
 		return "", err
 	}
 
-	rawTx, chainID, err := e.createRawTransaction(payload)
+	rawTx, chainID, err := e.createRawTransaction(payload, isDev)
 	if err != nil {
 		logger.Error("Failed to create raw transaction", "error", err)
 		return "", err
 	}
 
 	// sign raw transaction using raw transaction + chainId + private key
-	signedTx, err := types.SignTx(rawTx, types.NewEIP155Signer(chainID), privateKey)
+	signedTx, err := types.SignTx(rawTx, types.LatestSignerForChainID(chainID), privateKey)
+	if err != nil {
+		return "", err
+	}
+	// obtains signed transaction hex, typed transactions use the EIP-2718 envelope
+	signedTxBytes, err := signedTx.MarshalBinary()
 	if err != nil {
 		return "", err
 	}
-	// obtains signed transaction hex
-	var signedTxBuff bytes.Buffer
-	_ = signedTx.EncodeRLP(&signedTxBuff)
-	txHex := hexutil.Encode(signedTxBuff.Bytes())
+	txHex := hexutil.Encode(signedTxBytes)
 
-	logger.Info("Signed transaction created successfully", "tx", txHex)
+	logger.Info("Signed transaction created successfully", "txHash", signedTx.Hash().Hex())
 
 	return txHex, nil
 }
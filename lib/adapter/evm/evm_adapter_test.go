@@ -2,11 +2,14 @@ package evm
 
 import (
 	"encoding/hex"
+	"fmt"
 	"log/slog"
 	"math/big"
 	"os"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -198,7 +201,7 @@ func TestEthereumAdapter_DerivePrivateKey(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := adapter.DerivePrivateKey(tt.seed, tt.derivationPath, tt.isDev)
+			got, err := adapter.DerivePrivateKey(lib.NewSeedKeychain(tt.seed), tt.derivationPath, tt.isDev)
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Empty(t, got)
@@ -253,7 +256,7 @@ func TestEthereumAdapter_DerivePublicKey(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := adapter.DerivePublicKey(tt.seed, tt.derivationPath, tt.isDev)
+			got, err := adapter.DerivePublicKey(lib.NewSeedKeychain(tt.seed), tt.derivationPath, tt.isDev)
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Empty(t, got)
@@ -308,7 +311,7 @@ func TestEthereumAdapter_DeriveAddress(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := adapter.DeriveAddress(tt.seed, tt.derivationPath, tt.isDev)
+			got, err := adapter.DeriveAddress(lib.NewSeedKeychain(tt.seed), tt.derivationPath, tt.isDev)
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Empty(t, got)
@@ -439,7 +442,7 @@ func TestEthereumAdapter_CreateSignedTransaction(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := adapter.CreateSignedTransaction(tt.seed, tt.derivationPath, tt.payload)
+			got, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(tt.seed), tt.derivationPath, tt.payload, false)
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Empty(t, got)
@@ -455,6 +458,17 @@ func TestEthereumAdapter_CreateSignedTransaction(t *testing.T) {
 	}
 }
 
+func TestEthereumAdapter_CreateSignedTransaction_MalformedPayload(t *testing.T) {
+	adapter := NewEthereumAdapter(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	// payloads may carry customer data, errors must not echo them
+	for _, payload := range []string{`{"to": "0xsecret-recipient", "nonce": }`, `{"memo": "secret-recipient"}`} {
+		_, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(nil), testDerivationPath, payload, false)
+		require.ErrorIs(t, err, ErrInvalidPayloadData, payload)
+		assert.NotContains(t, err.Error(), "secret-recipient", payload)
+	}
+}
+
 func TestValidatePayload(t *testing.T) {
 	adapter := NewEthereumAdapter(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 
@@ -576,6 +590,138 @@ func TestValidatePayload(t *testing.T) {
 			want:   false,
 			wantTx: "",
 		},
+		{
+			name: "legacy without gas price",
+			payload: lib.EthereumRawTx{
+				Nonce:    42,
+				Value:    big.NewInt(1000000000000000000),
+				GasLimit: 21000,
+				To:       "0x742d35Cc6634C0532925a3b8D359A5C5119e32C8",
+				ChainID:  big.NewInt(1),
+			},
+			want:   false,
+			wantTx: "",
+		},
+		{
+			name: "legacy with access list",
+			payload: lib.EthereumRawTx{
+				Nonce:      42,
+				Value:      big.NewInt(1000000000000000000),
+				GasLimit:   21000,
+				GasPrice:   big.NewInt(20000000000),
+				To:         "0x742d35Cc6634C0532925a3b8D359A5C5119e32C8",
+				ChainID:    big.NewInt(1),
+				AccessList: []lib.EthereumAccessTuple{{Address: "0x742d35Cc6634C0532925a3b8D359A5C5119e32C8"}},
+			},
+			want:   false,
+			wantTx: "",
+		},
+		{
+			name: "valid access list transfer",
+			payload: lib.EthereumRawTx{
+				Type:       1,
+				Nonce:      42,
+				Value:      big.NewInt(1000000000000000000),
+				GasLimit:   21000,
+				GasPrice:   big.NewInt(20000000000),
+				To:         "0x742d35Cc6634C0532925a3b8D359A5C5119e32C8",
+				ChainID:    big.NewInt(1),
+				AccessList: []lib.EthereumAccessTuple{{Address: "0x742d35Cc6634C0532925a3b8D359A5C5119e32C8"}},
+			},
+			want:   true,
+			wantTx: "Ether Transfer",
+		},
+		{
+			name: "valid dynamic fee contract call",
+			payload: lib.EthereumRawTx{
+				Type:                 2,
+				Nonce:                42,
+				Value:                big.NewInt(0),
+				GasLimit:             50000,
+				MaxFeePerGas:         big.NewInt(30000000000),
+				MaxPriorityFeePerGas: big.NewInt(1000000000),
+				To:                   "0x742d35Cc6634C0532925a3b8D359A5C5119e32C8",
+				Data:                 "0xa9059cbb",
+				ChainID:              big.NewInt(1),
+			},
+			want:   true,
+			wantTx: "Contract Function Call",
+		},
+		{
+			name: "dynamic fee with gas price",
+			payload: lib.EthereumRawTx{
+				Type:                 2,
+				Nonce:                42,
+				Value:                big.NewInt(0),
+				GasLimit:             21000,
+				GasPrice:             big.NewInt(20000000000),
+				MaxFeePerGas:         big.NewInt(30000000000),
+				MaxPriorityFeePerGas: big.NewInt(1000000000),
+				To:                   "0x742d35Cc6634C0532925a3b8D359A5C5119e32C8",
+				ChainID:              big.NewInt(1),
+			},
+			want:   false,
+			wantTx: "",
+		},
+		{
+			name: "dynamic fee with priority fee above max fee",
+			payload: lib.EthereumRawTx{
+				Type:                 2,
+				Nonce:                42,
+				Value:                big.NewInt(0),
+				GasLimit:             21000,
+				MaxFeePerGas:         big.NewInt(1000000000),
+				MaxPriorityFeePerGas: big.NewInt(2000000000),
+				To:                   "0x742d35Cc6634C0532925a3b8D359A5C5119e32C8",
+				ChainID:              big.NewInt(1),
+			},
+			want:   false,
+			wantTx: "",
+		},
+		{
+			name: "dynamic fee with negative max fee",
+			payload: lib.EthereumRawTx{
+				Type:                 2,
+				Nonce:                42,
+				Value:                big.NewInt(0),
+				GasLimit:             21000,
+				MaxFeePerGas:         big.NewInt(-1),
+				MaxPriorityFeePerGas: big.NewInt(-2),
+				To:                   "0x742d35Cc6634C0532925a3b8D359A5C5119e32C8",
+				ChainID:              big.NewInt(1),
+			},
+			want:   false,
+			wantTx: "",
+		},
+		{
+			name: "typed transaction without chain id",
+			payload: lib.EthereumRawTx{
+				Type:                 2,
+				Nonce:                42,
+				Value:                big.NewInt(0),
+				GasLimit:             21000,
+				MaxFeePerGas:         big.NewInt(30000000000),
+				MaxPriorityFeePerGas: big.NewInt(1000000000),
+				To:                   "0x742d35Cc6634C0532925a3b8D359A5C5119e32C8",
+				ChainID:              big.NewInt(0),
+			},
+			want:   false,
+			wantTx: "",
+		},
+		{
+			name: "unknown transaction type",
+			payload: lib.EthereumRawTx{
+				Type:     3,
+				Nonce:    42,
+				Value:    big.NewInt(0),
+				GasLimit: 21000,
+				GasPrice: big.NewInt(20000000000),
+				To:       "0x742d35Cc6634C0532925a3b8D359A5C5119e32C8",
+				ChainID:  big.NewInt(1),
+			},
+			want:   false,
+			wantTx: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -587,6 +733,149 @@ func TestValidatePayload(t *testing.T) {
 	}
 }
 
+func TestEthereumAdapter_CreateSignedTransaction_Typed(t *testing.T) {
+	testSeed, err := hex.DecodeString(testSeedHex)
+	require.NoError(t, err)
+
+	adapter := NewEthereumAdapter(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	const storageKey = "0x0000000000000000000000000000000000000000000000000000000000000003"
+
+	tests := []struct {
+		name            string
+		payload         string
+		wantType        uint8
+		wantAccessList  int
+		wantGasFeeCap   int64
+		wantGasTipCap   int64
+		wantErr         error
+		wantContractNew bool
+	}{
+		{
+			name: "legacy transaction",
+			payload: `{"nonce":42,"value":1000000000000000000,"gasLimit":21000,"gasPrice":20000000000,
+				"to":"0x742d35Cc6634C0532925a3b8D359A5C5119e32C8","chainId":1}`,
+			wantType:      types.LegacyTxType,
+			wantGasFeeCap: 20000000000,
+			wantGasTipCap: 20000000000,
+		},
+		{
+			name: "access list transaction",
+			payload: `{"type":1,"nonce":42,"value":1000000000000000000,"gasLimit":30000,"gasPrice":20000000000,
+				"to":"0x742d35Cc6634C0532925a3b8D359A5C5119e32C8","chainId":1,
+				"accessList":[{"address":"0x742d35Cc6634C0532925a3b8D359A5C5119e32C8","storageKeys":["` + storageKey + `"]}]}`,
+			wantType:       types.AccessListTxType,
+			wantAccessList: 1,
+			wantGasFeeCap:  20000000000,
+			wantGasTipCap:  20000000000,
+		},
+		{
+			name: "dynamic fee transaction",
+			payload: `{"type":2,"nonce":42,"value":1000000000000000000,"gasLimit":21000,
+				"maxFeePerGas":30000000000,"maxPriorityFeePerGas":1500000000,
+				"to":"0x742d35Cc6634C0532925a3b8D359A5C5119e32C8","chainId":137}`,
+			wantType:      types.DynamicFeeTxType,
+			wantGasFeeCap: 30000000000,
+			wantGasTipCap: 1500000000,
+		},
+		{
+			name: "dynamic fee contract creation",
+			payload: `{"type":2,"nonce":0,"value":0,"gasLimit":500000,
+				"maxFeePerGas":30000000000,"maxPriorityFeePerGas":1500000000,
+				"data":"0x608060405234801561001057600080fd5b50","chainId":1}`,
+			wantType:        types.DynamicFeeTxType,
+			wantGasFeeCap:   30000000000,
+			wantGasTipCap:   1500000000,
+			wantContractNew: true,
+		},
+		{
+			name: "dynamic fee without priority fee",
+			payload: `{"type":2,"nonce":42,"value":1,"gasLimit":21000,"maxFeePerGas":30000000000,
+				"to":"0x742d35Cc6634C0532925a3b8D359A5C5119e32C8","chainId":1}`,
+			wantErr: ErrInvalidPayloadData,
+		},
+		{
+			name: "invalid access list address",
+			payload: `{"type":1,"nonce":42,"value":1,"gasLimit":21000,"gasPrice":20000000000,
+				"to":"0x742d35Cc6634C0532925a3b8D359A5C5119e32C8","chainId":1,
+				"accessList":[{"address":"invalid","storageKeys":[]}]}`,
+			wantErr: ErrInvalidAccessList,
+		},
+		{
+			name: "invalid access list storage key",
+			payload: `{"type":2,"nonce":42,"value":1,"gasLimit":21000,
+				"maxFeePerGas":30000000000,"maxPriorityFeePerGas":1500000000,
+				"to":"0x742d35Cc6634C0532925a3b8D359A5C5119e32C8","chainId":1,
+				"accessList":[{"address":"0x742d35Cc6634C0532925a3b8D359A5C5119e32C8","storageKeys":["0x03"]}]}`,
+			wantErr: ErrInvalidAccessList,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(testSeed), testDerivationPath, tt.payload, false)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, got)
+				return
+			}
+			require.NoError(t, err)
+
+			var tx types.Transaction
+			require.NoError(t, tx.UnmarshalBinary(common.FromHex(got)))
+			assert.Equal(t, tt.wantType, tx.Type())
+			assert.Len(t, tx.AccessList(), tt.wantAccessList)
+			assert.Equal(t, big.NewInt(tt.wantGasFeeCap), tx.GasFeeCap())
+			assert.Equal(t, big.NewInt(tt.wantGasTipCap), tx.GasTipCap())
+			assert.Equal(t, tt.wantContractNew, tx.To() == nil)
+
+			// signature recovers to the derived address
+			sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), &tx)
+			require.NoError(t, err)
+			assert.Equal(t, expectedAddress, sender.Hex())
+		})
+	}
+}
+
+func TestEthereumAdapter_CreateSignedTransaction_DevMode(t *testing.T) {
+	testSeed, err := hex.DecodeString(testSeedHex)
+	require.NoError(t, err)
+
+	adapter := NewEthereumAdapter(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	tests := []struct {
+		name    string
+		chainID int64
+		isDev   bool
+		wantErr error
+	}{
+		{name: "mainnet outside development mode", chainID: 1},
+		{name: "sepolia in development mode", chainID: 11155111, isDev: true},
+		{name: "amoy in development mode", chainID: 80002, isDev: true},
+		{name: "ethereum mainnet in development mode", chainID: 1, isDev: true, wantErr: ErrMainnetChainID},
+		{name: "polygon mainnet in development mode", chainID: 137, isDev: true, wantErr: ErrMainnetChainID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := fmt.Sprintf(`{"nonce":1,"value":1,"gasLimit":21000,"gasPrice":20000000000,
+				"to":"0x742d35Cc6634C0532925a3b8D359A5C5119e32C8","chainId":%d}`, tt.chainID)
+
+			got, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(testSeed), testDerivationPath, payload, tt.isDev)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, got)
+				return
+			}
+			require.NoError(t, err)
+
+			var tx types.Transaction
+			require.NoError(t, tx.UnmarshalBinary(common.FromHex(got)))
+			assert.Equal(t, big.NewInt(tt.chainID), tx.ChainId())
+		})
+	}
+}
+
 // Benchmark tests
 func BenchmarkEthereumAdapter_DerivePrivateKey(b *testing.B) {
 	testSeed, _ := hex.DecodeString(testSeedHex)
@@ -594,7 +883,7 @@ func BenchmarkEthereumAdapter_DerivePrivateKey(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = adapter.DerivePrivateKey(testSeed, testDerivationPath, false)
+		_, _ = adapter.DerivePrivateKey(lib.NewSeedKeychain(testSeed), testDerivationPath, false)
 	}
 }
 
@@ -604,6 +893,6 @@ func BenchmarkEthereumAdapter_DeriveAddress(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = adapter.DeriveAddress(testSeed, testDerivationPath, false)
+		_, _ = adapter.DeriveAddress(lib.NewSeedKeychain(testSeed), testDerivationPath, false)
 	}
 }
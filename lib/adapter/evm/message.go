@@ -0,0 +1,105 @@
+package evm
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/payment-system/dq-vault/lib"
+)
+
+const (
+	// signatureRecoveryOffset is added to the recovery id, wallets expect v in {27, 28}
+	signatureRecoveryOffset = 27
+)
+
+// personalSignHash returns the EIP-191 hash of message.
+// 0x prefixed hex messages are signed as raw bytes, anything else as UTF-8 text.
+func personalSignHash(message string) ([]byte, error) {
+	data := []byte(message)
+	if strings.HasPrefix(message, "0x") {
+		decoded, err := hexutil.Decode(message)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+		}
+		data = decoded
+	}
+	return accounts.TextHash(data), nil
+}
+
+// typedDataHash returns the EIP-712 hash of a JSON encoded typed data object
+// (domain, types, primaryType and message)
+func typedDataHash(message string) ([]byte, error) {
+	var typedData apitypes.TypedData
+	if err := json.Unmarshal([]byte(message), &typedData); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTypedData, err)
+	}
+
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTypedData, err)
+	}
+	return hash, nil
+}
+
+// signHash signs hash and returns the 65 byte r||s||v signature with v in {27, 28}
+func signHash(privateKey *ecdsa.PrivateKey, hash []byte) (string, error) {
+	sig, err := crypto.Sign(hash, privateKey)
+	if err != nil {
+		return "", err
+	}
+	sig[crypto.RecoveryIDOffset] += signatureRecoveryOffset
+
+	return hexutil.Encode(sig), nil
+}
+
+// SignMessage signs an off-chain message with the key of derivationPath.
+// method is either lib.MessageMethodPersonalSign or lib.MessageMethodSignTypedDataV4.
+func (e *EthereumAdapter) SignMessage(keys *lib.Keychain, derivationPath, method, message string) (string, error) {
+	logger := e.logger.With(slog.String("op", "sign_message"), slog.String("derivationPath", derivationPath),
+		slog.String("method", method))
+	logger.Info("Signing message")
+
+	var (
+		hash []byte
+		err  error
+	)
+	switch method {
+	case lib.MessageMethodPersonalSign:
+		hash, err = personalSignHash(message)
+	case lib.MessageMethodSignTypedDataV4:
+		hash, err = typedDataHash(message)
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedMessageMethod, method)
+	}
+	if err != nil {
+		logger.Error("Failed to hash message", "error", err)
+		return "", err
+	}
+
+	prvKey, err := e.DerivePrivateKey(keys, derivationPath, false)
+	if err != nil {
+		logger.Error("Failed to derive private key", "error", err)
+		return "", err
+	}
+
+	privateKey, err := crypto.HexToECDSA(prvKey)
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := signHash(privateKey, hash)
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info("Message signed successfully")
+
+	return signature, nil
+}
@@ -0,0 +1,165 @@
+package evm
+
+import (
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/payment-system/dq-vault/lib"
+)
+
+// EIP-712 example from the specification
+const testTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestPersonalSignHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "utf-8 text",
+			message: "hello",
+			want:    hexutil.Encode(crypto.Keccak256([]byte("\x19Ethereum Signed Message:\n5hello"))),
+		},
+		{
+			name:    "hex bytes",
+			message: "0x68656c6c6f",
+			want:    hexutil.Encode(crypto.Keccak256([]byte("\x19Ethereum Signed Message:\n5hello"))),
+		},
+		{
+			name:    "invalid hex",
+			message: "0xzz",
+			wantErr: ErrInvalidMessage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := personalSignHash(tt.message)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, hexutil.Encode(got))
+		})
+	}
+}
+
+func TestTypedDataHash(t *testing.T) {
+	got, err := typedDataHash(testTypedData)
+	require.NoError(t, err)
+	assert.Equal(t, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", hexutil.Encode(got))
+
+	_, err = typedDataHash(`{invalid json}`)
+	assert.ErrorIs(t, err, ErrInvalidTypedData)
+
+	_, err = typedDataHash(`{"types": {}, "primaryType": "Mail", "domain": {}, "message": {}}`)
+	assert.ErrorIs(t, err, ErrInvalidTypedData)
+}
+
+func TestEthereumAdapter_SignMessage(t *testing.T) {
+	adapter := NewEthereumAdapter(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	seed, err := hex.DecodeString(testSeedHex)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		method  string
+		message string
+		hash    func() ([]byte, error)
+		wantErr error
+	}{
+		{
+			name:    "personal_sign text",
+			method:  lib.MessageMethodPersonalSign,
+			message: "hello",
+			hash:    func() ([]byte, error) { return personalSignHash("hello") },
+		},
+		{
+			name:    "personal_sign hex",
+			method:  lib.MessageMethodPersonalSign,
+			message: "0xdeadbeef",
+			hash:    func() ([]byte, error) { return personalSignHash("0xdeadbeef") },
+		},
+		{
+			name:    "eth_signTypedData_v4",
+			method:  lib.MessageMethodSignTypedDataV4,
+			message: testTypedData,
+			hash:    func() ([]byte, error) { return typedDataHash(testTypedData) },
+		},
+		{
+			name:    "unsupported method",
+			method:  "eth_sign",
+			message: "hello",
+			wantErr: ErrUnsupportedMessageMethod,
+		},
+		{
+			name:    "invalid typed data",
+			method:  lib.MessageMethodSignTypedDataV4,
+			message: "hello",
+			wantErr: ErrInvalidTypedData,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := adapter.SignMessage(lib.NewSeedKeychain(seed), testDerivationPath, tt.method, tt.message)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			sig, err := hexutil.Decode(got)
+			require.NoError(t, err)
+			require.Len(t, sig, crypto.SignatureLength)
+			assert.Contains(t, []byte{27, 28}, sig[crypto.RecoveryIDOffset])
+
+			hash, err := tt.hash()
+			require.NoError(t, err)
+			sig[crypto.RecoveryIDOffset] -= signatureRecoveryOffset
+			pubKey, err := crypto.SigToPub(hash, sig)
+			require.NoError(t, err)
+			assert.Equal(t, expectedAddress, crypto.PubkeyToAddress(*pubKey).Hex())
+		})
+	}
+}
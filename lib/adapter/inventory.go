@@ -1,13 +1,60 @@
 package adapter
 
-import "log/slog"
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/payment-system/dq-vault/lib"
+	"github.com/payment-system/dq-vault/lib/adapter/bitcoin"
+)
 
 type adapter interface {
 	CanDo(coinType uint16) bool
-	DerivePrivateKey(seed []byte, derivationPath string, isDev bool) (string, error)
-	DerivePublicKey(seed []byte, derivationPath string, isDev bool) (string, error)
-	DeriveAddress(seed []byte, derivationPath string, isDev bool) (string, error)
-	CreateSignedTransaction(seed []byte, derivationPath string, payload string) (string, error)
+	DerivePrivateKey(keys *lib.Keychain, derivationPath string, isDev bool) (string, error)
+	DerivePublicKey(keys *lib.Keychain, derivationPath string, isDev bool) (string, error)
+	DeriveAddress(keys *lib.Keychain, derivationPath string, isDev bool) (string, error)
+	CreateSignedTransaction(keys *lib.Keychain, derivationPath string, payload string, isDev bool) (string, error)
+}
+
+// partialSigner is implemented by adapters of UTXO chains that can add
+// signatures to partially signed transactions (PSBT)
+type partialSigner interface {
+	SignPartialTransaction(keys *lib.Keychain, derivationPath string, payload string) (string, error)
+}
+
+// messageSigner is implemented by adapters that can sign off-chain messages
+type messageSigner interface {
+	SignMessage(keys *lib.Keychain, derivationPath, method, message string) (string, error)
+}
+
+// ecPublicKeyDeriver is implemented by adapters of BIP-32 secp256k1 chains,
+// their public keys can be exported SEC1 encoded and as extended public keys
+type ecPublicKeyDeriver interface {
+	DeriveECPublicKey(keys *lib.Keychain, derivationPath string, isDev bool) (*btcec.PublicKey, error)
+}
+
+// pathResolver is implemented by adapters of BIP-32 chains, it returns the
+// absolute path the keys of a request path are derived at
+type pathResolver interface {
+	ResolveDerivationPath(derivationPath string) (string, error)
+}
+
+// transactionDecoder is implemented by adapters that can summarise a payload
+// without deriving any key, signing policies are evaluated against the summary
+type transactionDecoder interface {
+	DecodeTransaction(payload string, isDev bool) (*lib.TransactionSummary, error)
+}
+
+// transactionHasher is implemented by adapters that can compute the chain
+// transaction id of a payload and its signed form, for the audit journal
+type transactionHasher interface {
+	TransactionHash(payload, signedTx string, isDev bool) (string, error)
+}
+
+// isPartiallySignedPayload reports whether payload is a base64 encoded PSBT
+func isPartiallySignedPayload(payload string) bool {
+	return strings.HasPrefix(strings.TrimSpace(payload), bitcoin.PSBTBase64Magic)
 }
 
 type Inventory struct {
@@ -31,7 +78,28 @@ func (i *Inventory) getProvider(coinType uint16) adapter {
 	return nil
 }
 
-func (i *Inventory) DerivePublicKey(seed []byte, coinType uint16,
+// AccountPath returns the account of the absolute path the adapter of
+// coinType derives derivationPath at. It reports false unless the keys of
+// coinType are BIP-32 secp256k1 keys, which can be derived from the extended
+// key of their account.
+func (i *Inventory) AccountPath(coinType uint16, derivationPath string) (string, bool) {
+	adapter := i.getProvider(coinType)
+	if _, ok := adapter.(ecPublicKeyDeriver); !ok {
+		return "", false
+	}
+	resolver, ok := adapter.(pathResolver)
+	if !ok {
+		return "", false
+	}
+
+	path, err := resolver.ResolveDerivationPath(derivationPath)
+	if err != nil {
+		return "", false
+	}
+	return lib.AccountPath(path)
+}
+
+func (i *Inventory) DerivePublicKey(keys *lib.Keychain, coinType uint16,
 	derivationPath string, isDev bool) (string, error) {
 	logger := i.logger.With(slog.String("op", "derive_public_key"), slog.Uint64("coinType", uint64(coinType)))
 	logger.Info("Deriving public key")
@@ -42,18 +110,76 @@ func (i *Inventory) DerivePublicKey(seed []byte, coinType uint16,
 		return "", ErrNoAdapterFound
 	}
 
-	pubKey, err := adapter.DerivePublicKey(seed, derivationPath, isDev)
+	pubKey, err := adapter.DerivePublicKey(keys, derivationPath, isDev)
 	if err != nil {
 		logger.Error("Failed to derive public key", "error", err)
 		return "", err
 	}
 
-	logger.Info("Public key derived successfully", "pubKey", pubKey)
+	logger.Debug("Public key derived successfully", "pubKey", pubKey)
 
 	return pubKey, nil
 }
 
-func (i *Inventory) DeriveAddress(seed []byte, coinType uint16,
+// DeriveECPublicKey derives the secp256k1 public key of derivationPath,
+// callers pick the compressed or uncompressed SEC1 encoding
+func (i *Inventory) DeriveECPublicKey(keys *lib.Keychain, coinType uint16,
+	derivationPath string, isDev bool) (*btcec.PublicKey, error) {
+	logger := i.logger.With(slog.String("op", "derive_ec_public_key"), slog.Uint64("coinType", uint64(coinType)))
+	logger.Info("Deriving secp256k1 public key")
+
+	adapter := i.getProvider(coinType)
+	if adapter == nil {
+		logger.Error("No adapter found for coin type", "coinType", coinType)
+		return nil, ErrNoAdapterFound
+	}
+
+	deriver, ok := adapter.(ecPublicKeyDeriver)
+	if !ok {
+		logger.Error("Adapter does not use secp256k1 keys")
+		return nil, ErrECPublicKeyUnsupported
+	}
+
+	pubKey, err := deriver.DeriveECPublicKey(keys, derivationPath, isDev)
+	if err != nil {
+		logger.Error("Failed to derive public key", "error", err)
+		return nil, err
+	}
+
+	return pubKey, nil
+}
+
+// DeriveExtendedPublicKey derives the account-level extended public key of
+// derivationPath for watch-only wallets (xpub/ypub/zpub or their testnet forms)
+func (i *Inventory) DeriveExtendedPublicKey(keys *lib.Keychain, coinType uint16,
+	derivationPath string, isDev bool) (string, error) {
+	logger := i.logger.With(slog.String("op", "derive_extended_public_key"), slog.Uint64("coinType", uint64(coinType)))
+	logger.Info("Deriving extended public key")
+
+	adapter := i.getProvider(coinType)
+	if adapter == nil {
+		logger.Error("No adapter found for coin type", "coinType", coinType)
+		return "", ErrNoAdapterFound
+	}
+
+	// ed25519 keys (SLIP-0010) have no public child derivation
+	if _, ok := adapter.(ecPublicKeyDeriver); !ok {
+		logger.Error("Adapter does not support extended public keys")
+		return "", ErrExtendedPublicKeyUnsupported
+	}
+
+	xpub, err := lib.DeriveExtendedPublicKey(keys, derivationPath, isDev)
+	if err != nil {
+		logger.Error("Failed to derive extended public key", "error", err)
+		return "", err
+	}
+
+	logger.Debug("Extended public key derived successfully", "xpub", xpub)
+
+	return xpub, nil
+}
+
+func (i *Inventory) DeriveAddress(keys *lib.Keychain, coinType uint16,
 	derivationPath string, isDev bool) (string, error) {
 	logger := i.logger.With(slog.String("op", "derive_address"), slog.Uint64("coinType", uint64(coinType)))
 	logger.Info("Deriving address")
@@ -64,19 +190,19 @@ func (i *Inventory) DeriveAddress(seed []byte, coinType uint16,
 		return "", ErrNoAdapterFound
 	}
 
-	address, err := adapter.DeriveAddress(seed, derivationPath, isDev)
+	address, err := adapter.DeriveAddress(keys, derivationPath, isDev)
 	if err != nil {
 		logger.Error("Failed to derive address", "error", err)
 		return "", err
 	}
 
-	logger.Info("Address derived successfully", "address", address)
+	logger.Debug("Address derived successfully", "address", address)
 
 	return address, nil
 }
 
-func (i *Inventory) CreateSignedTransaction(seed []byte, coinType uint16,
-	derivationPath string, payload string, _ bool) (string, error) {
+func (i *Inventory) CreateSignedTransaction(keys *lib.Keychain, coinType uint16,
+	derivationPath string, payload string, isDev bool) (string, error) {
 	logger := i.logger.With(slog.String("op", "create_signed_transaction"), slog.Uint64("coinType", uint64(coinType)))
 	logger.Info("Creating signed transaction")
 
@@ -86,13 +212,116 @@ func (i *Inventory) CreateSignedTransaction(seed []byte, coinType uint16,
 		return "", ErrNoAdapterFound
 	}
 
-	tx, err := adapter.CreateSignedTransaction(seed, derivationPath, payload)
+	if isPartiallySignedPayload(payload) {
+		return i.signPartialTransaction(logger, adapter, keys, derivationPath, payload)
+	}
+
+	tx, err := adapter.CreateSignedTransaction(keys, derivationPath, payload, isDev)
 	if err != nil {
 		logger.Error("Failed to create signed transaction", "error", err)
 		return "", err
 	}
 
-	logger.Info("Signed transaction created successfully", "tx", tx)
+	logger.Info("Signed transaction created successfully")
 
 	return tx, nil
 }
+
+func (*Inventory) signPartialTransaction(logger *slog.Logger, adapter adapter, keys *lib.Keychain,
+	derivationPath string, payload string) (string, error) {
+	signer, ok := adapter.(partialSigner)
+	if !ok {
+		logger.Error("Adapter does not support partial signing")
+		return "", ErrPartialSigningUnsupported
+	}
+
+	psbt, err := signer.SignPartialTransaction(keys, derivationPath, strings.TrimSpace(payload))
+	if err != nil {
+		logger.Error("Failed to sign partial transaction", "error", err)
+		return "", err
+	}
+
+	logger.Info("Partial transaction signed successfully")
+
+	return psbt, nil
+}
+
+// DecodeTransaction summarises the recipients and values of payload without
+// deriving any key
+func (i *Inventory) DecodeTransaction(coinType uint16, payload string, isDev bool) (*lib.TransactionSummary, error) {
+	logger := i.logger.With(slog.String("op", "decode_transaction"), slog.Uint64("coinType", uint64(coinType)))
+	logger.Info("Decoding transaction")
+
+	adapter := i.getProvider(coinType)
+	if adapter == nil {
+		logger.Error("No adapter found for coin type", "coinType", coinType)
+		return nil, ErrNoAdapterFound
+	}
+
+	decoder, ok := adapter.(transactionDecoder)
+	if !ok {
+		logger.Error("Adapter does not support transaction decoding")
+		return nil, ErrDecodingUnsupported
+	}
+
+	summary, err := decoder.DecodeTransaction(payload, isDev)
+	if err != nil {
+		logger.Error("Failed to decode transaction", "error", err)
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// TransactionHash returns the chain transaction id of payload, signedTx being
+// what CreateSignedTransaction returned for it
+func (i *Inventory) TransactionHash(coinType uint16, payload, signedTx string, isDev bool) (string, error) {
+	logger := i.logger.With(slog.String("op", "transaction_hash"), slog.Uint64("coinType", uint64(coinType)))
+
+	adapter := i.getProvider(coinType)
+	if adapter == nil {
+		logger.Error("No adapter found for coin type", "coinType", coinType)
+		return "", ErrNoAdapterFound
+	}
+
+	hasher, ok := adapter.(transactionHasher)
+	if !ok {
+		return "", ErrTransactionHashUnsupported
+	}
+
+	txHash, err := hasher.TransactionHash(payload, signedTx, isDev)
+	if err != nil {
+		logger.Error("Failed to compute transaction hash", "error", err)
+		return "", err
+	}
+
+	return txHash, nil
+}
+
+func (i *Inventory) SignMessage(keys *lib.Keychain, coinType uint16,
+	derivationPath, method, message string) (string, error) {
+	logger := i.logger.With(slog.String("op", "sign_message"), slog.Uint64("coinType", uint64(coinType)))
+	logger.Info("Signing message")
+
+	adapter := i.getProvider(coinType)
+	if adapter == nil {
+		logger.Error("No adapter found for coin type", "coinType", coinType)
+		return "", ErrNoAdapterFound
+	}
+
+	signer, ok := adapter.(messageSigner)
+	if !ok {
+		logger.Error("Adapter does not support message signing")
+		return "", ErrMessageSigningUnsupported
+	}
+
+	signature, err := signer.SignMessage(keys, derivationPath, method, message)
+	if err != nil {
+		logger.Error("Failed to sign message", "error", err)
+		return "", err
+	}
+
+	logger.Info("Message signed successfully")
+
+	return signature, nil
+}
@@ -0,0 +1,39 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+func TestInventory_AccountPath(t *testing.T) {
+	inventory := batchTestInventory()
+
+	tests := []struct {
+		name     string
+		coinType uint16
+		path     string
+		want     string
+		wantOK   bool
+	}{
+		{"evm", slip44.Ether, "m/44'/60'/0'/0/0", "m/44'/60'/0'", true},
+		{"evm relative", slip44.Ether, "0/0", "", false},
+		{"tron", slip44.Tron, "m/44'/195'/2'/0/0", "m/44'/195'/2'", true},
+		{"tron relative", slip44.Tron, "m/0'/0/1", "m/44'/195'/0'", true},
+		{"tron relative hardened", slip44.Tron, "m/0'/1'/2'", "m/44'/195'/0'", true},
+		{"tron other coin", slip44.Tron, "m/44'/60'/0'/0/0", "", false},
+		{"bitcoin", slip44.Bitcoin, "m/84'/0'/1'/0/0", "m/84'/0'/1'", true},
+		{"bitcoin unsupported purpose", slip44.Bitcoin, "m/45'/0'/0'/0/0", "", false},
+		{"solana", slip44.Solana, "m/44'/501'/0'/0'", "", false},
+		{"no adapter", 9999, "m/44'/9999'/0'/0/0", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := inventory.AccountPath(tt.coinType, tt.path)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
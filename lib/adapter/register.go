@@ -2,24 +2,22 @@ package adapter
 
 import (
 	"log/slog"
-	"sync"
 
+	"github.com/payment-system/dq-vault/lib/adapter/bitcoin"
+	"github.com/payment-system/dq-vault/lib/adapter/bitshares"
 	"github.com/payment-system/dq-vault/lib/adapter/evm"
+	"github.com/payment-system/dq-vault/lib/adapter/solana"
+	"github.com/payment-system/dq-vault/lib/adapter/tron"
 )
 
-// Package-level variables for singleton pattern
-var (
-	inventory *Inventory //nolint:gochecknoglobals // singleton pattern requires global state
-	once      sync.Once  //nolint:gochecknoglobals // singleton pattern requires global state
-)
-
-// GetInventory returns the singleton adapter inventory instance
-func GetInventory(logger *slog.Logger) *Inventory {
-	once.Do(func() {
-		inventory = NewAdapterInventory(
-			logger,
-			evm.NewEthereumAdapter(logger),
-		)
-	})
-	return inventory
+// NewInventory returns an inventory of every supported adapter logging to logger
+func NewInventory(logger *slog.Logger) *Inventory {
+	return NewAdapterInventory(
+		logger,
+		evm.NewEthereumAdapter(logger),
+		tron.NewTronAdapter(logger.With(slog.String("adapter", "tron"))),
+		bitcoin.NewBitcoinAdapter(logger.With(slog.String("adapter", "bitcoin"))),
+		solana.NewSolanaAdapter(logger.With(slog.String("adapter", "solana"))),
+		bitshares.NewBitsharesAdapter(logger.With(slog.String("adapter", "bitshares"))),
+	)
 }
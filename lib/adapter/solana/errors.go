@@ -0,0 +1,13 @@
+package solana
+
+import "errors"
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrInvalidDerivationPath = errors.New("invalid derivation path")
+	ErrInvalidPayloadData    = errors.New("invalid payload data")
+	ErrUnsupportedEncoding   = errors.New("unsupported message encoding")
+	ErrInvalidMessage        = errors.New("invalid transaction message")
+	ErrUnsupportedVersion    = errors.New("unsupported transaction message version")
+	ErrSignerNotRequired     = errors.New("derived key is not a required signer of the message")
+)
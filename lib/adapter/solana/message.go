@@ -0,0 +1,201 @@
+package solana
+
+import (
+	"crypto/ed25519"
+	"fmt"
+)
+
+const (
+	// versionPrefixMask is set on the first byte of versioned messages,
+	// legacy messages start with the header which never has it set
+	versionPrefixMask = 0x80
+	messageVersion0   = 0
+
+	messageHeaderLength = 3
+	blockhashLength     = 32
+	signatureLength     = ed25519.SignatureSize
+
+	// shortvec encodes an u16 in up to 3 bytes, 7 bits per byte
+	shortVecMaxLength = 3
+	shortVecDataMask  = 0x7f
+	shortVecMoreBit   = 0x80
+	shortVecShift     = 7
+)
+
+// message is the part of a serialized transaction message needed to sign it
+type message struct {
+	version               int
+	numRequiredSignatures int
+	accountKeys           []ed25519.PublicKey
+}
+
+// messageReader walks a serialized message, every read fails once the data is exhausted
+type messageReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *messageReader) readBytes(n int) ([]byte, error) {
+	if n < 0 || r.offset+n > len(r.data) {
+		return nil, fmt.Errorf("%w: unexpected end of message", ErrInvalidMessage)
+	}
+	b := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return b, nil
+}
+
+func (r *messageReader) readByte() (byte, error) {
+	b, err := r.readBytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// readShortVec reads a compact-u16 length prefix
+func (r *messageReader) readShortVec() (int, error) {
+	value := 0
+	for i := range shortVecMaxLength {
+		b, err := r.readByte()
+		if err != nil {
+			return 0, err
+		}
+		value |= int(b&shortVecDataMask) << (i * shortVecShift)
+		if b&shortVecMoreBit == 0 {
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: invalid compact-u16", ErrInvalidMessage)
+}
+
+// skipShortVecBytes skips a compact-u16 prefixed byte array
+func (r *messageReader) skipShortVecBytes() error {
+	n, err := r.readShortVec()
+	if err != nil {
+		return err
+	}
+	_, err = r.readBytes(n)
+	return err
+}
+
+// parseMessage decodes a legacy or v0 transaction message and checks that it
+// is well formed and fully consumed
+func parseMessage(data []byte) (*message, error) {
+	r := &messageReader{data: data}
+	msg := &message{version: -1}
+
+	if len(data) > 0 && data[0]&versionPrefixMask != 0 {
+		msg.version = int(data[0] &^ versionPrefixMask)
+		if msg.version != messageVersion0 {
+			return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, msg.version)
+		}
+		r.offset++
+	}
+
+	header, err := r.readBytes(messageHeaderLength)
+	if err != nil {
+		return nil, err
+	}
+	msg.numRequiredSignatures = int(header[0])
+
+	numAccountKeys, err := r.readShortVec()
+	if err != nil {
+		return nil, err
+	}
+	if msg.numRequiredSignatures == 0 || msg.numRequiredSignatures > numAccountKeys {
+		return nil, fmt.Errorf("%w: %d required signatures for %d accounts", ErrInvalidMessage,
+			msg.numRequiredSignatures, numAccountKeys)
+	}
+
+	msg.accountKeys = make([]ed25519.PublicKey, numAccountKeys)
+	for idx := range msg.accountKeys {
+		key, err := r.readBytes(ed25519.PublicKeySize)
+		if err != nil {
+			return nil, err
+		}
+		msg.accountKeys[idx] = key
+	}
+
+	if _, err := r.readBytes(blockhashLength); err != nil {
+		return nil, err
+	}
+
+	numInstructions, err := r.readShortVec()
+	if err != nil {
+		return nil, err
+	}
+	for range numInstructions {
+		// program id index, account indexes and instruction data
+		if _, err := r.readByte(); err != nil {
+			return nil, err
+		}
+		if err := r.skipShortVecBytes(); err != nil {
+			return nil, err
+		}
+		if err := r.skipShortVecBytes(); err != nil {
+			return nil, err
+		}
+	}
+
+	if msg.version == messageVersion0 {
+		numLookups, err := r.readShortVec()
+		if err != nil {
+			return nil, err
+		}
+		for range numLookups {
+			// table address, writable and readonly indexes
+			if _, err := r.readBytes(ed25519.PublicKeySize); err != nil {
+				return nil, err
+			}
+			if err := r.skipShortVecBytes(); err != nil {
+				return nil, err
+			}
+			if err := r.skipShortVecBytes(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if r.offset != len(data) {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrInvalidMessage, len(data)-r.offset)
+	}
+
+	return msg, nil
+}
+
+// signerIndex returns the signature slot of pubKey in the message
+func (m *message) signerIndex(pubKey ed25519.PublicKey) (int, error) {
+	for idx := range m.numRequiredSignatures {
+		if pubKey.Equal(m.accountKeys[idx]) {
+			return idx, nil
+		}
+	}
+	return 0, ErrSignerNotRequired
+}
+
+// appendShortVec appends n encoded as compact-u16
+func appendShortVec(buf []byte, n int) []byte {
+	for {
+		b := byte(n & shortVecDataMask)
+		n >>= shortVecShift
+		if n == 0 {
+			return append(buf, b)
+		}
+		buf = append(buf, b|shortVecMoreBit)
+	}
+}
+
+// serializeTransaction builds the wire transaction: the signatures of every
+// required signer followed by the message. Signatures of other signers are left zeroed.
+func serializeTransaction(msg *message, rawMessage []byte, signerIdx int, signature []byte) []byte {
+	tx := make([]byte, 0, shortVecMaxLength+msg.numRequiredSignatures*signatureLength+len(rawMessage))
+	tx = appendShortVec(tx, msg.numRequiredSignatures)
+	for idx := range msg.numRequiredSignatures {
+		if idx == signerIdx {
+			tx = append(tx, signature...)
+			continue
+		}
+		tx = append(tx, make([]byte, signatureLength)...)
+	}
+	return append(tx, rawMessage...)
+}
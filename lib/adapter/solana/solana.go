@@ -0,0 +1,191 @@
+package solana
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/payment-system/dq-vault/lib"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+// Adapter represents a Solana blockchain adapter
+type Adapter struct {
+	logger   *slog.Logger
+	basePath string
+}
+
+// NewSolanaAdapter creates a new Solana adapter instance
+func NewSolanaAdapter(logger *slog.Logger) *Adapter {
+	return &Adapter{
+		logger:   logger,
+		basePath: "m/44'/501'/",
+	}
+}
+
+// CanDo checks if this adapter can handle the given coin type
+func (s *Adapter) CanDo(coinType uint16) bool {
+	return coinType == slip44.Solana
+}
+
+// parseDerivationPath returns the absolute Solana path of derivationPath.
+// Relative paths (0'/0') are appended to m/44'/501'/, absolute paths must be under it.
+func (s *Adapter) parseDerivationPath(derivationPath string) (string, error) {
+	path := strings.ReplaceAll(derivationPath, " ", "")
+	switch {
+	case path == "" || strings.HasPrefix(path, "/"):
+		return "", ErrInvalidDerivationPath
+
+	case strings.HasPrefix(path, "m/"):
+		if !strings.HasPrefix(path+"/", s.basePath) {
+			return "", ErrInvalidDerivationPath
+		}
+		return path, nil
+
+	default:
+		return s.basePath + path, nil
+	}
+}
+
+func (s *Adapter) deriveKeyForPath(keys *lib.Keychain, derivationPath string) (ed25519.PrivateKey, error) {
+	path, err := s.parseDerivationPath(derivationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// SLIP-0010 keys are derived from the seed, never from a BIP-32 account key
+	seed, err := keys.Seed()
+	if err != nil {
+		return nil, err
+	}
+	return lib.DeriveEd25519PrivateKey(seed, path)
+}
+
+// DerivePrivateKey derives a private key from the given keychain and derivation path
+// and returns the base58 encoded 64 byte keypair, as used by Solana wallets
+func (s *Adapter) DerivePrivateKey(keys *lib.Keychain, derivationPath string, _ bool) (string, error) {
+	logger := s.logger.With(slog.String("op", "derive_private_key"), slog.String("derivationPath", derivationPath))
+	logger.Info("Deriving private key")
+
+	privateKey, err := s.deriveKeyForPath(keys, derivationPath)
+	if err != nil {
+		logger.Error("Failed to derive private key", "error", err)
+		return "", err
+	}
+
+	logger.Info("Private key derived successfully")
+
+	return base58.Encode(privateKey), nil
+}
+
+// DerivePublicKey derives the hex encoded ed25519 public key from the given keychain and derivation path
+func (s *Adapter) DerivePublicKey(keys *lib.Keychain, derivationPath string, _ bool) (string, error) {
+	logger := s.logger.With(slog.String("op", "derive_public_key"), slog.String("derivationPath", derivationPath))
+	logger.Info("Deriving public key")
+
+	privateKey, err := s.deriveKeyForPath(keys, derivationPath)
+	if err != nil {
+		logger.Error("Failed to derive public key", "error", err)
+		return "", err
+	}
+
+	publicKeyHex := hex.EncodeToString(privateKey.Public().(ed25519.PublicKey))
+	logger.Debug("Public key derived successfully", "publicKey", publicKeyHex)
+
+	return publicKeyHex, nil
+}
+
+// DeriveAddress derives the base58 encoded address from the given keychain and derivation path
+func (s *Adapter) DeriveAddress(keys *lib.Keychain, derivationPath string, _ bool) (string, error) {
+	logger := s.logger.With(slog.String("op", "derive_address"), slog.String("derivationPath", derivationPath))
+	logger.Info("Deriving address")
+
+	privateKey, err := s.deriveKeyForPath(keys, derivationPath)
+	if err != nil {
+		logger.Error("Failed to derive address", "error", err)
+		return "", err
+	}
+
+	address := base58.Encode(privateKey.Public().(ed25519.PublicKey))
+	logger.Debug("Address derived successfully", "address", address)
+
+	return address, nil
+}
+
+// decodeMessage decodes the message of payload and returns the encoder of its encoding
+func decodeMessage(payload *lib.SolanaRawTx) ([]byte, func([]byte) string, error) {
+	switch payload.Encoding {
+	case "", lib.SolanaEncodingBase64:
+		data, err := base64.StdEncoding.DecodeString(payload.Message)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidPayloadData, err)
+		}
+		return data, base64.StdEncoding.EncodeToString, nil
+
+	case lib.SolanaEncodingHex:
+		data, err := hex.DecodeString(strings.TrimPrefix(payload.Message, "0x"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidPayloadData, err)
+		}
+		return data, hex.EncodeToString, nil
+
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, payload.Encoding)
+	}
+}
+
+// CreateSignedTransaction signs a serialized legacy or v0 transaction message
+// with the key of derivationPath. It returns a JSON encoded lib.SolanaSignedTx
+// holding the signature and the wire transaction. The key must be one of the
+// required signers of the message, slots of other signers are left zeroed.
+// Solana clusters share keys and addresses, so development mode changes nothing.
+func (s *Adapter) CreateSignedTransaction(keys *lib.Keychain, derivationPath, payload string, _ bool) (string, error) {
+	logger := s.logger.With(slog.String("op", "create_signed_transaction"), slog.String("derivationPath", derivationPath))
+	logger.Info("Creating signed transaction")
+
+	var rawTx lib.SolanaRawTx
+	if err := json.Unmarshal([]byte(payload), &rawTx); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidPayloadData, err)
+	}
+
+	rawMessage, encode, err := decodeMessage(&rawTx)
+	if err != nil {
+		return "", err
+	}
+
+	msg, err := parseMessage(rawMessage)
+	if err != nil {
+		logger.Error("Failed to parse message", "error", err)
+		return "", err
+	}
+
+	privateKey, err := s.deriveKeyForPath(keys, derivationPath)
+	if err != nil {
+		logger.Error("Failed to derive private key", "error", err)
+		return "", err
+	}
+
+	signerIdx, err := msg.signerIndex(privateKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		return "", err
+	}
+
+	signature := ed25519.Sign(privateKey, rawMessage)
+
+	signedTx, err := json.Marshal(lib.SolanaSignedTx{
+		Signature:   base58.Encode(signature),
+		Transaction: encode(serializeTransaction(msg, rawMessage, signerIdx, signature)),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info("Signed transaction created successfully", "signerIndex", signerIdx)
+
+	return string(signedTx), nil
+}
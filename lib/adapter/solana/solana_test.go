@@ -0,0 +1,291 @@
+package solana
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+
+	"github.com/payment-system/dq-vault/lib"
+	"github.com/payment-system/dq-vault/lib/slip44"
+)
+
+const (
+	testMnemonic       = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	testDerivationPath = "m/44'/501'/0'/0'"
+	// first account of the "abandon ... about" mnemonic in Solana wallets
+	testAddress = "HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk"
+)
+
+var (
+	testSeed = bip39.NewSeed(testMnemonic, "")
+	logger   = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+)
+
+// transferMessage builds a system program transfer message from signers[0].
+// With version >= 0 a v0 message without address table lookups is built.
+func transferMessage(version int, signers ...ed25519.PublicKey) []byte {
+	systemProgram := make([]byte, ed25519.PublicKeySize)
+	recipient := make([]byte, ed25519.PublicKeySize)
+	recipient[0] = 1
+
+	var msg []byte
+	if version >= 0 {
+		msg = append(msg, versionPrefixMask|byte(version))
+	}
+	msg = append(msg, byte(len(signers)), 0, 1)
+	msg = appendShortVec(msg, len(signers)+2)
+	for _, signer := range signers {
+		msg = append(msg, signer...)
+	}
+	msg = append(msg, recipient...)
+	msg = append(msg, systemProgram...)
+	msg = append(msg, make([]byte, blockhashLength)...)
+
+	// transfer instruction: program, accounts [from, to], data
+	data := []byte{2, 0, 0, 0, 0x40, 0x42, 0x0f, 0, 0, 0, 0, 0}
+	msg = appendShortVec(msg, 1)
+	msg = append(msg, byte(len(signers)+1))
+	msg = appendShortVec(msg, 2)
+	msg = append(msg, 0, byte(len(signers)))
+	msg = appendShortVec(msg, len(data))
+	msg = append(msg, data...)
+
+	if version >= 0 {
+		msg = appendShortVec(msg, 0)
+	}
+	return msg
+}
+
+func ownPublicKey(t *testing.T) ed25519.PublicKey {
+	t.Helper()
+
+	key, err := lib.DeriveEd25519PrivateKey(testSeed, testDerivationPath)
+	require.NoError(t, err)
+	return key.Public().(ed25519.PublicKey)
+}
+
+func createPayload(t *testing.T, message []byte, encoding string) string {
+	t.Helper()
+
+	encoded := base64.StdEncoding.EncodeToString(message)
+	if encoding == lib.SolanaEncodingHex {
+		encoded = hex.EncodeToString(message)
+	}
+	payload, err := json.Marshal(lib.SolanaRawTx{Message: encoded, Encoding: encoding})
+	require.NoError(t, err)
+	return string(payload)
+}
+
+func TestSolanaAdapter_CanDo(t *testing.T) {
+	adapter := NewSolanaAdapter(logger)
+
+	assert.True(t, adapter.CanDo(slip44.Solana))
+	assert.False(t, adapter.CanDo(slip44.Ether))
+	assert.False(t, adapter.CanDo(slip44.Stellar))
+}
+
+func TestSolanaAdapter_DeriveAddress(t *testing.T) {
+	adapter := NewSolanaAdapter(logger)
+
+	tests := []struct {
+		name           string
+		derivationPath string
+		expected       string
+		expectedError  error
+	}{
+		{name: "absolute path", derivationPath: testDerivationPath, expected: testAddress},
+		{name: "relative path", derivationPath: "0'/0'", expected: testAddress},
+		{name: "account path", derivationPath: "m/44'/501'/0'"},
+		{
+			name:           "non hardened component",
+			derivationPath: "m/44'/501'/0'/0",
+			expectedError:  lib.ErrNonHardenedComponent,
+		},
+		{
+			name:           "other coin type",
+			derivationPath: "m/44'/60'/0'/0'",
+			expectedError:  ErrInvalidDerivationPath,
+		},
+		{
+			name:           "coin type prefix of solana",
+			derivationPath: "m/44'/5011'/0'",
+			expectedError:  ErrInvalidDerivationPath,
+		},
+		{name: "empty path", derivationPath: "", expectedError: ErrInvalidDerivationPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := adapter.DeriveAddress(lib.NewSeedKeychain(testSeed), tt.derivationPath, false)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, base58.Decode(got), ed25519.PublicKeySize)
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSolanaAdapter_DeriveKeys(t *testing.T) {
+	adapter := NewSolanaAdapter(logger)
+
+	privateKey, err := adapter.DerivePrivateKey(lib.NewSeedKeychain(testSeed), testDerivationPath, false)
+	require.NoError(t, err)
+	keypair := base58.Decode(privateKey)
+	require.Len(t, keypair, ed25519.PrivateKeySize)
+	assert.Equal(t, testAddress, base58.Encode(keypair[ed25519.SeedSize:]))
+
+	publicKey, err := adapter.DerivePublicKey(lib.NewSeedKeychain(testSeed), testDerivationPath, false)
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(base58.Decode(testAddress)), publicKey)
+}
+
+func TestSolanaAdapter_CreateSignedTransaction(t *testing.T) {
+	adapter := NewSolanaAdapter(logger)
+	own := ownPublicKey(t)
+	feePayer := ed25519.PublicKey(make([]byte, ed25519.PublicKeySize))
+	feePayer[0] = 0xfe
+
+	tests := []struct {
+		name      string
+		message   []byte
+		encoding  string
+		signerIdx int
+	}{
+		{name: "legacy base64", message: transferMessage(-1, own), encoding: lib.SolanaEncodingBase64},
+		{name: "legacy hex", message: transferMessage(-1, own), encoding: lib.SolanaEncodingHex},
+		{name: "default encoding", message: transferMessage(-1, own)},
+		{name: "v0", message: transferMessage(0, own), encoding: lib.SolanaEncodingBase64},
+		{
+			name:      "second signer",
+			message:   transferMessage(0, feePayer, own),
+			encoding:  lib.SolanaEncodingBase64,
+			signerIdx: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(testSeed), testDerivationPath,
+				createPayload(t, tt.message, tt.encoding), false)
+			require.NoError(t, err)
+
+			var signed lib.SolanaSignedTx
+			require.NoError(t, json.Unmarshal([]byte(got), &signed))
+
+			signature := base58.Decode(signed.Signature)
+			assert.True(t, ed25519.Verify(own, tt.message, signature))
+
+			var tx []byte
+			if tt.encoding == lib.SolanaEncodingHex {
+				tx, err = hex.DecodeString(signed.Transaction)
+			} else {
+				tx, err = base64.StdEncoding.DecodeString(signed.Transaction)
+			}
+			require.NoError(t, err)
+
+			msg, err := parseMessage(tt.message)
+			require.NoError(t, err)
+			require.Equal(t, byte(msg.numRequiredSignatures), tx[0])
+			signatures := tx[1 : 1+msg.numRequiredSignatures*signatureLength]
+			for idx := range msg.numRequiredSignatures {
+				slot := signatures[idx*signatureLength : (idx+1)*signatureLength]
+				if idx == tt.signerIdx {
+					assert.Equal(t, signature, slot)
+				} else {
+					assert.Equal(t, make([]byte, signatureLength), slot)
+				}
+			}
+			assert.Equal(t, tt.message, tx[1+len(signatures):])
+		})
+	}
+}
+
+func TestSolanaAdapter_CreateSignedTransaction_Errors(t *testing.T) {
+	adapter := NewSolanaAdapter(logger)
+	own := ownPublicKey(t)
+	other := ed25519.PublicKey(make([]byte, ed25519.PublicKeySize))
+
+	v1 := transferMessage(0, own)
+	v1[0] = versionPrefixMask | 1
+
+	tests := []struct {
+		name          string
+		payload       string
+		expectedError error
+	}{
+		{name: "invalid json", payload: "{invalid json}", expectedError: ErrInvalidPayloadData},
+		{
+			name:          "invalid base64",
+			payload:       `{"message": "not base64!"}`,
+			expectedError: ErrInvalidPayloadData,
+		},
+		{
+			name:          "unsupported encoding",
+			payload:       `{"message": "AA==", "encoding": "base58"}`,
+			expectedError: ErrUnsupportedEncoding,
+		},
+		{
+			name:          "truncated message",
+			payload:       createPayload(t, transferMessage(-1, own)[:40], ""),
+			expectedError: ErrInvalidMessage,
+		},
+		{
+			name:          "trailing bytes",
+			payload:       createPayload(t, append(transferMessage(-1, own), 0), ""),
+			expectedError: ErrInvalidMessage,
+		},
+		{
+			name:          "unsupported version",
+			payload:       createPayload(t, v1, ""),
+			expectedError: ErrUnsupportedVersion,
+		},
+		{
+			name:          "not a signer",
+			payload:       createPayload(t, transferMessage(-1, other), ""),
+			expectedError: ErrSignerNotRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(testSeed), testDerivationPath, tt.payload, false)
+			assert.ErrorIs(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestShortVec(t *testing.T) {
+	tests := []struct {
+		value   int
+		encoded []byte
+	}{
+		{value: 0, encoded: []byte{0x00}},
+		{value: 0x7f, encoded: []byte{0x7f}},
+		{value: 0x80, encoded: []byte{0x80, 0x01}},
+		{value: 0x3fff, encoded: []byte{0xff, 0x7f}},
+		{value: 0xffff, encoded: []byte{0xff, 0xff, 0x03}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.encoded, appendShortVec(nil, tt.value))
+
+		r := &messageReader{data: tt.encoded}
+		got, err := r.readShortVec()
+		require.NoError(t, err)
+		assert.Equal(t, tt.value, got)
+	}
+}
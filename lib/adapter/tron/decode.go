@@ -0,0 +1,179 @@
+package tron
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/fbsobreira/gotron-sdk/pkg/common"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
+	"github.com/payment-system/dq-vault/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// methodIDLength is the length of the selector prefixing contract call data
+	methodIDLength = 4
+
+	// tronAddressPrefix is the network byte of mainnet addresses
+	tronAddressPrefix = 0x41
+)
+
+// tokenCallRecipient maps the TRC-20 methods moving tokens to the index of
+// their recipient argument, the amount is always the last argument
+var tokenCallRecipient = map[string]int{
+	"transfer":     0,
+	"transferFrom": 1,
+	"approve":      0,
+}
+
+// DecodeTransaction extracts the recipient, value and fee limit of a hex
+// encoded raw transaction without deriving any key. TRC-20 transfer,
+// transferFrom and approve calls are reported as token transfers of the called
+// contract. The type and method are those of the first contract.
+// Tron transactions carry no chain id.
+func (t *Adapter) DecodeTransaction(payload string, _ bool) (*lib.TransactionSummary, error) {
+	logger := t.logger.With(slog.String("op", "decode_transaction"))
+	logger.Info("Decoding transaction")
+
+	decodedHex, err := hex.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRawData, err)
+	}
+
+	raw := &core.TransactionRaw{}
+	if err := proto.Unmarshal(decodedHex, raw); err != nil {
+		return nil, ErrInvalidRawData
+	}
+
+	summary := &lib.TransactionSummary{}
+	if feeLimit := raw.GetFeeLimit(); feeLimit > 0 {
+		summary.Fee.FeeLimit = big.NewInt(feeLimit)
+	}
+
+	for idx, contract := range raw.GetContract() {
+		transfers, method, err := decodeContract(contract)
+		if err != nil {
+			return nil, err
+		}
+		if idx == 0 {
+			summary.Type = contract.GetType().String()
+			summary.Method = method
+		}
+		summary.Transfers = append(summary.Transfers, transfers...)
+	}
+
+	if len(summary.Transfers) == 0 {
+		return nil, ErrInvalidRawData
+	}
+
+	return summary, nil
+}
+
+// decodeContract returns the transfers of a contract and the method it calls, if any
+func decodeContract(contract *core.Transaction_Contract) ([]lib.Transfer, string, error) {
+	switch contract.GetType() {
+	case core.Transaction_Contract_TransferContract:
+		transfer := &core.TransferContract{}
+		if err := contract.GetParameter().UnmarshalTo(transfer); err != nil {
+			return nil, "", err
+		}
+
+		return []lib.Transfer{{
+			To:    common.EncodeCheck(transfer.GetToAddress()),
+			Value: big.NewInt(transfer.GetAmount()),
+		}}, "", nil
+
+	case core.Transaction_Contract_TriggerSmartContract:
+		trigger := &core.TriggerSmartContract{}
+		if err := contract.GetParameter().UnmarshalTo(trigger); err != nil {
+			return nil, "", err
+		}
+
+		contractAddress := common.EncodeCheck(trigger.GetContractAddress())
+		transfers := []lib.Transfer{{
+			To:       contractAddress,
+			Value:    big.NewInt(trigger.GetCallValue()),
+			Contract: true,
+		}}
+
+		tokenTransfer, method, ok, err := decodeTokenCall(trigger.GetData())
+		if err != nil {
+			return nil, "", err
+		}
+		if ok {
+			tokenTransfer.Token = contractAddress
+			transfers = append(transfers, tokenTransfer)
+		}
+		return transfers, method, nil
+
+	default:
+		return nil, "", ErrUnsupportedTransactionType
+	}
+}
+
+// decodeTokenCall decodes the method name, recipient and amount of a TRC-20
+// call. ok is false for calls of other methods, whose method is the hex
+// encoded selector when unknown to the TRC-20 ABI.
+func decodeTokenCall(data []byte) (transfer lib.Transfer, methodName string, ok bool, err error) {
+	if len(data) < methodIDLength {
+		return lib.Transfer{}, "", false, nil
+	}
+
+	trc20, err := abi.JSON(strings.NewReader(TRC20ABI))
+	if err != nil {
+		return lib.Transfer{}, "", false, err
+	}
+
+	method, err := trc20.MethodById(data[:methodIDLength])
+	if err != nil {
+		return lib.Transfer{}, "0x" + hex.EncodeToString(data[:methodIDLength]), false, nil
+	}
+	recipientIndex, ok := tokenCallRecipient[method.Name]
+	if !ok {
+		return lib.Transfer{}, method.Name, false, nil
+	}
+
+	args, err := method.Inputs.Unpack(data[methodIDLength:])
+	if err != nil {
+		return lib.Transfer{}, "", false, fmt.Errorf("%w: %s arguments: %w", ErrInvalidRawData, method.Name, err)
+	}
+
+	recipient, okRecipient := args[recipientIndex].(ethcommon.Address)
+	amount, okAmount := args[len(args)-1].(*big.Int)
+	if !okRecipient || !okAmount {
+		return lib.Transfer{}, "", false, fmt.Errorf("%w: %s arguments", ErrInvalidRawData, method.Name)
+	}
+
+	// TRC-20 arguments hold the 20 byte address without the 0x41 network prefix
+	tronAddress := append([]byte{tronAddressPrefix}, recipient.Bytes()...)
+
+	return lib.Transfer{To: common.EncodeCheck(tronAddress), Value: amount}, method.Name, true, nil
+}
+
+// TransactionHash returns the transaction id of a hex encoded raw transaction,
+// the SHA-256 of its raw data. Signatures are not part of it.
+func (t *Adapter) TransactionHash(payload string, _ string, _ bool) (string, error) {
+	decodedHex, err := hex.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidRawData, err)
+	}
+
+	raw := &core.TransactionRaw{}
+	if err := proto.Unmarshal(decodedHex, raw); err != nil {
+		return "", ErrInvalidRawData
+	}
+
+	// CreateSignedTransaction signs the hash of the re-encoded raw data
+	rawData, err := proto.Marshal(raw)
+	if err != nil {
+		return "", err
+	}
+	txID := sha256.Sum256(rawData)
+	return hex.EncodeToString(txID[:]), nil
+}
@@ -0,0 +1,154 @@
+package tron
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/fbsobreira/gotron-sdk/pkg/common"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/payment-system/dq-vault/lib"
+)
+
+const (
+	testTronRecipient = "TJRabPrwbZy45sbavfcjinPJC18kjpRTv8"
+	testTronToken     = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+)
+
+func decodeTronAddress(t *testing.T, address string) []byte {
+	t.Helper()
+
+	decoded, err := common.DecodeCheck(address)
+	require.NoError(t, err)
+	return decoded
+}
+
+func encodeRawTransaction(t *testing.T, contractType core.Transaction_Contract_ContractType,
+	parameter proto.Message, feeLimit int64) string {
+	t.Helper()
+
+	anyParameter, err := anypb.New(parameter)
+	require.NoError(t, err)
+
+	raw, err := proto.Marshal(&core.TransactionRaw{
+		Contract: []*core.Transaction_Contract{{Type: contractType, Parameter: anyParameter}},
+		FeeLimit: feeLimit,
+	})
+	require.NoError(t, err)
+	return hex.EncodeToString(raw)
+}
+
+func TestTronAdapter_DecodeTransaction(t *testing.T) {
+	adapter := NewTronAdapter(logger)
+
+	trc20, err := abi.JSON(strings.NewReader(TRC20ABI))
+	require.NoError(t, err)
+	recipient := ethcommon.BytesToAddress(decodeTronAddress(t, testTronRecipient)[1:])
+	transferData, err := trc20.Pack("transfer", recipient, big.NewInt(2500000))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		payload string
+		want    *lib.TransactionSummary
+		wantErr error
+	}{
+		{
+			name: "trx transfer",
+			payload: encodeRawTransaction(t, core.Transaction_Contract_TransferContract, &core.TransferContract{
+				ToAddress: decodeTronAddress(t, testTronRecipient),
+				Amount:    1000000,
+			}, 0),
+			want: &lib.TransactionSummary{
+				Type:      "TransferContract",
+				Transfers: []lib.Transfer{{To: testTronRecipient, Value: big.NewInt(1000000)}},
+			},
+		},
+		{
+			name: "trc20 transfer",
+			payload: encodeRawTransaction(t, core.Transaction_Contract_TriggerSmartContract, &core.TriggerSmartContract{
+				ContractAddress: decodeTronAddress(t, testTronToken),
+				Data:            transferData,
+			}, 30000000),
+			want: &lib.TransactionSummary{
+				Type:   "TriggerSmartContract",
+				Method: "transfer",
+				Transfers: []lib.Transfer{
+					{To: testTronToken, Value: big.NewInt(0), Contract: true},
+					{To: testTronRecipient, Value: big.NewInt(2500000), Token: testTronToken},
+				},
+				Fee: lib.Fee{FeeLimit: big.NewInt(30000000)},
+			},
+		},
+		{
+			name: "unknown method selector",
+			payload: encodeRawTransaction(t, core.Transaction_Contract_TriggerSmartContract, &core.TriggerSmartContract{
+				ContractAddress: decodeTronAddress(t, testTronToken),
+				Data:            []byte{0xde, 0xad, 0xbe, 0xef},
+				CallValue:       5,
+			}, 0),
+			want: &lib.TransactionSummary{
+				Type:      "TriggerSmartContract",
+				Method:    "0xdeadbeef",
+				Transfers: []lib.Transfer{{To: testTronToken, Value: big.NewInt(5), Contract: true}},
+			},
+		},
+		{
+			name: "unsupported contract",
+			payload: encodeRawTransaction(t, core.Transaction_Contract_FreezeBalanceContract,
+				&core.FreezeBalanceContract{FrozenBalance: 1}, 0),
+			wantErr: ErrUnsupportedTransactionType,
+		},
+		{
+			name:    "invalid hex",
+			payload: "invalid_hex",
+			wantErr: ErrInvalidRawData,
+		},
+		{
+			name:    "no contract",
+			payload: "",
+			wantErr: ErrInvalidRawData,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := adapter.DecodeTransaction(tt.payload, false)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTronAdapter_TransactionHash(t *testing.T) {
+	adapter := NewTronAdapter(logger)
+
+	payload := encodeRawTransaction(t, core.Transaction_Contract_TransferContract, &core.TransferContract{
+		ToAddress: decodeTronAddress(t, testTronRecipient),
+		Amount:    1000000,
+	}, 0)
+	rawData, err := hex.DecodeString(payload)
+	require.NoError(t, err)
+	txID := sha256.Sum256(rawData)
+
+	got, err := adapter.TransactionHash(payload, "", false)
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(txID[:]), got)
+
+	_, err = adapter.TransactionHash("invalid_hex", "", false)
+	assert.ErrorIs(t, err, ErrInvalidRawData)
+}
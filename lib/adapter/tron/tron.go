@@ -14,15 +14,13 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/fbsobreira/gotron-sdk/pkg/address"
 	"github.com/fbsobreira/gotron-sdk/pkg/common"
-	"github.com/fbsobreira/gotron-sdk/pkg/keys/hd"
 	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
+	"github.com/payment-system/dq-vault/lib"
 	"github.com/payment-system/dq-vault/lib/slip44"
 	"google.golang.org/protobuf/proto"
 )
 
 const (
-	// maskingLength is the number of characters to show at the end of masked keys
-	maskingLength          = 4
 	tronHexAddressPrefix   = "41"
 	relativePathComponents = 3
 	hexPrefixLength        = 2
@@ -76,34 +74,36 @@ func (t *Adapter) parseDerivationPath(path string) (string, error) {
 	}
 }
 
-func (t *Adapter) deriveKeysForPath(seed []byte, derivationPath string) (
+// ResolveDerivationPath returns the absolute path the keys of derivationPath
+// are derived at, relative paths m/account'/change/index being under m/44'/195'
+func (t *Adapter) ResolveDerivationPath(derivationPath string) (string, error) {
+	path, err := t.parseDerivationPath(derivationPath)
+	if err != nil {
+		return "", err
+	}
+	return "m/" + path, nil
+}
+
+func (t *Adapter) deriveKeysForPath(keys *lib.Keychain, derivationPath string) (
 	*secp256k1.PrivateKey, *secp256k1.PublicKey, error) {
-	derivationPath, err := t.parseDerivationPath(derivationPath)
+	derivationPath, err := t.ResolveDerivationPath(derivationPath)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	master, ch := hd.ComputeMastersFromSeed(seed, []byte("Bitcoin seed"))
-	private, err := hd.DerivePrivateKeyForPath(
-		btcec.S256(),
-		master,
-		ch,
-		derivationPath,
-	)
+	privateKey, err := keys.DerivePrivateKey(derivationPath)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	privateKey, publicKey := btcec.PrivKeyFromBytes(private[:])
-	return privateKey, publicKey, nil
+	return privateKey, privateKey.PubKey(), nil
 }
 
-// DerivePrivateKey derives a private key from the given seed and derivation path
-func (t *Adapter) DerivePrivateKey(seed []byte, derivationPath string, _ bool) (string, error) {
+// DerivePrivateKey derives a private key from the given keychain and derivation path
+func (t *Adapter) DerivePrivateKey(keys *lib.Keychain, derivationPath string, _ bool) (string, error) {
 	logger := t.logger.With(slog.String("op", "derive_private_key"), slog.String("derivationPath", derivationPath))
 	logger.Info("Deriving private key")
 
-	privateKey, _, err := t.deriveKeysForPath(seed, derivationPath)
+	privateKey, _, err := t.deriveKeysForPath(keys, derivationPath)
 	if err != nil {
 		logger.Error("Failed to derive private key", "error", err)
 		return "", err
@@ -115,19 +115,17 @@ func (t *Adapter) DerivePrivateKey(seed []byte, derivationPath string, _ bool) (
 	// excluding "0x" prefix
 	privateKeyHex := hexutil.Encode(privateKeyBytes)[hexPrefixLength:]
 
-	// mask the private key
-	maskedPrivateKey := privateKeyHex[:len(privateKeyHex)-maskingLength] + strings.Repeat("*", maskingLength)
-	logger.Info("Private key", "privateKey", maskedPrivateKey)
+	logger.Debug("Private key derived successfully")
 
 	return privateKeyHex, nil
 }
 
-// DerivePublicKey derives a public key from the given seed and derivation path
-func (t *Adapter) DerivePublicKey(seed []byte, derivationPath string, _ bool) (string, error) {
+// DerivePublicKey derives a public key from the given keychain and derivation path
+func (t *Adapter) DerivePublicKey(keys *lib.Keychain, derivationPath string, _ bool) (string, error) {
 	logger := t.logger.With(slog.String("op", "derive_public_key"), slog.String("derivationPath", derivationPath))
 	logger.Info("Deriving public key")
 
-	_, publicKey, err := t.deriveKeysForPath(seed, derivationPath)
+	_, publicKey, err := t.deriveKeysForPath(keys, derivationPath)
 	if err != nil {
 		logger.Error("Failed to derive public key", "error", err)
 		return "", err
@@ -136,19 +134,39 @@ func (t *Adapter) DerivePublicKey(seed []byte, derivationPath string, _ bool) (s
 	publicKeyBytes := crypto.FromECDSAPub(publicKey.ToECDSA())
 	publicKeyHex := hexutil.Encode(publicKeyBytes)[hexPrefixLength:]
 
-	// mask the public key
-	maskedPublicKey := publicKeyHex[:len(publicKeyHex)-maskingLength] + strings.Repeat("*", maskingLength)
-	logger.Info("Public key", "publicKey", maskedPublicKey)
+	logger.Debug("Public key derived successfully", "publicKey", publicKeyHex)
 
 	return publicKeyHex, nil
 }
 
-// DeriveAddress derives an address from the given seed and derivation path
-func (t *Adapter) DeriveAddress(seed []byte, derivationPath string, _ bool) (string, error) {
+// DeriveECPublicKey derives the secp256k1 public key of derivationPath
+func (t *Adapter) DeriveECPublicKey(keys *lib.Keychain, derivationPath string, _ bool) (*btcec.PublicKey, error) {
+	_, publicKey, err := t.deriveKeysForPath(keys, derivationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return publicKey, nil
+}
+
+// EncodePublicKey returns the uncompressed hex public key and the base58
+// address of publicKey, the key of derivationPath
+func (t *Adapter) EncodePublicKey(publicKey *btcec.PublicKey, derivationPath string, _ bool) (string, string, error) {
+	if _, err := t.parseDerivationPath(derivationPath); err != nil {
+		return "", "", err
+	}
+
+	publicKeyECDSA := publicKey.ToECDSA()
+	publicKeyHex := hexutil.Encode(crypto.FromECDSAPub(publicKeyECDSA))[hexPrefixLength:]
+	return publicKeyHex, address.PubkeyToAddress(*publicKeyECDSA).String(), nil
+}
+
+// DeriveAddress derives an address from the given keychain and derivation path
+func (t *Adapter) DeriveAddress(keys *lib.Keychain, derivationPath string, _ bool) (string, error) {
 	logger := t.logger.With(slog.String("op", "derive_address"), slog.String("derivationPath", derivationPath))
 	logger.Info("Deriving address")
 
-	_, publicKey, err := t.deriveKeysForPath(seed, derivationPath)
+	_, publicKey, err := t.deriveKeysForPath(keys, derivationPath)
 	if err != nil {
 		logger.Error("Failed to derive address", "error", err)
 		return "", err
@@ -156,13 +174,15 @@ func (t *Adapter) DeriveAddress(seed []byte, derivationPath string, _ bool) (str
 
 	tronAddress := address.PubkeyToAddress(*publicKey.ToECDSA())
 
-	logger.Info("Address", "address", tronAddress.String())
+	logger.Debug("Address derived successfully", "address", tronAddress.String())
 
 	return tronAddress.String(), nil
 }
 
-// CreateSignedTransaction creates a signed transaction from the given parameters
-func (t *Adapter) CreateSignedTransaction(seed []byte, derivationPath, payload string) (string, error) {
+// CreateSignedTransaction creates a signed transaction from the given parameters.
+// Tron testnets share the mainnet address format and transactions carry no
+// chain id, so development mode changes nothing.
+func (t *Adapter) CreateSignedTransaction(keys *lib.Keychain, derivationPath, payload string, _ bool) (string, error) {
 	logger := t.logger.With(slog.String("op", "create_signed_transaction"), slog.String("derivationPath", derivationPath))
 	logger.Info("Creating signed transaction")
 
@@ -222,9 +242,9 @@ func (t *Adapter) CreateSignedTransaction(seed []byte, derivationPath, payload s
 		return "", ErrUnsupportedTransactionType
 	}
 
-	logger.Info("To address", "toAddress", toAddress)
+	logger.Debug("To address", "toAddress", toAddress)
 
-	privateKey, err := t.DerivePrivateKey(seed, derivationPath, false)
+	privateKey, err := t.DerivePrivateKey(keys, derivationPath, false)
 	if err != nil {
 		return "", err
 	}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/payment-system/dq-vault/lib"
 	"github.com/payment-system/dq-vault/lib/slip44"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -222,7 +223,7 @@ func TestTronAdapter_DerivePrivateKey(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := adapter.DerivePrivateKey(tt.seed, tt.derivationPath, tt.isDev)
+			result, err := adapter.DerivePrivateKey(lib.NewSeedKeychain(tt.seed), tt.derivationPath, tt.isDev)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -302,7 +303,7 @@ func TestTronAdapter_DerivePublicKey(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := adapter.DerivePublicKey(tt.seed, tt.derivationPath, tt.isDev)
+			result, err := adapter.DerivePublicKey(lib.NewSeedKeychain(tt.seed), tt.derivationPath, tt.isDev)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -382,7 +383,7 @@ func TestTronAdapter_DeriveAddress(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := adapter.DeriveAddress(tt.seed, tt.derivationPath, tt.isDev)
+			result, err := adapter.DeriveAddress(lib.NewSeedKeychain(tt.seed), tt.derivationPath, tt.isDev)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -467,7 +468,7 @@ func TestTronAdapter_CreateSignedTransaction(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := adapter.CreateSignedTransaction(tt.seed, tt.derivationPath, tt.payload)
+			result, err := adapter.CreateSignedTransaction(lib.NewSeedKeychain(tt.seed), tt.derivationPath, tt.payload, false)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -581,28 +582,28 @@ func TestTronAdapter_Integration(t *testing.T) {
 
 	// Test that derived keys are consistent
 	t.Run("derived keys consistency", func(t *testing.T) {
-		privateKey1, err1 := adapter.DerivePrivateKey(testSeedBytes, testDerivationPath, false)
+		privateKey1, err1 := adapter.DerivePrivateKey(lib.NewSeedKeychain(testSeedBytes), testDerivationPath, false)
 		require.NoError(t, err1)
 
-		privateKey2, err2 := adapter.DerivePrivateKey(testSeedBytes, testDerivationPath, false)
+		privateKey2, err2 := adapter.DerivePrivateKey(lib.NewSeedKeychain(testSeedBytes), testDerivationPath, false)
 		require.NoError(t, err2)
 
 		// Same seed and path should produce same private key
 		assert.Equal(t, privateKey1, privateKey2)
 
-		publicKey1, err3 := adapter.DerivePublicKey(testSeedBytes, testDerivationPath, false)
+		publicKey1, err3 := adapter.DerivePublicKey(lib.NewSeedKeychain(testSeedBytes), testDerivationPath, false)
 		require.NoError(t, err3)
 
-		publicKey2, err4 := adapter.DerivePublicKey(testSeedBytes, testDerivationPath, false)
+		publicKey2, err4 := adapter.DerivePublicKey(lib.NewSeedKeychain(testSeedBytes), testDerivationPath, false)
 		require.NoError(t, err4)
 
 		// Same seed and path should produce same public key
 		assert.Equal(t, publicKey1, publicKey2)
 
-		address1, err5 := adapter.DeriveAddress(testSeedBytes, testDerivationPath, false)
+		address1, err5 := adapter.DeriveAddress(lib.NewSeedKeychain(testSeedBytes), testDerivationPath, false)
 		require.NoError(t, err5)
 
-		address2, err6 := adapter.DeriveAddress(testSeedBytes, testDerivationPath, false)
+		address2, err6 := adapter.DeriveAddress(lib.NewSeedKeychain(testSeedBytes), testDerivationPath, false)
 		require.NoError(t, err6)
 
 		// Same seed and path should produce same address
@@ -614,18 +615,18 @@ func TestTronAdapter_Integration(t *testing.T) {
 		seed1 := []byte("seed1")
 		seed2 := []byte("seed2")
 
-		privateKey1, err1 := adapter.DerivePrivateKey(seed1, testDerivationPath, false)
+		privateKey1, err1 := adapter.DerivePrivateKey(lib.NewSeedKeychain(seed1), testDerivationPath, false)
 		require.NoError(t, err1)
 
-		privateKey2, err2 := adapter.DerivePrivateKey(seed2, testDerivationPath, false)
+		privateKey2, err2 := adapter.DerivePrivateKey(lib.NewSeedKeychain(seed2), testDerivationPath, false)
 		require.NoError(t, err2)
 
 		assert.NotEqual(t, privateKey1, privateKey2)
 
-		address1, err3 := adapter.DeriveAddress(seed1, testDerivationPath, false)
+		address1, err3 := adapter.DeriveAddress(lib.NewSeedKeychain(seed1), testDerivationPath, false)
 		require.NoError(t, err3)
 
-		address2, err4 := adapter.DeriveAddress(seed2, testDerivationPath, false)
+		address2, err4 := adapter.DeriveAddress(lib.NewSeedKeychain(seed2), testDerivationPath, false)
 		require.NoError(t, err4)
 
 		assert.NotEqual(t, address1, address2)
@@ -636,18 +637,18 @@ func TestTronAdapter_Integration(t *testing.T) {
 		path1 := "m/44'/195'/0'/0/0"
 		path2 := "m/44'/195'/0'/0/1"
 
-		privateKey1, err1 := adapter.DerivePrivateKey(testSeedBytes, path1, false)
+		privateKey1, err1 := adapter.DerivePrivateKey(lib.NewSeedKeychain(testSeedBytes), path1, false)
 		require.NoError(t, err1)
 
-		privateKey2, err2 := adapter.DerivePrivateKey(testSeedBytes, path2, false)
+		privateKey2, err2 := adapter.DerivePrivateKey(lib.NewSeedKeychain(testSeedBytes), path2, false)
 		require.NoError(t, err2)
 
 		assert.NotEqual(t, privateKey1, privateKey2)
 
-		address1, err3 := adapter.DeriveAddress(testSeedBytes, path1, false)
+		address1, err3 := adapter.DeriveAddress(lib.NewSeedKeychain(testSeedBytes), path1, false)
 		require.NoError(t, err3)
 
-		address2, err4 := adapter.DeriveAddress(testSeedBytes, path2, false)
+		address2, err4 := adapter.DeriveAddress(lib.NewSeedKeychain(testSeedBytes), path2, false)
 		require.NoError(t, err4)
 
 		assert.NotEqual(t, address1, address2)
@@ -7,14 +7,16 @@ import (
 	"math/big"
 	"strings"
 
-	"github.com/btcsuite/btcd/btcec"
-	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil"
 	bip32 "github.com/tyler-smith/go-bip32"
 )
 
 const (
 	// DerivationPathCapacity is the initial capacity for derivation path slices
 	DerivationPathCapacity = 8
+
+	// FingerprintLength is the length in bytes of a BIP-32 key fingerprint
+	FingerprintLength = 4
 )
 
 // Static error variables to avoid dynamic error creation
@@ -44,32 +46,15 @@ func getDefaultRootDerivationPath() derivationPath {
 // the `coin_type` 60' (or 0x8000003C) to Ethereum.
 type derivationPath []uint32
 
-// DerivePrivateKey derives the private key of the derivation path.
-func DerivePrivateKey(seed []byte, path string, _ bool) (*btcec.PrivateKey, error) {
-	// parse derivation path
-	deriavtionPath, err := parseDerivationPath(path)
-	if err != nil {
-		return nil, err
-	}
-
+// MasterKeyFingerprint returns the BIP-32 fingerprint of the master key of seed,
+// the first 4 bytes of the hash160 of its compressed public key.
+func MasterKeyFingerprint(seed []byte) ([]byte, error) {
 	key, err := bip32.NewMasterKey(seed)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, n := range deriavtionPath {
-		key, err = key.NewChildKey(n)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	privKey, err := hdkeychain.NewKeyFromString(key.B58Serialize())
-	if err != nil {
-		return nil, err
-	}
-
-	return privKey.ECPrivKey()
+	return btcutil.Hash160(key.PublicKey().Key)[:FingerprintLength], nil
 }
 
 // ParseDerivationPath converts a user specified derivation path string to the
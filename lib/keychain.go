@@ -0,0 +1,225 @@
+package lib
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// bip32SeedKey is the HMAC key of the BIP-32 master key
+const bip32SeedKey = "Bitcoin seed"
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrSeedUnavailable    = errors.New("keychain holds an account key, not a seed")
+	ErrPathOutsideAccount = errors.New("derivation path is outside of the account of the keychain")
+)
+
+// Keychain derives the keys of derivation paths, either from a BIP-39 seed or
+// from the extended private key of a BIP-44 account, m/purpose'/coin'/account'.
+// Account keychains only derive the paths of their account and hold no seed,
+// so they can be kept in memory without exposing the other keys of a user.
+// Keychains are safe for concurrent use.
+type Keychain struct {
+	seed []byte
+
+	account     *hdkeychain.ExtendedKey
+	accountPath derivationPath
+	fingerprint []byte
+}
+
+// NewSeedKeychain returns a keychain deriving the keys of seed
+func NewSeedKeychain(seed []byte) *Keychain {
+	return &Keychain{seed: seed}
+}
+
+// AccountPath returns the account path m/purpose'/coin'/account' of an
+// absolute derivation path, false when path has no such prefix
+func AccountPath(path string) (string, bool) {
+	components := strings.Split(path, "/")
+	if len(components) < accountLevelDepth+1 || strings.TrimSpace(components[0]) != "m" {
+		return "", false
+	}
+
+	accountPath := strings.Join(components[:accountLevelDepth+1], "/")
+	if _, err := parseAccountPath(accountPath); err != nil {
+		return "", false
+	}
+	return accountPath, true
+}
+
+// parseAccountPath parses an account path, whose components are all hardened
+func parseAccountPath(path string) (derivationPath, error) {
+	components, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(components) != accountLevelDepth {
+		return nil, ErrNotAccountLevelPath
+	}
+	for _, component := range components {
+		if component < hdkeychain.HardenedKeyStart {
+			return nil, ErrNotAccountLevelPath
+		}
+	}
+	return components, nil
+}
+
+// AccountKeychain derives the keychain of the account at accountPath from a
+// seed keychain. Callers zero it once done.
+func (k *Keychain) AccountKeychain(accountPath string) (*Keychain, error) {
+	if k.account != nil {
+		return nil, ErrSeedUnavailable
+	}
+	components, err := parseAccountPath(accountPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := MasterKeyFingerprint(k.seed)
+	if err != nil {
+		return nil, err
+	}
+	account, err := deriveExtendedKey(k.seed, components)
+	if err != nil {
+		return nil, err
+	}
+	return newAccountKeychain(account, components, fingerprint)
+}
+
+// newAccountKeychain returns the keychain of account. Its public key is
+// memoized up front, deriving its children only reads the key afterwards.
+func newAccountKeychain(account *hdkeychain.ExtendedKey, accountPath derivationPath,
+	fingerprint []byte) (*Keychain, error) {
+	if _, err := account.ECPubKey(); err != nil {
+		account.Zero()
+		return nil, err
+	}
+	return &Keychain{
+		account:     account,
+		accountPath: accountPath,
+		fingerprint: fingerprint,
+	}, nil
+}
+
+// Clone returns a copy of the keychain, which can be zeroed independently
+func (k *Keychain) Clone() (*Keychain, error) {
+	if k.account == nil {
+		return NewSeedKeychain(bytes.Clone(k.seed)), nil
+	}
+	account, err := cloneExtendedKey(k.account)
+	if err != nil {
+		return nil, err
+	}
+	return newAccountKeychain(account, k.accountPath, bytes.Clone(k.fingerprint))
+}
+
+// Zero clears the seed or the account key of the keychain
+func (k *Keychain) Zero() {
+	clear(k.seed)
+	if k.account != nil {
+		k.account.Zero()
+	}
+}
+
+// Seed returns the seed of the keychain, for derivation schemes other than
+// BIP-32 such as SLIP-0010. Account keychains fail with ErrSeedUnavailable.
+func (k *Keychain) Seed() ([]byte, error) {
+	if k.account != nil {
+		return nil, ErrSeedUnavailable
+	}
+	return k.seed, nil
+}
+
+// MasterKeyFingerprint returns the BIP-32 fingerprint of the master key
+func (k *Keychain) MasterKeyFingerprint() ([]byte, error) {
+	if k.account != nil {
+		return bytes.Clone(k.fingerprint), nil
+	}
+	return MasterKeyFingerprint(k.seed)
+}
+
+// DerivePrivateKey derives the private key of path
+func (k *Keychain) DerivePrivateKey(path string) (*btcec.PrivateKey, error) {
+	key, err := k.DeriveExtendedKey(path)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+
+	return key.ECPrivKey()
+}
+
+// DeriveExtendedKey derives the extended private key of path, e.g., the parent
+// m/44'/60'/0'/0 of a batch of addresses. Callers zero it once done.
+func (k *Keychain) DeriveExtendedKey(path string) (*hdkeychain.ExtendedKey, error) {
+	components, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return k.deriveExtendedKey(components)
+}
+
+// deriveExtendedKey derives the extended private key of the path components
+func (k *Keychain) deriveExtendedKey(components derivationPath) (*hdkeychain.ExtendedKey, error) {
+	if k.account == nil {
+		return deriveExtendedKey(k.seed, components)
+	}
+
+	if len(components) < accountLevelDepth || !slices.Equal(components[:accountLevelDepth], k.accountPath) {
+		return nil, ErrPathOutsideAccount
+	}
+	if len(components) == accountLevelDepth {
+		return cloneExtendedKey(k.account)
+	}
+
+	key := k.account
+	for _, component := range components[accountLevelDepth:] {
+		child, err := key.Derive(component)
+		if key != k.account {
+			key.Zero()
+		}
+		if err != nil {
+			return nil, err
+		}
+		key = child
+	}
+	return key, nil
+}
+
+// newMasterKey returns the BIP-32 master key of seed. Unlike hdkeychain.NewMaster,
+// seeds of any length are accepted, as keys have always been derived.
+func newMasterKey(seed []byte) (*hdkeychain.ExtendedKey, error) {
+	mac := hmac.New(sha512.New, []byte(bip32SeedKey))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:sha512.Size/2], sum[sha512.Size/2:]
+
+	var scalar btcec.ModNScalar
+	if overflow := scalar.SetByteSlice(key); overflow || scalar.IsZero() {
+		return nil, hdkeychain.ErrUnusableSeed
+	}
+	return hdkeychain.NewExtendedKey(chaincfg.MainNetParams.HDPrivateKeyID[:], key, chainCode,
+		make([]byte, FingerprintLength), 0, 0, true), nil
+}
+
+// cloneExtendedKey returns a copy of an extended private key, sharing no
+// bytes with it so that either can be zeroed
+func cloneExtendedKey(key *hdkeychain.ExtendedKey) (*hdkeychain.ExtendedKey, error) {
+	privateKey, err := key.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	defer privateKey.Zero()
+
+	return hdkeychain.NewExtendedKey(bytes.Clone(key.Version()), privateKey.Serialize(), bytes.Clone(key.ChainCode()),
+		binary.BigEndian.AppendUint32(nil, key.ParentFingerprint()), key.Depth(), key.ChildIndex(), true), nil
+}
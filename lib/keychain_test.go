@@ -0,0 +1,94 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+)
+
+func TestAccountPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{path: "m/44'/60'/0'/0/0", want: "m/44'/60'/0'", wantOK: true},
+		{path: "m/84'/0'/3'", want: "m/84'/0'/3'", wantOK: true},
+		{path: "m/44'/60'/0'/0/%d", want: "m/44'/60'/0'", wantOK: true},
+		{path: "m/44'/60'/0/0/0"},
+		{path: "m/44'/60'"},
+		{path: "44'/60'/0'/0/0"},
+		{path: "m/44'/60'/%d'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := AccountPath(tt.path)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestKeychain_AccountKeychain(t *testing.T) {
+	seed := bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	keys := NewSeedKeychain(seed)
+	account, err := keys.AccountKeychain("m/44'/60'/0'")
+	require.NoError(t, err)
+
+	t.Run("derives the keys of the seed", func(t *testing.T) {
+		for _, path := range []string{"m/44'/60'/0'/0/0", "m/44'/60'/0'/1/7", "m/44'/60'/0'/0'/2'"} {
+			want, err := keys.DerivePrivateKey(path)
+			require.NoError(t, err)
+			got, err := account.DerivePrivateKey(path)
+			require.NoError(t, err, path)
+			assert.Equal(t, want.Serialize(), got.Serialize(), path)
+		}
+
+		want, err := keys.MasterKeyFingerprint()
+		require.NoError(t, err)
+		got, err := account.MasterKeyFingerprint()
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("outside the account", func(t *testing.T) {
+		for _, path := range []string{"m/44'/60'/1'/0/0", "m/44'/0'/0'/0/0", "m/44'/60'", "m/0"} {
+			_, err := account.DerivePrivateKey(path)
+			assert.ErrorIs(t, err, ErrPathOutsideAccount, path)
+		}
+	})
+
+	t.Run("no seed", func(t *testing.T) {
+		_, err := account.Seed()
+		assert.ErrorIs(t, err, ErrSeedUnavailable)
+		_, err = account.AccountKeychain("m/44'/60'/0'")
+		assert.ErrorIs(t, err, ErrSeedUnavailable)
+	})
+
+	t.Run("not an account path", func(t *testing.T) {
+		_, err := keys.AccountKeychain("m/44'/60'/0'/0/0")
+		assert.ErrorIs(t, err, ErrNotAccountLevelPath)
+	})
+}
+
+func TestKeychain_Clone(t *testing.T) {
+	seed := bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	account, err := NewSeedKeychain(seed).AccountKeychain("m/44'/60'/0'")
+	require.NoError(t, err)
+	want, err := account.DerivePrivateKey("m/44'/60'/0'/0/0")
+	require.NoError(t, err)
+
+	clone, err := account.Clone()
+	require.NoError(t, err)
+	account.Zero()
+
+	_, err = account.DerivePrivateKey("m/44'/60'/0'/0/0")
+	assert.Error(t, err, "zeroed keychains derive no key")
+
+	got, err := clone.DerivePrivateKey("m/44'/60'/0'/0/0")
+	require.NoError(t, err, "clones hold their own copy of the key")
+	assert.Equal(t, want.Serialize(), got.Serialize())
+}
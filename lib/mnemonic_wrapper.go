@@ -1,21 +1,139 @@
 package lib
 
 import (
+	"crypto/sha256"
 	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/tyler-smith/go-bip39"
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/text/unicode/norm"
 )
 
 const (
 	// DefaultEntropyLength is the default entropy length for mnemonic generation
 	DefaultEntropyLength = 256
+
+	// wordBits is the number of bits encoded by a mnemonic word
+	wordBits = 11
+)
+
+// BIP-39 word list languages
+const (
+	LanguageEnglish            = "english"
+	LanguageJapanese           = "japanese"
+	LanguageKorean             = "korean"
+	LanguageSpanish            = "spanish"
+	LanguageChineseSimplified  = "chinese_simplified"
+	LanguageChineseTraditional = "chinese_traditional"
+	LanguageFrench             = "french"
+	LanguageItalian            = "italian"
+	LanguageCzech              = "czech"
 )
 
 // Static error variables to avoid dynamic error creation
 var (
-	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+	ErrInvalidMnemonic     = errors.New("invalid mnemonic")
+	ErrUnsupportedLanguage = errors.New("unsupported mnemonic language")
+	ErrMnemonicLength      = errors.New("mnemonic must have 12, 15, 18, 21 or 24 words")
+	ErrMnemonicWord        = errors.New("word is not in the word list")
+	ErrMnemonicChecksum    = errors.New("mnemonic checksum mismatch")
 )
 
+// mnemonicWordCounts are the word counts of BIP-39 mnemonics
+var mnemonicWordCounts = []int{12, 15, 18, 21, 24}
+
+// MnemonicWordError reports a mnemonic word missing from the word list by its
+// 1-based position, never the word itself
+type MnemonicWordError struct {
+	Position int
+}
+
+func (e *MnemonicWordError) Error() string {
+	return fmt.Sprintf("%s: word at position %d", ErrMnemonicWord, e.Position)
+}
+
+func (e *MnemonicWordError) Unwrap() error {
+	return ErrMnemonicWord
+}
+
+// wordList is a BIP-39 word list with the index of its NFKD normalized words
+type wordList struct {
+	words []string
+	index map[string]int
+}
+
+var (
+	wordListsOnce       sync.Once
+	wordListsByLanguage map[string]*wordList
+)
+
+// loadWordLists indexes the word lists of all languages once
+func loadWordLists() map[string]*wordList {
+	wordListsOnce.Do(func() {
+		lists := map[string][]string{
+			LanguageEnglish:            wordlists.English,
+			LanguageJapanese:           wordlists.Japanese,
+			LanguageKorean:             wordlists.Korean,
+			LanguageSpanish:            wordlists.Spanish,
+			LanguageChineseSimplified:  wordlists.ChineseSimplified,
+			LanguageChineseTraditional: wordlists.ChineseTraditional,
+			LanguageFrench:             wordlists.French,
+			LanguageItalian:            wordlists.Italian,
+			LanguageCzech:              wordlists.Czech,
+		}
+		wordListsByLanguage = make(map[string]*wordList, len(lists))
+		for language, words := range lists {
+			list := &wordList{words: make([]string, len(words)), index: make(map[string]int, len(words))}
+			for i, word := range words {
+				list.words[i] = norm.NFKD.String(word)
+				list.index[list.words[i]] = i
+			}
+			wordListsByLanguage[language] = list
+		}
+	})
+	return wordListsByLanguage
+}
+
+// getWordList returns the word list of language, English when empty
+func getWordList(language string) (*wordList, error) {
+	if language == "" {
+		language = LanguageEnglish
+	}
+	list, ok := loadWordLists()[language]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedLanguage, language)
+	}
+	return list, nil
+}
+
+// MnemonicLanguages returns the supported word list languages, sorted
+func MnemonicLanguages() []string {
+	languages := make([]string, 0, len(loadWordLists()))
+	for language := range loadWordLists() {
+		languages = append(languages, language)
+	}
+	slices.Sort(languages)
+	return languages
+}
+
+// NormalizeMnemonic returns the NFKD form of mnemonic with its words separated
+// by single ASCII spaces, the form BIP-39 derives seeds from. Ideographic
+// spaces of Japanese mnemonics are folded into ASCII spaces by NFKD.
+func NormalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(norm.NFKD.String(mnemonic)), " ")
+}
+
+// NormalizePassphrase returns the NFKD form of passphrase, the form BIP-39
+// salts seeds with. Unlike mnemonics, spaces in passphrases are kept.
+func NormalizePassphrase(passphrase string) string {
+	return norm.NFKD.String(passphrase)
+}
+
 // GenerateMnemonic will return a string consisting of the mnemonic words for
 // the default entropy = 256.
 // If the provide entropy is invalid, an error will be returned.
@@ -26,23 +144,112 @@ func GenerateMnemonic() (string, error) {
 // MnemonicFromEntropy will return a string consisting of the mnemonic words for
 // the given entropy.
 func MnemonicFromEntropy(entropyLength int) (string, error) {
+	return NewMnemonic(entropyLength, LanguageEnglish)
+}
+
+// NewMnemonic returns a normalized mnemonic of random entropy of entropyLength
+// bits, in the word list of language.
+func NewMnemonic(entropyLength int, language string) (string, error) {
 	entropy, err := bip39.NewEntropy(entropyLength)
 	if err != nil {
 		return "", err
 	}
+	return EntropyToMnemonic(entropy, language)
+}
+
+// EntropyToMnemonic returns the normalized mnemonic encoding entropy in the
+// word list of language, English when empty.
+func EntropyToMnemonic(entropy []byte, language string) (string, error) {
+	list, err := getWordList(language)
+	if err != nil {
+		return "", err
+	}
+
+	entropyLength := len(entropy) * 8
+	if entropyLength%32 != 0 || !slices.Contains(mnemonicWordCounts, entropyLength*33/32/wordBits) {
+		return "", fmt.Errorf("%w: entropy of %d bits", ErrMnemonicLength, entropyLength)
+	}
 
-	return bip39.NewMnemonic(entropy)
+	// entropy bits followed by the first entropyLength/32 bits of its hash
+	checksumBits := entropyLength / 32
+	hash := sha256.Sum256(entropy)
+	bits := new(big.Int).SetBytes(entropy)
+	bits.Lsh(bits, uint(checksumBits))
+	bits.Or(bits, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	wordCount := (entropyLength + checksumBits) / wordBits
+	words := make([]string, wordCount)
+	mask := big.NewInt(1<<wordBits - 1)
+	for i := wordCount - 1; i >= 0; i-- {
+		words[i] = list.words[new(big.Int).And(bits, mask).Int64()]
+		bits.Rsh(bits, wordBits)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy returns the entropy a normalized mnemonic of the word list
+// of language encodes, English when empty. Errors tell the kind of failure
+// (length, word position or checksum) without echoing any word.
+func MnemonicToEntropy(mnemonic, language string) ([]byte, error) {
+	list, err := getWordList(language)
+	if err != nil {
+		return nil, err
+	}
+
+	words := strings.Split(mnemonic, " ")
+	if !slices.Contains(mnemonicWordCounts, len(words)) {
+		return nil, fmt.Errorf("%w, got %d", ErrMnemonicLength, len(words))
+	}
+
+	bits := new(big.Int)
+	for i, word := range words {
+		index, ok := list.index[word]
+		if !ok {
+			return nil, &MnemonicWordError{Position: i + 1}
+		}
+		bits.Lsh(bits, wordBits)
+		bits.Or(bits, big.NewInt(int64(index)))
+	}
+
+	// split the checksum from the entropy and compare it with its hash
+	checksumBits := len(words) * wordBits / 33
+	checksum := new(big.Int).And(bits, big.NewInt(1<<checksumBits-1)).Int64()
+	bits.Rsh(bits, uint(checksumBits))
+
+	entropy := make([]byte, checksumBits*4)
+	bits.FillBytes(entropy)
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum {
+		return nil, ErrMnemonicChecksum
+	}
+	return entropy, nil
+}
+
+// ValidateMnemonic checks a normalized mnemonic against the word list of
+// language, English when empty.
+func ValidateMnemonic(mnemonic, language string) error {
+	_, err := MnemonicToEntropy(mnemonic, language)
+	return err
 }
 
 // IsMnemonicValid attempts to verify that the provided mnemonic is valid.
 // Validity is determined by both the number of words being appropriate,
-// and that all the words in the mnemonic are present in the word list.
+// and that all the words in the mnemonic are present in one of the word
+// lists, with a matching checksum.
 func IsMnemonicValid(mnemonic string) bool {
-	return bip39.IsMnemonicValid(mnemonic)
+	mnemonic = NormalizeMnemonic(mnemonic)
+	for language := range loadWordLists() {
+		if ValidateMnemonic(mnemonic, language) == nil {
+			return true
+		}
+	}
+	return false
 }
 
 // SeedFromMnemonic creates a hashed seed output given a provided string and password.
-// No checking is performed to validate that the string provided is a valid mnemonic.
+// The mnemonic must be valid in one of the word lists. Both are hashed as stored:
+// mnemonics and passphrases are normalized when registered, and older users
+// keep their seeds.
 func SeedFromMnemonic(mnemonic, passphrase string) ([]byte, error) {
 	if !IsMnemonicValid(mnemonic) {
 		return nil, ErrInvalidMnemonic
@@ -0,0 +1,142 @@
+package lib
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+)
+
+const (
+	testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	testJapanesePassphrase = "㍍ガバヴァぱばぐゞちぢ十人十色"
+	testJapaneseSeed       = "a262d6fb6122ecf45be09c50492b31f92e9beb7d9a845987a02cefda57a15f9c" +
+		"467a17872029a9e92299b5cbdf306e3a0ee620245cbd508959b6cb7ca637bd55"
+)
+
+func TestNormalizeMnemonic(t *testing.T) {
+	assert.Equal(t, testMnemonic, NormalizeMnemonic("  "+strings.ReplaceAll(testMnemonic, " ", " \t\n ")+"\n"))
+
+	// ideographic spaces fold into ASCII spaces
+	assert.Equal(t, "あいこくしん あいさつ", NormalizeMnemonic("あいこくしん\u3000あいさつ"))
+
+	// precomposed characters are decomposed
+	assert.Equal(t, "a\u0301baco", NormalizeMnemonic("\u00e1baco"))
+}
+
+func TestNormalizePassphrase(t *testing.T) {
+	assert.Equal(t, " a\u0301 b ", NormalizePassphrase(" \u00e1 b "))
+	assert.Equal(t, "メートル", NormalizePassphrase("㍍"))
+}
+
+func TestValidateMnemonic(t *testing.T) {
+	tests := []struct {
+		name     string
+		mnemonic string
+		language string
+		wantErr  error
+	}{
+		{name: "valid", mnemonic: testMnemonic, language: LanguageEnglish},
+		{name: "english by default", mnemonic: testMnemonic},
+		{name: "bad length", mnemonic: "abandon abandon abandon", wantErr: ErrMnemonicLength},
+		{name: "bad word", mnemonic: strings.Replace(testMnemonic, "abandon", "abandom", 1), wantErr: ErrMnemonicWord},
+		{name: "bad checksum", mnemonic: strings.Repeat("abandon ", 11) + "abandon", wantErr: ErrMnemonicChecksum},
+		{name: "other language", mnemonic: testMnemonic, language: LanguageSpanish, wantErr: ErrMnemonicWord},
+		{name: "unsupported language", mnemonic: testMnemonic, language: "klingon", wantErr: ErrUnsupportedLanguage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMnemonic(tt.mnemonic, tt.language)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.NotContains(t, err.Error(), "abandon")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("bad word position", func(t *testing.T) {
+		words := strings.Fields(testMnemonic)
+		words[4] = "zzz"
+		var wordErr *MnemonicWordError
+		require.ErrorAs(t, ValidateMnemonic(strings.Join(words, " "), LanguageEnglish), &wordErr)
+		assert.Equal(t, 5, wordErr.Position)
+	})
+}
+
+func TestNewMnemonic(t *testing.T) {
+	for _, language := range MnemonicLanguages() {
+		t.Run(language, func(t *testing.T) {
+			for _, entropyLength := range []int{128, 160, 192, 224, 256} {
+				mnemonic, err := NewMnemonic(entropyLength, language)
+				require.NoError(t, err)
+				assert.Len(t, strings.Fields(mnemonic), (entropyLength+entropyLength/32)/wordBits)
+				assert.Equal(t, mnemonic, NormalizeMnemonic(mnemonic))
+				assert.NoError(t, ValidateMnemonic(mnemonic, language))
+				assert.True(t, IsMnemonicValid(mnemonic))
+			}
+		})
+	}
+
+	t.Run("matches the reference implementation", func(t *testing.T) {
+		mnemonic, err := MnemonicFromEntropy(256)
+		require.NoError(t, err)
+		assert.True(t, bip39.IsMnemonicValid(mnemonic))
+
+		entropy, err := bip39.NewEntropy(128)
+		require.NoError(t, err)
+		reference, err := bip39.NewMnemonic(entropy)
+		require.NoError(t, err)
+		assert.NoError(t, ValidateMnemonic(reference, LanguageEnglish))
+	})
+
+	_, err := NewMnemonic(256, "klingon")
+	assert.ErrorIs(t, err, ErrUnsupportedLanguage)
+}
+
+func TestSeedFromMnemonic(t *testing.T) {
+	seed, err := SeedFromMnemonic(testMnemonic, "")
+	require.NoError(t, err)
+	assert.Equal(t, bip39.NewSeed(testMnemonic, ""), seed)
+
+	japanese, err := NewMnemonic(128, LanguageJapanese)
+	require.NoError(t, err)
+	_, err = SeedFromMnemonic(japanese, "")
+	assert.NoError(t, err)
+
+	_, err = SeedFromMnemonic("abandon abandon abandon", "")
+	assert.ErrorIs(t, err, ErrInvalidMnemonic)
+
+	t.Run("japanese vector", func(t *testing.T) {
+		// first Japanese test vector of BIP-39, its passphrase is not in NFKD
+		mnemonic := strings.Repeat("あいこくしん\u3000", 11) + "あおぞら"
+		seed, err := SeedFromMnemonic(NormalizeMnemonic(mnemonic), NormalizePassphrase(testJapanesePassphrase))
+		require.NoError(t, err)
+		assert.Equal(t, testJapaneseSeed, hex.EncodeToString(seed))
+	})
+}
+
+func TestMnemonicEntropy(t *testing.T) {
+	entropy, err := MnemonicToEntropy(testMnemonic, LanguageEnglish)
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, 16), entropy)
+
+	for _, language := range MnemonicLanguages() {
+		mnemonic, err := EntropyToMnemonic(entropy, language)
+		require.NoError(t, err)
+		recovered, err := MnemonicToEntropy(mnemonic, language)
+		require.NoError(t, err)
+		assert.Equal(t, entropy, recovered)
+	}
+
+	_, err = EntropyToMnemonic(make([]byte, 15), LanguageEnglish)
+	assert.ErrorIs(t, err, ErrMnemonicLength)
+	_, err = EntropyToMnemonic(make([]byte, 36), LanguageEnglish)
+	assert.ErrorIs(t, err, ErrMnemonicLength)
+}
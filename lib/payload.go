@@ -2,35 +2,67 @@ package lib
 
 import "math/big"
 
+// Off-chain message signing methods, named after the equivalent JSON-RPC methods
+const (
+	// MessageMethodPersonalSign signs an EIP-191 prefixed message
+	MessageMethodPersonalSign = "personal_sign"
+	// MessageMethodSignTypedDataV4 signs EIP-712 typed structured data
+	MessageMethodSignTypedDataV4 = "eth_signTypedData_v4"
+)
+
 // IRawTx Raw transaction interface
 // to enable decoding of all variants of raw transactions (JSON)
 type IRawTx interface{}
 
 // EthereumRawTx Ethereum raw transaction implements IRawTx
 // to store raw Ethereum JSON payload
+//
+// Type selects the transaction envelope: 0 (legacy, default), 1 (EIP-2930
+// access list) or 2 (EIP-1559 dynamic fee). Legacy and access list
+// transactions are priced with GasPrice, dynamic fee transactions with
+// MaxFeePerGas and MaxPriorityFeePerGas.
 type EthereumRawTx struct {
-	Nonce    uint64   `json:"nonce"`
-	Value    *big.Int `json:"value"`
-	GasLimit uint64   `json:"gasLimit"`
-	GasPrice *big.Int `json:"gasPrice"`
-	To       string   `json:"to"`
-	Data     string   `json:"data"`
-	ChainID  *big.Int `json:"chainId"`
+	Type                 uint8                 `json:"type"`
+	Nonce                uint64                `json:"nonce"`
+	Value                *big.Int              `json:"value"`
+	GasLimit             uint64                `json:"gasLimit"`
+	GasPrice             *big.Int              `json:"gasPrice"`
+	MaxFeePerGas         *big.Int              `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *big.Int              `json:"maxPriorityFeePerGas"`
+	To                   string                `json:"to"`
+	Data                 string                `json:"data"`
+	ChainID              *big.Int              `json:"chainId"`
+	AccessList           []EthereumAccessTuple `json:"accessList"`
 	IRawTx
 }
 
+// EthereumAccessTuple is an EIP-2930 access list entry: a contract address
+// and the hex encoded 32 byte storage slots the transaction will touch
+type EthereumAccessTuple struct {
+	Address     string   `json:"address"`
+	StorageKeys []string `json:"storageKeys"`
+}
+
 // BitcoinRawTx stores bitcoin based raw transaction payloads
 // stores input UTXO's and output Addresses
 // implements IRawTx
+//
+// Amount and Script of an input describe the previous output being spent
+// (value in satoshis and hex encoded scriptPubKey). Segwit and taproot
+// signatures commit to them, so the amount is required for every input.
+// Script may be left empty, in which case the script of the signing key is used.
 type BitcoinRawTx struct {
 	Inputs []struct {
 		Txhash string `json:"txhash"`
 		Vout   uint32 `json:"vout"`
+		Amount int64  `json:"amount"`
+		Script string `json:"script"`
 	} `json:"inputs"`
 	Outputs []struct {
 		Address string `json:"address"`
 		Amount  int64  `json:"amount"`
 	} `json:"outputs"`
+	LockTime uint32 `json:"lockTime"`
 	IRawTx
 }
 
@@ -41,3 +73,26 @@ type BitsharesRawTx struct {
 	TransactionDigest string `json:"transactionDigest"`
 	IRawTx
 }
+
+// Solana message encodings
+const (
+	SolanaEncodingBase64 = "base64"
+	SolanaEncodingHex    = "hex"
+)
+
+// SolanaRawTx stores a serialized Solana transaction message (legacy or v0)
+// implements IRawTx
+//
+// Encoding is either "base64" (default) or "hex".
+type SolanaRawTx struct {
+	Message  string `json:"message"`
+	Encoding string `json:"encoding"`
+	IRawTx
+}
+
+// SolanaSignedTx is the result of signing a SolanaRawTx: the base58 signature
+// (also the transaction id) and the wire transaction, in the encoding of the message
+type SolanaSignedTx struct {
+	Signature   string `json:"signature"`
+	Transaction string `json:"transaction"`
+}
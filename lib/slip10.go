@@ -0,0 +1,59 @@
+package lib
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	// slip10Ed25519Curve is the HMAC key of the SLIP-0010 ed25519 master key
+	slip10Ed25519Curve = "ed25519 seed"
+
+	// hardenedKeyStart is the index of the first hardened child key
+	hardenedKeyStart = 0x80000000
+
+	// childIndexLength is the size of a serialized child index
+	childIndexLength = 4
+)
+
+// ErrNonHardenedComponent is returned for ed25519 paths with a non hardened
+// component, SLIP-0010 only defines hardened derivation for ed25519.
+var ErrNonHardenedComponent = errors.New("ed25519 derivation paths must only contain hardened components")
+
+// DeriveEd25519PrivateKey derives the ed25519 key of an absolute derivation
+// path (m/44'/501'/0'/0') following SLIP-0010.
+//
+// https://github.com/satoshilabs/slips/blob/master/slip-0010.md
+func DeriveEd25519PrivateKey(seed []byte, path string) (ed25519.PrivateKey, error) {
+	derivationPath, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha512.New, []byte(slip10Ed25519Curve))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:ed25519.SeedSize], sum[ed25519.SeedSize:]
+
+	for _, index := range derivationPath {
+		if index < hardenedKeyStart {
+			return nil, ErrNonHardenedComponent
+		}
+
+		// data = 0x00 || key || index
+		data := make([]byte, 0, 1+ed25519.SeedSize+childIndexLength)
+		data = append(data, 0)
+		data = append(data, key...)
+		data = binary.BigEndian.AppendUint32(data, index)
+
+		mac = hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum = mac.Sum(nil)
+		key, chainCode = sum[:ed25519.SeedSize], sum[ed25519.SeedSize:]
+	}
+
+	return ed25519.NewKeyFromSeed(key), nil
+}
@@ -0,0 +1,52 @@
+package lib
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vector 1 for ed25519 from SLIP-0010
+func TestDeriveEd25519PrivateKey(t *testing.T) {
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	require.NoError(t, err)
+
+	tests := []struct {
+		path       string
+		privateKey string
+		publicKey  string
+	}{
+		{
+			path:       "m/0'",
+			privateKey: "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3",
+			publicKey:  "8c8a13df77a28f3445213a0f432fde644acaa215fc72dcdf300d5efaa85d350c",
+		},
+		{
+			path:       "m/0'/1'/2'/2'/1000000000'",
+			privateKey: "8f94d394a8e8fd6b1bc2f3f49f5c47e385281d5c17e65324b0f62483e37e8793",
+			publicKey:  "3c24da049451555d51a7014a37337aa4e12d41e485abccfa46b47dfb2af54b7a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			key, err := DeriveEd25519PrivateKey(seed, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.privateKey, hex.EncodeToString(key.Seed()))
+			assert.Equal(t, tt.publicKey, hex.EncodeToString(key.Public().(ed25519.PublicKey)))
+		})
+	}
+}
+
+func TestDeriveEd25519PrivateKey_Errors(t *testing.T) {
+	seed := []byte("seed")
+
+	_, err := DeriveEd25519PrivateKey(seed, "m/44'/501'/0'/0")
+	assert.ErrorIs(t, err, ErrNonHardenedComponent)
+
+	_, err = DeriveEd25519PrivateKey(seed, "m/44'/x'")
+	assert.ErrorIs(t, err, ErrInvalidComponent)
+}
@@ -0,0 +1,130 @@
+package slip39
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+const (
+	// digestLength is the length in bytes of the digest of a shared secret
+	digestLength = 4
+	// digestIndex is the x coordinate of the share holding the digest
+	digestIndex = 254
+	// secretIndex is the x coordinate of the shared secret
+	secretIndex = 255
+)
+
+// gf256Exp and gf256Log are the exponent and logarithm tables of GF(256)
+// with the Rijndael polynomial x^8 + x^4 + x^3 + x + 1 and generator 3
+var gf256Exp, gf256Log = func() ([255]byte, [256]byte) {
+	var exp [255]byte
+	var log [256]byte
+	poly := 1
+	for i := range exp {
+		exp[i] = byte(poly)
+		log[poly] = byte(i)
+		// multiply poly by the generator x + 1
+		poly = (poly << 1) ^ poly
+		if poly&0x100 != 0 {
+			poly ^= 0x11b
+		}
+	}
+	return exp, log
+}()
+
+// share is a point of the polynomials sharing a secret, one per byte
+type share struct {
+	x     byte
+	value []byte
+}
+
+// interpolate returns the value at x of the polynomials passing through shares
+func interpolate(shares []share, x byte) []byte {
+	for _, s := range shares {
+		if s.x == x {
+			return s.value
+		}
+	}
+
+	// Lagrange interpolation with the logarithms of the basis polynomials
+	logProd := 0
+	for _, s := range shares {
+		logProd += int(gf256Log[s.x^x])
+	}
+
+	result := make([]byte, len(shares[0].value))
+	for _, s := range shares {
+		logBasis := logProd - int(gf256Log[s.x^x])
+		for _, other := range shares {
+			if other.x != s.x {
+				logBasis -= int(gf256Log[s.x^other.x])
+			}
+		}
+		logBasis = ((logBasis % 255) + 255) % 255
+
+		for i, v := range s.value {
+			if v != 0 {
+				result[i] ^= gf256Exp[(int(gf256Log[v])+logBasis)%255]
+			}
+		}
+	}
+	return result
+}
+
+// createDigest returns the digest of secret keyed with randomPart
+func createDigest(randomPart, secret []byte) []byte {
+	mac := hmac.New(sha256.New, randomPart)
+	mac.Write(secret)
+	return mac.Sum(nil)[:digestLength]
+}
+
+// splitSecret splits secret into count shares, threshold of which recover it
+func splitSecret(threshold, count int, secret []byte) ([]share, error) {
+	shares := make([]share, 0, count)
+	if threshold == 1 {
+		for i := range count {
+			shares = append(shares, share{x: byte(i), value: secret})
+		}
+		return shares, nil
+	}
+
+	randomShareCount := threshold - 2
+	for i := range randomShareCount {
+		value := make([]byte, len(secret))
+		if _, err := rand.Read(value); err != nil {
+			return nil, err
+		}
+		shares = append(shares, share{x: byte(i), value: value})
+	}
+
+	// the digest share lets recovery detect invalid shares
+	randomPart := make([]byte, len(secret)-digestLength)
+	if _, err := rand.Read(randomPart); err != nil {
+		return nil, err
+	}
+	digest := append(createDigest(randomPart, secret), randomPart...)
+
+	baseShares := append(append([]share(nil), shares...),
+		share{x: digestIndex, value: digest}, share{x: secretIndex, value: secret})
+	for i := randomShareCount; i < count; i++ {
+		shares = append(shares, share{x: byte(i), value: interpolate(baseShares, byte(i))})
+	}
+	return shares, nil
+}
+
+// recoverSecret recovers the secret shared by threshold shares and checks its digest
+func recoverSecret(threshold int, shares []share) ([]byte, error) {
+	if threshold == 1 {
+		return shares[0].value, nil
+	}
+
+	secret := interpolate(shares, secretIndex)
+	digestShare := interpolate(shares, digestIndex)
+	digest, randomPart := digestShare[:digestLength], digestShare[digestLength:]
+	if subtle.ConstantTimeCompare(digest, createDigest(randomPart, secret)) != 1 {
+		return nil, ErrInvalidDigest
+	}
+	return secret, nil
+}
@@ -0,0 +1,381 @@
+// Package slip39 splits secrets into SLIP-39 Shamir mnemonic shares and
+// recovers them.
+//
+// https://github.com/satoshilabs/slips/blob/master/slip-0039.md
+package slip39
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// radixBits is the number of bits encoded by a share word
+	radixBits = 10
+	// radixSize is the number of words of the word list
+	radixSize = 1 << radixBits
+
+	// idLengthBits is the length of the random identifier of a share set
+	idLengthBits = 15
+	// maxShareCount bounds the count of groups and of members of a group
+	maxShareCount = 16
+	// checksumWords is the number of words of the RS1024 checksum
+	checksumWords = 3
+	// metadataWords is the number of words of a share that are not its value
+	metadataWords = 4 + checksumWords
+	// minSecretLength is the minimum length of a secret in bytes
+	minSecretLength = 16
+	// minMnemonicWords is the number of words of the shares of a 16 byte secret
+	minMnemonicWords = metadataWords + (minSecretLength*8+radixBits-1)/radixBits
+
+	// baseIterationCount is the PBKDF2 iteration count at exponent 0
+	baseIterationCount = 10000
+	// roundCount is the number of rounds of the Feistel cipher
+	roundCount = 4
+	// maxIterationExponent bounds the iteration exponent of a share set
+	maxIterationExponent = 15
+
+	// DefaultIterationExponent is the iteration exponent of generated shares,
+	// 20000 PBKDF2 iterations
+	DefaultIterationExponent = 1
+)
+
+// customization strings of the checksum, and of the salt of the cipher
+const (
+	customizationOriginal   = "shamir"
+	customizationExtendable = "shamir_extendable"
+)
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrInvalidSecret      = errors.New("secret must be at least 16 bytes long and of even length")
+	ErrInvalidPassphrase  = errors.New("passphrase must only contain printable ASCII characters")
+	ErrInvalidGroups      = errors.New("invalid group configuration")
+	ErrInvalidShare       = errors.New("invalid share")
+	ErrInvalidChecksum    = errors.New("invalid share checksum")
+	ErrMismatchedShares   = errors.New("shares do not belong to the same set")
+	ErrInsufficientShares = errors.New("insufficient shares")
+	ErrInvalidDigest      = errors.New("invalid digest of the shared secret")
+)
+
+// Group is the threshold and member count of a share group
+type Group struct {
+	Threshold int
+	Count     int
+}
+
+// shareData is a decoded share mnemonic
+type shareData struct {
+	identifier        uint16
+	extendable        bool
+	iterationExponent int
+	groupIndex        int
+	groupThreshold    int
+	groupCount        int
+	memberIndex       int
+	memberThreshold   int
+	value             []byte
+}
+
+// Split splits secret into groups of mnemonic shares: groupThreshold groups
+// recover the secret, each with the threshold of its members. The secret is
+// encrypted with passphrase, which recovery needs as well.
+func Split(secret []byte, passphrase string, groupThreshold int, groups []Group,
+	iterationExponent int) ([][]string, error) {
+	if len(secret) < minSecretLength || len(secret)%2 != 0 {
+		return nil, ErrInvalidSecret
+	}
+	if err := validatePassphrase(passphrase); err != nil {
+		return nil, err
+	}
+	if iterationExponent < 0 || iterationExponent > maxIterationExponent {
+		return nil, fmt.Errorf("%w: iteration exponent must be between 0 and %d",
+			ErrInvalidGroups, maxIterationExponent)
+	}
+	if len(groups) == 0 || len(groups) > maxShareCount {
+		return nil, fmt.Errorf("%w: between 1 and %d groups are required", ErrInvalidGroups, maxShareCount)
+	}
+	if groupThreshold < 1 || groupThreshold > len(groups) {
+		return nil, fmt.Errorf("%w: group threshold must be between 1 and %d", ErrInvalidGroups, len(groups))
+	}
+	for i, group := range groups {
+		if group.Count < 1 || group.Count > maxShareCount || group.Threshold < 1 || group.Threshold > group.Count {
+			return nil, fmt.Errorf("%w: group %d must have a threshold between 1 and its member count, "+
+				"at most %d", ErrInvalidGroups, i+1, maxShareCount)
+		}
+		if group.Threshold == 1 && group.Count > 1 {
+			return nil, fmt.Errorf("%w: group %d has several members with threshold 1, use 1-of-1 instead",
+				ErrInvalidGroups, i+1)
+		}
+	}
+
+	var random [2]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return nil, err
+	}
+	identifier := binary.BigEndian.Uint16(random[:]) & (1<<idLengthBits - 1)
+
+	encrypted, err := crypt(secret, passphrase, iterationExponent, identifier, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	groupShares, err := splitSecret(groupThreshold, len(groups), encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	mnemonics := make([][]string, len(groups))
+	for i, groupShare := range groupShares {
+		group := groups[i]
+		memberShares, err := splitSecret(group.Threshold, group.Count, groupShare.value)
+		if err != nil {
+			return nil, err
+		}
+		for _, memberShare := range memberShares {
+			mnemonics[i] = append(mnemonics[i], encodeShare(&shareData{
+				identifier:        identifier,
+				extendable:        true,
+				iterationExponent: iterationExponent,
+				groupIndex:        int(groupShare.x),
+				groupThreshold:    groupThreshold,
+				groupCount:        len(groups),
+				memberIndex:       int(memberShare.x),
+				memberThreshold:   group.Threshold,
+				value:             memberShare.value,
+			}))
+		}
+	}
+	return mnemonics, nil
+}
+
+// Combine recovers the secret of mnemonic shares, decrypted with passphrase.
+// A wrong passphrase recovers a different secret rather than failing.
+func Combine(mnemonics []string, passphrase string) ([]byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, ErrInsufficientShares
+	}
+	if err := validatePassphrase(passphrase); err != nil {
+		return nil, err
+	}
+
+	shares := make([]*shareData, 0, len(mnemonics))
+	for i, mnemonic := range mnemonics {
+		s, err := decodeShare(mnemonic)
+		if err != nil {
+			return nil, fmt.Errorf("share %d: %w", i+1, err)
+		}
+		shares = append(shares, s)
+	}
+
+	// members of the share groups, by group index
+	first := shares[0]
+	groups := make(map[int][]*shareData)
+	for i, s := range shares {
+		if s.identifier != first.identifier || s.extendable != first.extendable ||
+			s.iterationExponent != first.iterationExponent || s.groupThreshold != first.groupThreshold ||
+			s.groupCount != first.groupCount {
+			return nil, fmt.Errorf("%w: share %d", ErrMismatchedShares, i+1)
+		}
+		for _, member := range groups[s.groupIndex] {
+			if member.memberThreshold != s.memberThreshold {
+				return nil, fmt.Errorf("%w: member thresholds of group %d differ", ErrMismatchedShares,
+					s.groupIndex+1)
+			}
+			if member.memberIndex == s.memberIndex {
+				return nil, fmt.Errorf("%w: share %d is a duplicate", ErrInvalidShare, i+1)
+			}
+		}
+		groups[s.groupIndex] = append(groups[s.groupIndex], s)
+	}
+
+	// recover the shares of the groups having enough members
+	groupShares := make([]share, 0, first.groupThreshold)
+	for groupIndex, members := range groups {
+		if len(members) < members[0].memberThreshold || len(groupShares) == first.groupThreshold {
+			continue
+		}
+		memberShares := make([]share, 0, members[0].memberThreshold)
+		for _, member := range members[:members[0].memberThreshold] {
+			memberShares = append(memberShares, share{x: byte(member.memberIndex), value: member.value})
+		}
+		value, err := recoverSecret(members[0].memberThreshold, memberShares)
+		if err != nil {
+			return nil, fmt.Errorf("group %d: %w", groupIndex+1, err)
+		}
+		groupShares = append(groupShares, share{x: byte(groupIndex), value: value})
+	}
+	if len(groupShares) < first.groupThreshold {
+		return nil, fmt.Errorf("%w: %d of %d groups are complete", ErrInsufficientShares,
+			len(groupShares), first.groupThreshold)
+	}
+
+	encrypted, err := recoverSecret(first.groupThreshold, groupShares)
+	if err != nil {
+		return nil, err
+	}
+	return crypt(encrypted, passphrase, first.iterationExponent, first.identifier, first.extendable, true)
+}
+
+// validatePassphrase checks that passphrase only holds printable ASCII characters
+func validatePassphrase(passphrase string) error {
+	for _, c := range passphrase {
+		if c < 32 || c > 126 {
+			return ErrInvalidPassphrase
+		}
+	}
+	return nil
+}
+
+// crypt encrypts, or decrypts, secret with the 4 round Feistel cipher of SLIP-39
+func crypt(secret []byte, passphrase string, iterationExponent int, identifier uint16,
+	extendable, decrypt bool) ([]byte, error) {
+	var salt []byte
+	if !extendable {
+		salt = binary.BigEndian.AppendUint16([]byte(customizationOriginal), identifier)
+	}
+	iterations := (baseIterationCount << iterationExponent) / roundCount
+
+	half := len(secret) / 2
+	l := append([]byte(nil), secret[:half]...)
+	r := append([]byte(nil), secret[half:]...)
+	for round := range roundCount {
+		if decrypt {
+			round = roundCount - 1 - round
+		}
+		f, err := pbkdf2.Key(sha256.New, string(append([]byte{byte(round)}, passphrase...)),
+			append(append([]byte(nil), salt...), r...), iterations, len(r))
+		if err != nil {
+			return nil, err
+		}
+		for i := range l {
+			l[i] ^= f[i]
+		}
+		l, r = r, l
+	}
+	return append(r, l...), nil
+}
+
+// rs1024Polymod returns the RS1024 checksum state of values
+func rs1024Polymod(values []int) int {
+	generator := [...]int{
+		0xE0E040, 0x1C1C080, 0x3838100, 0x7070200, 0xE0E0009,
+		0x1C0C2412, 0x38086C24, 0x3090FC48, 0x21B1F890, 0x3F3F120,
+	}
+	chk := 1
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xFFFFF)<<radixBits ^ v
+		for i, g := range generator {
+			if (b>>i)&1 != 0 {
+				chk ^= g
+			}
+		}
+	}
+	return chk
+}
+
+// customizationValues returns the checksum customization string of a share
+func customizationValues(extendable bool) []int {
+	customization := customizationOriginal
+	if extendable {
+		customization = customizationExtendable
+	}
+	values := make([]int, len(customization))
+	for i := range customization {
+		values[i] = int(customization[i])
+	}
+	return values
+}
+
+// encodeShare returns the mnemonic of s
+func encodeShare(s *shareData) string {
+	ext := 0
+	if s.extendable {
+		ext = 1
+	}
+	idExp := int(s.identifier)<<5 | ext<<4 | s.iterationExponent
+	params := s.groupIndex<<16 | (s.groupThreshold-1)<<12 | (s.groupCount-1)<<8 |
+		s.memberIndex<<4 | (s.memberThreshold - 1)
+
+	valueWords := (len(s.value)*8 + radixBits - 1) / radixBits
+	values := make([]int, 0, metadataWords+valueWords)
+	values = append(values, idExp>>radixBits, idExp&(radixSize-1), params>>radixBits, params&(radixSize-1))
+
+	// the value is left padded with zero bits to a multiple of radixBits
+	value := new(big.Int).SetBytes(s.value)
+	for i := valueWords - 1; i >= 0; i-- {
+		values = append(values, int(new(big.Int).Rsh(value, uint(i*radixBits)).Int64()&(radixSize-1)))
+	}
+
+	chk := rs1024Polymod(append(append(customizationValues(s.extendable), values...), 0, 0, 0)) ^ 1
+	for i := checksumWords - 1; i >= 0; i-- {
+		values = append(values, (chk>>(i*radixBits))&(radixSize-1))
+	}
+
+	words := make([]string, len(values))
+	for i, v := range values {
+		words[i] = wordList[v]
+	}
+	return strings.Join(words, " ")
+}
+
+// decodeShare parses and checks the mnemonic of a share
+func decodeShare(mnemonic string) (*shareData, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) < minMnemonicWords {
+		return nil, fmt.Errorf("%w: a share must have at least %d words", ErrInvalidShare, minMnemonicWords)
+	}
+
+	values := make([]int, len(words))
+	for i, word := range words {
+		index, ok := wordIndex[word]
+		if !ok {
+			return nil, fmt.Errorf("%w: word at position %d is not in the word list", ErrInvalidShare, i+1)
+		}
+		values[i] = index
+	}
+
+	paddingBits := (radixBits * (len(values) - metadataWords)) % 16
+	if paddingBits > 8 {
+		return nil, fmt.Errorf("%w: invalid length", ErrInvalidShare)
+	}
+
+	idExp := values[0]<<radixBits | values[1]
+	s := &shareData{
+		identifier:        uint16(idExp >> 5),
+		extendable:        (idExp>>4)&1 == 1,
+		iterationExponent: idExp & 0xF,
+	}
+	if rs1024Polymod(append(customizationValues(s.extendable), values...)) != 1 {
+		return nil, ErrInvalidChecksum
+	}
+
+	params := values[2]<<radixBits | values[3]
+	s.groupIndex = params >> 16
+	s.groupThreshold = (params>>12)&0xF + 1
+	s.groupCount = (params>>8)&0xF + 1
+	s.memberIndex = (params >> 4) & 0xF
+	s.memberThreshold = params&0xF + 1
+	if s.groupThreshold > s.groupCount {
+		return nil, fmt.Errorf("%w: group threshold exceeds the group count", ErrInvalidShare)
+	}
+
+	value := new(big.Int)
+	for _, v := range values[4 : len(values)-checksumWords] {
+		value.Lsh(value, radixBits)
+		value.Or(value, big.NewInt(int64(v)))
+	}
+	valueLength := (radixBits*(len(values)-metadataWords) - paddingBits) / 8
+	if value.BitLen() > valueLength*8 {
+		return nil, fmt.Errorf("%w: invalid padding", ErrInvalidShare)
+	}
+	s.value = value.FillBytes(make([]byte, valueLength))
+	return s, nil
+}
@@ -0,0 +1,152 @@
+package slip39
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors from the SLIP-39 reference implementation, passphrase TREZOR
+func TestCombine_Vectors(t *testing.T) {
+	tests := []struct {
+		name      string
+		mnemonics []string
+		want      string
+	}{
+		{
+			name: "1-of-1",
+			mnemonics: []string{
+				"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal " +
+					"husband erode duke ajar critical decision keyboard",
+			},
+			want: "bb54aac4b89dc868ba37d9cc21b2cece",
+		},
+		{
+			name: "2-of-3",
+			mnemonics: []string{
+				"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue " +
+					"view short owner flip making coding armed",
+				"shadow pistol academic acid actress prayer class unknown daughter sweater depict flip twice " +
+					"unkind craft early superior advocate guest smoking",
+			},
+			want: "b43ceb7e57a0ea8766221624d01b0864",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret, err := Combine(tt.mnemonics, "TREZOR")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, hex.EncodeToString(secret))
+		})
+	}
+}
+
+func TestSplitCombine(t *testing.T) {
+	secret, err := hex.DecodeString("0c94e5b3a2d1f6e8c7b9a0d3e2f1c4b5a6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1")
+	require.NoError(t, err)
+
+	groups, err := Split(secret, "passphrase", 2, []Group{{1, 1}, {2, 3}, {3, 5}}, 0)
+	require.NoError(t, err)
+	require.Len(t, groups, 3)
+	assert.Len(t, groups[1], 3)
+	assert.Len(t, groups[2], 5)
+	for _, group := range groups {
+		for _, mnemonic := range group {
+			assert.Len(t, strings.Fields(mnemonic), 33)
+		}
+	}
+
+	t.Run("threshold of groups", func(t *testing.T) {
+		recovered, err := Combine([]string{groups[0][0], groups[2][4], groups[2][0], groups[2][2]}, "passphrase")
+		require.NoError(t, err)
+		assert.Equal(t, secret, recovered)
+
+		recovered, err = Combine([]string{groups[1][2], groups[1][0], groups[2][1], groups[2][3], groups[2][4]},
+			"passphrase")
+		require.NoError(t, err)
+		assert.Equal(t, secret, recovered)
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		recovered, err := Combine([]string{groups[0][0], groups[1][0], groups[1][1]}, "other")
+		require.NoError(t, err)
+		assert.NotEqual(t, secret, recovered)
+	})
+
+	t.Run("insufficient shares", func(t *testing.T) {
+		_, err := Combine([]string{groups[0][0], groups[1][0]}, "passphrase")
+		assert.ErrorIs(t, err, ErrInsufficientShares)
+	})
+
+	t.Run("duplicate share", func(t *testing.T) {
+		_, err := Combine([]string{groups[0][0], groups[1][0], groups[1][0]}, "passphrase")
+		assert.ErrorIs(t, err, ErrInvalidShare)
+	})
+
+	t.Run("shares of another set", func(t *testing.T) {
+		other, err := Split(secret, "passphrase", 1, []Group{{1, 1}}, 0)
+		require.NoError(t, err)
+		_, err = Combine([]string{groups[0][0], other[0][0]}, "passphrase")
+		assert.ErrorIs(t, err, ErrMismatchedShares)
+	})
+
+	t.Run("invalid checksum", func(t *testing.T) {
+		words := strings.Fields(groups[0][0])
+		words[len(words)-1] = wordList[(wordIndex[words[len(words)-1]]+1)%radixSize]
+		_, err := Combine([]string{strings.Join(words, " ")}, "passphrase")
+		assert.ErrorIs(t, err, ErrInvalidChecksum)
+	})
+
+	t.Run("invalid digest", func(t *testing.T) {
+		// member shares of group 2 with the value of a member swapped for another set
+		tampered, err := Split(secret, "passphrase", 2, []Group{{1, 1}, {2, 3}, {3, 5}}, 0)
+		require.NoError(t, err)
+		bad, err := decodeShare(tampered[1][1])
+		require.NoError(t, err)
+		good, err := decodeShare(groups[1][1])
+		require.NoError(t, err)
+		good.value = bad.value
+		_, err = Combine([]string{groups[0][0], groups[1][0], encodeShare(good)}, "passphrase")
+		assert.ErrorIs(t, err, ErrInvalidDigest)
+	})
+}
+
+func TestSplit_Invalid(t *testing.T) {
+	secret := make([]byte, 16)
+
+	tests := []struct {
+		name           string
+		secret         []byte
+		passphrase     string
+		groupThreshold int
+		groups         []Group
+		wantErr        error
+	}{
+		{name: "short secret", secret: make([]byte, 14), groupThreshold: 1, groups: []Group{{1, 1}},
+			wantErr: ErrInvalidSecret},
+		{name: "odd secret", secret: make([]byte, 17), groupThreshold: 1, groups: []Group{{1, 1}},
+			wantErr: ErrInvalidSecret},
+		{name: "non ASCII passphrase", secret: secret, passphrase: "pässword", groupThreshold: 1,
+			groups: []Group{{1, 1}}, wantErr: ErrInvalidPassphrase},
+		{name: "no groups", secret: secret, groupThreshold: 1, wantErr: ErrInvalidGroups},
+		{name: "group threshold above count", secret: secret, groupThreshold: 2, groups: []Group{{1, 1}},
+			wantErr: ErrInvalidGroups},
+		{name: "member threshold above count", secret: secret, groupThreshold: 1, groups: []Group{{3, 2}},
+			wantErr: ErrInvalidGroups},
+		{name: "several members with threshold 1", secret: secret, groupThreshold: 1, groups: []Group{{1, 3}},
+			wantErr: ErrInvalidGroups},
+		{name: "too many members", secret: secret, groupThreshold: 1, groups: []Group{{2, 17}},
+			wantErr: ErrInvalidGroups},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Split(tt.secret, tt.passphrase, tt.groupThreshold, tt.groups, 0)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package lib
+
+import "math/big"
+
+// TransactionSummary is the chain independent view of a transaction payload,
+// extracted without deriving any key. Signing policies are evaluated against it
+// and dq/decode reports it.
+//
+// Type names the kind of transaction in the terms of its chain, Method the
+// contract method called, if any. ChainID is nil for chains whose
+// transactions carry no chain id.
+type TransactionSummary struct {
+	Type      string     `json:"type"`
+	ChainID   *big.Int   `json:"chainId,omitempty"`
+	Method    string     `json:"method,omitempty"`
+	Transfers []Transfer `json:"transfers"`
+	Fee       Fee        `json:"fee"`
+}
+
+// Fee holds the fee fields of a transaction, fields that do not apply to its
+// chain are left unset.
+//
+// Amount is the fee paid by UTXO transactions, the inputs minus the outputs,
+// when the payload carries the input amounts. FeeLimit is the Tron energy fee limit in sun.
+type Fee struct {
+	GasLimit             uint64   `json:"gasLimit,omitempty"`
+	GasPrice             *big.Int `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *big.Int `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *big.Int `json:"maxPriorityFeePerGas,omitempty"`
+	FeeLimit             *big.Int `json:"feeLimit,omitempty"`
+	Amount               *big.Int `json:"amount,omitempty"`
+}
+
+// Transfer is a value movement of a transaction.
+//
+// Native transfers leave Token empty. Token transfers (ERC-20 / TRC-20) set
+// Token to the token contract, To to the token recipient and Value to the
+// token amount in the smallest unit of the token. Contract calls that are not
+// token transfers are reported with the contract as destination. Contract is
+// set on the transfer calling the contract To, token transfer or not.
+type Transfer struct {
+	To       string   `json:"to"`
+	Value    *big.Int `json:"value"`
+	Token    string   `json:"token,omitempty"`
+	Contract bool     `json:"contract,omitempty"`
+}
+
+// NativeValue returns the sum of the native transfers of the transaction
+func (s *TransactionSummary) NativeValue() *big.Int {
+	total := new(big.Int)
+	for _, transfer := range s.Transfers {
+		if transfer.Token == "" && transfer.Value != nil {
+			total.Add(total, transfer.Value)
+		}
+	}
+	return total
+}
@@ -0,0 +1,93 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+)
+
+const (
+	// accountLevelDepth is the depth of m / purpose' / coin_type' / account'
+	accountLevelDepth = 3
+
+	// testnetCoinType is the SLIP-44 coin type shared by all testnets
+	testnetCoinType = 1
+)
+
+// Static error variables to avoid dynamic error creation
+var (
+	ErrNotAccountLevelPath    = errors.New("extended public keys are exported at account level: m/purpose'/coin'/account'")
+	ErrUnsupportedXpubPurpose = errors.New("unsupported derivation path purpose for extended public keys")
+)
+
+// extendedKeyVersions holds the SLIP-0132 version bytes of an extended public key
+type extendedKeyVersions struct {
+	mainnet [4]byte
+	testnet [4]byte
+}
+
+// xpubVersions maps the BIP-43 purpose of a path to its extended public key versions:
+// xpub/tpub for BIP-44 and BIP-86, ypub/upub for BIP-49 and zpub/vpub for BIP-84.
+var xpubVersions = map[uint32]extendedKeyVersions{
+	44: {mainnet: [4]byte{0x04, 0x88, 0xb2, 0x1e}, testnet: [4]byte{0x04, 0x35, 0x87, 0xcf}},
+	49: {mainnet: [4]byte{0x04, 0x9d, 0x7c, 0xb2}, testnet: [4]byte{0x04, 0x4a, 0x52, 0x62}},
+	84: {mainnet: [4]byte{0x04, 0xb2, 0x47, 0x46}, testnet: [4]byte{0x04, 0x5f, 0x1c, 0xf6}},
+	86: {mainnet: [4]byte{0x04, 0x88, 0xb2, 0x1e}, testnet: [4]byte{0x04, 0x35, 0x87, 0xcf}},
+}
+
+// DeriveExtendedPublicKey derives the account-level extended public key of path,
+// e.g. m/84'/0'/0', so that watch-only services can derive receive addresses.
+//
+// The version bytes follow the purpose of the path. Testnet versions are used in
+// development mode and for the testnet coin type 1'.
+func DeriveExtendedPublicKey(keys *Keychain, path string, isDev bool) (string, error) {
+	components, err := parseAccountPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	purpose := components[0] - hdkeychain.HardenedKeyStart
+	versions, ok := xpubVersions[purpose]
+	if !ok {
+		return "", fmt.Errorf("%w: %d", ErrUnsupportedXpubPurpose, purpose)
+	}
+	version := versions.mainnet
+	if isDev || components[1]-hdkeychain.HardenedKeyStart == testnetCoinType {
+		version = versions.testnet
+	}
+
+	key, err := keys.deriveExtendedKey(components)
+	if err != nil {
+		return "", err
+	}
+	defer key.Zero()
+
+	publicKey, err := key.Neuter()
+	if err != nil {
+		return "", err
+	}
+	publicKey, err = publicKey.CloneWithVersion(version[:])
+	if err != nil {
+		return "", err
+	}
+
+	return publicKey.String(), nil
+}
+
+// deriveExtendedKey derives the extended private key of the path components
+func deriveExtendedKey(seed []byte, components derivationPath) (*hdkeychain.ExtendedKey, error) {
+	key, err := newMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	for _, component := range components {
+		child, err := key.Derive(component)
+		key.Zero()
+		if err != nil {
+			return nil, err
+		}
+		key = child
+	}
+	return key, nil
+}
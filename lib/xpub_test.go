@@ -0,0 +1,73 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Test vectors from BIP-49, BIP-84 and BIP-86 for the abandon ... about mnemonic,
+// the development mode case re-encodes the BIP-84 key with vpub versions
+func TestDeriveExtendedPublicKey(t *testing.T) {
+	seed := bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+
+	tests := []struct {
+		name    string
+		path    string
+		isDev   bool
+		want    string
+		wantErr error
+	}{
+		{
+			name: "bip84 zpub",
+			path: "m/84'/0'/0'",
+			want: "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs",
+		},
+		{
+			name: "bip49 upub on testnet coin type",
+			path: "m/49'/1'/0'",
+			want: "upub5EFU65HtV5TeiSHmZZm7FUffBGy8UKeqp7vw43jYbvZPpoVsgU93oac7Wk3u6moKegAEWtGNF8DehrnHtv21XXEMYRUocHqguyjknFHYfgY",
+		},
+		{
+			name: "bip86 xpub",
+			path: "m/86'/0'/0'",
+			want: "xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ",
+		},
+		{
+			name:  "development mode selects testnet versions",
+			path:  "m/84'/0'/0'",
+			isDev: true,
+			want:  "vpub5YvMuJNjRSYon44z9QmCfdf8SqJRVNvz6m55Qy5iVjZQxDfUgtiQjnc7CC1fAbED2tAGCZRERUfvtn2DstZGU6HMns6dXXH2wujSc2wfi2x",
+		},
+		{
+			name:    "address level path",
+			path:    "m/84'/0'/0'/0/0",
+			wantErr: ErrNotAccountLevelPath,
+		},
+		{
+			name:    "non hardened account",
+			path:    "m/84'/0'/0",
+			wantErr: ErrNotAccountLevelPath,
+		},
+		{
+			name:    "unsupported purpose",
+			path:    "m/45'/0'/0'",
+			wantErr: ErrUnsupportedXpubPurpose,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DeriveExtendedPublicKey(NewSeedKeychain(seed), tt.path, tt.isDev)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}